@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.infratographer.com/tenant-api/pkg/api/v1"
+	"go.uber.org/zap"
+)
+
+var nameUniquenessCmd = &cobra.Command{
+	Use:   "name-uniqueness",
+	Short: "Apply the configured tenant name uniqueness to the database",
+	Long: "Creates the index enforcing global tenant name uniqueness when --global-unique-names is set, and drops it when it is not. " +
+		"Run it once before deploying servers with a changed mode, servers refuse to start while the database does not match their configuration.",
+	Run: func(cmd *cobra.Command, args []string) {
+		applyNameUniqueness(cmd.Context())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(nameUniquenessCmd)
+}
+
+func applyNameUniqueness(ctx context.Context) {
+	db, err := newDB(prometheus.NewRegistry())
+	if err != nil {
+		logger.Fatal("unable to initialize crdb client", zap.Error(err))
+	}
+
+	defer db.Close() //nolint:errcheck // Not needed
+
+	global := viper.GetBool("api.global-unique-names")
+
+	if err := api.ApplyNameUniqueness(ctx, db, global); err != nil {
+		logger.Fatal("failed to apply tenant name uniqueness", zap.Error(err))
+	}
+
+	logger.Info("applied tenant name uniqueness", zap.Bool("global", global))
+}
@@ -4,10 +4,12 @@ import (
 	"os"
 	"strings"
 
+	"github.com/nats-io/nats.go"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	dbm "go.infratographer.com/tenant-api/db"
 	"go.infratographer.com/tenant-api/internal/config"
+	"go.infratographer.com/tenant-api/internal/pubsub"
 	"go.infratographer.com/x/crdbx"
 	"go.infratographer.com/x/goosex"
 	"go.infratographer.com/x/loggingx"
@@ -45,6 +47,9 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is /etc/infratographer/tenant-api.yaml)")
 	viperx.MustBindFlag(viper.GetViper(), "config", rootCmd.PersistentFlags().Lookup("config"))
 
+	rootCmd.PersistentFlags().Bool("nats-enabled", true, "publish events to NATS, when disabled changes are not published and webhooks are not delivered")
+	viperx.MustBindFlag(viper.GetViper(), "nats.enabled", rootCmd.PersistentFlags().Lookup("nats-enabled"))
+
 	rootCmd.PersistentFlags().String("nats-url", "nats://nats:4222", "NATS server connection url")
 	viperx.MustBindFlag(viper.GetViper(), "nats.url", rootCmd.PersistentFlags().Lookup("nats-url"))
 
@@ -57,6 +62,51 @@ func init() {
 	rootCmd.PersistentFlags().String("nats-stream-name", "tenant-api", "nats stream name")
 	viperx.MustBindFlag(viper.GetViper(), "nats.stream-name", rootCmd.PersistentFlags().Lookup("nats-stream-name"))
 
+	rootCmd.PersistentFlags().Int("nats-publish-buffer-size", 0, "number of messages to buffer before flushing to NATS, 0 publishes synchronously")
+	viperx.MustBindFlag(viper.GetViper(), "nats.publish.buffer-size", rootCmd.PersistentFlags().Lookup("nats-publish-buffer-size"))
+
+	rootCmd.PersistentFlags().Duration("nats-publish-flush-interval", pubsub.DefaultFlushInterval, "interval buffered messages are flushed to NATS")
+	viperx.MustBindFlag(viper.GetViper(), "nats.publish.flush-interval", rootCmd.PersistentFlags().Lookup("nats-publish-flush-interval"))
+
+	rootCmd.PersistentFlags().String("nats-publish-buffer-full-policy", string(pubsub.BufferFullBlock), "behavior of publishes while the publish buffer is full, block or error")
+	viperx.MustBindFlag(viper.GetViper(), "nats.publish.buffer-full-policy", rootCmd.PersistentFlags().Lookup("nats-publish-buffer-full-policy"))
+
+	rootCmd.PersistentFlags().Duration("nats-publish-buffer-full-timeout", 0, "maximum time blocked publishes wait for room in the publish buffer, 0 waits until the request ends")
+	viperx.MustBindFlag(viper.GetViper(), "nats.publish.buffer-full-timeout", rootCmd.PersistentFlags().Lookup("nats-publish-buffer-full-timeout"))
+
+	rootCmd.PersistentFlags().Duration("nats-publish-timeout", 0, "maximum time synchronous publishes wait for NATS to acknowledge a message, 0 uses the NATS default")
+	viperx.MustBindFlag(viper.GetViper(), "nats.publish.timeout", rootCmd.PersistentFlags().Lookup("nats-publish-timeout"))
+
+	rootCmd.PersistentFlags().String("nats-publish-timeout-policy", string(pubsub.PublishTimeoutError), "behavior of publishes which time out, error or buffer to retry them from the publish buffer")
+	viperx.MustBindFlag(viper.GetViper(), "nats.publish.timeout-policy", rootCmd.PersistentFlags().Lookup("nats-publish-timeout-policy"))
+
+	rootCmd.PersistentFlags().Int("nats-publish-max-attempts", 0, "number of times a buffered message is published before it is dead-lettered, 0 retries until it succeeds")
+	viperx.MustBindFlag(viper.GetViper(), "nats.publish.max-attempts", rootCmd.PersistentFlags().Lookup("nats-publish-max-attempts"))
+
+	rootCmd.PersistentFlags().String("nats-publish-dead-letter-subject", "", "subject messages failing the maximum attempts are published to, defaults to the subject prefix followed by .dead-letter")
+	viperx.MustBindFlag(viper.GetViper(), "nats.publish.dead-letter-subject", rootCmd.PersistentFlags().Lookup("nats-publish-dead-letter-subject"))
+
+	rootCmd.PersistentFlags().StringSlice("nats-disabled-event-types", nil, "event types which are not published, any of create, update or delete")
+	viperx.MustBindFlag(viper.GetViper(), "nats.disabled-event-types", rootCmd.PersistentFlags().Lookup("nats-disabled-event-types"))
+
+	rootCmd.PersistentFlags().StringSlice("nats-redacted-fields", nil, "fields whose changes are removed from published events, such as description or labels, including any fields nested under them")
+	viperx.MustBindFlag(viper.GetViper(), "nats.redacted-fields", rootCmd.PersistentFlags().Lookup("nats-redacted-fields"))
+
+	rootCmd.PersistentFlags().Bool("nats-aggregate-batch-events", false, "publish a single event carrying every affected tenant for each batch create, batch move and cascade delete, rather than an event for each tenant")
+	viperx.MustBindFlag(viper.GetViper(), "nats.aggregate-batch-events", rootCmd.PersistentFlags().Lookup("nats-aggregate-batch-events"))
+
+	rootCmd.PersistentFlags().Int("nats-max-reconnects", nats.DefaultMaxReconnect, "number of attempts to reconnect to NATS after a disconnect, -1 retries forever")
+	viperx.MustBindFlag(viper.GetViper(), "nats.max-reconnects", rootCmd.PersistentFlags().Lookup("nats-max-reconnects"))
+
+	rootCmd.PersistentFlags().Duration("nats-reconnect-wait", nats.DefaultReconnectWait, "time to wait between NATS reconnect attempts")
+	viperx.MustBindFlag(viper.GetViper(), "nats.reconnect-wait", rootCmd.PersistentFlags().Lookup("nats-reconnect-wait"))
+
+	rootCmd.PersistentFlags().Int("nats-reconnect-buffer-size", nats.DefaultReconnectBufSize, "bytes of messages buffered while reconnecting to NATS, publishes fail once exceeded")
+	viperx.MustBindFlag(viper.GetViper(), "nats.reconnect-buffer-size", rootCmd.PersistentFlags().Lookup("nats-reconnect-buffer-size"))
+
+	rootCmd.PersistentFlags().Bool("global-unique-names", false, "require tenant names to be unique across all tenants rather than within a parent")
+	viperx.MustBindFlag(viper.GetViper(), "api.global-unique-names", rootCmd.PersistentFlags().Lookup("global-unique-names"))
+
 	// Logging flags
 	loggingx.MustViperFlags(viper.GetViper(), rootCmd.PersistentFlags())
 
@@ -2,22 +2,31 @@ package cmd
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"net/url"
+	"regexp"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/metal-toolbox/auditevent/helpers"
 	"github.com/metal-toolbox/auditevent/middleware/echoaudit"
 	nats "github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	dbm "go.infratographer.com/tenant-api/db"
+	"go.infratographer.com/tenant-api/internal/auth"
 	"go.infratographer.com/tenant-api/internal/config"
 	"go.infratographer.com/tenant-api/internal/pubsub"
+	"go.infratographer.com/tenant-api/internal/reqlog"
+	"go.infratographer.com/tenant-api/internal/slowquery"
+	"go.infratographer.com/tenant-api/internal/webhooks"
 	"go.infratographer.com/tenant-api/pkg/api/v1"
 	"go.infratographer.com/x/crdbx"
-	"go.infratographer.com/x/echojwtx"
 	"go.infratographer.com/x/echox"
 	"go.infratographer.com/x/otelx"
-	"go.infratographer.com/x/versionx"
 	"go.infratographer.com/x/viperx"
 	"go.uber.org/zap"
 )
@@ -25,6 +34,15 @@ import (
 var (
 	// APIDefaultListen defines the default listening address for the tenant-api.
 	APIDefaultListen = ":7601"
+
+	// DBDefaultMaxOpenConns defines the default maximum number of open database connections.
+	DBDefaultMaxOpenConns = 25
+
+	// DBDefaultMaxIdleConns defines the default maximum number of idle database connections.
+	DBDefaultMaxIdleConns = 25
+
+	// DBDefaultMaxConnLifetime defines the default maximum amount of time a database connection may be reused.
+	DBDefaultMaxConnLifetime = 5 * time.Minute
 )
 
 var serveCmd = &cobra.Command{
@@ -39,11 +57,143 @@ func init() {
 	rootCmd.AddCommand(serveCmd)
 
 	echox.MustViperFlags(viper.GetViper(), serveCmd.Flags(), APIDefaultListen)
-	echojwtx.MustViperFlags(viper.GetViper(), serveCmd.Flags())
+	auth.MustViperFlags(viper.GetViper(), serveCmd.Flags())
+
+	// admin endpoints
+	serveCmd.Flags().String("admin-scope", api.DefaultAdminScope, "scope required to access the admin endpoints")
+	viperx.MustBindFlag(viper.GetViper(), "api.admin-scope", serveCmd.Flags().Lookup("admin-scope"))
+
+	serveCmd.Flags().String("lock-scope", api.DefaultLockScope, "scope required to lock or unlock tenants")
+	viperx.MustBindFlag(viper.GetViper(), "api.lock-scope", serveCmd.Flags().Lookup("lock-scope"))
+
+	serveCmd.Flags().String("write-scope", api.DefaultWriteScope, "scope required to touch tenants")
+	viperx.MustBindFlag(viper.GetViper(), "api.write-scope", serveCmd.Flags().Lookup("write-scope"))
+
+	// webhooks
+	serveCmd.Flags().StringSlice("webhook-urls", nil, "endpoints published events are also delivered to, webhooks are disabled when empty")
+	viperx.MustBindFlag(viper.GetViper(), "webhooks.urls", serveCmd.Flags().Lookup("webhook-urls"))
+	serveCmd.Flags().String("webhook-secret", "", "secret webhook deliveries are signed with")
+	viperx.MustBindFlag(viper.GetViper(), "webhooks.secret", serveCmd.Flags().Lookup("webhook-secret"))
+	serveCmd.Flags().Int("webhook-max-attempts", webhooks.DefaultMaxAttempts, "number of times a webhook delivery is attempted")
+	viperx.MustBindFlag(viper.GetViper(), "webhooks.max-attempts", serveCmd.Flags().Lookup("webhook-max-attempts"))
+	serveCmd.Flags().Duration("webhook-timeout", webhooks.DefaultTimeout, "timeout of a single webhook delivery attempt")
+	viperx.MustBindFlag(viper.GetViper(), "webhooks.timeout", serveCmd.Flags().Lookup("webhook-timeout"))
+	serveCmd.Flags().Duration("webhook-backoff", webhooks.DefaultBackoff, "delay before the first webhook retry, doubling on each retry")
+	viperx.MustBindFlag(viper.GetViper(), "webhooks.backoff", serveCmd.Flags().Lookup("webhook-backoff"))
+	serveCmd.Flags().Duration("webhook-close-timeout", webhooks.DefaultCloseTimeout, "time shutdown waits for queued webhook deliveries before dropping them")
+	viperx.MustBindFlag(viper.GetViper(), "webhooks.close-timeout", serveCmd.Flags().Lookup("webhook-close-timeout"))
+
+	// request timeout
+	serveCmd.Flags().Duration("request-timeout", 0, "timeout for the database operations of a request, unbounded when zero")
+	viperx.MustBindFlag(viper.GetViper(), "api.request-timeout", serveCmd.Flags().Lookup("request-timeout"))
+	serveCmd.Flags().StringToString("route-timeouts", nil, "request timeouts of routes overriding request-timeout, by route template, such as /v1/tenants/:id/export=1m")
+	viperx.MustBindFlag(viper.GetViper(), "api.route-timeouts", serveCmd.Flags().Lookup("route-timeouts"))
+
+	// retry-after jitter
+	serveCmd.Flags().Duration("retry-after-jitter", 0, "maximum random delay added to the Retry-After values of rejected requests, so clients spread their retries out")
+	viperx.MustBindFlag(viper.GetViper(), "api.retry-after-jitter", serveCmd.Flags().Lookup("retry-after-jitter"))
+
+	// list order
+	serveCmd.Flags().String("list-default-sort", api.DefaultListSort.Field, "order tenants are listed in when the request doesn't provide one: created_at, updated_at or name, prefixed with - for descending")
+	viperx.MustBindFlag(viper.GetViper(), "api.list-default-sort", serveCmd.Flags().Lookup("list-default-sort"))
+
+	// response fields
+	serveCmd.Flags().String("json-field-naming", string(api.FieldNamingSnakeCase), "naming of JSON response fields, snake_case or camelCase, requests are accepted in either")
+	viperx.MustBindFlag(viper.GetViper(), "api.json-field-naming", serveCmd.Flags().Lookup("json-field-naming"))
+
+	// transactions
+	serveCmd.Flags().String("tx-isolation", "default", "isolation level of multi-step changes: default, read-committed, repeatable-read or serializable")
+	viperx.MustBindFlag(viper.GetViper(), "api.tx-isolation", serveCmd.Flags().Lookup("tx-isolation"))
+
+	serveCmd.Flags().Int("tx-attempts", api.DefaultTxAttempts, "number of times multi-step changes are attempted when failing with a serialization failure")
+	viperx.MustBindFlag(viper.GetViper(), "api.tx-attempts", serveCmd.Flags().Lookup("tx-attempts"))
+
+	// tenant ids
+	serveCmd.Flags().String("tenant-id-prefix", api.TenantIDPrefix, "gidx prefix of tenant ids, existing tenants must have the same prefix")
+	viperx.MustBindFlag(viper.GetViper(), "api.tenant-id-prefix", serveCmd.Flags().Lookup("tenant-id-prefix"))
+	serveCmd.Flags().Bool("strict-tenant-id-prefix", false, "reject requests with ids of other prefixes in their path or body")
+	viperx.MustBindFlag(viper.GetViper(), "api.strict-tenant-id-prefix", serveCmd.Flags().Lookup("strict-tenant-id-prefix"))
+
+	// tenant limits
+	serveCmd.Flags().Int("max-children", 0, "maximum number of direct children per tenant, unlimited when zero")
+	viperx.MustBindFlag(viper.GetViper(), "api.max-children", serveCmd.Flags().Lookup("max-children"))
+
+	serveCmd.Flags().Int("max-result-size", 0, "maximum number of tenants in exports, delete previews and cascade deletes, unlimited when zero")
+	viperx.MustBindFlag(viper.GetViper(), "api.max-result-size", serveCmd.Flags().Lookup("max-result-size"))
+
+	serveCmd.Flags().Int("max-depth", api.DefaultMaxDepth, "maximum number of hierarchy levels walked by recursive queries")
+	viperx.MustBindFlag(viper.GetViper(), "api.max-depth", serveCmd.Flags().Lookup("max-depth"))
+	serveCmd.Flags().Int("max-path-segments", api.DefaultMaxPathSegments, "maximum number of names in the name paths tenants are looked up by")
+	viperx.MustBindFlag(viper.GetViper(), "api.max-path-segments", serveCmd.Flags().Lookup("max-path-segments"))
+
+	serveCmd.Flags().String("external-base-url", "", "external base url pagination links are built from when served behind a reverse proxy, such as https://example.com/tenant-api, links are relative to the request host when empty")
+	viperx.MustBindFlag(viper.GetViper(), "api.external-base-url", serveCmd.Flags().Lookup("external-base-url"))
+
+	serveCmd.Flags().Bool("idempotent-create", false, "respond to creates of an existing child tenant name with the existing tenant instead of a conflict")
+	viperx.MustBindFlag(viper.GetViper(), "api.idempotent-create", serveCmd.Flags().Lookup("idempotent-create"))
+
+	serveCmd.Flags().String("tenant-name-pattern", "", "regular expression tenant names must match when created or renamed, any valid name is allowed when empty")
+	viperx.MustBindFlag(viper.GetViper(), "api.tenant-name-pattern", serveCmd.Flags().Lookup("tenant-name-pattern"))
+
+	serveCmd.Flags().StringSlice("tenant-kinds", nil, "kinds tenants may be created with, such as customer or partner, any kind is allowed when empty")
+	viperx.MustBindFlag(viper.GetViper(), "api.tenant-kinds", serveCmd.Flags().Lookup("tenant-kinds"))
+
+	serveCmd.Flags().String("system-actor", "", "actor recorded for changes made by requests without a token subject, anonymous when empty")
+	viperx.MustBindFlag(viper.GetViper(), "api.system-actor", serveCmd.Flags().Lookup("system-actor"))
+
+	serveCmd.Flags().String("message-catalog-dir", "", "directory of JSON message catalogs, named after their language, used to localize error messages")
+	viperx.MustBindFlag(viper.GetViper(), "api.message-catalog-dir", serveCmd.Flags().Lookup("message-catalog-dir"))
+
+	// cors
+	serveCmd.Flags().StringSlice("cors-allowed-origins", nil, "origins allowed to make cross-origin requests, cross-origin requests are denied when empty")
+	viperx.MustBindFlag(viper.GetViper(), "cors.allowed-origins", serveCmd.Flags().Lookup("cors-allowed-origins"))
+
+	serveCmd.Flags().StringSlice("cors-allowed-methods", api.DefaultCORSAllowedMethods, "methods allowed for cross-origin requests")
+	viperx.MustBindFlag(viper.GetViper(), "cors.allowed-methods", serveCmd.Flags().Lookup("cors-allowed-methods"))
+
+	serveCmd.Flags().StringSlice("cors-allowed-headers", api.DefaultCORSAllowedHeaders, "request headers allowed for cross-origin requests")
+	viperx.MustBindFlag(viper.GetViper(), "cors.allowed-headers", serveCmd.Flags().Lookup("cors-allowed-headers"))
+
+	// compression
+	serveCmd.Flags().Bool("compression-enabled", true, "compress responses with gzip or deflate when accepted by the client")
+	viperx.MustBindFlag(viper.GetViper(), "compression.enabled", serveCmd.Flags().Lookup("compression-enabled"))
+
+	serveCmd.Flags().Int("compression-level", 0, "compression level from 1 (best speed) to 9 (best compression), the default level is used when zero")
+	viperx.MustBindFlag(viper.GetViper(), "compression.level", serveCmd.Flags().Lookup("compression-level"))
+
+	serveCmd.Flags().Int("compression-min-length", api.DefaultCompressionMinLength, "minimum size in bytes of compressed responses")
+	viperx.MustBindFlag(viper.GetViper(), "compression.min-length", serveCmd.Flags().Lookup("compression-min-length"))
+
+	// request logs
+	serveCmd.Flags().Float64("request-log-sample-rate", 1, "fraction of successful requests logged, failed and slow requests are always logged")
+	viperx.MustBindFlag(viper.GetViper(), "request-log.sample-rate", serveCmd.Flags().Lookup("request-log-sample-rate"))
+
+	serveCmd.Flags().Duration("request-log-slow-threshold", time.Second, "duration of requests which are always logged when sampling, disabled when zero")
+	viperx.MustBindFlag(viper.GetViper(), "request-log.slow-threshold", serveCmd.Flags().Lookup("request-log-slow-threshold"))
 
 	// audit log path
 	serveCmd.Flags().String("audit-log-path", "/app-audit/audit.log", "Path to the audit log file")
 	viperx.MustBindFlag(viper.GetViper(), "audit.log.path", serveCmd.Flags().Lookup("audit-log-path"))
+
+	// database connection pool
+	serveCmd.Flags().Int("db-max-open-conns", DBDefaultMaxOpenConns, "maximum number of open database connections")
+	viperx.MustBindFlag(viper.GetViper(), "crdb.connections.max_open", serveCmd.Flags().Lookup("db-max-open-conns"))
+
+	serveCmd.Flags().Int("db-max-idle-conns", DBDefaultMaxIdleConns, "maximum number of idle database connections")
+	viperx.MustBindFlag(viper.GetViper(), "crdb.connections.max_idle", serveCmd.Flags().Lookup("db-max-idle-conns"))
+
+	serveCmd.Flags().Duration("db-max-conn-lifetime", DBDefaultMaxConnLifetime, "maximum amount of time a database connection may be reused")
+	viperx.MustBindFlag(viper.GetViper(), "crdb.connections.max_lifetime", serveCmd.Flags().Lookup("db-max-conn-lifetime"))
+
+	// database migrations
+	serveCmd.Flags().Bool("db-auto-migrate", false, "apply pending database migrations on startup")
+	viperx.MustBindFlag(viper.GetViper(), "crdb.migrations.auto", serveCmd.Flags().Lookup("db-auto-migrate"))
+
+	serveCmd.Flags().Bool("db-fail-on-drift", true, "fail to start when the database schema does not match the expected migrations, otherwise log an error")
+	viperx.MustBindFlag(viper.GetViper(), "crdb.migrations.fail_on_drift", serveCmd.Flags().Lookup("db-fail-on-drift"))
+
+	serveCmd.Flags().Duration("db-slow-query-threshold", 0, "log database queries taking longer than the threshold, disabled when zero")
+	viperx.MustBindFlag(viper.GetViper(), "crdb.slow_query_threshold", serveCmd.Flags().Lookup("db-slow-query-threshold"))
 }
 
 func serve(ctx context.Context) {
@@ -52,24 +202,53 @@ func serve(ctx context.Context) {
 		logger.Fatal("unable to initialize tracing system", zap.Error(err))
 	}
 
-	db, err := crdbx.NewDB(config.AppConfig.CRDB, config.AppConfig.Tracing.Enabled)
+	db, err := newDB(prometheus.DefaultRegisterer)
 	if err != nil {
 		logger.Fatal("unable to initialize crdb client", zap.Error(err))
 	}
 
-	js, natsClose, err := newJetstreamConnection()
-	if err != nil {
-		logger.Fatal("failed to create NATS jetstream connection", zap.Error(err))
+	if err := checkMigrations(ctx, db); err != nil {
+		logger.Fatal("database schema check failed", zap.Error(err))
 	}
 
-	defer natsClose()
-
 	auditMiddleware, auditCloseFn, err := newAuditMiddleware(ctx)
 	if err != nil {
 		logger.Fatal("Failed to initialize audit middleware", zap.Error(err))
 	}
 
-	srv, err := echox.NewServer(logger, echox.ConfigFromViper(viper.GetViper()), versionx.BuildDetails())
+	serverConfig := echox.ConfigFromViper(viper.GetViper())
+
+	if cors := api.CORSMiddleware(api.CORSConfig{
+		AllowedOrigins: viper.GetStringSlice("cors.allowed-origins"),
+		AllowedMethods: viper.GetStringSlice("cors.allowed-methods"),
+		AllowedHeaders: viper.GetStringSlice("cors.allowed-headers"),
+	}); cors != nil {
+		serverConfig = serverConfig.WithMiddleware(cors)
+	}
+
+	compression, err := api.CompressionMiddleware(api.CompressionConfig{
+		Enabled:   viper.GetBool("compression.enabled"),
+		Level:     viper.GetInt("compression.level"),
+		MinLength: viper.GetInt("compression.min-length"),
+	})
+	if err != nil {
+		logger.Fatal("invalid compression config", zap.Error(err))
+	}
+
+	if compression != nil {
+		serverConfig = serverConfig.WithMiddleware(compression)
+	}
+
+	requestLogger, err := reqlog.NewLogger(logger, reqlog.Config{
+		SampleRate:    viper.GetFloat64("request-log.sample-rate"),
+		SlowThreshold: viper.GetDuration("request-log.slow-threshold"),
+	})
+	if err != nil {
+		logger.Fatal("invalid request log config", zap.Error(err))
+	}
+
+	// The build details are served by the api router, which includes the api version.
+	srv, err := echox.NewServer(requestLogger, serverConfig, nil)
 	if err != nil {
 		logger.Fatal("failed to initialize new server", zap.Error(err))
 	}
@@ -82,31 +261,105 @@ func serve(ctx context.Context) {
 		middleware = append(middleware, auditMiddleware.Audit())
 	}
 
-	if config, err := echojwtx.AuthConfigFromViper(viper.GetViper()); err != nil {
-		logger.Fatal("failed to initialize jwt authentication", zap.Error(err))
-	} else if config != nil {
-		config.JWTConfig.Skipper = echox.SkipDefaultEndpoints
+	if config := auth.ConfigFromViper(viper.GetViper()); config != nil {
+		config.Logger = logger
+		config.Skipper = echox.SkipDefaultEndpoints
 
-		auth, err := echojwtx.NewAuth(ctx, *config)
+		jwtAuth, err := auth.NewAuth(ctx, *config)
 		if err != nil {
 			logger.Fatal("failed to initialize jwt authentication", zap.Error(err))
 		}
 
-		middleware = append(middleware, auth.Middleware())
+		middleware = append(middleware, jwtAuth.Middleware())
+	}
+
+	isolation, err := api.ParseIsolationLevel(viper.GetString("api.tx-isolation"))
+	if err != nil {
+		logger.Fatal("invalid transaction isolation level", zap.Error(err))
+	}
+
+	listSort, err := api.ParseListSort(viper.GetString("api.list-default-sort"))
+	if err != nil {
+		logger.Fatal("invalid default list sort", zap.Error(err))
+	}
+
+	fieldNaming, err := api.ParseFieldNaming(viper.GetString("api.json-field-naming"))
+	if err != nil {
+		logger.Fatal("invalid json field naming", zap.Error(err))
+	}
+
+	routeTimeouts, err := api.ParseRouteTimeouts(viper.GetStringMapString("api.route-timeouts"))
+	if err != nil {
+		logger.Fatal("invalid route timeouts", zap.Error(err))
+	}
+
+	var namePattern *regexp.Regexp
+
+	if pattern := viper.GetString("api.tenant-name-pattern"); pattern != "" {
+		if namePattern, err = regexp.Compile(pattern); err != nil {
+			logger.Fatal("invalid tenant name pattern", zap.Error(err))
+		}
+	}
+
+	var externalBaseURL *url.URL
+
+	if value := viper.GetString("api.external-base-url"); value != "" {
+		if externalBaseURL, err = api.ParseExternalBaseURL(value); err != nil {
+			logger.Fatal("invalid external base url", zap.Error(err))
+		}
+	}
+
+	ps, psClose := newPubSubClient()
+
+	defer psClose()
+
+	var catalogs api.RouterOption = func(*api.Router) {}
+
+	if dir := viper.GetString("api.message-catalog-dir"); dir != "" {
+		if catalogs, err = api.LoadMessageCatalogs(dir); err != nil {
+			logger.Fatal("failed to load message catalogs", zap.Error(err))
+		}
 	}
 
 	r := api.NewRouter(
 		db,
-		pubsub.NewClient(
-			pubsub.WithJetreamContext(js),
-			pubsub.WithLogger(logger),
-			pubsub.WithStreamName(viper.GetString("nats.stream-name")),
-			pubsub.WithSubjectPrefix(viper.GetString("nats.subject-prefix")),
-		),
+		ps,
 		api.WithLogger(logger),
 		api.WithMiddleware(middleware...),
+		api.WithAdminScope(viper.GetString("api.admin-scope")),
+		api.WithLockScope(viper.GetString("api.lock-scope")),
+		api.WithWriteScope(viper.GetString("api.write-scope")),
+		api.WithMaxChildren(viper.GetInt("api.max-children")),
+		api.WithMaxResultSize(viper.GetInt("api.max-result-size")),
+		api.WithMaxDepth(viper.GetInt("api.max-depth")),
+		api.WithMaxPathSegments(viper.GetInt("api.max-path-segments")),
+		api.WithExternalBaseURL(externalBaseURL),
+		api.WithIdempotentCreate(viper.GetBool("api.idempotent-create")),
+		api.WithGlobalNameUniqueness(viper.GetBool("api.global-unique-names")),
+		api.WithNamePattern(namePattern),
+		api.WithTenantKinds(viper.GetStringSlice("api.tenant-kinds")...),
+		api.WithSystemActor(viper.GetString("api.system-actor")),
+		api.WithIDPrefix(viper.GetString("api.tenant-id-prefix")),
+		api.WithStrictIDPrefix(viper.GetBool("api.strict-tenant-id-prefix")),
+		api.WithRequestTimeout(viper.GetDuration("api.request-timeout")),
+		api.WithRouteTimeouts(routeTimeouts),
+		api.WithRetryAfterJitter(viper.GetDuration("api.retry-after-jitter")),
+		api.WithAggregateBatchEvents(viper.GetBool("nats.aggregate-batch-events")),
+		api.WithTxIsolation(isolation),
+		api.WithTxAttempts(viper.GetInt("api.tx-attempts")),
+		api.WithDefaultListSort(listSort),
+		api.WithFieldNaming(fieldNaming),
+		catalogs,
 	)
 
+	if err := r.ValidateIDPrefix(ctx); err != nil {
+		logger.Fatal("invalid tenant id prefix", zap.Error(err))
+	}
+
+	if err := r.ValidateNameUniqueness(ctx); err != nil {
+		logger.Fatal("invalid tenant name uniqueness, run the name-uniqueness command to apply it", zap.Error(err))
+	}
+
 	srv.AddHandler(r).AddReadinessCheck("database", r.DatabaseCheck)
 
 	if err := srv.Run(); err != nil {
@@ -114,8 +367,189 @@ func serve(ctx context.Context) {
 	}
 }
 
+// newDB initializes the database connection pool and registers the pool
+// statistics with reg, the default registerer is served on /metrics.
+func newDB(reg prometheus.Registerer) (*sql.DB, error) {
+	dbConfig := config.AppConfig.CRDB
+
+	db, err := crdbx.NewDB(dbConfig, config.AppConfig.Tracing.Enabled)
+	if err != nil {
+		return nil, err
+	}
+
+	if threshold := viper.GetDuration("crdb.slow_query_threshold"); threshold > 0 {
+		// Reopen the pool with connections timing their queries, reusing the
+		// driver from crdbx so tracing is preserved.
+		slowDB := sql.OpenDB(slowquery.NewConnector(db.Driver(), dbConfig.GetURI(), threshold, logger))
+
+		if err := db.Close(); err != nil {
+			return nil, err
+		}
+
+		db = slowDB
+
+		db.SetMaxOpenConns(dbConfig.Connections.MaxOpen)
+		db.SetMaxIdleConns(dbConfig.Connections.MaxIdle)
+		db.SetConnMaxIdleTime(dbConfig.Connections.MaxLifetime)
+
+		logger.Info("database slow query logging enabled", zap.Duration("crdb.slow_query_threshold", threshold))
+	}
+
+	// crdbx only applies the lifetime as the idle timeout, ensure connections
+	// are also recycled once they reach their max lifetime.
+	db.SetConnMaxLifetime(dbConfig.Connections.MaxLifetime)
+
+	logger.Debug("database connection pool configured",
+		zap.Int("crdb.connections.max_open", dbConfig.Connections.MaxOpen),
+		zap.Int("crdb.connections.max_idle", dbConfig.Connections.MaxIdle),
+		zap.Duration("crdb.connections.max_lifetime", dbConfig.Connections.MaxLifetime),
+	)
+
+	if err := reg.Register(collectors.NewDBStatsCollector(db, appName)); err != nil {
+		return nil, fmt.Errorf("failed to register database pool metrics: %w", err)
+	}
+
+	return db, nil
+}
+
+// checkMigrations compares the database schema against the embedded migrations,
+// applying any pending migrations when auto migrate is enabled.
+func checkMigrations(ctx context.Context, db *sql.DB) error {
+	drift, err := dbm.CheckDrift(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if len(drift.Pending) != 0 && viper.GetBool("crdb.migrations.auto") {
+		logger.Info("applying pending database migrations", zap.Int("migrations.pending", len(drift.Pending)))
+
+		if err := dbm.Migrate(db); err != nil {
+			return fmt.Errorf("failed to apply migrations: %w", err)
+		}
+
+		if drift, err = dbm.CheckDrift(ctx, db); err != nil {
+			return err
+		}
+	}
+
+	if !drift.HasDrift() {
+		return nil
+	}
+
+	if viper.GetBool("crdb.migrations.fail_on_drift") {
+		return fmt.Errorf("%w: %s", dbm.ErrSchemaDrift, drift)
+	}
+
+	logger.Error("database schema does not match expected migrations, continuing", zap.String("migrations.drift", drift.String()))
+
+	return nil
+}
+
+// newPubSubClient creates the client publishing events to NATS and any
+// webhooks, returning the function closing it. When pubsub is disabled no
+// client is created, and events are not published.
+func newPubSubClient() (*pubsub.Client, func()) {
+	if !viper.GetBool("nats.enabled") {
+		logger.Info("pubsub is disabled, events will not be published")
+
+		if len(viper.GetStringSlice("webhooks.urls")) != 0 {
+			logger.Warn("webhooks are delivered with events, and are disabled with pubsub")
+		}
+
+		return nil, func() {}
+	}
+
+	js, natsClose, err := newJetstreamConnection()
+	if err != nil {
+		logger.Fatal("failed to create NATS jetstream connection", zap.Error(err))
+	}
+
+	closers := []func(){natsClose}
+
+	fullPolicy, err := pubsub.ParseBufferFullPolicy(viper.GetString("nats.publish.buffer-full-policy"))
+	if err != nil {
+		logger.Fatal("invalid nats publish buffer full policy", zap.Error(err))
+	}
+
+	timeoutPolicy, err := pubsub.ParsePublishTimeoutPolicy(viper.GetString("nats.publish.timeout-policy"))
+	if err != nil {
+		logger.Fatal("invalid nats publish timeout policy", zap.Error(err))
+	}
+
+	if timeoutPolicy == pubsub.PublishTimeoutBuffer && viper.GetInt("nats.publish.buffer-size") <= 0 {
+		logger.Warn("nats publish timeout policy buffer requires a publish buffer, timed out publishes will fail")
+	}
+
+	disabledEventTypes := viper.GetStringSlice("nats.disabled-event-types")
+
+	if err := pubsub.ValidateEventTypes(disabledEventTypes); err != nil {
+		logger.Fatal("invalid disabled nats event types", zap.Error(err))
+	}
+
+	deadLetterSubject := viper.GetString("nats.publish.dead-letter-subject")
+	if deadLetterSubject == "" {
+		// Under the prefix, so dead-lettered messages are kept by the stream.
+		deadLetterSubject = viper.GetString("nats.subject-prefix") + ".dead-letter"
+	}
+
+	psOpts := []pubsub.Option{
+		pubsub.WithJetreamContext(js),
+		pubsub.WithLogger(logger),
+		pubsub.WithStreamName(viper.GetString("nats.stream-name")),
+		pubsub.WithSubjectPrefix(viper.GetString("nats.subject-prefix")),
+		pubsub.WithBuffering(viper.GetInt("nats.publish.buffer-size"), viper.GetDuration("nats.publish.flush-interval")),
+		pubsub.WithBufferFullPolicy(fullPolicy, viper.GetDuration("nats.publish.buffer-full-timeout")),
+		pubsub.WithPublishTimeout(viper.GetDuration("nats.publish.timeout"), timeoutPolicy),
+		pubsub.WithDisabledEventTypes(disabledEventTypes...),
+		pubsub.WithRedactedFields(viper.GetStringSlice("nats.redacted-fields")...),
+		pubsub.WithDeadLetter(deadLetterSubject, viper.GetInt("nats.publish.max-attempts")),
+	}
+
+	if dispatcher := webhooks.NewDispatcher(webhooks.Config{
+		URLs:         viper.GetStringSlice("webhooks.urls"),
+		Secret:       viper.GetString("webhooks.secret"),
+		MaxAttempts:  viper.GetInt("webhooks.max-attempts"),
+		Timeout:      viper.GetDuration("webhooks.timeout"),
+		Backoff:      viper.GetDuration("webhooks.backoff"),
+		CloseTimeout: viper.GetDuration("webhooks.close-timeout"),
+	}, logger); dispatcher != nil {
+		// Closed after the pubsub client, once it has stopped publishing.
+		closers = append(closers, dispatcher.Close)
+
+		if viper.GetString("webhooks.secret") == "" {
+			logger.Warn("webhooks are enabled without a secret, deliveries are signed with an empty key")
+		}
+
+		psOpts = append(psOpts, pubsub.WithSinks(dispatcher))
+	}
+
+	ps := pubsub.NewClient(psOpts...)
+
+	closers = append(closers, ps.Close)
+
+	return ps, func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i]()
+		}
+	}
+}
+
 func newJetstreamConnection() (nats.JetStreamContext, func(), error) {
-	opts := []nats.Option{nats.Name(appName)}
+	opts := []nats.Option{
+		nats.Name(appName),
+		nats.MaxReconnects(viper.GetInt("nats.max-reconnects")),
+		nats.ReconnectWait(viper.GetDuration("nats.reconnect-wait")),
+		nats.ReconnectBufSize(viper.GetInt("nats.reconnect-buffer-size")),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			logger.Warn("disconnected from nats", zap.Error(err))
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			logger.Info("reconnected to nats", zap.String("nats.url", nc.ConnectedUrl()))
+		}),
+		nats.ClosedHandler(func(_ *nats.Conn) {
+			logger.Info("nats connection closed")
+		}),
+	}
 
 	if viper.GetBool("debug") {
 		logger.Debug("enabling development settings")
@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach-go/v2/testserver"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	dbm "go.infratographer.com/tenant-api/db"
+	"go.infratographer.com/tenant-api/internal/config"
+	"go.infratographer.com/x/crdbx"
+	"go.infratographer.com/x/echox"
+	"go.uber.org/zap"
+)
+
+// newTestDBURI starts a database without any migrations applied and returns
+// its connection uri.
+func newTestDBURI(t *testing.T) string {
+	t.Helper()
+
+	srv, err := testserver.NewTestServer()
+	require.NoError(t, err, "no error expected for new test server")
+
+	t.Cleanup(srv.Stop)
+
+	require.NoError(t, srv.WaitForInit(), "no error expected for test server init")
+
+	return srv.PGURL().String()
+}
+
+// newTestDB starts a database without any migrations applied.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("postgres", newTestDBURI(t))
+	require.NoError(t, err, "no error expected opening database")
+
+	t.Cleanup(func() {
+		db.Close() //nolint:errcheck // Not needed
+	})
+
+	return db
+}
+
+func TestCheckMigrations(t *testing.T) {
+	logger = zap.NewNop()
+
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	files, err := fs.ReadDir(dbm.Migrations, "migrations")
+	require.NoError(t, err, "no error expected listing migrations")
+	require.NotEmpty(t, files, "expected embedded migrations")
+
+	setMigrationConfig := func(t *testing.T, auto, failOnDrift bool) {
+		t.Helper()
+
+		viper.Set("crdb.migrations.auto", auto)
+		viper.Set("crdb.migrations.fail_on_drift", failOnDrift)
+
+		t.Cleanup(func() {
+			viper.Set("crdb.migrations.auto", nil)
+			viper.Set("crdb.migrations.fail_on_drift", nil)
+		})
+	}
+
+	t.Run("drift logged", func(t *testing.T) {
+		setMigrationConfig(t, false, false)
+
+		assert.NoError(t, checkMigrations(ctx, db), "expected drift to only be logged")
+	})
+
+	t.Run("drift fails", func(t *testing.T) {
+		setMigrationConfig(t, false, true)
+
+		err := checkMigrations(ctx, db)
+		require.ErrorIs(t, err, dbm.ErrSchemaDrift, "expected drift to fail startup")
+
+		for _, f := range files {
+			assert.Contains(t, err.Error(), f.Name(), "expected the missing migration to be listed")
+		}
+	})
+
+	t.Run("auto migrate", func(t *testing.T) {
+		setMigrationConfig(t, true, true)
+
+		require.NoError(t, checkMigrations(ctx, db), "expected pending migrations to be applied")
+
+		drift, err := dbm.CheckDrift(ctx, db)
+		require.NoError(t, err, "no error expected checking drift")
+		assert.False(t, drift.HasDrift(), "expected the database to be current, got %s", drift)
+	})
+}
+
+func TestNewDBPoolStats(t *testing.T) {
+	logger = zap.NewNop()
+
+	ctx := context.Background()
+
+	config.AppConfig.CRDB = crdbx.Config{URI: newTestDBURI(t)}
+	config.AppConfig.CRDB.Connections.MaxOpen = 1
+	config.AppConfig.CRDB.Connections.MaxIdle = 1
+	config.AppConfig.CRDB.Connections.MaxLifetime = time.Minute
+
+	t.Cleanup(func() {
+		config.AppConfig.CRDB = crdbx.Config{}
+	})
+
+	reg := prometheus.NewRegistry()
+
+	db, err := newDB(reg)
+	require.NoError(t, err, "no error expected initializing database")
+
+	t.Cleanup(func() {
+		db.Close() //nolint:errcheck // Not needed
+	})
+
+	stat := func(t *testing.T, name string) float64 {
+		t.Helper()
+
+		families, err := reg.Gather()
+		require.NoError(t, err, "no error expected gathering metrics")
+
+		for _, family := range families {
+			if family.GetName() != name {
+				continue
+			}
+
+			require.Len(t, family.GetMetric(), 1, "expected a single %s metric", name)
+
+			m := family.GetMetric()[0]
+
+			require.Equal(t, appName, m.GetLabel()[0].GetValue(), "expected the pool to be labeled with the app name")
+
+			if m.GetCounter() != nil {
+				return m.GetCounter().GetValue()
+			}
+
+			return m.GetGauge().GetValue()
+		}
+
+		require.Failf(t, "metric not found", "expected %s to be registered", name)
+
+		return 0
+	}
+
+	// crdbx pings the database, leaving the connection idle in the pool.
+	assert.Equal(t, float64(1), stat(t, "go_sql_idle_connections"), "expected the ping connection to be idle")
+	assert.Equal(t, float64(0), stat(t, "go_sql_in_use_connections"), "expected no connections in use")
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err, "no error expected acquiring connection")
+
+	assert.Equal(t, float64(0), stat(t, "go_sql_idle_connections"), "expected no idle connections")
+	assert.Equal(t, float64(1), stat(t, "go_sql_in_use_connections"), "expected the held connection to be in use")
+	assert.Equal(t, float64(0), stat(t, "go_sql_wait_count_total"), "expected no waits yet")
+
+	// With the only connection held, a query has to wait for it.
+	done := make(chan error, 1)
+
+	go func() {
+		var one int
+
+		done <- db.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+	}()
+
+	require.Eventually(t, func() bool {
+		return db.Stats().WaitCount > 0
+	}, 5*time.Second, 10*time.Millisecond, "expected the query to wait for a connection")
+
+	assert.Equal(t, float64(1), stat(t, "go_sql_wait_count_total"), "expected the waiting query to be counted")
+
+	require.NoError(t, conn.Close(), "no error expected releasing connection")
+	require.NoError(t, <-done, "no error expected running query")
+
+	assert.Equal(t, float64(1), stat(t, "go_sql_idle_connections"), "expected the released connection to be idle")
+	assert.Equal(t, float64(0), stat(t, "go_sql_in_use_connections"), "expected no connections in use")
+}
+
+// clientIPHandler responds with the client IP of the request.
+type clientIPHandler struct{}
+
+func (clientIPHandler) Routes(g *echo.Group) {
+	g.GET("/client-ip", func(c echo.Context) error {
+		return c.String(http.StatusOK, c.RealIP())
+	})
+}
+
+func TestClientIP(t *testing.T) {
+	testCases := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		forwardedFor   string
+		expected       string
+	}{
+		{
+			name:         "no trusted proxies",
+			remoteAddr:   "203.0.113.10:1234",
+			forwardedFor: "198.51.100.7",
+			expected:     "203.0.113.10",
+		},
+		{
+			name:           "trusted proxy",
+			trustedProxies: []string{"203.0.113.0/24"},
+			remoteAddr:     "203.0.113.10:1234",
+			forwardedFor:   "198.51.100.7",
+			expected:       "198.51.100.7",
+		},
+		{
+			name:           "trusted proxy without header",
+			trustedProxies: []string{"203.0.113.0/24"},
+			remoteAddr:     "203.0.113.10:1234",
+			expected:       "203.0.113.10",
+		},
+		{
+			name:           "untrusted proxy",
+			trustedProxies: []string{"203.0.113.0/24"},
+			remoteAddr:     "192.0.2.10:1234",
+			forwardedFor:   "198.51.100.7",
+			expected:       "192.0.2.10",
+		},
+		{
+			name:           "untrusted hop behind trusted proxy",
+			trustedProxies: []string{"203.0.113.0/24"},
+			remoteAddr:     "203.0.113.10:1234",
+			forwardedFor:   "198.51.100.7, 192.0.2.99",
+			expected:       "192.0.2.99",
+		},
+		{
+			name:           "trusted proxy chain",
+			trustedProxies: []string{"203.0.113.0/24"},
+			remoteAddr:     "203.0.113.10:1234",
+			forwardedFor:   "198.51.100.7, 203.0.113.20",
+			expected:       "198.51.100.7",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			viper.Set("server.trusted-proxies", tc.trustedProxies)
+
+			t.Cleanup(func() {
+				viper.Set("server.trusted-proxies", nil)
+			})
+
+			srv, err := echox.NewServer(zap.NewNop(), echox.ConfigFromViper(viper.GetViper()), nil)
+			require.NoError(t, err, "no error expected for new server")
+
+			req := httptest.NewRequest(http.MethodGet, "/client-ip", nil)
+			req.RemoteAddr = tc.remoteAddr
+
+			if tc.forwardedFor != "" {
+				req.Header.Set(echo.HeaderXForwardedFor, tc.forwardedFor)
+			}
+
+			rec := httptest.NewRecorder()
+
+			srv.AddHandler(clientIPHandler{}).Handler().ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code, "unexpected status code returned")
+
+			body, err := io.ReadAll(rec.Body)
+			require.NoError(t, err, "no error expected reading response")
+
+			assert.Equal(t, tc.expected, string(body), "unexpected client ip")
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pressly/goose/v3"
+)
+
+const migrationsDir = "migrations"
+
+// ErrSchemaDrift is returned when the database schema does not match the embedded migrations.
+var ErrSchemaDrift = errors.New("database schema drift detected")
+
+// Drift describes the differences between the embedded migrations and the
+// migrations which have been applied to the database.
+type Drift struct {
+	// Pending are the embedded migrations which have not been applied to the database.
+	Pending []*goose.Migration
+	// Unknown are the versions applied to the database which have no embedded migration,
+	// meaning the database schema is newer than this build.
+	Unknown []int64
+}
+
+// HasDrift returns true when the database schema does not match the embedded migrations.
+func (d *Drift) HasDrift() bool {
+	return len(d.Pending) != 0 || len(d.Unknown) != 0
+}
+
+// String returns a description of the drift.
+func (d *Drift) String() string {
+	var parts []string
+
+	if len(d.Pending) != 0 {
+		pending := make([]string, len(d.Pending))
+
+		for i, m := range d.Pending {
+			pending[i] = fmt.Sprintf("%d (%s)", m.Version, m.Source)
+		}
+
+		parts = append(parts, "pending migrations: "+strings.Join(pending, ", "))
+	}
+
+	if len(d.Unknown) != 0 {
+		unknown := make([]string, len(d.Unknown))
+
+		for i, v := range d.Unknown {
+			unknown[i] = fmt.Sprint(v)
+		}
+
+		parts = append(parts, "unknown applied migrations: "+strings.Join(unknown, ", "))
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// CheckDrift compares the embedded migrations against the migrations applied
+// to the database. The database is not modified.
+func CheckDrift(ctx context.Context, db *sql.DB) (*Drift, error) {
+	goose.SetBaseFS(Migrations)
+
+	migrations, err := goose.CollectMigrations(migrationsDir, 0, goose.MaxVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect migrations: %w", err)
+	}
+
+	applied, err := appliedVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	drift := new(Drift)
+
+	known := make(map[int64]bool, len(migrations))
+
+	for _, m := range migrations {
+		known[m.Version] = true
+
+		if !applied[m.Version] {
+			drift.Pending = append(drift.Pending, m)
+		}
+	}
+
+	for version, isApplied := range applied {
+		// version 0 is the initial row goose inserts when creating its table.
+		if isApplied && version != 0 && !known[version] {
+			drift.Unknown = append(drift.Unknown, version)
+		}
+	}
+
+	sort.Slice(drift.Unknown, func(i, j int) bool { return drift.Unknown[i] < drift.Unknown[j] })
+
+	return drift, nil
+}
+
+// Migrate applies all pending migrations to the database.
+func Migrate(db *sql.DB) error {
+	goose.SetBaseFS(Migrations)
+
+	if err := goose.SetDialect("postgres"); err != nil {
+		return err
+	}
+
+	return goose.Up(db, migrationsDir)
+}
+
+// appliedVersions returns the applied state of each version recorded in the
+// goose version table. The latest record for a version determines its state.
+func appliedVersions(ctx context.Context, db *sql.DB) (map[int64]bool, error) {
+	applied := make(map[int64]bool)
+
+	var exists bool
+
+	err := db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_name = $1)",
+		goose.TableName(),
+	).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for migration table: %w", err)
+	}
+
+	if !exists {
+		return applied, nil
+	}
+
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT version_id, is_applied FROM %s ORDER BY id", goose.TableName())) //nolint:gosec // table name is not user input
+	if err != nil {
+		return nil, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			version   int64
+			isApplied bool
+		)
+
+		if err := rows.Scan(&version, &isApplied); err != nil {
+			return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+		}
+
+		applied[version] = isApplied
+	}
+
+	return applied, rows.Err()
+}
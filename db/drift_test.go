@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach-go/v2/testserver"
+	_ "github.com/lib/pq" // Registers the postgres driver.
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestDB starts a database without any migrations applied.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	srv, err := testserver.NewTestServer()
+	require.NoError(t, err, "no error expected for new test server")
+
+	t.Cleanup(srv.Stop)
+
+	require.NoError(t, srv.WaitForInit(), "no error expected for test server init")
+
+	db, err := sql.Open("postgres", srv.PGURL().String())
+	require.NoError(t, err, "no error expected opening database")
+
+	t.Cleanup(func() {
+		db.Close() //nolint:errcheck // Not needed
+	})
+
+	return db
+}
+
+// embeddedVersions returns the versions of the embedded migrations, in order.
+func embeddedVersions(t *testing.T) []int64 {
+	t.Helper()
+
+	goose.SetBaseFS(Migrations)
+
+	migrations, err := goose.CollectMigrations(migrationsDir, 0, goose.MaxVersion)
+	require.NoError(t, err, "no error expected collecting migrations")
+	require.Greater(t, len(migrations), 2, "expected embedded migrations")
+
+	versions := make([]int64, len(migrations))
+
+	for i, m := range migrations {
+		versions[i] = m.Version
+	}
+
+	return versions
+}
+
+func pendingVersions(drift *Drift) []int64 {
+	versions := make([]int64, len(drift.Pending))
+
+	for i, m := range drift.Pending {
+		versions[i] = m.Version
+	}
+
+	return versions
+}
+
+func TestCheckDrift(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	versions := embeddedVersions(t)
+
+	t.Run("empty database", func(t *testing.T) {
+		drift, err := CheckDrift(ctx, db)
+		require.NoError(t, err, "no error expected checking drift")
+
+		assert.True(t, drift.HasDrift(), "expected drift without any migrations applied")
+		assert.Equal(t, versions, pendingVersions(drift), "expected every migration to be pending")
+		assert.Empty(t, drift.Unknown, "expected no unknown migrations")
+	})
+
+	t.Run("behind", func(t *testing.T) {
+		require.NoError(t, goose.SetDialect("postgres"))
+
+		// Apply all but the last two migrations.
+		require.NoError(t, goose.UpTo(db, migrationsDir, versions[len(versions)-3]), "no error expected applying migrations")
+
+		drift, err := CheckDrift(ctx, db)
+		require.NoError(t, err, "no error expected checking drift")
+
+		missing := versions[len(versions)-2:]
+
+		assert.True(t, drift.HasDrift(), "expected drift with migrations pending")
+		assert.Equal(t, missing, pendingVersions(drift), "expected only the unapplied migrations to be pending")
+		assert.Empty(t, drift.Unknown, "expected no unknown migrations")
+
+		for _, m := range drift.Pending {
+			assert.Contains(t, drift.String(), fmt.Sprintf("%d (%s)", m.Version, m.Source), "expected the pending migration to be described")
+		}
+	})
+
+	t.Run("migrated", func(t *testing.T) {
+		require.NoError(t, Migrate(db), "no error expected migrating database")
+
+		drift, err := CheckDrift(ctx, db)
+		require.NoError(t, err, "no error expected checking drift")
+
+		assert.False(t, drift.HasDrift(), "expected no drift once migrated, got %s", drift)
+		assert.Empty(t, drift.String(), "expected no drift description")
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		unknown := versions[len(versions)-1] + 1
+
+		_, err := db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (version_id, is_applied) VALUES ($1, true)", goose.TableName()), unknown) //nolint:gosec // table name is not user input
+		require.NoError(t, err, "no error expected recording unknown migration")
+
+		drift, err := CheckDrift(ctx, db)
+		require.NoError(t, err, "no error expected checking drift")
+
+		assert.True(t, drift.HasDrift(), "expected drift with an unknown migration applied")
+		assert.Empty(t, drift.Pending, "expected no pending migrations")
+		assert.Equal(t, []int64{unknown}, drift.Unknown, "expected the unknown migration to be reported")
+		assert.Equal(t, fmt.Sprintf("unknown applied migrations: %d", unknown), drift.String())
+	})
+}
@@ -0,0 +1,40 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE tenants ADD COLUMN path TEXT NOT NULL DEFAULT '';
+
+-- +goose StatementEnd
+-- +goose StatementBegin
+
+WITH RECURSIVE paths AS (
+  SELECT id, id::TEXT AS path
+  FROM tenants
+  WHERE parent_tenant_id IS NULL
+
+  UNION ALL
+
+  SELECT t.id, p.path || '.' || t.id
+  FROM tenants t
+  INNER JOIN paths p ON t.parent_tenant_id = p.id
+)
+UPDATE tenants SET path = paths.path FROM paths WHERE tenants.id = paths.id;
+
+-- +goose StatementEnd
+-- +goose StatementBegin
+
+CREATE INDEX tenants_path_idx ON tenants (path);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX tenants@tenants_path_idx;
+
+-- +goose StatementEnd
+-- +goose StatementBegin
+
+ALTER TABLE tenants DROP COLUMN path;
+
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE tenant_name_history (
+  id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+  tenant_id VARCHAR(29) NOT NULL REFERENCES tenants(id),
+  old_name TEXT NOT NULL,
+  new_name TEXT NOT NULL,
+  actor TEXT NOT NULL DEFAULT '',
+  changed_at TIMESTAMPTZ NOT NULL,
+  INDEX tenant_name_history_tenant_id_idx (tenant_id, changed_at)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE tenant_name_history;
+
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE INDEX tenants_parent_tenant_id_idx ON tenants (parent_tenant_id) WHERE deleted_at IS NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX tenants@tenants_parent_tenant_id_idx;
+
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE UNIQUE INDEX tenants_parent_tenant_id_name_key ON tenants (parent_tenant_id, name) WHERE deleted_at IS NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX tenants@tenants_parent_tenant_id_name_key;
+
+-- +goose StatementEnd
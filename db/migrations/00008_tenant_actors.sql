@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE tenants ADD COLUMN created_by STRING NULL;
+ALTER TABLE tenants ADD COLUMN updated_by STRING NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE tenants DROP COLUMN updated_by;
+ALTER TABLE tenants DROP COLUMN created_by;
+
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE tenants ADD COLUMN quota INT8 NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE tenants DROP COLUMN quota;
+
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE SEQUENCE tenants_seq;
+
+ALTER TABLE tenants ADD COLUMN seq INT8 NOT NULL DEFAULT nextval('tenants_seq') ON UPDATE nextval('tenants_seq');
+
+CREATE INDEX tenants_seq_idx ON tenants (seq);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX tenants@tenants_seq_idx;
+
+ALTER TABLE tenants DROP COLUMN seq;
+
+DROP SEQUENCE tenants_seq;
+
+-- +goose StatementEnd
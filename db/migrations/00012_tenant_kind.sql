@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE tenants ADD COLUMN kind STRING NULL;
+
+CREATE INDEX tenants_kind_idx ON tenants (kind) WHERE deleted_at IS NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX tenants@tenants_kind_idx;
+
+ALTER TABLE tenants DROP COLUMN kind;
+
+-- +goose StatementEnd
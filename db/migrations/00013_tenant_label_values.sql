@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE INDEX tenant_labels_key_value_idx ON tenant_labels (key, value);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX tenant_labels@tenant_labels_key_value_idx;
+
+-- +goose StatementEnd
@@ -0,0 +1,211 @@
+// Package auth authenticates requests using JWTs issued by any of several trusted OIDC providers.
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/MicahParks/keyfunc"
+	"github.com/golang-jwt/jwt/v4"
+	echojwt "github.com/labstack/echo-jwt/v4"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"go.infratographer.com/x/echojwtx"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrNoIssuers is returned when authentication is configured without any trusted issuers.
+	ErrNoIssuers = errors.New("no trusted issuers configured")
+
+	// ErrJWKSURIMissing is returned when the jwks_uri field is not found in an issuer's oidc well-known configuration.
+	ErrJWKSURIMissing = errors.New("jwks_uri missing from oidc provider")
+
+	// ErrUntrustedIssuer is returned when a token was issued by an issuer which is not trusted.
+	ErrUntrustedIssuer = errors.New("untrusted issuer")
+
+	// ErrInvalidAudience is returned when a token was not issued for the required audience.
+	ErrInvalidAudience = errors.New("invalid audience")
+)
+
+// Config configures the trusted issuers and required audience of tokens.
+type Config struct {
+	// Logger defines the auth logger to use.
+	Logger *zap.Logger
+
+	// Issuers are the issuers tokens are accepted from.
+	Issuers []string
+
+	// Audience is the audience tokens must have been issued for. No audience
+	// is required when empty.
+	Audience string
+
+	// Skipper defines requests which are not authenticated.
+	Skipper middleware.Skipper
+
+	// KeyFuncOptions configuration for fetching the JWKS of each issuer.
+	KeyFuncOptions keyfunc.Options
+}
+
+// Auth authenticates requests with tokens from the trusted issuers.
+type Auth struct {
+	logger   *zap.Logger
+	audience string
+	skipper  middleware.Skipper
+	keyFuncs map[string]jwt.Keyfunc
+	jwt      *echojwtx.Auth
+}
+
+// NewAuth fetches the JWKS of each trusted issuer and returns the auth.
+func NewAuth(ctx context.Context, config Config) (*Auth, error) {
+	if len(config.Issuers) == 0 {
+		return nil, ErrNoIssuers
+	}
+
+	a := &Auth{
+		logger:   config.Logger,
+		audience: config.Audience,
+		skipper:  config.Skipper,
+		keyFuncs: make(map[string]jwt.Keyfunc, len(config.Issuers)),
+	}
+
+	if a.logger == nil {
+		a.logger = zap.NewNop()
+	}
+
+	if a.skipper == nil {
+		a.skipper = middleware.DefaultSkipper
+	}
+
+	for _, issuer := range config.Issuers {
+		if _, ok := a.keyFuncs[issuer]; ok {
+			continue
+		}
+
+		jwksURI, err := jwksURI(ctx, issuer)
+		if err != nil {
+			return nil, fmt.Errorf("issuer %s: %w", issuer, err)
+		}
+
+		jwks, err := keyfunc.Get(jwksURI, config.KeyFuncOptions)
+		if err != nil {
+			return nil, fmt.Errorf("issuer %s: %w", issuer, err)
+		}
+
+		a.keyFuncs[issuer] = jwks.Keyfunc
+	}
+
+	// The issuer is checked when selecting the signing keys, while the
+	// audience is checked separately so mismatches may be rejected as forbidden.
+	jwtAuth, err := echojwtx.NewAuth(ctx, echojwtx.AuthConfig{
+		Logger: a.logger,
+		JWTConfig: echojwt.Config{
+			Skipper: a.skipper,
+			KeyFunc: a.keyFunc,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a.jwt = jwtAuth
+
+	return a, nil
+}
+
+// Middleware returns the middleware authenticating requests. Tokens from an
+// untrusted issuer are rejected as unauthorized, while tokens issued for a
+// different audience are rejected as forbidden.
+func (a *Auth) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return a.jwt.Middleware()(func(c echo.Context) error {
+			if a.skipper(c) {
+				return next(c)
+			}
+
+			if err := a.validateAudience(c); err != nil {
+				return err
+			}
+
+			return next(c)
+		})
+	}
+}
+
+// keyFunc returns the signing keys of the issuer of the token.
+func (a *Auth) keyFunc(token *jwt.Token) (interface{}, error) {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrUntrustedIssuer
+	}
+
+	issuer, _ := claims["iss"].(string)
+
+	keyFunc, ok := a.keyFuncs[issuer]
+	if !ok {
+		a.logger.Error("jwt user claim untrusted issuer", zap.Any("issuer", claims["iss"]))
+
+		return nil, fmt.Errorf("%w: %s", ErrUntrustedIssuer, issuer)
+	}
+
+	return keyFunc(token)
+}
+
+// validateAudience ensures the request's token was issued for the required audience.
+func (a *Auth) validateAudience(c echo.Context) error {
+	if a.audience == "" {
+		return nil
+	}
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return echo.NewHTTPError(http.StatusForbidden, "invalid audience").SetInternal(ErrInvalidAudience)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !claims.VerifyAudience(a.audience, true) {
+		a.logger.Error("jwt user claim invalid audience", zap.Any("audience", claims["aud"]))
+
+		return echo.NewHTTPError(http.StatusForbidden, "invalid audience").SetInternal(ErrInvalidAudience)
+	}
+
+	return nil
+}
+
+// jwksURI returns the JWKS URI from the issuer's oidc well-known configuration.
+func jwksURI(ctx context.Context, issuer string) (string, error) {
+	uri, err := url.JoinPath(issuer, ".well-known", "openid-configuration")
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer res.Body.Close() //nolint:errcheck // no need to check
+
+	var config struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&config); err != nil {
+		return "", err
+	}
+
+	if config.JWKSURI == "" {
+		return "", ErrJWKSURIMissing
+	}
+
+	return config.JWKSURI, nil
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/x/echojwtx"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	const audience = "tenant-api"
+
+	firstClient, firstIssuer, closeFirst := echojwtx.TestOAuthClient("first-actor", audience)
+	defer closeFirst()
+
+	secondClient, secondIssuer, closeSecond := echojwtx.TestOAuthClient("second-actor", audience)
+	defer closeSecond()
+
+	otherAudienceClient, otherAudienceIssuer, closeOtherAudience := echojwtx.TestOAuthClient("other-actor", "other-api")
+	defer closeOtherAudience()
+
+	untrustedClient, _, closeUntrusted := echojwtx.TestOAuthClient("untrusted-actor", audience)
+	defer closeUntrusted()
+
+	auth, err := NewAuth(context.Background(), Config{
+		Issuers:  []string{firstIssuer, secondIssuer, otherAudienceIssuer},
+		Audience: audience,
+		Skipper: func(c echo.Context) bool {
+			return c.Path() == "/skipped"
+		},
+	})
+	require.NoError(t, err, "no error expected creating auth")
+
+	e := echo.New()
+	e.Use(auth.Middleware())
+
+	actorHandler := func(c echo.Context) error {
+		return c.String(http.StatusOK, echojwtx.Actor(c))
+	}
+
+	e.GET("/", actorHandler)
+	e.GET("/skipped", actorHandler)
+
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	testCases := []struct {
+		name          string
+		client        *http.Client
+		path          string
+		expectedCode  int
+		expectedActor string
+	}{
+		{"first issuer", firstClient, "/", http.StatusOK, "first-actor"},
+		{"second issuer", secondClient, "/", http.StatusOK, "second-actor"},
+		{"audience mismatch", otherAudienceClient, "/", http.StatusForbidden, ""},
+		{"untrusted issuer", untrustedClient, "/", http.StatusUnauthorized, ""},
+		{"missing token", http.DefaultClient, "/", http.StatusUnauthorized, ""},
+		{"skipped", http.DefaultClient, "/skipped", http.StatusOK, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := tc.client.Get(srv.URL + tc.path)
+			require.NoError(t, err, "no error expected for request")
+
+			defer resp.Body.Close() //nolint:errcheck // Not needed
+
+			assert.Equal(t, tc.expectedCode, resp.StatusCode, "unexpected status code returned")
+
+			if tc.expectedCode == http.StatusOK {
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err, "no error expected reading body")
+
+				assert.Equal(t, tc.expectedActor, string(body), "unexpected actor")
+			}
+		})
+	}
+}
+
+func TestNewAuthNoIssuers(t *testing.T) {
+	_, err := NewAuth(context.Background(), Config{})
+
+	assert.ErrorIs(t, err, ErrNoIssuers)
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"github.com/MicahParks/keyfunc"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.infratographer.com/x/echojwtx"
+	"go.infratographer.com/x/viperx"
+)
+
+// MustViperFlags adds the oidc flags to the provided flagset, binding them to viper.
+func MustViperFlags(v *viper.Viper, flags *pflag.FlagSet) {
+	echojwtx.MustViperFlags(v, flags)
+
+	flags.StringSlice("oidc-issuers", nil, "additional trusted issuers of OIDC JWT")
+	viperx.MustBindFlag(v, "oidc.issuers", flags.Lookup("oidc-issuers"))
+}
+
+// ConfigFromViper returns the auth config from viper, or nil when oidc is disabled.
+// Both the single issuer and the list of issuers are trusted.
+func ConfigFromViper(v *viper.Viper) *Config {
+	if !v.GetBool("oidc.enabled") {
+		return nil
+	}
+
+	var issuers []string
+
+	if issuer := v.GetString("oidc.issuer"); issuer != "" {
+		issuers = append(issuers, issuer)
+	}
+
+	issuers = append(issuers, v.GetStringSlice("oidc.issuers")...)
+
+	return &Config{
+		Issuers:  issuers,
+		Audience: v.GetString("oidc.audience"),
+		KeyFuncOptions: keyfunc.Options{
+			RefreshTimeout: v.GetDuration("oidc.jwks.remote-timeout"),
+		},
+	}
+}
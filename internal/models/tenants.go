@@ -32,6 +32,13 @@ type Tenant struct {
 	CreatedAt      time.Time        `boil:"created_at" json:"created_at" toml:"created_at" yaml:"created_at"`
 	UpdatedAt      time.Time        `boil:"updated_at" json:"updated_at" toml:"updated_at" yaml:"updated_at"`
 	DeletedAt      null.Time        `boil:"deleted_at" json:"deleted_at,omitempty" toml:"deleted_at" yaml:"deleted_at,omitempty"`
+	Path           string           `boil:"path" json:"path" toml:"path" yaml:"path"`
+	Locked         bool             `boil:"locked" json:"locked" toml:"locked" yaml:"locked"`
+	CreatedBy      null.String      `boil:"created_by" json:"created_by,omitempty" toml:"created_by" yaml:"created_by,omitempty"`
+	UpdatedBy      null.String      `boil:"updated_by" json:"updated_by,omitempty" toml:"updated_by" yaml:"updated_by,omitempty"`
+	Quota          null.Int64       `boil:"quota" json:"quota,omitempty" toml:"quota" yaml:"quota,omitempty"`
+	Description    null.String      `boil:"description" json:"description,omitempty" toml:"description" yaml:"description,omitempty"`
+	Kind           null.String      `boil:"kind" json:"kind,omitempty" toml:"kind" yaml:"kind,omitempty"`
 
 	R *tenantR `boil:"-" json:"-" toml:"-" yaml:"-"`
 	L tenantL  `boil:"-" json:"-" toml:"-" yaml:"-"`
@@ -44,6 +51,13 @@ var TenantColumns = struct {
 	CreatedAt      string
 	UpdatedAt      string
 	DeletedAt      string
+	Path           string
+	Locked         string
+	CreatedBy      string
+	UpdatedBy      string
+	Quota          string
+	Description    string
+	Kind           string
 }{
 	ID:             "id",
 	Name:           "name",
@@ -51,6 +65,13 @@ var TenantColumns = struct {
 	CreatedAt:      "created_at",
 	UpdatedAt:      "updated_at",
 	DeletedAt:      "deleted_at",
+	Path:           "path",
+	Locked:         "locked",
+	CreatedBy:      "created_by",
+	UpdatedBy:      "updated_by",
+	Quota:          "quota",
+	Description:    "description",
+	Kind:           "kind",
 }
 
 var TenantTableColumns = struct {
@@ -60,6 +81,13 @@ var TenantTableColumns = struct {
 	CreatedAt      string
 	UpdatedAt      string
 	DeletedAt      string
+	Path           string
+	Locked         string
+	CreatedBy      string
+	UpdatedBy      string
+	Quota          string
+	Description    string
+	Kind           string
 }{
 	ID:             "tenants.id",
 	Name:           "tenants.name",
@@ -67,6 +95,13 @@ var TenantTableColumns = struct {
 	CreatedAt:      "tenants.created_at",
 	UpdatedAt:      "tenants.updated_at",
 	DeletedAt:      "tenants.deleted_at",
+	Path:           "tenants.path",
+	Locked:         "tenants.locked",
+	CreatedBy:      "tenants.created_by",
+	UpdatedBy:      "tenants.updated_by",
+	Quota:          "tenants.quota",
+	Description:    "tenants.description",
+	Kind:           "tenants.kind",
 }
 
 // Generated where
@@ -184,6 +219,63 @@ func (w whereHelpernull_Time) GTE(x null.Time) qm.QueryMod {
 func (w whereHelpernull_Time) IsNull() qm.QueryMod    { return qmhelper.WhereIsNull(w.field) }
 func (w whereHelpernull_Time) IsNotNull() qm.QueryMod { return qmhelper.WhereIsNotNull(w.field) }
 
+type whereHelpernull_String struct{ field string }
+
+func (w whereHelpernull_String) EQ(x null.String) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, false, x)
+}
+func (w whereHelpernull_String) NEQ(x null.String) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, true, x)
+}
+func (w whereHelpernull_String) LT(x null.String) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LT, x)
+}
+func (w whereHelpernull_String) LTE(x null.String) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LTE, x)
+}
+func (w whereHelpernull_String) GT(x null.String) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GT, x)
+}
+func (w whereHelpernull_String) GTE(x null.String) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GTE, x)
+}
+
+func (w whereHelpernull_String) IsNull() qm.QueryMod    { return qmhelper.WhereIsNull(w.field) }
+func (w whereHelpernull_String) IsNotNull() qm.QueryMod { return qmhelper.WhereIsNotNull(w.field) }
+
+type whereHelpernull_Int64 struct{ field string }
+
+func (w whereHelpernull_Int64) EQ(x null.Int64) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, false, x)
+}
+func (w whereHelpernull_Int64) NEQ(x null.Int64) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, true, x)
+}
+func (w whereHelpernull_Int64) LT(x null.Int64) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LT, x)
+}
+func (w whereHelpernull_Int64) LTE(x null.Int64) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LTE, x)
+}
+func (w whereHelpernull_Int64) GT(x null.Int64) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GT, x)
+}
+func (w whereHelpernull_Int64) GTE(x null.Int64) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GTE, x)
+}
+
+func (w whereHelpernull_Int64) IsNull() qm.QueryMod    { return qmhelper.WhereIsNull(w.field) }
+func (w whereHelpernull_Int64) IsNotNull() qm.QueryMod { return qmhelper.WhereIsNotNull(w.field) }
+
+type whereHelperbool struct{ field string }
+
+func (w whereHelperbool) EQ(x bool) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.EQ, x) }
+func (w whereHelperbool) NEQ(x bool) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.NEQ, x) }
+func (w whereHelperbool) LT(x bool) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.LT, x) }
+func (w whereHelperbool) LTE(x bool) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.LTE, x) }
+func (w whereHelperbool) GT(x bool) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.GT, x) }
+func (w whereHelperbool) GTE(x bool) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.GTE, x) }
+
 var TenantWhere = struct {
 	ID             whereHelpergidx_PrefixedID
 	Name           whereHelperstring
@@ -191,6 +283,13 @@ var TenantWhere = struct {
 	CreatedAt      whereHelpertime_Time
 	UpdatedAt      whereHelpertime_Time
 	DeletedAt      whereHelpernull_Time
+	Path           whereHelperstring
+	Locked         whereHelperbool
+	CreatedBy      whereHelpernull_String
+	UpdatedBy      whereHelpernull_String
+	Quota          whereHelpernull_Int64
+	Description    whereHelpernull_String
+	Kind           whereHelpernull_String
 }{
 	ID:             whereHelpergidx_PrefixedID{field: "\"tenants\".\"id\""},
 	Name:           whereHelperstring{field: "\"tenants\".\"name\""},
@@ -198,6 +297,13 @@ var TenantWhere = struct {
 	CreatedAt:      whereHelpertime_Time{field: "\"tenants\".\"created_at\""},
 	UpdatedAt:      whereHelpertime_Time{field: "\"tenants\".\"updated_at\""},
 	DeletedAt:      whereHelpernull_Time{field: "\"tenants\".\"deleted_at\""},
+	Path:           whereHelperstring{field: "\"tenants\".\"path\""},
+	Locked:         whereHelperbool{field: "\"tenants\".\"locked\""},
+	CreatedBy:      whereHelpernull_String{field: "\"tenants\".\"created_by\""},
+	UpdatedBy:      whereHelpernull_String{field: "\"tenants\".\"updated_by\""},
+	Quota:          whereHelpernull_Int64{field: "\"tenants\".\"quota\""},
+	Description:    whereHelpernull_String{field: "\"tenants\".\"description\""},
+	Kind:           whereHelpernull_String{field: "\"tenants\".\"kind\""},
 }
 
 // TenantRels is where relationship names are stored.
@@ -238,9 +344,9 @@ func (r *tenantR) GetParentTenantTenants() TenantSlice {
 type tenantL struct{}
 
 var (
-	tenantAllColumns            = []string{"id", "name", "parent_tenant_id", "created_at", "updated_at", "deleted_at"}
+	tenantAllColumns            = []string{"id", "name", "parent_tenant_id", "created_at", "updated_at", "deleted_at", "path", "locked", "created_by", "updated_by", "quota", "description", "kind"}
 	tenantColumnsWithoutDefault = []string{"id", "name", "created_at", "updated_at"}
-	tenantColumnsWithDefault    = []string{"parent_tenant_id", "deleted_at"}
+	tenantColumnsWithDefault    = []string{"parent_tenant_id", "deleted_at", "path", "locked", "created_by", "updated_by", "quota", "description", "kind"}
 	tenantPrimaryKeyColumns     = []string{"id"}
 	tenantGeneratedColumns      = []string{}
 )
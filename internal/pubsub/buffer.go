@@ -0,0 +1,402 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"go.infratographer.com/tenant-api/internal/reqctx"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultFlushInterval is the default interval buffered messages are flushed on.
+	DefaultFlushInterval = time.Second
+
+	// flushAckTimeout is how long a flush waits for the server to acknowledge a batch.
+	flushAckTimeout = 5 * time.Second
+
+	// maxRetryInterval is the longest interval failed buffered messages are retried on.
+	maxRetryInterval = 30 * time.Second
+
+	// HeaderDeadLetterSubject is the header of dead-lettered messages holding the subject the message was published to.
+	HeaderDeadLetterSubject = "Dead-Letter-Subject"
+
+	// HeaderDeadLetterReason is the header of dead-lettered messages holding the last publish failure.
+	HeaderDeadLetterReason = "Dead-Letter-Reason"
+
+	// HeaderDeadLetterAttempts is the header of dead-lettered messages holding the number of attempts made.
+	HeaderDeadLetterAttempts = "Dead-Letter-Attempts"
+)
+
+var (
+	// ErrClientClosed is returned when publishing on a client which has been closed.
+	ErrClientClosed = errors.New("pubsub client closed")
+
+	// ErrBufferFull is returned when a message can't be buffered because the publish buffer is full.
+	ErrBufferFull = errors.New("pubsub publish buffer full")
+
+	// errAckTimeout is the failure of buffered messages which weren't acknowledged in time.
+	errAckTimeout = errors.New("timed out waiting for ack")
+
+	// ErrInvalidBufferFullPolicy is returned when parsing an unknown buffer full policy.
+	ErrInvalidBufferFullPolicy = errors.New("invalid buffer full policy")
+)
+
+// BufferFullPolicy defines how publishes behave while the publish buffer is full.
+type BufferFullPolicy string
+
+const (
+	// BufferFullBlock blocks publishes until the buffer has room, failing
+	// with ErrBufferFull once the timeout is reached, if any.
+	BufferFullBlock BufferFullPolicy = "block"
+
+	// BufferFullError fails publishes with ErrBufferFull without waiting.
+	BufferFullError BufferFullPolicy = "error"
+)
+
+// ParseBufferFullPolicy parses the name of a buffer full policy.
+func ParseBufferFullPolicy(policy string) (BufferFullPolicy, error) {
+	switch p := BufferFullPolicy(policy); p {
+	case BufferFullBlock, BufferFullError:
+		return p, nil
+	}
+
+	return "", fmt.Errorf("%w: %q must be %s or %s", ErrInvalidBufferFullPolicy, policy, BufferFullBlock, BufferFullError)
+}
+
+type bufferedMessage struct {
+	subject   string
+	data      []byte
+	requestID string
+
+	// attempts is the number of failed attempts to publish the message, and
+	// err the last failure.
+	attempts int
+	err      error
+}
+
+// logFields returns the fields identifying the message in logs, followed by any additional fields.
+func (m *bufferedMessage) logFields(fields ...zap.Field) []zap.Field {
+	base := []zap.Field{zap.String("nats.subject", m.subject)}
+
+	if m.requestID != "" {
+		base = append(base, zap.String("request_id", m.requestID))
+	}
+
+	return append(base, fields...)
+}
+
+// publishBuffer collects published messages and flushes them to jetstream in
+// batches, either once the batch size is reached or on the flush interval.
+// Messages which fail to be acknowledged are retained and retried with
+// backoff, providing at-least-once delivery while the process is running,
+// until they reach the client's maximum attempts and are dead-lettered.
+type publishBuffer struct {
+	size     int
+	interval time.Duration
+
+	// fullPolicy and fullTimeout define how enqueue behaves while the buffer is full.
+	fullPolicy  BufferFullPolicy
+	fullTimeout time.Duration
+
+	msgs chan *bufferedMessage
+
+	mu      sync.RWMutex
+	closed  bool
+	closing chan struct{}
+	done    chan struct{}
+}
+
+func newPublishBuffer(size int, interval time.Duration) *publishBuffer {
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+
+	return &publishBuffer{
+		size:       size,
+		interval:   interval,
+		fullPolicy: BufferFullBlock,
+		msgs:       make(chan *bufferedMessage, size),
+		closing:    make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// enqueue adds a message to the buffer. While the buffer is full, enqueue
+// follows the buffer's full policy.
+func (b *publishBuffer) enqueue(ctx context.Context, subject string, data []byte) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return ErrClientClosed
+	}
+
+	msg := &bufferedMessage{subject: subject, data: data, requestID: reqctx.RequestID(ctx)}
+
+	select {
+	case b.msgs <- msg:
+		return nil
+	default:
+	}
+
+	if b.fullPolicy == BufferFullError {
+		return ErrBufferFull
+	}
+
+	// A nil channel never receives, blocking without a timeout.
+	var timeout <-chan time.Time
+
+	if b.fullTimeout > 0 {
+		timer := time.NewTimer(b.fullTimeout)
+		defer timer.Stop()
+
+		timeout = timer.C
+	}
+
+	select {
+	case b.msgs <- msg:
+		return nil
+	case <-timeout:
+		return ErrBufferFull
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// rejecting reports whether messages are currently rejected because the
+// buffer is full.
+func (b *publishBuffer) rejecting() bool {
+	return b.fullPolicy == BufferFullError && len(b.msgs) == cap(b.msgs)
+}
+
+// close stops accepting new messages and waits for the remaining messages to be flushed.
+func (b *publishBuffer) close() {
+	b.mu.Lock()
+
+	if b.closed {
+		b.mu.Unlock()
+
+		return
+	}
+
+	b.closed = true
+
+	close(b.closing)
+	close(b.msgs)
+
+	b.mu.Unlock()
+
+	<-b.done
+}
+
+// runBuffer flushes the buffer until it has been closed.
+//
+// Messages which fail to publish are retained and retried on the flush
+// interval, backing off up to maxRetryInterval while they keep failing. The
+// retained messages and the batch together never exceed the buffer size:
+// while the retained messages fill the buffer no new messages are read, so
+// publishes follow the buffer full policy until the retries succeed or the
+// messages are dead-lettered.
+func (c *Client) runBuffer() {
+	defer close(c.buffer.done)
+
+	ticker := time.NewTicker(c.buffer.interval)
+	defer ticker.Stop()
+
+	var (
+		batch = make([]*bufferedMessage, 0, c.buffer.size)
+		retry []*bufferedMessage
+
+		retryInterval = c.buffer.interval
+		nextRetry     time.Time
+	)
+
+	for {
+		msgs := c.buffer.msgs
+		if len(retry) >= c.buffer.size {
+			msgs = nil
+		}
+
+		select {
+		case msg, ok := <-msgs:
+			if !ok {
+				c.flushOnClose(append(retry, batch...))
+
+				return
+			}
+
+			batch = append(batch, msg)
+
+			if len(batch)+len(retry) >= c.buffer.size {
+				retry = append(retry, c.flush(batch)...)
+				batch = batch[:0]
+			}
+		case <-c.buffer.closing:
+			// The remaining messages are read until the channel is closed.
+			for msg := range c.buffer.msgs {
+				batch = append(batch, msg)
+			}
+
+			c.flushOnClose(append(retry, batch...))
+
+			return
+		case now := <-ticker.C:
+			if len(retry) != 0 && !now.Before(nextRetry) {
+				retry = c.flush(retry)
+
+				if len(retry) == 0 {
+					retryInterval = c.buffer.interval
+				} else {
+					if retryInterval *= 2; retryInterval > maxRetryInterval {
+						retryInterval = maxRetryInterval
+					}
+
+					c.logger.Warn("retrying failed buffered nats messages",
+						zap.Int("nats.messages.failed", len(retry)),
+						zap.Duration("nats.retry_interval", retryInterval),
+					)
+				}
+
+				nextRetry = now.Add(retryInterval)
+			}
+
+			if failed := c.flush(batch); len(failed) != 0 {
+				if len(retry) == 0 {
+					nextRetry = now.Add(retryInterval)
+				}
+
+				retry = append(retry, failed...)
+			}
+
+			batch = batch[:0]
+		}
+	}
+}
+
+// flushOnClose flushes the remaining messages once the buffer has been
+// closed. Messages can't be retried once closed, so any failures are
+// dead-lettered rather than dropped.
+func (c *Client) flushOnClose(pending []*bufferedMessage) {
+	failed := c.flush(pending)
+	if len(failed) == 0 {
+		return
+	}
+
+	c.logger.Error("failed to flush buffered nats messages on close", zap.Int("nats.messages.failed", len(failed)))
+
+	for _, msg := range failed {
+		c.deadLetter(msg)
+	}
+}
+
+// flush publishes the batch and waits for the acknowledgements. Any messages
+// which failed to publish, and haven't reached the maximum attempts, are
+// returned so they may be retried. The batch may be reused once flushed.
+func (c *Client) flush(batch []*bufferedMessage) []*bufferedMessage {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	futures := make([]nats.PubAckFuture, len(batch))
+
+	for i, msg := range batch {
+		future, err := c.js.PublishAsync(msg.subject, msg.data)
+		if err != nil {
+			c.logger.Debug("failed to publish buffered nats message", msg.logFields(zap.Error(err))...)
+
+			msg.err = err
+
+			continue
+		}
+
+		futures[i] = future
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushAckTimeout)
+	defer cancel()
+
+	var failed []*bufferedMessage
+
+	for i, future := range futures {
+		if future == nil {
+			failed = append(failed, batch[i])
+
+			continue
+		}
+
+		select {
+		case <-future.Ok():
+			c.logger.Debug("published nats message", batch[i].logFields()...)
+		case err := <-future.Err():
+			c.logger.Debug("failed to publish buffered nats message", batch[i].logFields(zap.Error(err))...)
+
+			batch[i].err = err
+			failed = append(failed, batch[i])
+		case <-ctx.Done():
+			c.logger.Debug("timed out waiting for buffered nats message ack", batch[i].logFields()...)
+
+			batch[i].err = errAckTimeout
+			failed = append(failed, batch[i])
+		}
+	}
+
+	return c.deadLetterFailed(failed)
+}
+
+// deadLetterFailed counts the failed attempt of each message, publishing the
+// messages which reached the maximum number of attempts to the dead-letter
+// subject. The messages to retry are returned.
+func (c *Client) deadLetterFailed(failed []*bufferedMessage) []*bufferedMessage {
+	retry := failed[:0]
+
+	for _, msg := range failed {
+		msg.attempts++
+
+		if c.maxAttempts <= 0 || msg.attempts < c.maxAttempts {
+			retry = append(retry, msg)
+
+			continue
+		}
+
+		c.deadLetter(msg)
+	}
+
+	return retry
+}
+
+// deadLetter publishes the message to the dead-letter subject, with the
+// original subject and the failure in the headers. Messages which can't be
+// dead-lettered, or without a dead-letter subject configured, are dropped,
+// and logged with their data so they may be recovered from the logs.
+func (c *Client) deadLetter(msg *bufferedMessage) {
+	fields := msg.logFields(zap.Int("nats.attempts", msg.attempts), zap.NamedError("nats.reason", msg.err))
+
+	if c.deadLetterSubject == "" {
+		c.logger.Error("failed to publish nats message, dropped", append(fields, zap.ByteString("nats.data", msg.data))...)
+
+		return
+	}
+
+	dead := nats.NewMsg(c.deadLetterSubject)
+	dead.Data = msg.data
+	dead.Header.Set(HeaderDeadLetterSubject, msg.subject)
+	dead.Header.Set(HeaderDeadLetterAttempts, strconv.Itoa(msg.attempts))
+
+	if msg.err != nil {
+		dead.Header.Set(HeaderDeadLetterReason, msg.err.Error())
+	}
+
+	if _, err := c.js.PublishMsg(dead, nats.AckWait(flushAckTimeout)); err != nil {
+		c.logger.Error("failed to dead-letter nats message, dropped", append(fields, zap.ByteString("nats.data", msg.data), zap.Error(err))...)
+
+		return
+	}
+
+	c.logger.Warn("dead-lettered nats message", append(fields, zap.String("nats.dead_letter_subject", c.deadLetterSubject))...)
+}
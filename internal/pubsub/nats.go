@@ -2,16 +2,32 @@ package pubsub
 
 import (
 	"errors"
+	"time"
 
 	"github.com/nats-io/nats.go"
 	"go.uber.org/zap"
 )
 
-// Client is an event bus client with some configuration
+// Client is an event bus client with some configuration. A nil client is
+// disabled, its publishes succeed without publishing anything.
 type Client struct {
 	js             nats.JetStreamContext
 	logger         *zap.Logger
 	prefix, stream string
+	buffer         *publishBuffer
+	fullPolicy     BufferFullPolicy
+	fullTimeout    time.Duration
+	publishTimeout time.Duration
+	timeoutPolicy  PublishTimeoutPolicy
+	sinks          []Sink
+	schemaVersion  string
+	disabled       map[string]bool
+	redacted       redactedFields
+
+	// deadLetterSubject and maxAttempts define when buffered messages stop
+	// being retried, and where they're published instead.
+	deadLetterSubject string
+	maxAttempts       int
 }
 
 // Option is a functional configuration option for governor eventing
@@ -20,16 +36,40 @@ type Option func(c *Client)
 // NewClient configures and establishes a new event bus client connection
 func NewClient(opts ...Option) *Client {
 	client := Client{
-		logger: zap.NewNop(),
+		logger:        zap.NewNop(),
+		schemaVersion: SchemaVersion,
 	}
 
 	for _, opt := range opts {
 		opt(&client)
 	}
 
+	if client.buffer != nil {
+		if client.fullPolicy != "" {
+			client.buffer.fullPolicy = client.fullPolicy
+		}
+
+		client.buffer.fullTimeout = client.fullTimeout
+
+		go client.runBuffer()
+	}
+
 	return &client
 }
 
+// RejectingPublishes reports whether publishes currently fail with
+// ErrBufferFull, as the publish buffer is full and its policy is to error.
+func (c *Client) RejectingPublishes() bool {
+	return c != nil && c.buffer != nil && c.buffer.rejecting()
+}
+
+// Close flushes any buffered messages and stops the client from accepting new messages.
+func (c *Client) Close() {
+	if c != nil && c.buffer != nil {
+		c.buffer.close()
+	}
+}
+
 // WithJetreamContext sets the nats jetstream context
 func WithJetreamContext(js nats.JetStreamContext) Option {
 	return func(c *Client) {
@@ -51,6 +91,101 @@ func WithSubjectPrefix(p string) Option {
 	}
 }
 
+// WithBuffering enables buffering of published messages, which are then flushed
+// in batches of size or on the provided interval, whichever comes first.
+// A size of zero or less keeps publishing synchronous.
+func WithBuffering(size int, interval time.Duration) Option {
+	return func(c *Client) {
+		if size > 0 {
+			c.buffer = newPublishBuffer(size, interval)
+		}
+	}
+}
+
+// WithBufferFullPolicy sets how publishes behave while the publish buffer is
+// full. With BufferFullBlock, publishes wait for room in the buffer for up
+// to the timeout, or indefinitely when the timeout is zero. With
+// BufferFullError, publishes fail immediately. The policy only applies when
+// buffering is enabled, and defaults to blocking without a timeout.
+func WithBufferFullPolicy(policy BufferFullPolicy, timeout time.Duration) Option {
+	return func(c *Client) {
+		c.fullPolicy = policy
+		c.fullTimeout = timeout
+	}
+}
+
+// WithPublishTimeout limits how long synchronous publishes wait for their
+// acknowledgement, so a stalled nats server doesn't hold up requests. With
+// PublishTimeoutError, timed out publishes fail with ErrPublishTimeout. With
+// PublishTimeoutBuffer, publishes are made synchronously even when buffering
+// is enabled, and only timed out messages are added to the publish buffer,
+// to be retried in the background. Without buffering enabled, timed out
+// publishes fail under either policy. A zero timeout keeps the jetstream
+// context's default wait.
+func WithPublishTimeout(timeout time.Duration, policy PublishTimeoutPolicy) Option {
+	return func(c *Client) {
+		c.publishTimeout = timeout
+		c.timeoutPolicy = policy
+	}
+}
+
+// WithDeadLetter limits the number of times a buffered message is attempted.
+// Messages failing maxAttempts times are published to the dead-letter subject
+// instead, with headers describing the failure, so they no longer hold up
+// the buffer and may be inspected or replayed. Buffered messages are retried
+// until they succeed when maxAttempts is zero, the default. The limit only
+// applies when buffering is enabled.
+func WithDeadLetter(subject string, maxAttempts int) Option {
+	return func(c *Client) {
+		c.deadLetterSubject = subject
+		c.maxAttempts = maxAttempts
+	}
+}
+
+// WithSinks sends every published message to the provided sinks in addition to nats.
+func WithSinks(sinks ...Sink) Option {
+	return func(c *Client) {
+		c.sinks = append(c.sinks, sinks...)
+	}
+}
+
+// WithSchemaVersion overrides the schema version published events carry.
+func WithSchemaVersion(version string) Option {
+	return func(c *Client) {
+		c.schemaVersion = version
+	}
+}
+
+// WithDisabledEventTypes disables publishing the provided event types, which
+// are skipped entirely rather than published to nats or any sinks.
+func WithDisabledEventTypes(eventTypes ...string) Option {
+	return func(c *Client) {
+		for _, eventType := range eventTypes {
+			if c.disabled == nil {
+				c.disabled = make(map[string]bool)
+			}
+
+			c.disabled[eventType] = true
+		}
+	}
+}
+
+// WithRedactedFields removes the field changes of the provided fields from
+// published events, such as "description" or "labels", so their values
+// aren't published to nats or any sinks. A field also redacts the fields
+// nested under it, "labels" redacting every "labels.<key>" change.
+func WithRedactedFields(fields ...string) Option {
+	return func(c *Client) {
+		for _, field := range fields {
+			if c.redacted == nil {
+				c.redacted = make(redactedFields)
+			}
+
+			c.redacted[field] = true
+		}
+	}
+}
+
 // WithLogger sets the client logger
 func WithLogger(l *zap.Logger) Option {
 	return func(c *Client) {
@@ -60,7 +195,13 @@ func WithLogger(l *zap.Logger) Option {
 
 // AddStream checks if a stream exists and attempts to create it if it doesn't. Currently we don't
 // currently check that the stream is configured identically to the desired configuration.
+//
+// A disabled client has no stream, and returns no info.
 func (c *Client) AddStream() (*nats.StreamInfo, error) {
+	if c == nil {
+		return nil, nil
+	}
+
 	c.logger.Debug("checking for nats stream", zap.String("nats.stream.name", c.stream))
 
 	info, err := c.js.StreamInfo(c.stream)
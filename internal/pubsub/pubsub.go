@@ -4,10 +4,12 @@ package pubsub
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/nats-io/nats.go"
+	"go.infratographer.com/tenant-api/internal/reqctx"
 	"go.infratographer.com/x/gidx"
 	"go.infratographer.com/x/pubsubx"
 	"go.uber.org/zap"
@@ -20,11 +22,90 @@ const (
 	DeleteEventType = "delete"
 	// UpdateEventType is the update event type string
 	UpdateEventType = "update"
+
+	// SchemaVersion is the default version of the event payload schema,
+	// which is incremented when the format of published events changes.
+	SchemaVersion = "1"
+
+	// SchemaVersionKey is the additional data key published events carry their schema version under.
+	SchemaVersionKey = "schemaVersion"
+
+	// CorrelationIDKey is the additional data key published events carry the
+	// correlation id of the request which caused them under.
+	CorrelationIDKey = "correlationID"
+
+	// BatchOperationKey is the additional data key aggregate events of batch
+	// operations carry the operation under.
+	BatchOperationKey = "batchOperation"
+
+	// BatchCreateOperation is the operation of aggregate events of tenants created in a batch.
+	BatchCreateOperation = "create"
+	// BatchMoveOperation is the operation of aggregate events of tenants moved in a batch.
+	BatchMoveOperation = "move"
+	// BatchDeleteOperation is the operation of aggregate events of tenants deleted in a batch.
+	BatchDeleteOperation = "delete"
 )
 
+var (
+	// ErrInvalidEventType is returned when validating an unknown event type.
+	ErrInvalidEventType = errors.New("invalid event type")
+
+	// ErrPublishTimeout is returned when a synchronous publish isn't acknowledged in time.
+	ErrPublishTimeout = errors.New("pubsub publish timed out")
+
+	// ErrEmptyBatch is returned when creating an aggregate event of a batch which affected no tenants.
+	ErrEmptyBatch = errors.New("empty batch")
+
+	// ErrInvalidPublishTimeoutPolicy is returned when parsing an unknown publish timeout policy.
+	ErrInvalidPublishTimeoutPolicy = errors.New("invalid publish timeout policy")
+)
+
+// PublishTimeoutPolicy defines how synchronous publishes behave once they
+// time out waiting for their acknowledgement.
+type PublishTimeoutPolicy string
+
+const (
+	// PublishTimeoutError fails publishes with ErrPublishTimeout.
+	PublishTimeoutError PublishTimeoutPolicy = "error"
+
+	// PublishTimeoutBuffer adds timed out messages to the publish buffer,
+	// which retries them in the background, and the publish succeeds.
+	PublishTimeoutBuffer PublishTimeoutPolicy = "buffer"
+)
+
+// ParsePublishTimeoutPolicy parses the name of a publish timeout policy.
+func ParsePublishTimeoutPolicy(policy string) (PublishTimeoutPolicy, error) {
+	switch p := PublishTimeoutPolicy(policy); p {
+	case PublishTimeoutError, PublishTimeoutBuffer:
+		return p, nil
+	}
+
+	return "", fmt.Errorf("%w: %q must be %s or %s", ErrInvalidPublishTimeoutPolicy, policy, PublishTimeoutError, PublishTimeoutBuffer)
+}
+
+// ValidateEventTypes ensures each of the event types is a create, update or delete event type.
+func ValidateEventTypes(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		switch eventType {
+		case CreateEventType, UpdateEventType, DeleteEventType:
+		default:
+			return fmt.Errorf("%w: %q must be %s, %s or %s", ErrInvalidEventType, eventType, CreateEventType, UpdateEventType, DeleteEventType)
+		}
+	}
+
+	return nil
+}
+
 // May be a config option later
 var prefix = "com.infratographer.events"
 
+// Sink receives a copy of every message once published or buffered, such as
+// for delivery over another transport. Send must not block on delivering the
+// message.
+type Sink interface {
+	Send(ctx context.Context, subject string, data []byte) error
+}
+
 func newMessage(actorID, subjectID gidx.PrefixedID, additionalSubjectIDs ...gidx.PrefixedID) *pubsubx.ChangeMessage {
 	return &pubsubx.ChangeMessage{
 		SubjectID:            subjectID,
@@ -37,46 +118,214 @@ func newMessage(actorID, subjectID gidx.PrefixedID, additionalSubjectIDs ...gidx
 
 // PublishCreate publishes a create event
 func (c *Client) PublishCreate(ctx context.Context, actor gidx.PrefixedID, location string, data *pubsubx.ChangeMessage) error {
+	if c == nil {
+		return skipDisabled(ctx, CreateEventType, data)
+	}
+
 	data.EventType = CreateEventType
+	c.setSchemaVersion(data)
+	setCorrelationID(ctx, data)
+	c.redactFields(data)
 
 	return c.publish(ctx, CreateEventType, actor, location, data)
 }
 
 // PublishUpdate publishes an update event
 func (c *Client) PublishUpdate(ctx context.Context, actor gidx.PrefixedID, location string, data *pubsubx.ChangeMessage) error {
+	if c == nil {
+		return skipDisabled(ctx, UpdateEventType, data)
+	}
+
 	data.EventType = UpdateEventType
+	c.setSchemaVersion(data)
+	setCorrelationID(ctx, data)
+	c.redactFields(data)
 
 	return c.publish(ctx, UpdateEventType, actor, location, data)
 }
 
 // PublishDelete publishes a delete event
 func (c *Client) PublishDelete(ctx context.Context, actor gidx.PrefixedID, location string, data *pubsubx.ChangeMessage) error {
+	if c == nil {
+		return skipDisabled(ctx, DeleteEventType, data)
+	}
+
 	data.EventType = DeleteEventType
+	c.setSchemaVersion(data)
+	setCorrelationID(ctx, data)
+	c.redactFields(data)
+
 	return c.publish(ctx, DeleteEventType, actor, location, data)
 }
 
+// skipDisabled logs the event of a disabled client, which is not published.
+func skipDisabled(ctx context.Context, eventType string, data *pubsubx.ChangeMessage) error {
+	reqctx.Logger(ctx, zap.NewNop()).Debug("pubsub is disabled, skipped publishing event",
+		zap.String("event.type", eventType),
+		zap.String("event.subject_id", string(data.SubjectID)),
+	)
+
+	return nil
+}
+
+// setSchemaVersion records the client's schema version in the message's additional data.
+func (c *Client) setSchemaVersion(data *pubsubx.ChangeMessage) {
+	if data.AdditionalData == nil {
+		data.AdditionalData = make(map[string]interface{})
+	}
+
+	data.AdditionalData[SchemaVersionKey] = c.schemaVersion
+}
+
+// setCorrelationID records the correlation id from the context, if any, in
+// the message's additional data.
+func setCorrelationID(ctx context.Context, data *pubsubx.ChangeMessage) {
+	id := reqctx.CorrelationID(ctx)
+	if id == "" {
+		return
+	}
+
+	if data.AdditionalData == nil {
+		data.AdditionalData = make(map[string]interface{})
+	}
+
+	data.AdditionalData[CorrelationIDKey] = id
+}
+
+// redactFields removes the field changes of the client's redacted fields
+// from the message. A redacted field also redacts the fields nested under
+// it, so "labels" redacts every "labels.<key>" change. The subject, actor
+// and additional subject ids are always kept.
+func (c *Client) redactFields(data *pubsubx.ChangeMessage) {
+	if len(c.redacted) == 0 || len(data.FieldChanges) == 0 {
+		return
+	}
+
+	changes := data.FieldChanges[:0]
+
+	for _, change := range data.FieldChanges {
+		if !c.redacted.matches(change.Field) {
+			changes = append(changes, change)
+		}
+	}
+
+	data.FieldChanges = changes
+}
+
 // publish publishes an event
 func (c *Client) publish(ctx context.Context, action, actor gidx.PrefixedID, location string, data interface{}) error {
 	subject := fmt.Sprintf("%s.%s.%s.%s", prefix, actor, action, location)
 
+	logger := c.contextLogger(ctx)
+
+	if c.disabled[string(action)] {
+		logger.Debug("skipped publishing disabled event type", zap.String("nats.subject", subject))
+
+		return nil
+	}
+
 	b, err := json.Marshal(data)
 	if err != nil {
-		c.logger.Debug("failed to marshal message", zap.String("nats.subject", subject), zap.Error(err))
+		logger.Debug("failed to marshal message", zap.String("nats.subject", subject), zap.Error(err))
 
 		return err
 	}
 
-	if _, err := c.js.Publish(subject, b); err != nil {
-		c.logger.Debug("failed to publish nats message", zap.String("nats.subject", subject), zap.Error(err))
+	// With the buffer timeout policy, messages are only buffered once their
+	// synchronous publish times out.
+	if c.buffer != nil && c.timeoutPolicy != PublishTimeoutBuffer {
+		return c.enqueueAndSend(ctx, subject, b)
+	}
+
+	err = c.publishSync(subject, b)
+	if errors.Is(err, ErrPublishTimeout) && c.buffer != nil {
+		logger.Warn("nats publish timed out, buffering message", zap.String("nats.subject", subject), zap.Error(err))
+
+		return c.enqueueAndSend(ctx, subject, b)
+	}
 
+	if err != nil {
+		logger.Debug("failed to publish nats message", zap.String("nats.subject", subject), zap.Error(err))
+
+		return err
+	}
+
+	logger.Debug("published nats message", zap.String("nats.subject", subject))
+
+	c.sendToSinks(ctx, subject, b)
+
+	return nil
+}
+
+// enqueueAndSend adds the message to the publish buffer and sends it to the
+// sinks once buffered.
+func (c *Client) enqueueAndSend(ctx context.Context, subject string, b []byte) error {
+	if err := c.enqueue(ctx, subject, b); err != nil {
 		return err
 	}
 
-	c.logger.Debug("published nats message", zap.String("nats.subject", subject))
+	c.sendToSinks(ctx, subject, b)
 
 	return nil
 }
 
+// sendToSinks sends the message to the sinks. Messages are only sent once
+// published or buffered, so sinks don't receive events of changes whose
+// publish failed. Sinks are additive to nats, a failure to send to a sink
+// doesn't fail the publish.
+func (c *Client) sendToSinks(ctx context.Context, subject string, b []byte) {
+	for _, sink := range c.sinks {
+		if err := sink.Send(ctx, subject, b); err != nil {
+			c.contextLogger(ctx).Warn("failed to send message to sink", zap.String("nats.subject", subject), zap.Error(err))
+		}
+	}
+}
+
+// enqueue adds the message to the publish buffer.
+func (c *Client) enqueue(ctx context.Context, subject string, b []byte) error {
+	logger := c.contextLogger(ctx)
+
+	if err := c.buffer.enqueue(ctx, subject, b); err != nil {
+		logger.Debug("failed to buffer nats message", zap.String("nats.subject", subject), zap.Error(err))
+
+		return err
+	}
+
+	logger.Debug("buffered nats message", zap.String("nats.subject", subject))
+
+	return nil
+}
+
+// publishSync publishes the message and waits for its acknowledgement, for
+// up to the publish timeout when set. ErrPublishTimeout is returned once
+// the wait times out.
+func (c *Client) publishSync(subject string, b []byte) error {
+	var opts []nats.PubOpt
+
+	if c.publishTimeout > 0 {
+		opts = append(opts, nats.AckWait(c.publishTimeout))
+	}
+
+	if _, err := c.js.Publish(subject, b, opts...); err != nil {
+		if errors.Is(err, nats.ErrTimeout) {
+			return fmt.Errorf("%w: %s", ErrPublishTimeout, err)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// contextLogger returns the client logger including the request id from the context, if any.
+func (c *Client) contextLogger(ctx context.Context) *zap.Logger {
+	if id := reqctx.RequestID(ctx); id != "" {
+		return c.logger.With(zap.String("request_id", id))
+	}
+
+	return c.logger
+}
+
 // ChanSubscribe creates a subcription and returns messages on a channel
 func (c *Client) ChanSubscribe(ctx context.Context, sub string, ch chan *nats.Msg, stream string) (*nats.Subscription, error) {
 	return c.js.ChanSubscribe(sub, ch, nats.BindStream(stream))
@@ -0,0 +1,707 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/tenant-api/internal/reqctx"
+	"go.infratographer.com/x/gidx"
+	"go.infratographer.com/x/pubsubx"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+const testMsgTimeout = 2 * time.Second
+
+// newTestClient creates a new client with its own stream on the test nats server.
+func newTestClient(t *testing.T, opts ...Option) (*Client, chan *nats.Msg) {
+	t.Helper()
+
+	id := gidx.MustNewID("testing")
+
+	nc, err := nats.Connect(natsSrv.ClientURL())
+	require.NoError(t, err, "expected no error connecting to nats")
+
+	t.Cleanup(nc.Close)
+
+	js, err := nc.JetStream()
+	require.NoError(t, err, "expected no error creating jetstream context")
+
+	opts = append([]Option{
+		WithJetreamContext(js),
+		WithLogger(zap.NewNop()),
+		WithStreamName("nats-test-" + string(id)),
+		WithSubjectPrefix(prefix),
+	}, opts...)
+
+	client := NewClient(opts...)
+
+	_, err = client.AddStream()
+	require.NoError(t, err, "expected no error adding stream")
+
+	t.Cleanup(func() {
+		assert.NoError(t, client.deleteStream())
+	})
+
+	msgs := make(chan *nats.Msg, 10)
+
+	sub, err := client.ChanSubscribe(context.Background(), prefix+".>", msgs, client.stream)
+	require.NoError(t, err, "expected no error subscribing")
+
+	t.Cleanup(func() {
+		assert.NoError(t, sub.Unsubscribe())
+	})
+
+	return client, msgs
+}
+
+func receiveMessages(t *testing.T, msgs chan *nats.Msg, count int, timeout time.Duration) []*nats.Msg {
+	t.Helper()
+
+	var received []*nats.Msg
+
+	deadline := time.After(timeout)
+
+	for len(received) < count {
+		select {
+		case msg := <-msgs:
+			received = append(received, msg)
+		case <-deadline:
+			return received
+		}
+	}
+
+	return received
+}
+
+func TestClient_PublishBuffered(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("flush on size", func(t *testing.T) {
+		client, msgs := newTestClient(t, WithBuffering(2, time.Hour))
+		defer client.Close()
+
+		msg, err := NewTenantMessage("", gidx.MustNewID("testten"))
+		require.NoError(t, err)
+
+		require.NoError(t, client.PublishCreate(ctx, "tenants", "global", msg))
+
+		assert.Empty(t, receiveMessages(t, msgs, 1, 100*time.Millisecond), "expected message to remain buffered")
+
+		require.NoError(t, client.PublishCreate(ctx, "tenants", "global", msg))
+
+		assert.Len(t, receiveMessages(t, msgs, 2, testMsgTimeout), 2, "expected buffer to be flushed once full")
+	})
+
+	t.Run("flush on interval", func(t *testing.T) {
+		client, msgs := newTestClient(t, WithBuffering(100, 50*time.Millisecond))
+		defer client.Close()
+
+		msg, err := NewTenantMessage("", gidx.MustNewID("testten"))
+		require.NoError(t, err)
+
+		require.NoError(t, client.PublishUpdate(ctx, "tenants", "global", msg))
+
+		received := receiveMessages(t, msgs, 1, testMsgTimeout)
+		require.Len(t, received, 1, "expected buffer to be flushed on interval")
+		assert.Equal(t, prefix+".tenants.update.global", received[0].Subject)
+	})
+
+	t.Run("flush on close", func(t *testing.T) {
+		client, msgs := newTestClient(t, WithBuffering(100, time.Hour))
+
+		msg, err := NewTenantMessage("", gidx.MustNewID("testten"))
+		require.NoError(t, err)
+
+		require.NoError(t, client.PublishDelete(ctx, "tenants", "global", msg))
+
+		client.Close()
+
+		assert.Len(t, receiveMessages(t, msgs, 1, testMsgTimeout), 1, "expected buffer to be flushed on close")
+
+		assert.ErrorIs(t, client.PublishDelete(ctx, "tenants", "global", msg), ErrClientClosed)
+	})
+
+	t.Run("synchronous by default", func(t *testing.T) {
+		client, msgs := newTestClient(t)
+		defer client.Close()
+
+		msg, err := NewTenantMessage("", gidx.MustNewID("testten"))
+		require.NoError(t, err)
+
+		require.NoError(t, client.PublishCreate(ctx, "tenants", "global", msg))
+
+		assert.Len(t, receiveMessages(t, msgs, 1, testMsgTimeout), 1, "expected message to be published immediately")
+	})
+}
+
+func TestClient_PublishDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	deadLetterSubject := prefix + ".dead-letter"
+
+	t.Run("dead-lettered after max attempts", func(t *testing.T) {
+		client, msgs := newTestClient(t, WithBuffering(10, 20*time.Millisecond), WithDeadLetter(deadLetterSubject, 3))
+		defer client.Close()
+
+		// No stream captures the subject, so every attempt fails.
+		require.NoError(t, client.buffer.enqueue(ctx, "unrouted.subject", []byte(`{"failing":true}`)))
+
+		msg, err := NewTenantMessage("", gidx.MustNewID("testten"))
+		require.NoError(t, err)
+
+		require.NoError(t, client.PublishCreate(ctx, "tenants", "global", msg))
+
+		received := receiveMessages(t, msgs, 2, testMsgTimeout)
+		require.Len(t, received, 2, "expected the event and the dead-lettered message")
+
+		assert.Equal(t, prefix+".tenants.create.global", received[0].Subject, "expected the event not to be held up")
+
+		dead := received[1]
+
+		assert.Equal(t, deadLetterSubject, dead.Subject)
+		assert.Equal(t, `{"failing":true}`, string(dead.Data), "expected the original message")
+		assert.Equal(t, "unrouted.subject", dead.Header.Get(HeaderDeadLetterSubject))
+		assert.Equal(t, "3", dead.Header.Get(HeaderDeadLetterAttempts))
+		assert.NotEmpty(t, dead.Header.Get(HeaderDeadLetterReason), "expected the failure reason")
+
+		assert.Empty(t, receiveMessages(t, msgs, 1, 100*time.Millisecond), "expected the message not to be retried")
+	})
+
+	t.Run("dead-lettered on close", func(t *testing.T) {
+		client, msgs := newTestClient(t, WithBuffering(10, time.Hour), WithDeadLetter(deadLetterSubject, 0))
+
+		require.NoError(t, client.buffer.enqueue(ctx, "unrouted.subject", []byte(`{"failing":true}`)))
+
+		client.Close()
+
+		received := receiveMessages(t, msgs, 1, testMsgTimeout)
+		require.Len(t, received, 1, "expected the message failing on close to be dead-lettered")
+
+		assert.Equal(t, deadLetterSubject, received[0].Subject)
+		assert.Equal(t, `{"failing":true}`, string(received[0].Data), "expected the original message")
+		assert.Equal(t, "unrouted.subject", received[0].Header.Get(HeaderDeadLetterSubject))
+		assert.Equal(t, "1", received[0].Header.Get(HeaderDeadLetterAttempts))
+	})
+
+	t.Run("logged on close without a dead-letter subject", func(t *testing.T) {
+		core, logs := observer.New(zap.ErrorLevel)
+
+		client, _ := newTestClient(t, WithBuffering(10, time.Hour), WithLogger(zap.New(core)))
+
+		require.NoError(t, client.buffer.enqueue(ctx, "unrouted.subject", []byte(`{"failing":true}`)))
+
+		client.Close()
+
+		dropped := logs.FilterMessage("failed to publish nats message, dropped").All()
+		require.Len(t, dropped, 1, "expected the dropped message to be logged")
+
+		fields := dropped[0].ContextMap()
+
+		assert.Equal(t, "unrouted.subject", fields["nats.subject"])
+		assert.Equal(t, `{"failing":true}`, fields["nats.data"], "expected the message data to be logged")
+	})
+
+	t.Run("retried without a limit", func(t *testing.T) {
+		client, msgs := newTestClient(t, WithBuffering(10, 20*time.Millisecond))
+		defer client.Close()
+
+		require.NoError(t, client.buffer.enqueue(ctx, "unrouted.subject", []byte(`{}`)))
+
+		assert.Empty(t, receiveMessages(t, msgs, 1, 200*time.Millisecond), "expected the message not to be dead-lettered")
+	})
+}
+
+func TestClient_PublishBufferedRetries(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("failed messages fill the buffer", func(t *testing.T) {
+		client, _ := newTestClient(t, WithBuffering(2, 20*time.Millisecond), WithBufferFullPolicy(BufferFullError, 0))
+		defer client.Close()
+
+		// No stream captures the subject, so every attempt fails.
+		require.NoError(t, client.buffer.enqueue(ctx, "unrouted.subject", []byte(`{}`)))
+		require.NoError(t, client.buffer.enqueue(ctx, "unrouted.subject", []byte(`{}`)))
+
+		require.Eventually(t, func() bool {
+			return len(client.buffer.msgs) == 0
+		}, testMsgTimeout, 10*time.Millisecond, "expected the failing messages to be read")
+
+		// The retained messages fill the buffer, so new messages are no
+		// longer read and only the channel has room.
+		require.NoError(t, client.buffer.enqueue(ctx, "unrouted.subject", []byte(`{}`)))
+		require.NoError(t, client.buffer.enqueue(ctx, "unrouted.subject", []byte(`{}`)))
+
+		assert.ErrorIs(t, client.buffer.enqueue(ctx, "unrouted.subject", []byte(`{}`)), ErrBufferFull)
+		assert.True(t, client.RejectingPublishes(), "expected publishes to be rejected")
+	})
+
+	t.Run("retries back off", func(t *testing.T) {
+		core, logs := observer.New(zap.WarnLevel)
+
+		client, _ := newTestClient(t, WithBuffering(10, 20*time.Millisecond), WithLogger(zap.New(core)))
+		defer client.Close()
+
+		require.NoError(t, client.buffer.enqueue(ctx, "unrouted.subject", []byte(`{}`)))
+
+		time.Sleep(400 * time.Millisecond)
+
+		// Retried on every flush the message would be retried around 20
+		// times, while backing off it is retried after 40, 80 and 160ms.
+		retries := logs.FilterMessage("retrying failed buffered nats messages").Len()
+		assert.Positive(t, retries, "expected the message to be retried")
+		assert.LessOrEqual(t, retries, 5, "expected retries to back off")
+	})
+}
+
+func TestParseBufferFullPolicy(t *testing.T) {
+	for _, policy := range []BufferFullPolicy{BufferFullBlock, BufferFullError} {
+		parsed, err := ParseBufferFullPolicy(string(policy))
+		require.NoError(t, err)
+		assert.Equal(t, policy, parsed)
+	}
+
+	_, err := ParseBufferFullPolicy("spool")
+	assert.ErrorIs(t, err, ErrInvalidBufferFullPolicy)
+}
+
+func TestParsePublishTimeoutPolicy(t *testing.T) {
+	for _, policy := range []PublishTimeoutPolicy{PublishTimeoutError, PublishTimeoutBuffer} {
+		parsed, err := ParsePublishTimeoutPolicy(string(policy))
+		require.NoError(t, err)
+		assert.Equal(t, policy, parsed)
+	}
+
+	_, err := ParsePublishTimeoutPolicy("drop")
+	assert.ErrorIs(t, err, ErrInvalidPublishTimeoutPolicy)
+}
+
+func TestClient_PublishTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	// stalledClient creates a client publishing to subjects no stream
+	// captures, which a subscriber receives without ever acknowledging them,
+	// as a stalled server would.
+	stalledClient := func(t *testing.T, opts ...Option) *Client {
+		t.Helper()
+
+		nc, err := nats.Connect(natsSrv.ClientURL())
+		require.NoError(t, err, "expected no error connecting to nats")
+
+		t.Cleanup(nc.Close)
+
+		js, err := nc.JetStream()
+		require.NoError(t, err, "expected no error creating jetstream context")
+
+		client := NewClient(append([]Option{WithJetreamContext(js), WithLogger(zap.NewNop())}, opts...)...)
+
+		// Closed once unsubscribed, so buffered messages fail to flush without stalling.
+		t.Cleanup(client.Close)
+
+		sub, err := nc.Subscribe(prefix+".>", func(*nats.Msg) {})
+		require.NoError(t, err, "expected no error subscribing")
+
+		t.Cleanup(func() {
+			assert.NoError(t, sub.Unsubscribe())
+		})
+
+		return client
+	}
+
+	msg, err := NewTenantMessage("", gidx.MustNewID("testten"))
+	require.NoError(t, err)
+
+	t.Run("error", func(t *testing.T) {
+		client := stalledClient(t, WithPublishTimeout(50*time.Millisecond, PublishTimeoutError))
+
+		start := time.Now()
+
+		assert.ErrorIs(t, client.PublishCreate(ctx, "tenants", "global", msg), ErrPublishTimeout)
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond, "expected publish to wait for the timeout")
+		assert.Less(t, time.Since(start), time.Second, "expected publish not to wait past the timeout")
+	})
+
+	t.Run("buffer", func(t *testing.T) {
+		core, logs := observer.New(zap.DebugLevel)
+
+		client := stalledClient(t, WithBuffering(10, time.Hour), WithPublishTimeout(50*time.Millisecond, PublishTimeoutBuffer), WithLogger(zap.New(core)))
+
+		start := time.Now()
+
+		assert.NoError(t, client.PublishCreate(ctx, "tenants", "global", msg), "expected timed out message to be buffered")
+		assert.Less(t, time.Since(start), time.Second, "expected publish not to wait past the timeout")
+		assert.Equal(t, 1, logs.FilterMessage("nats publish timed out, buffering message").Len(), "expected publish to time out")
+		assert.Equal(t, 1, logs.FilterMessage("buffered nats message").Len(), "expected message to be buffered")
+	})
+
+	t.Run("buffer without buffering", func(t *testing.T) {
+		client := stalledClient(t, WithPublishTimeout(50*time.Millisecond, PublishTimeoutBuffer))
+
+		assert.ErrorIs(t, client.PublishCreate(ctx, "tenants", "global", msg), ErrPublishTimeout)
+	})
+}
+
+func TestPublishBufferFull(t *testing.T) {
+	ctx := context.Background()
+
+	// The buffers are never flushed, so they remain full after the first message.
+	fullBuffer := func(t *testing.T, policy BufferFullPolicy, timeout time.Duration) *publishBuffer {
+		t.Helper()
+
+		b := newPublishBuffer(1, time.Hour)
+		b.fullPolicy = policy
+		b.fullTimeout = timeout
+
+		require.NoError(t, b.enqueue(ctx, "subject", nil), "expected message to be buffered")
+
+		return b
+	}
+
+	t.Run("error", func(t *testing.T) {
+		b := fullBuffer(t, BufferFullError, time.Hour)
+		client := &Client{buffer: b}
+
+		assert.True(t, client.RejectingPublishes(), "expected publishes to be rejected")
+
+		start := time.Now()
+
+		assert.ErrorIs(t, b.enqueue(ctx, "subject", nil), ErrBufferFull)
+		assert.Less(t, time.Since(start), 100*time.Millisecond, "expected publish to fail without waiting")
+
+		<-b.msgs
+
+		assert.False(t, client.RejectingPublishes(), "expected publishes to be accepted once the buffer has room")
+	})
+
+	t.Run("block with timeout", func(t *testing.T) {
+		b := fullBuffer(t, BufferFullBlock, 50*time.Millisecond)
+
+		assert.False(t, (&Client{buffer: b}).RejectingPublishes(), "expected blocking publishes not to be rejected")
+
+		start := time.Now()
+
+		assert.ErrorIs(t, b.enqueue(ctx, "subject", nil), ErrBufferFull)
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond, "expected publish to wait for the timeout")
+	})
+
+	t.Run("block until room", func(t *testing.T) {
+		b := fullBuffer(t, BufferFullBlock, time.Hour)
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			<-b.msgs
+		}()
+
+		assert.NoError(t, b.enqueue(ctx, "subject", nil), "expected publish to succeed once the buffer has room")
+	})
+
+	t.Run("block without timeout", func(t *testing.T) {
+		b := fullBuffer(t, BufferFullBlock, 0)
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		assert.ErrorIs(t, b.enqueue(ctx, "subject", nil), context.DeadlineExceeded, "expected publish to block until the context is done")
+	})
+}
+
+func TestClient_PublishRequestID(t *testing.T) {
+	ctx := reqctx.WithRequestID(context.Background(), "test-request-id")
+
+	msg, err := NewTenantMessage("", gidx.MustNewID("testten"))
+	require.NoError(t, err)
+
+	t.Run("synchronous", func(t *testing.T) {
+		core, logs := observer.New(zap.DebugLevel)
+
+		client, msgs := newTestClient(t, WithLogger(zap.New(core)))
+		defer client.Close()
+
+		require.NoError(t, client.PublishCreate(ctx, "tenants", "global", msg))
+		require.Len(t, receiveMessages(t, msgs, 1, testMsgTimeout), 1)
+
+		published := logs.FilterMessage("published nats message").All()
+		require.Len(t, published, 1, "expected publish to be logged")
+		assert.Equal(t, "test-request-id", published[0].ContextMap()["request_id"])
+	})
+
+	t.Run("buffered", func(t *testing.T) {
+		core, logs := observer.New(zap.DebugLevel)
+
+		client, msgs := newTestClient(t, WithLogger(zap.New(core)), WithBuffering(1, time.Hour))
+		defer client.Close()
+
+		require.NoError(t, client.PublishCreate(ctx, "tenants", "global", msg))
+		require.Len(t, receiveMessages(t, msgs, 1, testMsgTimeout), 1)
+
+		client.Close()
+
+		published := logs.FilterMessage("published nats message").All()
+		require.Len(t, published, 1, "expected flushed publish to be logged")
+		assert.Equal(t, "test-request-id", published[0].ContextMap()["request_id"])
+	})
+}
+
+func TestClient_PublishCorrelationID(t *testing.T) {
+	ctx := reqctx.WithCorrelationID(context.Background(), "test-correlation-id")
+
+	client, msgs := newTestClient(t)
+	defer client.Close()
+
+	msg, err := UpdateTenantMessage("", gidx.MustNewID("testten"))
+	require.NoError(t, err)
+
+	require.NoError(t, client.PublishUpdate(ctx, "tenants", "global", msg))
+
+	received := receiveMessages(t, msgs, 1, testMsgTimeout)
+	require.Len(t, received, 1)
+
+	var published pubsubx.ChangeMessage
+
+	require.NoError(t, json.Unmarshal(received[0].Data, &published))
+	assert.Equal(t, "test-correlation-id", published.AdditionalData[CorrelationIDKey], "expected correlation id in event")
+}
+
+func TestValidateEventTypes(t *testing.T) {
+	assert.NoError(t, ValidateEventTypes(nil))
+	assert.NoError(t, ValidateEventTypes([]string{CreateEventType, UpdateEventType, DeleteEventType}))
+	assert.ErrorIs(t, ValidateEventTypes([]string{UpdateEventType, "move"}), ErrInvalidEventType)
+}
+
+func TestClient_PublishDisabledEventTypes(t *testing.T) {
+	ctx := context.Background()
+	tenantID := gidx.MustNewID("testten")
+
+	sink := new(testSink)
+
+	client, msgs := newTestClient(t, WithDisabledEventTypes(UpdateEventType, DeleteEventType), WithSinks(sink))
+	defer client.Close()
+
+	update, err := UpdateTenantMessage("", tenantID)
+	require.NoError(t, err)
+
+	require.NoError(t, client.PublishUpdate(ctx, "tenants", "global", update), "expected disabled publishes not to fail")
+
+	remove, err := DeleteTenantMessage("", tenantID)
+	require.NoError(t, err)
+
+	require.NoError(t, client.PublishDelete(ctx, "tenants", "global", remove), "expected disabled publishes not to fail")
+
+	create, err := NewTenantMessage("", tenantID)
+	require.NoError(t, err)
+
+	require.NoError(t, client.PublishCreate(ctx, "tenants", "global", create))
+
+	received := receiveMessages(t, msgs, 2, testMsgTimeout)
+	require.Len(t, received, 1, "expected only the enabled event type to be published")
+
+	var msg pubsubx.ChangeMessage
+
+	require.NoError(t, json.Unmarshal(received[0].Data, &msg))
+	assert.Equal(t, CreateEventType, msg.EventType, "expected the create event to be published")
+
+	assert.Len(t, sink.subjects, 1, "expected disabled event types not to be sent to sinks")
+}
+
+func TestClient_PublishRedactedFields(t *testing.T) {
+	ctx := context.Background()
+	tenantID := gidx.MustNewID("testten")
+	parentID := gidx.MustNewID("testten")
+
+	sink := new(testSink)
+
+	client, msgs := newTestClient(t, WithRedactedFields("labels"), WithSinks(sink))
+	defer client.Close()
+
+	create, err := NewTenantWithLabelsMessage("", tenantID, map[string]string{"secret": "s3cr3t"}, parentID)
+	require.NoError(t, err)
+
+	require.NoError(t, client.PublishCreate(ctx, "tenants", "global", create))
+
+	move, err := MoveTenantMessage("", tenantID, "", parentID)
+	require.NoError(t, err)
+
+	require.NoError(t, client.PublishUpdate(ctx, "tenants", "global", move))
+
+	received := receiveMessages(t, msgs, 2, testMsgTimeout)
+	require.Len(t, received, 2, "expected both messages to be published")
+
+	assert.NotContains(t, string(received[0].Data), "s3cr3t", "expected the redacted label value to be absent")
+	assert.NotContains(t, string(received[0].Data), "labels.secret", "expected the redacted label field to be absent")
+
+	var msg pubsubx.ChangeMessage
+
+	require.NoError(t, json.Unmarshal(received[0].Data, &msg))
+	assert.Empty(t, msg.FieldChanges, "expected the label changes to be redacted")
+	assert.Equal(t, tenantID, msg.SubjectID, "expected the subject id to be kept")
+	assert.Equal(t, []gidx.PrefixedID{parentID}, msg.AdditionalSubjectIDs, "expected the additional subject ids to be kept")
+
+	require.NoError(t, json.Unmarshal(received[1].Data, &msg))
+	require.Len(t, msg.FieldChanges, 1, "expected other fields not to be redacted")
+	assert.Equal(t, "parent_tenant_id", msg.FieldChanges[0].Field)
+
+	require.Len(t, sink.data, 2, "expected messages sent to sinks")
+	assert.NotContains(t, string(sink.data[0]), "s3cr3t", "expected the redacted label value to be absent from sinks")
+}
+
+func TestRedactedFields(t *testing.T) {
+	redacted := redactedFields{"labels": true, "description": true}
+
+	assert.True(t, redacted.matches("description"))
+	assert.True(t, redacted.matches("labels.tier"))
+	assert.True(t, redacted.matches("labels.example.com/tier"))
+	assert.False(t, redacted.matches("parent_tenant_id"))
+	assert.False(t, redacted.matches("labelsx"))
+}
+
+// testSink records the messages sent to it.
+type testSink struct {
+	subjects []string
+	data     [][]byte
+	err      error
+}
+
+func (s *testSink) Send(_ context.Context, subject string, data []byte) error {
+	s.subjects = append(s.subjects, subject)
+	s.data = append(s.data, data)
+
+	return s.err
+}
+
+func TestClient_PublishSinks(t *testing.T) {
+	ctx := context.Background()
+
+	sink := &testSink{}
+	failing := &testSink{err: errors.New("sink unavailable")}
+
+	client, msgs := newTestClient(t, WithSinks(sink, failing))
+	defer client.Close()
+
+	msg, err := NewTenantMessage("", gidx.MustNewID("testten"))
+	require.NoError(t, err)
+
+	require.NoError(t, client.PublishCreate(ctx, "tenants", "global", msg), "expected sink failures not to fail the publish")
+
+	received := receiveMessages(t, msgs, 1, testMsgTimeout)
+	require.Len(t, received, 1, "expected message to be published to nats")
+
+	require.Len(t, sink.subjects, 1, "expected message to be sent to the sink")
+	assert.Equal(t, received[0].Subject, sink.subjects[0], "expected the sink to receive the nats subject")
+	assert.Equal(t, received[0].Data, sink.data[0], "expected the sink to receive the nats payload")
+
+	assert.Len(t, failing.subjects, 1, "expected message to be sent to every sink")
+}
+
+func TestClient_PublishSinksAfterFailedPublish(t *testing.T) {
+	nc, err := nats.Connect(natsSrv.ClientURL())
+	require.NoError(t, err, "expected no error connecting to nats")
+
+	defer nc.Close()
+
+	js, err := nc.JetStream()
+	require.NoError(t, err, "expected no error creating jetstream context")
+
+	sink := &testSink{}
+
+	// Without a stream for its subjects, every publish fails.
+	client := NewClient(WithJetreamContext(js), WithLogger(zap.NewNop()), WithSinks(sink))
+
+	msg, err := NewTenantMessage("", gidx.MustNewID("testten"))
+	require.NoError(t, err)
+
+	require.Error(t, client.PublishCreate(context.Background(), "tenants", "global", msg), "expected the publish to fail")
+	assert.Empty(t, sink.subjects, "expected no message sent to the sink for a failed publish")
+}
+
+func TestClient_PublishSchemaVersion(t *testing.T) {
+	ctx := context.Background()
+
+	tenantID := gidx.MustNewID("testten")
+	parentID := gidx.MustNewID("testten")
+
+	must := func(msg *pubsubx.ChangeMessage, err error) *pubsubx.ChangeMessage {
+		require.NoError(t, err)
+
+		return msg
+	}
+
+	testCases := []struct {
+		name    string
+		publish func(c *Client) error
+	}{
+		{"create", func(c *Client) error {
+			return c.PublishCreate(ctx, "tenants", "global", must(NewTenantMessage("", tenantID)))
+		}},
+		{"create with labels", func(c *Client) error {
+			return c.PublishCreate(ctx, "tenants", "global", must(NewTenantWithLabelsMessage("", tenantID, map[string]string{"tier": "gold"})))
+		}},
+		{"update", func(c *Client) error {
+			return c.PublishUpdate(ctx, "tenants", "global", must(UpdateTenantMessage("", tenantID)))
+		}},
+		{"move", func(c *Client) error {
+			return c.PublishUpdate(ctx, "tenants", "global", must(MoveTenantMessage("", tenantID, "", parentID)))
+		}},
+		{"label change", func(c *Client) error {
+			return c.PublishUpdate(ctx, "tenants", "global", must(LabelChangeMessage("", tenantID, "tier", "", "gold")))
+		}},
+		{"delete", func(c *Client) error {
+			return c.PublishDelete(ctx, "tenants", "global", must(DeleteTenantMessage("", tenantID)))
+		}},
+	}
+
+	for _, version := range []string{SchemaVersion, "2"} {
+		t.Run("version "+version, func(t *testing.T) {
+			client, msgs := newTestClient(t, WithSchemaVersion(version))
+			defer client.Close()
+
+			for _, tc := range testCases {
+				require.NoError(t, tc.publish(client), "expected no error publishing %s", tc.name)
+
+				received := receiveMessages(t, msgs, 1, testMsgTimeout)
+				require.Len(t, received, 1, "expected %s message to be published", tc.name)
+
+				var msg pubsubx.ChangeMessage
+
+				require.NoError(t, json.Unmarshal(received[0].Data, &msg))
+				assert.Equal(t, version, msg.AdditionalData[SchemaVersionKey], "expected schema version in %s event", tc.name)
+				assert.NotContains(t, msg.AdditionalData, CorrelationIDKey, "expected no correlation id in %s event", tc.name)
+			}
+		})
+	}
+}
+
+func TestClient_Disabled(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	ctx := reqctx.WithLogger(context.Background(), zap.New(core))
+	tenantID := gidx.MustNewID("testten")
+
+	var client *Client
+
+	create, err := NewTenantMessage("", tenantID)
+	require.NoError(t, err)
+
+	update, err := UpdateTenantMessage("", tenantID)
+	require.NoError(t, err)
+
+	remove, err := DeleteTenantMessage("", tenantID)
+	require.NoError(t, err)
+
+	assert.NoError(t, client.PublishCreate(ctx, "tenants", "global", create), "expected disabled client publishes not to fail")
+	assert.NoError(t, client.PublishUpdate(ctx, "tenants", "global", update), "expected disabled client publishes not to fail")
+	assert.NoError(t, client.PublishDelete(ctx, "tenants", "global", remove), "expected disabled client publishes not to fail")
+
+	assert.Equal(t, 3, logs.FilterMessage("pubsub is disabled, skipped publishing event").Len(), "expected skipped publishes to be logged")
+
+	assert.False(t, client.RejectingPublishes(), "expected disabled client not to reject publishes")
+
+	info, err := client.AddStream()
+	assert.NoError(t, err)
+	assert.Nil(t, info, "expected no stream for disabled client")
+
+	assert.NotPanics(t, client.Close)
+}
@@ -0,0 +1,26 @@
+package pubsub
+
+import "strings"
+
+// fieldSeparator separates the nested fields of field changes, such as
+// "labels.<key>".
+const fieldSeparator = "."
+
+// redactedFields are the fields whose changes are removed from published events.
+type redactedFields map[string]bool
+
+// matches reports whether the field, or a field it is nested under, is redacted.
+func (r redactedFields) matches(field string) bool {
+	for {
+		if r[field] {
+			return true
+		}
+
+		i := strings.LastIndex(field, fieldSeparator)
+		if i < 0 {
+			return false
+		}
+
+		field = field[:i]
+	}
+}
@@ -1,6 +1,9 @@
 package pubsub
 
 import (
+	"fmt"
+	"sort"
+
 	"go.infratographer.com/x/gidx"
 	"go.infratographer.com/x/pubsubx"
 )
@@ -10,12 +13,122 @@ func NewTenantMessage(actorID, tenantID gidx.PrefixedID, additionalSubjectIDs ..
 	return newMessage(actorID, tenantID, additionalSubjectIDs...), nil
 }
 
+// NewTenantWithLabelsMessage creates a new tenant event message for a tenant
+// created with labels. Each label is included in the field changes under the
+// "labels.<key>" field, ordered by key.
+func NewTenantWithLabelsMessage(actorID, tenantID gidx.PrefixedID, labels map[string]string, additionalSubjectIDs ...gidx.PrefixedID) (*pubsubx.ChangeMessage, error) {
+	msg := newMessage(actorID, tenantID, additionalSubjectIDs...)
+
+	keys := make([]string, 0, len(labels))
+
+	for key := range labels {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		msg.FieldChanges = append(msg.FieldChanges, pubsubx.FieldChange{
+			Field:        "labels." + key,
+			CurrentValue: labels[key],
+		})
+	}
+
+	return msg, nil
+}
+
 // UpdateTenantMessage creates a updated tenant event message
 func UpdateTenantMessage(actorID, tenantID gidx.PrefixedID, additionalSubjectIDs ...gidx.PrefixedID) (*pubsubx.ChangeMessage, error) {
 	return newMessage(actorID, tenantID, additionalSubjectIDs...), nil
 }
 
+// TouchTenantMessage creates an updated tenant event message for a tenant
+// touched without any changes, carrying an empty field changes list.
+func TouchTenantMessage(actorID, tenantID gidx.PrefixedID) (*pubsubx.ChangeMessage, error) {
+	msg := newMessage(actorID, tenantID)
+
+	msg.FieldChanges = []pubsubx.FieldChange{}
+
+	return msg, nil
+}
+
 // DeleteTenantMessage creates a delete tenant event message
 func DeleteTenantMessage(actorID, tenantID gidx.PrefixedID, additionalSubjectIDs ...gidx.PrefixedID) (*pubsubx.ChangeMessage, error) {
 	return newMessage(actorID, tenantID, additionalSubjectIDs...), nil
 }
+
+// MoveTenantMessage creates an updated tenant event message for a tenant moved
+// to a new parent. The previous and current parents are included in the
+// additional subject ids and the change in the field changes under the
+// "parent_tenant_id" field, where an empty value is a root tenant.
+func MoveTenantMessage(actorID, tenantID, previousParentID, currentParentID gidx.PrefixedID) (*pubsubx.ChangeMessage, error) {
+	var additionalSubjectIDs []gidx.PrefixedID
+
+	for _, id := range []gidx.PrefixedID{previousParentID, currentParentID} {
+		if id != "" {
+			additionalSubjectIDs = append(additionalSubjectIDs, id)
+		}
+	}
+
+	msg := newMessage(actorID, tenantID, additionalSubjectIDs...)
+
+	msg.FieldChanges = []pubsubx.FieldChange{
+		{
+			Field:         "parent_tenant_id",
+			PreviousValue: string(previousParentID),
+			CurrentValue:  string(currentParentID),
+		},
+	}
+
+	return msg, nil
+}
+
+// RenameTenantMessage creates an updated tenant event message for a tenant
+// renamed. The change is included in the field changes under the "name" field.
+func RenameTenantMessage(actorID, tenantID gidx.PrefixedID, previousName, currentName string) (*pubsubx.ChangeMessage, error) {
+	msg := newMessage(actorID, tenantID)
+
+	msg.FieldChanges = []pubsubx.FieldChange{
+		{
+			Field:         "name",
+			PreviousValue: previousName,
+			CurrentValue:  currentName,
+		},
+	}
+
+	return msg, nil
+}
+
+// BatchTenantMessage creates a single event message for the tenants affected
+// by a batch operation, in place of an event for each tenant. Every affected
+// tenant is included in the additional subject ids, the first also being the
+// subject, and the operation in the additional data under BatchOperationKey.
+func BatchTenantMessage(actorID gidx.PrefixedID, operation string, tenantIDs []gidx.PrefixedID) (*pubsubx.ChangeMessage, error) {
+	if len(tenantIDs) == 0 {
+		return nil, fmt.Errorf("%w: no tenants were affected", ErrEmptyBatch)
+	}
+
+	msg := newMessage(actorID, tenantIDs[0], tenantIDs...)
+
+	msg.AdditionalData = map[string]interface{}{
+		BatchOperationKey: operation,
+	}
+
+	return msg, nil
+}
+
+// LabelChangeMessage creates an updated tenant event message for a label change.
+// The change is included in the field changes under the "labels.<key>" field.
+func LabelChangeMessage(actorID, tenantID gidx.PrefixedID, key, previous, current string) (*pubsubx.ChangeMessage, error) {
+	msg := newMessage(actorID, tenantID)
+
+	msg.FieldChanges = []pubsubx.FieldChange{
+		{
+			Field:         "labels." + key,
+			PreviousValue: previous,
+			CurrentValue:  current,
+		},
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,104 @@
+// Package reqctx carries request scoped values, such as the request id,
+// correlation id, route, tenant and logger, through a context.
+package reqctx
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	correlationIDKey
+	operationKey
+	tenantIDKey
+	loggerKey
+)
+
+// WithRequestID returns a copy of the context carrying the provided request id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID returns the request id from the context, or an empty string if none is set.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+
+	return id
+}
+
+// WithCorrelationID returns a copy of the context carrying the provided correlation id.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey, id)
+}
+
+// CorrelationID returns the correlation id from the context, or an empty string if none is set.
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey).(string)
+
+	return id
+}
+
+// WithOperation returns a copy of the context carrying the provided
+// operation, the request's method and route template.
+func WithOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey, operation)
+}
+
+// Operation returns the operation from the context, or an empty string if none is set.
+func Operation(ctx context.Context) string {
+	operation, _ := ctx.Value(operationKey).(string)
+
+	return operation
+}
+
+// WithTenantID returns a copy of the context carrying the id of the tenant the request operates on.
+func WithTenantID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantIDKey, id)
+}
+
+// TenantID returns the tenant id from the context, or an empty string if none is set.
+func TenantID(ctx context.Context) string {
+	id, _ := ctx.Value(tenantIDKey).(string)
+
+	return id
+}
+
+// Fields returns the log fields of the request scoped values set on the context.
+func Fields(ctx context.Context) []zap.Field {
+	var fields []zap.Field
+
+	for _, value := range []struct {
+		key   string
+		value string
+	}{
+		{key: "request_id", value: RequestID(ctx)},
+		{key: "correlation_id", value: CorrelationID(ctx)},
+		{key: "operation", value: Operation(ctx)},
+		{key: "tenant_id", value: TenantID(ctx)},
+	} {
+		if value.value != "" {
+			fields = append(fields, zap.String(value.key, value.value))
+		}
+	}
+
+	return fields
+}
+
+// WithLogger returns a copy of the context carrying the provided request scoped logger.
+func WithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// Logger returns the request scoped logger from the context. If the context has
+// no logger, the fallback is returned with the context's fields attached.
+func Logger(ctx context.Context, fallback *zap.Logger) *zap.Logger {
+	if logger, ok := ctx.Value(loggerKey).(*zap.Logger); ok {
+		return logger
+	}
+
+	return fallback.With(Fields(ctx)...)
+}
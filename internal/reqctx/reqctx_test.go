@@ -0,0 +1,57 @@
+package reqctx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	fallback := zap.New(core)
+
+	t.Run("without request id", func(t *testing.T) {
+		Logger(context.Background(), fallback).Info("no request")
+
+		entry := logs.TakeAll()[0]
+		assert.NotContains(t, entry.ContextMap(), "request_id")
+	})
+
+	t.Run("with request id", func(t *testing.T) {
+		ctx := WithRequestID(context.Background(), "abc123")
+
+		assert.Equal(t, "abc123", RequestID(ctx))
+
+		Logger(ctx, fallback).Info("with request")
+
+		entry := logs.TakeAll()[0]
+		assert.Equal(t, "abc123", entry.ContextMap()["request_id"])
+	})
+
+	t.Run("with request fields", func(t *testing.T) {
+		ctx := WithRequestID(context.Background(), "abc123")
+		ctx = WithCorrelationID(ctx, "def456")
+		ctx = WithOperation(ctx, "GET /v1/tenants/:id")
+		ctx = WithTenantID(ctx, "tnntten-abc123")
+
+		Logger(ctx, fallback).Info("with request fields")
+
+		fields := logs.TakeAll()[0].ContextMap()
+		assert.Equal(t, "abc123", fields["request_id"])
+		assert.Equal(t, "def456", fields["correlation_id"])
+		assert.Equal(t, "GET /v1/tenants/:id", fields["operation"])
+		assert.Equal(t, "tnntten-abc123", fields["tenant_id"])
+	})
+
+	t.Run("with logger", func(t *testing.T) {
+		ctx := WithLogger(context.Background(), fallback.With(zap.String("scoped", "yes")))
+
+		Logger(ctx, zap.NewNop()).Info("scoped logger")
+
+		entry := logs.TakeAll()[0]
+		assert.Equal(t, "yes", entry.ContextMap()["scoped"])
+	})
+}
@@ -0,0 +1,122 @@
+// Package reqlog samples the request logs written by the echo server's
+// logging middleware, so only a fraction of successful requests are logged.
+package reqlog
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrInvalidSampleRate is returned when the sample rate is not between 0 and 1.
+var ErrInvalidSampleRate = errors.New("invalid request log sample rate")
+
+// Config configures request log sampling.
+type Config struct {
+	// SampleRate is the fraction of successful requests logged, from 0 to 1.
+	// Requests are not sampled at a rate of 1.
+	SampleRate float64
+
+	// SlowThreshold is the duration of requests which are always logged,
+	// disabled when zero.
+	SlowThreshold time.Duration
+}
+
+// NewLogger returns a logger which writes the provided logger's request log
+// entries for a sample of the successful requests. Requests which failed,
+// responding with a status of 400 or above or logged as errors, and
+// requests taking at least the slow threshold are always logged. Entries
+// other than request logs are written as is.
+//
+// Request log entries are recognized by the status and latency fields the
+// echo server's logging middleware includes.
+func NewLogger(logger *zap.Logger, config Config) (*zap.Logger, error) {
+	if config.SampleRate < 0 || config.SampleRate > 1 || math.IsNaN(config.SampleRate) {
+		return nil, fmt.Errorf("%w: %v must be between 0 and 1", ErrInvalidSampleRate, config.SampleRate)
+	}
+
+	if config.SampleRate == 1 {
+		return logger, nil
+	}
+
+	return logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return newSamplingCore(core, config, rand.Float64) //nolint:gosec // Sampling doesn't need a secure source.
+	})), nil
+}
+
+// samplingCore drops the request log entries of unsampled successful requests.
+type samplingCore struct {
+	zapcore.Core
+
+	config Config
+	sample func() float64
+}
+
+func newSamplingCore(core zapcore.Core, config Config, sample func() float64) *samplingCore {
+	return &samplingCore{
+		Core:   core,
+		config: config,
+		sample: sample,
+	}
+}
+
+// With adds structured context to the core, keeping the sampling.
+func (c *samplingCore) With(fields []zapcore.Field) zapcore.Core {
+	return newSamplingCore(c.Core.With(fields), c.config, c.sample)
+}
+
+// Check adds the sampling core to the checked entry, so it is written through Write.
+func (c *samplingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+// Write writes the entry unless it is the request log of an unsampled successful request.
+func (c *samplingCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if c.drop(ent, fields) {
+		return nil
+	}
+
+	return c.Core.Write(ent, fields)
+}
+
+func (c *samplingCore) drop(ent zapcore.Entry, fields []zapcore.Field) bool {
+	var (
+		status   int64
+		duration time.Duration
+		request  bool
+	)
+
+	for _, f := range fields {
+		switch f.Key {
+		case "status":
+			status = f.Integer
+		case "latency":
+			request = true
+
+			if d, err := time.ParseDuration(f.String); err == nil {
+				duration = d
+			}
+		}
+	}
+
+	switch {
+	case !request || status == 0:
+		return false
+	case ent.Level >= zapcore.ErrorLevel || status >= http.StatusBadRequest:
+		return false
+	case c.config.SlowThreshold > 0 && duration >= c.config.SlowThreshold:
+		return false
+	}
+
+	return c.sample() >= c.config.SampleRate
+}
@@ -0,0 +1,115 @@
+package reqlog
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/x/echox/echozap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewLogger(t *testing.T) {
+	for _, rate := range []float64{-0.1, 1.1} {
+		_, err := NewLogger(zap.NewNop(), Config{SampleRate: rate})
+		assert.ErrorIs(t, err, ErrInvalidSampleRate, "expected %v to be invalid", rate)
+	}
+
+	logger := zap.NewNop()
+
+	unsampled, err := NewLogger(logger, Config{SampleRate: 1})
+	require.NoError(t, err)
+	assert.Same(t, logger, unsampled, "expected a rate of 1 not to sample")
+}
+
+func TestSampling(t *testing.T) {
+	const slow = 50 * time.Millisecond
+
+	core, logs := observer.New(zap.InfoLevel)
+
+	// Every other request is sampled.
+	var calls int
+
+	sampled := zap.New(core).WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return newSamplingCore(core, Config{SampleRate: 0.5, SlowThreshold: slow}, func() float64 {
+			calls++
+
+			if calls%2 == 0 {
+				return 0.9
+			}
+
+			return 0.1
+		})
+	}))
+
+	e := echo.New()
+	e.Use(echozap.Middleware(sampled))
+
+	e.GET("/ok", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	e.GET("/bad", func(c echo.Context) error {
+		return c.NoContent(http.StatusBadRequest)
+	})
+	e.GET("/failed", func(c echo.Context) error {
+		return c.NoContent(http.StatusInternalServerError)
+	})
+	e.GET("/error", func(c echo.Context) error {
+		return errors.New("handler failed") //nolint:goerr113 // Test error
+	})
+	e.GET("/slow", func(c echo.Context) error {
+		time.Sleep(slow)
+
+		return c.NoContent(http.StatusOK)
+	})
+
+	request := func(path string) {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	t.Run("successful requests sampled", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			request("/ok")
+		}
+
+		assert.Equal(t, 5, logs.FilterMessage("/ok").Len(), "expected half of the successful requests to be logged")
+	})
+
+	t.Run("failed requests always logged", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			request("/bad")
+			request("/failed")
+			request("/error")
+		}
+
+		assert.Equal(t, 4, logs.FilterMessage("/bad").Len(), "expected every client error to be logged")
+		assert.Equal(t, 4, logs.FilterMessage("/failed").Len(), "expected every server error to be logged")
+		assert.Equal(t, 4, logs.FilterMessage("/error").Len(), "expected every handler error to be logged")
+	})
+
+	t.Run("slow requests always logged", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			request("/slow")
+		}
+
+		assert.Equal(t, 2, logs.FilterMessage("/slow").Len(), "expected every slow request to be logged")
+	})
+
+	t.Run("other entries written", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			sampled.Info("not a request", zap.Int("status", http.StatusOK))
+		}
+
+		sampled.With(zap.String("component", "test")).Info("with context")
+
+		assert.Equal(t, 4, logs.FilterMessage("not a request").Len(), "expected entries other than request logs not to be sampled")
+		assert.Equal(t, 1, logs.FilterMessage("with context").Len(), "expected entries other than request logs not to be sampled")
+	})
+}
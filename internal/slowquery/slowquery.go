@@ -0,0 +1,241 @@
+// Package slowquery wraps a database driver to log queries which take longer than a threshold.
+package slowquery
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+
+	"go.infratographer.com/tenant-api/internal/reqctx"
+	"go.uber.org/zap"
+)
+
+// connector opens connections with the wrapped driver, timing their queries.
+type connector struct {
+	driver    driver.Driver
+	dsn       string
+	threshold time.Duration
+	logger    *zap.Logger
+}
+
+// NewConnector returns a connector opening connections to dsn with the provided
+// driver, logging any query or statement execution which takes longer than the
+// threshold. Queries are logged with the request's context, such as the
+// request id, operation and tenant, from the query's context.
+//
+// Query durations measure the time until the first results are available, the
+// time taken to read the rows is not included.
+func NewConnector(d driver.Driver, dsn string, threshold time.Duration, logger *zap.Logger) driver.Connector {
+	return &connector{
+		driver:    d,
+		dsn:       dsn,
+		threshold: threshold,
+		logger:    logger,
+	}
+}
+
+// Connect opens a new connection with the wrapped driver.
+func (c *connector) Connect(_ context.Context) (driver.Conn, error) {
+	dc, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conn{Conn: dc, connector: c}, nil
+}
+
+// Driver returns the wrapped driver.
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// observe logs the query if it took longer than the threshold since start.
+func (c *connector) observe(ctx context.Context, operation, query string, start time.Time, err error) {
+	duration := time.Since(start)
+	if duration < c.threshold {
+		return
+	}
+
+	fields := []zap.Field{
+		zap.String("query.operation", operation),
+		zap.String("query.statement", query),
+		zap.Duration("query.duration", duration),
+		zap.Duration("query.threshold", c.threshold),
+	}
+
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	}
+
+	c.logger.With(reqctx.Fields(ctx)...).Warn("slow database query", fields...)
+}
+
+// conn times the queries and executions of the wrapped connection.
+type conn struct {
+	driver.Conn
+	connector *connector
+}
+
+// QueryContext runs the query on the wrapped connection, logging it when slow.
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+
+	rows, err := queryer.QueryContext(ctx, query, args)
+
+	c.connector.observe(ctx, "query", query, start, err)
+
+	return rows, err
+}
+
+// ExecContext runs the statement on the wrapped connection, logging it when slow.
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+
+	result, err := execer.ExecContext(ctx, query, args)
+
+	c.connector.observe(ctx, "exec", query, start, err)
+
+	return result, err
+}
+
+// PrepareContext prepares the statement on the wrapped connection, timing its executions.
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		ds  driver.Stmt
+		err error
+	)
+
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		ds, err = preparer.PrepareContext(ctx, query)
+	} else {
+		ds, err = c.Conn.Prepare(query)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &stmt{Stmt: ds, query: query, connector: c.connector}, nil
+}
+
+// BeginTx starts a transaction on the wrapped connection.
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+
+	return c.Conn.Begin() //nolint:staticcheck // Fallback for drivers without BeginTx.
+}
+
+// Ping verifies the wrapped connection is still alive.
+func (c *conn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	return nil
+}
+
+// ResetSession resets the wrapped connection before it is reused.
+func (c *conn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+// IsValid reports whether the wrapped connection may be reused.
+func (c *conn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+
+	return true
+}
+
+// CheckNamedValue checks query arguments with the wrapped connection.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+
+	return driver.ErrSkip
+}
+
+// stmt times the executions of the wrapped prepared statement.
+type stmt struct {
+	driver.Stmt
+	query     string
+	connector *connector
+}
+
+// QueryContext runs the prepared query, logging it when slow.
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	var (
+		rows driver.Rows
+		err  error
+	)
+
+	start := time.Now()
+
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(namedValues(args)) //nolint:staticcheck // Fallback for drivers without QueryContext.
+	}
+
+	s.connector.observe(ctx, "query", s.query, start, err)
+
+	return rows, err
+}
+
+// ExecContext runs the prepared statement, logging it when slow.
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	var (
+		result driver.Result
+		err    error
+	)
+
+	start := time.Now()
+
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		result, err = s.Stmt.Exec(namedValues(args)) //nolint:staticcheck // Fallback for drivers without ExecContext.
+	}
+
+	s.connector.observe(ctx, "exec", s.query, start, err)
+
+	return result, err
+}
+
+// CheckNamedValue checks statement arguments with the wrapped statement.
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+
+	return driver.ErrSkip
+}
+
+// namedValues returns the values of the provided arguments.
+func namedValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+
+	return values
+}
@@ -0,0 +1,109 @@
+package slowquery
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/tenant-api/internal/reqctx"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// testDriver opens connections which take the query text as a duration to sleep for.
+type testDriver struct{}
+
+func (testDriver) Open(string) (driver.Conn, error) { return testConn{}, nil }
+
+type testConn struct{}
+
+func (testConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (testConn) Close() error                        { return nil }
+func (testConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (testConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if err := sleep(query); err != nil {
+		return nil, err
+	}
+
+	return testRows{}, nil
+}
+
+func (testConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if err := sleep(query); err != nil {
+		return nil, err
+	}
+
+	return driver.RowsAffected(0), nil
+}
+
+type testRows struct{}
+
+func (testRows) Columns() []string         { return nil }
+func (testRows) Close() error              { return nil }
+func (testRows) Next([]driver.Value) error { return io.EOF }
+
+func sleep(query string) error {
+	d, err := time.ParseDuration(query)
+	if err != nil {
+		return err
+	}
+
+	time.Sleep(d)
+
+	return nil
+}
+
+func TestConnector(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+
+	db := sql.OpenDB(NewConnector(testDriver{}, "", 50*time.Millisecond, zap.New(core)))
+	defer db.Close()
+
+	ctx := reqctx.WithRequestID(context.Background(), "abc123")
+	ctx = reqctx.WithOperation(ctx, "GET /v1/tenants/:id")
+	ctx = reqctx.WithTenantID(ctx, "tnntten-abc123")
+	ctx = reqctx.WithLogger(ctx, zap.NewNop())
+
+	t.Run("fast query", func(t *testing.T) {
+		rows, err := db.QueryContext(ctx, "1ms")
+		require.NoError(t, err)
+		require.NoError(t, rows.Close())
+
+		_, err = db.ExecContext(ctx, "1ms")
+		require.NoError(t, err)
+
+		assert.Empty(t, logs.TakeAll(), "expected fast queries not to be logged")
+	})
+
+	t.Run("slow query", func(t *testing.T) {
+		rows, err := db.QueryContext(ctx, "60ms")
+		require.NoError(t, err)
+		require.NoError(t, rows.Close())
+
+		entries := logs.TakeAll()
+		require.Len(t, entries, 1, "expected slow query to be logged")
+
+		fields := entries[0].ContextMap()
+		assert.Equal(t, "query", fields["query.operation"])
+		assert.Equal(t, "60ms", fields["query.statement"])
+		assert.Equal(t, "abc123", fields["request_id"], "expected request context in log")
+		assert.Equal(t, "GET /v1/tenants/:id", fields["operation"], "expected route in log")
+		assert.Equal(t, "tnntten-abc123", fields["tenant_id"], "expected tenant in log")
+		assert.GreaterOrEqual(t, fields["query.duration"], 60*time.Millisecond)
+	})
+
+	t.Run("slow exec", func(t *testing.T) {
+		_, err := db.ExecContext(ctx, "60ms")
+		require.NoError(t, err)
+
+		entries := logs.TakeAll()
+		require.Len(t, entries, 1, "expected slow exec to be logged")
+		assert.Equal(t, "exec", entries[0].ContextMap()["query.operation"])
+	})
+}
@@ -0,0 +1,313 @@
+// Package webhooks delivers published events to HTTP endpoints.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.infratographer.com/tenant-api/internal/reqctx"
+	"go.uber.org/zap"
+)
+
+const (
+	// HeaderSignature is the header carrying the HMAC-SHA256 signature of the delivery.
+	HeaderSignature = "X-Tenant-API-Signature"
+
+	// HeaderTimestamp is the header carrying the unix timestamp the delivery was signed at.
+	HeaderTimestamp = "X-Tenant-API-Timestamp"
+
+	// HeaderSubject is the header carrying the subject the event was published on.
+	HeaderSubject = "X-Tenant-API-Subject"
+
+	// DefaultMaxAttempts is the default number of times a delivery is attempted.
+	DefaultMaxAttempts = 5
+
+	// DefaultTimeout is the default timeout of a single delivery attempt.
+	DefaultTimeout = 10 * time.Second
+
+	// DefaultBackoff is the default delay before the first retry, doubling on each retry.
+	DefaultBackoff = time.Second
+
+	// DefaultCloseTimeout is the default time Close waits for queued deliveries to be sent.
+	DefaultCloseTimeout = 30 * time.Second
+
+	// defaultQueueSize is the number of deliveries which may be waiting to be sent.
+	defaultQueueSize = 1000
+
+	// defaultWorkers is the number of deliveries sent concurrently.
+	defaultWorkers = 4
+)
+
+var (
+	// ErrDispatcherClosed is returned when sending on a dispatcher which has been closed.
+	ErrDispatcherClosed = errors.New("webhook dispatcher closed")
+
+	// ErrQueueFull is returned when too many deliveries are waiting to be sent.
+	ErrQueueFull = errors.New("webhook delivery queue full")
+)
+
+// Config configures the webhook endpoints events are delivered to.
+type Config struct {
+	// URLs are the endpoints every event is delivered to.
+	URLs []string
+
+	// Secret is the key deliveries are signed with.
+	Secret string
+
+	// MaxAttempts is the number of times a delivery is attempted before it is dropped.
+	MaxAttempts int
+
+	// Timeout is the timeout of a single delivery attempt.
+	Timeout time.Duration
+
+	// Backoff is the delay before the first retry, doubling on each following retry.
+	Backoff time.Duration
+
+	// CloseTimeout is the time Close waits for queued deliveries to be sent
+	// before the remaining deliveries are dropped.
+	CloseTimeout time.Duration
+}
+
+// delivery is an event waiting to be sent to an endpoint.
+type delivery struct {
+	url       string
+	subject   string
+	data      []byte
+	requestID string
+}
+
+// Dispatcher delivers events to the configured webhook endpoints in the
+// background, retrying with exponential backoff when an endpoint does not
+// respond with a 2xx status.
+//
+// Each delivery is a POST of the event payload, signed as described by
+// Signature, with the signature, timestamp and subject in the HeaderSignature,
+// HeaderTimestamp and HeaderSubject headers.
+type Dispatcher struct {
+	config Config
+	client *http.Client
+	logger *zap.Logger
+
+	queue chan *delivery
+
+	// stop cancels the deliveries in progress once Close times out.
+	ctx  context.Context
+	stop context.CancelFunc
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher creates a dispatcher for the configured endpoints and starts
+// delivering events. Returns nil when no endpoints are configured.
+func NewDispatcher(config Config, logger *zap.Logger) *Dispatcher {
+	if len(config.URLs) == 0 {
+		return nil
+	}
+
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	if config.Backoff <= 0 {
+		config.Backoff = DefaultBackoff
+	}
+
+	if config.CloseTimeout <= 0 {
+		config.CloseTimeout = DefaultCloseTimeout
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+
+	d := &Dispatcher{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+		logger: logger,
+		queue:  make(chan *delivery, defaultQueueSize),
+		ctx:    ctx,
+		stop:   stop,
+	}
+
+	for i := 0; i < defaultWorkers; i++ {
+		d.wg.Add(1)
+
+		go d.run()
+	}
+
+	return d
+}
+
+// Signature returns the hex encoded HMAC-SHA256 signature of a delivery,
+// computed over the timestamp and the payload joined by a period.
+func Signature(secret, timestamp string, data []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(data)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send queues the event for delivery to every endpoint.
+func (d *Dispatcher) Send(ctx context.Context, subject string, data []byte) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed {
+		return ErrDispatcherClosed
+	}
+
+	for _, url := range d.config.URLs {
+		select {
+		case d.queue <- &delivery{url: url, subject: subject, data: data, requestID: reqctx.RequestID(ctx)}:
+		default:
+			return fmt.Errorf("%w: %s", ErrQueueFull, url)
+		}
+	}
+
+	return nil
+}
+
+// Close stops accepting new events and waits for the queued deliveries to be
+// sent. Once the close timeout passes, the deliveries in progress are
+// canceled and the remaining deliveries are dropped, so an endpoint which
+// doesn't respond can't hold up shutdown.
+func (d *Dispatcher) Close() {
+	d.mu.Lock()
+
+	if d.closed {
+		d.mu.Unlock()
+
+		return
+	}
+
+	d.closed = true
+
+	close(d.queue)
+
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(d.config.CloseTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return
+	case <-timer.C:
+	}
+
+	d.logger.Warn("timed out waiting for webhook deliveries, dropping remaining events",
+		zap.Int("webhook.queued", len(d.queue)),
+		zap.Duration("webhook.close_timeout", d.config.CloseTimeout),
+	)
+
+	d.stop()
+
+	<-done
+}
+
+// run sends queued deliveries until the dispatcher has been closed. Once
+// stopped, the remaining deliveries are dropped.
+func (d *Dispatcher) run() {
+	defer d.wg.Done()
+
+	for del := range d.queue {
+		if d.ctx.Err() != nil {
+			continue
+		}
+
+		d.deliver(del)
+	}
+}
+
+// deliver sends the delivery, retrying until it succeeds or the attempts are exhausted.
+func (d *Dispatcher) deliver(del *delivery) {
+	logger := d.logger.With(
+		zap.String("webhook.url", del.url),
+		zap.String("webhook.subject", del.subject),
+	)
+
+	if del.requestID != "" {
+		logger = logger.With(zap.String("request_id", del.requestID))
+	}
+
+	backoff := d.config.Backoff
+
+	for attempt := 1; ; attempt++ {
+		err := d.post(del)
+		if err == nil {
+			logger.Debug("delivered webhook", zap.Int("webhook.attempt", attempt))
+
+			return
+		}
+
+		if attempt >= d.config.MaxAttempts || d.ctx.Err() != nil {
+			logger.Error("failed to deliver webhook, dropping event", zap.Int("webhook.attempt", attempt), zap.Error(err))
+
+			return
+		}
+
+		logger.Warn("failed to deliver webhook, retrying", zap.Int("webhook.attempt", attempt), zap.Duration("webhook.backoff", backoff), zap.Error(err))
+
+		select {
+		case <-d.ctx.Done():
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
+
+// post makes a single delivery attempt.
+func (d *Dispatcher) post(del *delivery) error {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, del.url, bytes.NewReader(del.data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderSubject, del.subject)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Signature(d.config.Secret, timestamp, del.data))
+
+	if del.requestID != "" {
+		req.Header.Set("X-Request-ID", del.requestID)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close() //nolint:errcheck // Response body is not used.
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected webhook response status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
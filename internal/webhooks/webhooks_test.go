@@ -0,0 +1,146 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/tenant-api/internal/reqctx"
+	"go.uber.org/zap"
+)
+
+func TestSignature(t *testing.T) {
+	// Generated with: printf '1700000000.{"subjectID":"test"}' | openssl dgst -sha256 -hmac secret
+	expected := "sha256=ac03e482a40171deffbb02fea841a3ccbc033e752f14f0a41595732281c42857"
+
+	assert.Equal(t, expected, Signature("secret", "1700000000", []byte(`{"subjectID":"test"}`)))
+	assert.NotEqual(t, expected, Signature("other", "1700000000", []byte(`{"subjectID":"test"}`)), "expected the secret to change the signature")
+	assert.NotEqual(t, expected, Signature("secret", "1700000001", []byte(`{"subjectID":"test"}`)), "expected the timestamp to change the signature")
+}
+
+func TestNewDispatcherWithoutURLs(t *testing.T) {
+	assert.Nil(t, NewDispatcher(Config{}, zap.NewNop()), "expected no dispatcher without urls")
+}
+
+func TestDispatcher(t *testing.T) {
+	type received struct {
+		headers http.Header
+		body    []byte
+	}
+
+	var attempts atomic.Int32
+
+	deliveries := make(chan received, 10)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fail the first attempt to exercise the retry.
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+
+		deliveries <- received{headers: r.Header, body: body}
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(Config{
+		URLs:    []string{srv.URL},
+		Secret:  "secret",
+		Backoff: time.Millisecond,
+	}, zap.NewNop())
+	require.NotNil(t, d)
+
+	ctx := reqctx.WithRequestID(context.Background(), "abc123")
+
+	data := []byte(`{"subjectID":"test"}`)
+
+	require.NoError(t, d.Send(ctx, "com.infratographer.events.tenants.create.global", data))
+
+	select {
+	case del := <-deliveries:
+		assert.Equal(t, data, del.body)
+		assert.Equal(t, "com.infratographer.events.tenants.create.global", del.headers.Get(HeaderSubject))
+		assert.Equal(t, "abc123", del.headers.Get("X-Request-ID"))
+
+		timestamp := del.headers.Get(HeaderTimestamp)
+		require.NotEmpty(t, timestamp, "expected signature timestamp")
+		assert.Equal(t, Signature("secret", timestamp, data), del.headers.Get(HeaderSignature), "unexpected signature")
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected webhook to be delivered")
+	}
+
+	assert.Equal(t, int32(2), attempts.Load(), "expected the failed attempt to be retried")
+
+	d.Close()
+
+	assert.ErrorIs(t, d.Send(ctx, "subject", data), ErrDispatcherClosed)
+}
+
+func TestDispatcherGivesUp(t *testing.T) {
+	var attempts atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(Config{
+		URLs:        []string{srv.URL},
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+	}, zap.NewNop())
+
+	require.NoError(t, d.Send(context.Background(), "subject", []byte(`{}`)))
+
+	// Close waits for the queued delivery to finish.
+	d.Close()
+
+	assert.Equal(t, int32(3), attempts.Load(), "expected delivery to be attempted the maximum times")
+}
+
+func TestDispatcherCloseTimeout(t *testing.T) {
+	release := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	d := NewDispatcher(Config{
+		URLs:         []string{srv.URL},
+		Timeout:      time.Minute,
+		CloseTimeout: 50 * time.Millisecond,
+	}, zap.NewNop())
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, d.Send(context.Background(), "subject", []byte(`{}`)))
+	}
+
+	closed := make(chan struct{})
+
+	go func() {
+		d.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected close to stop waiting for the stuck endpoint")
+	}
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/volatiletech/null/v8"
+	"go.infratographer.com/x/echojwtx"
+)
+
+// actor returns the identity responsible for the request's changes. Requests
+// without a token subject are made by the system actor, when one is
+// configured, and are otherwise anonymous, returning an empty string.
+func (r *Router) actor(c echo.Context) string {
+	if actor := echojwtx.Actor(c); actor != "" {
+		return actor
+	}
+
+	return r.systemActor
+}
+
+// actorColumn returns the value of an audit actor column, which is null for
+// anonymous changes.
+func actorColumn(actor string) null.String {
+	return null.NewString(actor, actor != "")
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/x/gidx"
+	"go.uber.org/zap"
+)
+
+var (
+	// defaultReindexBatchSize is the default number of tenants processed per admin batch.
+	defaultReindexBatchSize = 500
+
+	// maxReindexBatchSize is the maximum number of tenants processed per admin batch.
+	maxReindexBatchSize = 5000
+)
+
+// parseBatchParams parses the query parameters of the admin operations
+// processing all tenants in batches ordered by ID: the last processed tenant
+// ID to resume `after`, the `batch_size` and the `max_batches` to process,
+// which is unlimited when zero.
+func parseBatchParams(c echo.Context) (gidx.PrefixedID, int, int, error) {
+	after := gidx.PrefixedID(c.QueryParam("after"))
+
+	batchSize := defaultReindexBatchSize
+	maxBatches := 0
+
+	if value := c.QueryParam("batch_size"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size <= 0 || size > maxReindexBatchSize {
+			return "", 0, 0, ErrInvalidQueryParam
+		}
+
+		batchSize = size
+	}
+
+	if value := c.QueryParam("max_batches"); value != "" {
+		batches, err := strconv.Atoi(value)
+		if err != nil || batches < 0 {
+			return "", 0, 0, ErrInvalidQueryParam
+		}
+
+		maxBatches = batches
+	}
+
+	return after, batchSize, maxBatches, nil
+}
+
+// tenantReindex recomputes the derived hierarchy fields for all tenants,
+// including deleted tenants, in batches ordered by ID.
+//
+// The reindex may be resumed by providing the last processed tenant ID as
+// `after`. When `max_batches` is provided, at most that many batches are
+// processed and the ID to resume from is returned as `next`.
+func (r *Router) tenantReindex(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantReindex")
+	defer span.End()
+
+	after, batchSize, maxBatches, err := parseBatchParams(c)
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	result := reindexResult{}
+
+	for batch := 0; maxBatches == 0 || batch < maxBatches; batch++ {
+		ts, err := models.Tenants(
+			qm.WithDeleted(),
+			qm.Select(models.TenantColumns.ID, models.TenantColumns.Path),
+			models.TenantWhere.ID.GT(after),
+			qm.OrderBy(models.TenantColumns.ID),
+			qm.Limit(batchSize),
+		).All(ctx, r.db)
+		if err != nil {
+			r.requestLogger(c).Error("failed to query tenants for reindex", zap.Error(err))
+
+			return v1InternalServerErrorResponse(c, err)
+		}
+
+		if len(ts) == 0 {
+			return v1ReindexResponse(c, result)
+		}
+
+		updated, unresolved, err := r.reindexBatch(ctx, ts)
+		if err != nil {
+			r.requestLogger(c).Error("failed to reindex tenants", zap.String("reindex.after", string(after)), zap.Error(err))
+
+			return v1InternalServerErrorResponse(c, err)
+		}
+
+		result.Processed += len(ts)
+		result.Updated += updated
+		result.Unresolved = append(result.Unresolved, unresolved...)
+
+		after = ts[len(ts)-1].ID
+
+		r.requestLogger(c).Debug("reindexed tenant batch",
+			zap.String("reindex.last_id", string(after)),
+			zap.Int("reindex.processed", result.Processed),
+			zap.Int("reindex.updated", result.Updated),
+		)
+
+		if len(ts) < batchSize {
+			return v1ReindexResponse(c, result)
+		}
+	}
+
+	result.Next = after
+
+	return v1ReindexResponse(c, result)
+}
+
+// reindexBatch persists the recomputed paths for the provided tenants within a
+// transaction, returning the number of updated tenants and the IDs of any
+// tenants whose path could not be resolved.
+func (r *Router) reindexBatch(ctx context.Context, ts models.TenantSlice) (int, []gidx.PrefixedID, error) {
+	var (
+		updated    int
+		unresolved []gidx.PrefixedID
+	)
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+
+		updated, unresolved, err = r.reindexPaths(ctx, tx, ts)
+
+		return err
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return updated, unresolved, nil
+}
+
+// reindexPaths persists the recomputed paths for the provided tenants,
+// which only need their ID and path loaded, returning the number of updated
+// tenants and the IDs of any tenants whose path could not be resolved.
+func (r *Router) reindexPaths(ctx context.Context, exec boil.ContextExecutor, ts models.TenantSlice) (int, []gidx.PrefixedID, error) {
+	ids := make([]gidx.PrefixedID, len(ts))
+
+	for i, t := range ts {
+		ids[i] = t.ID
+	}
+
+	paths, err := r.resolveTenantPaths(ctx, exec, ids)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var (
+		updated    int
+		unresolved []gidx.PrefixedID
+	)
+
+	for _, t := range ts {
+		path, ok := paths[t.ID]
+		if !ok {
+			unresolved = append(unresolved, t.ID)
+
+			continue
+		}
+
+		if path == t.Path {
+			continue
+		}
+
+		if _, err := models.Tenants(
+			qm.WithDeleted(),
+			models.TenantWhere.ID.EQ(t.ID),
+		).UpdateAll(ctx, exec, models.M{models.TenantColumns.Path: path}); err != nil {
+			return 0, nil, err
+		}
+
+		updated++
+	}
+
+	return updated, unresolved, nil
+}
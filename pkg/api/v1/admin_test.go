@@ -0,0 +1,510 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	nats "github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/x/echojwtx"
+	"go.infratographer.com/x/gidx"
+	"go.infratographer.com/x/pubsubx"
+)
+
+func TestTenantReindex(t *testing.T) {
+	testActorID := gidx.MustNewID(TenantIDPrefix)
+
+	// the test oauth client grants the "test" scope.
+	oauthClient, issuer, close := echojwtx.TestOAuthClient(string(testActorID), "")
+	defer close()
+
+	t.Run("requires admin scope", func(t *testing.T) {
+		srv, err := newTestServer(t, &testServerConfig{
+			client: oauthClient,
+			auth: &echojwtx.AuthConfig{
+				Issuer: issuer,
+			},
+		})
+		defer srv.close()
+
+		require.NoError(t, err, "no error expected for new test server")
+
+		resp, err := srv.Request(http.MethodPost, "/v1/admin/tenants/reindex", nil, nil, nil)
+		require.NoError(t, err, "no error expected for reindex")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode, "unexpected status code returned")
+	})
+
+	srv, err := newTestServer(t, &testServerConfig{
+		client: oauthClient,
+		auth: &echojwtx.AuthConfig{
+			Issuer: issuer,
+		},
+		routerOpts: []RouterOption{
+			WithAdminScope("test"),
+		},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	ctx := context.Background()
+
+	tree := buildTree(t, srv)
+
+	clearPaths := func(t *testing.T, ids ...gidx.PrefixedID) {
+		t.Helper()
+
+		for _, id := range ids {
+			_, err := srv.db.ExecContext(ctx, "UPDATE tenants SET path = '' WHERE id = $1", id)
+			require.NoError(t, err, "no error expected clearing tenant path")
+		}
+	}
+
+	assertPaths := func(t *testing.T) {
+		t.Helper()
+
+		for _, tenant := range tree.tenantsByID {
+			var expected string
+
+			for _, parent := range tree.parents[tenant.ID] {
+				expected = tenantPath(expected, parent.ID)
+			}
+
+			result, err := models.FindTenant(ctx, srv.db, tenant.ID)
+			require.NoError(t, err, "no error expected finding tenant")
+
+			assert.Equal(t, tenantPath(expected, tenant.ID), result.Path, "unexpected path for tenant %s", tenant.Name)
+		}
+	}
+
+	reindex := func(t *testing.T, path string) *v1ReindexResponseBody {
+		t.Helper()
+
+		var result *v1ReindexResponseBody
+
+		resp, err := srv.Request(http.MethodPost, path, nil, nil, &result)
+		require.NoError(t, err, "no error expected for reindex")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		require.NotNil(t, result, "expected reindex result")
+
+		return result
+	}
+
+	t.Run("repairs drifted paths", func(t *testing.T) {
+		clearPaths(t, tree.tenantsByName["t1a"].ID, tree.tenantsByName["t1a1b"].ID)
+
+		result := reindex(t, "/v1/admin/tenants/reindex")
+
+		assert.Equal(t, len(tree.tenantsByID), result.Processed, "unexpected processed count")
+		assert.Equal(t, 2, result.Updated, "unexpected updated count")
+		assert.Empty(t, result.Next, "expected reindex to complete")
+
+		assertPaths(t)
+	})
+
+	t.Run("idempotent", func(t *testing.T) {
+		result := reindex(t, "/v1/admin/tenants/reindex")
+
+		assert.Equal(t, len(tree.tenantsByID), result.Processed, "unexpected processed count")
+		assert.Equal(t, 0, result.Updated, "expected no tenants to be updated")
+	})
+
+	t.Run("resumable", func(t *testing.T) {
+		for id := range tree.tenantsByID {
+			clearPaths(t, id)
+		}
+
+		first := reindex(t, "/v1/admin/tenants/reindex?batch_size=4&max_batches=1")
+
+		assert.Equal(t, 4, first.Processed, "unexpected processed count")
+		assert.Equal(t, 4, first.Updated, "unexpected updated count")
+		require.NotEmpty(t, first.Next, "expected cursor to resume from")
+
+		second := reindex(t, "/v1/admin/tenants/reindex?batch_size=4&after="+string(first.Next))
+
+		assert.Equal(t, len(tree.tenantsByID)-4, second.Processed, "unexpected processed count")
+		assert.Equal(t, len(tree.tenantsByID)-4, second.Updated, "unexpected updated count")
+		assert.Empty(t, second.Next, "expected reindex to complete")
+
+		assertPaths(t)
+	})
+
+	t.Run("invalid batch size", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodPost, "/v1/admin/tenants/reindex?batch_size=0", nil, nil, nil)
+		require.NoError(t, err, "no error expected for reindex")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+	})
+}
+
+func TestNormalizeTenantName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"normalized", "café", "café"},
+		{"decomposed", "cafe\u0301", "café"},
+		{"trimmed", " \ttenant\n", "tenant"},
+		{"inner whitespace kept", "my  tenant", "my  tenant"},
+		{"whitespace", "   ", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, normalizeTenantName(tc.input))
+		})
+	}
+}
+
+func TestTenantNormalizeNames(t *testing.T) {
+	testActorID := gidx.MustNewID(TenantIDPrefix)
+
+	// the test oauth client grants the "test" scope.
+	oauthClient, issuer, close := echojwtx.TestOAuthClient(string(testActorID), "")
+	defer close()
+
+	srv, err := newTestServer(t, &testServerConfig{
+		client: oauthClient,
+		auth: &echojwtx.AuthConfig{
+			Issuer: issuer,
+		},
+		routerOpts: []RouterOption{
+			WithAdminScope("test"),
+			WithValidators(reservedNameValidator{reserved: "reserved"}),
+		},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	ctx := context.Background()
+
+	parent := srv.createTenant(t, "", "parent")
+	srv.createTenant(t, parent.ID, "café")
+
+	// The names predate validation, so they are written directly.
+	setName := func(t *testing.T, name string) gidx.PrefixedID {
+		t.Helper()
+
+		child := srv.createTenant(t, parent.ID, "child")
+
+		_, err := srv.db.ExecContext(ctx, "UPDATE tenants SET name = $1 WHERE id = $2", name, child.ID)
+		require.NoError(t, err, "no error expected setting tenant name")
+
+		return child.ID
+	}
+
+	decomposed := setName(t, "cafe\u0301")
+	padded := setName(t, " padded ")
+	blank := setName(t, "   ")
+	dupLeading := setName(t, " dup")
+	dupTrailing := setName(t, "dup ")
+	rejected := setName(t, " reserved")
+
+	normalize := func(t *testing.T) *v1NameNormalizationResponseBody {
+		t.Helper()
+
+		var result *v1NameNormalizationResponseBody
+
+		resp, err := srv.Request(http.MethodPost, "/v1/admin/tenants/normalize-names?batch_size=2", nil, nil, &result)
+		require.NoError(t, err, "no error expected for name normalization")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		require.NotNil(t, result, "expected name normalization result")
+
+		return result
+	}
+
+	name := func(t *testing.T, id gidx.PrefixedID) string {
+		t.Helper()
+
+		result, err := models.FindTenant(ctx, srv.db, id)
+		require.NoError(t, err, "no error expected finding tenant")
+
+		return result.Name
+	}
+
+	reasons := func(result *v1NameNormalizationResponseBody) map[gidx.PrefixedID]string {
+		reasons := make(map[gidx.PrefixedID]string)
+
+		for _, conflict := range result.Conflicts {
+			reasons[conflict.ID] = conflict.Reason
+		}
+
+		return reasons
+	}
+
+	t.Run("normalizes names", func(t *testing.T) {
+		result := normalize(t)
+
+		assert.Equal(t, 8, result.Processed, "unexpected processed count")
+		assert.Equal(t, 2, result.Updated, "unexpected updated count")
+		assert.Equal(t, 4, result.Conflicting, "unexpected conflicting count")
+		assert.Empty(t, result.Next, "expected normalization to complete")
+
+		assert.Equal(t, "padded", name(t, padded), "expected name to be trimmed")
+		assert.Equal(t, "cafe\u0301", name(t, decomposed), "expected conflicting name not to be changed")
+		assert.Equal(t, "   ", name(t, blank), "expected invalid name not to be changed")
+		assert.Equal(t, " reserved", name(t, rejected), "expected rejected name not to be changed")
+		// Whichever duplicate is normalized first takes the name.
+		dups := []string{name(t, dupLeading), name(t, dupTrailing)}
+		assert.Contains(t, dups, "dup", "expected a duplicate to be normalized")
+		assert.Subset(t, []string{"dup", " dup", "dup "}, dups, "expected the other duplicate not to be changed")
+		assert.NotEqual(t, dups[0], dups[1], "expected only one duplicate to be normalized")
+	})
+
+	t.Run("idempotent", func(t *testing.T) {
+		result := normalize(t)
+
+		assert.Equal(t, 0, result.Updated, "expected no tenants to be updated")
+		assert.Equal(t, 4, result.Conflicting, "expected conflicts to be reported again")
+		assert.Equal(t, errorCodeNameConflict, reasons(result)[decomposed], "unexpected conflict reason")
+		assert.Equal(t, normalizationReasonInvalid, reasons(result)[blank], "unexpected conflict reason")
+		assert.Equal(t, normalizationReasonValidationFailed, reasons(result)[rejected], "unexpected conflict reason")
+	})
+}
+
+func TestTenantIntegrity(t *testing.T) {
+	testActorID := gidx.MustNewID(TenantIDPrefix)
+
+	// the test oauth client grants the "test" scope.
+	oauthClient, issuer, close := echojwtx.TestOAuthClient(string(testActorID), "")
+	defer close()
+
+	srv, err := newTestServer(t, &testServerConfig{
+		client: oauthClient,
+		auth: &echojwtx.AuthConfig{
+			Issuer: issuer,
+		},
+		routerOpts: []RouterOption{
+			WithAdminScope("test"),
+			WithMaxDepth(20),
+		},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	ctx := context.Background()
+
+	tree := buildTree(t, srv)
+
+	integrity := func(t *testing.T) *v1TenantIntegrityResponseBody {
+		t.Helper()
+
+		var result *v1TenantIntegrityResponseBody
+
+		resp, err := srv.Request(http.MethodGet, "/v1/admin/tenants/integrity", nil, nil, &result)
+		require.NoError(t, err, "no error expected for integrity check")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		require.NotNil(t, result, "expected integrity report")
+
+		return result
+	}
+
+	t.Run("consistent", func(t *testing.T) {
+		result := integrity(t)
+
+		assert.Empty(t, result.MissingParents, "expected no missing parents")
+		assert.Empty(t, result.Cycles, "expected no cycles")
+	})
+
+	t.Run("injected cycle", func(t *testing.T) {
+		t1a := tree.tenantsByName["t1a"]
+		t1a1 := tree.tenantsByName["t1a1"]
+
+		// t1a becomes the child of its own child, t1a1, bypassing the api's cycle checks.
+		_, err := srv.db.ExecContext(ctx, "UPDATE tenants SET parent_tenant_id = $1 WHERE id = $2", t1a1.ID, t1a.ID)
+		require.NoError(t, err, "no error expected injecting cycle")
+
+		result := integrity(t)
+
+		assert.ElementsMatch(t, []gidx.PrefixedID{t1a.ID, t1a1.ID}, result.Cycles, "expected the tenants of the cycle")
+		assert.Empty(t, result.MissingParents, "expected no missing parents")
+
+		_, err = srv.db.ExecContext(ctx, "UPDATE tenants SET parent_tenant_id = $1 WHERE id = $2", tree.tenantsByName["t1"].ID, t1a.ID)
+		require.NoError(t, err, "no error expected restoring parent")
+
+		assert.Empty(t, integrity(t).Cycles, "expected no cycles once restored")
+	})
+
+	t.Run("self parent", func(t *testing.T) {
+		t2a := tree.tenantsByName["t2a"]
+
+		_, err := srv.db.ExecContext(ctx, "UPDATE tenants SET parent_tenant_id = id WHERE id = $1", t2a.ID)
+		require.NoError(t, err, "no error expected injecting cycle")
+
+		assert.Equal(t, []gidx.PrefixedID{t2a.ID}, integrity(t).Cycles, "expected the tenant parented by itself")
+	})
+}
+
+func TestTenantRepair(t *testing.T) {
+	testActorID := gidx.MustNewID(TenantIDPrefix)
+
+	// the test oauth client grants the "test" scope.
+	oauthClient, issuer, close := echojwtx.TestOAuthClient(string(testActorID), "")
+	defer close()
+
+	t.Run("requires admin scope", func(t *testing.T) {
+		srv, err := newTestServer(t, &testServerConfig{
+			client: oauthClient,
+			auth: &echojwtx.AuthConfig{
+				Issuer: issuer,
+			},
+		})
+		defer srv.close()
+
+		require.NoError(t, err, "no error expected for new test server")
+
+		resp, err := srv.Request(http.MethodPost, "/v1/admin/tenants/repair", nil, strings.NewReader(`{"tenant_id": "tnntten-abc"}`), nil)
+		require.NoError(t, err, "no error expected for repair")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode, "unexpected status code returned")
+	})
+
+	srv, err := newTestServer(t, &testServerConfig{
+		client: oauthClient,
+		auth: &echojwtx.AuthConfig{
+			Issuer: issuer,
+		},
+		routerOpts: []RouterOption{
+			WithAdminScope("test"),
+			WithMaxDepth(20),
+		},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	ctx := context.Background()
+
+	tree := buildTree(t, srv)
+
+	repair := func(t *testing.T, id gidx.PrefixedID, expectedStatus int) *v1TenantRepairResponseBody {
+		t.Helper()
+
+		var result *v1TenantRepairResponseBody
+
+		resp, err := srv.Request(http.MethodPost, "/v1/admin/tenants/repair", nil, strings.NewReader(fmt.Sprintf(`{"tenant_id": "%s"}`, id)), &result)
+		require.NoError(t, err, "no error expected for repair")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, expectedStatus, resp.StatusCode, "unexpected status code returned")
+
+		return result
+	}
+
+	integrity := func(t *testing.T) *v1TenantIntegrityResponseBody {
+		t.Helper()
+
+		var result *v1TenantIntegrityResponseBody
+
+		resp, err := srv.Request(http.MethodGet, "/v1/admin/tenants/integrity", nil, nil, &result)
+		require.NoError(t, err, "no error expected for integrity check")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		return result
+	}
+
+	t.Run("no issue", func(t *testing.T) {
+		repair(t, tree.tenantsByName["t1a"].ID, http.StatusConflict)
+		repair(t, tree.tenantsByName["t1"].ID, http.StatusConflict)
+		repair(t, gidx.MustNewID(TenantIDPrefix), http.StatusNotFound)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		repair(t, "", http.StatusBadRequest)
+		repair(t, "not-valid", http.StatusBadRequest)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		subscriber := newPubSubClient(t, srv.logger, srv.nats.ClientURL())
+		msgChan := make(chan *nats.Msg, 10)
+
+		subscription, err := subscriber.ChanSubscribe(ctx, "com.infratographer.events.tenants.>", msgChan, "tenant-api-test")
+		require.NoError(t, err)
+
+		defer func() {
+			if err := subscription.Unsubscribe(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		t1a := tree.tenantsByName["t1a"]
+		t1a1 := tree.tenantsByName["t1a1"]
+
+		// t1a becomes the child of its own child, t1a1, bypassing the api's cycle checks.
+		_, err = srv.db.ExecContext(ctx, "UPDATE tenants SET parent_tenant_id = $1 WHERE id = $2", t1a1.ID, t1a.ID)
+		require.NoError(t, err, "no error expected injecting cycle")
+
+		require.NotEmpty(t, integrity(t).Cycles, "expected the injected cycle")
+
+		result := repair(t, t1a.ID, http.StatusOK)
+		require.NotNil(t, result, "expected repair result")
+
+		assert.Equal(t, t1a.ID, result.ID)
+		assert.Equal(t, repairIssueCycle, result.Issue)
+		assert.Equal(t, t1a1.ID, result.PreviousParentID, "expected the removed parent")
+		subtree := 0
+
+		for path := range tree.tenantsByPath {
+			if path == "t1.t1a" || strings.HasPrefix(path, "t1.t1a.") {
+				subtree++
+			}
+		}
+
+		assert.Equal(t, subtree, result.PathsUpdated, "expected the subtree paths updated")
+
+		assert.Empty(t, integrity(t).Cycles, "expected no cycles once repaired")
+
+		stored, err := models.FindTenant(ctx, srv.db, t1a.ID)
+		require.NoError(t, err, "no error expected finding tenant")
+		assert.False(t, stored.ParentTenantID.Valid, "expected tenant promoted to root")
+		assert.Equal(t, tenantPath("", t1a.ID), stored.Path)
+
+		var parents *v1TenantSliceResponse
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(t1a1.ID)+"/parents", nil, nil, &parents)
+		require.NoError(t, err, "no error expected listing parents")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "expected the hierarchy queryable once repaired")
+		assert.Equal(t, []gidx.PrefixedID{t1a.ID}, tenantIDs(parents.Tenants), "expected the repaired tenant as the only parent")
+
+		select {
+		case msg := <-msgChan:
+			pMsg := &pubsubx.ChangeMessage{}
+			require.NoError(t, json.Unmarshal(msg.Data, pMsg))
+
+			assert.Equal(t, tenantSubjectUpdate, msg.Subject, "expected nats subject to be tenant update subject")
+			assert.Equal(t, t1a.ID, pMsg.SubjectID, "expected event for the repaired tenant")
+			require.Len(t, pMsg.FieldChanges, 1, "expected parent field change")
+			assert.Equal(t, "parent_tenant_id", pMsg.FieldChanges[0].Field)
+		case <-time.After(natsMsgSubTimeout):
+			t.Error("failed to receive nats message")
+		}
+	})
+
+	t.Run("missing parent", func(t *testing.T) {
+		t2a := tree.tenantsByName["t2a"]
+
+		_, err := srv.db.ExecContext(ctx, "UPDATE tenants SET parent_tenant_id = $1 WHERE id = $2", gidx.MustNewID(TenantIDPrefix), t2a.ID)
+		require.NoError(t, err, "no error expected injecting missing parent")
+
+		result := repair(t, t2a.ID, http.StatusOK)
+		require.NotNil(t, result, "expected repair result")
+
+		assert.Equal(t, repairIssueMissingParent, result.Issue)
+		assert.Empty(t, integrity(t).MissingParents, "expected no missing parents once repaired")
+	})
+}
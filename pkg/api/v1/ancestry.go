@@ -0,0 +1,193 @@
+package api
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/x/gidx"
+)
+
+// ancestryQuery walks the parent pointers up from the descendant ($2) until
+// the ancestor ($1), a root tenant or the maximum depth ($3) is reached,
+// returning whether both tenants exist, how many levels separate them and
+// whether the walk was stopped by the maximum depth.
+const ancestryQuery = `
+	WITH RECURSIVE ancestry AS (
+		SELECT id, parent_tenant_id, 0 AS depth
+		FROM tenants
+		WHERE id = $2 AND deleted_at IS NULL
+
+		UNION ALL
+
+		SELECT t.id, t.parent_tenant_id, a.depth + 1
+		FROM tenants t
+		INNER JOIN ancestry a ON t.id = a.parent_tenant_id
+		WHERE
+			a.id != $1
+			AND a.depth < $3
+			AND t.deleted_at IS NULL
+	)
+	SELECT
+		EXISTS (SELECT 1 FROM tenants WHERE id = $1 AND deleted_at IS NULL),
+		EXISTS (SELECT 1 FROM ancestry WHERE depth = 0),
+		(SELECT min(depth) FROM ancestry WHERE id = $1 AND depth > 0),
+		EXISTS (SELECT 1 FROM ancestry WHERE depth = $3 AND id != $1 AND parent_tenant_id IS NOT NULL)
+`
+
+// tenantIsAncestorOf reports whether the tenant is an ancestor of the other
+// tenant, and if so how many levels above it the tenant is. A tenant is not
+// considered an ancestor of itself.
+func (r *Router) tenantIsAncestorOf(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantIsAncestorOf")
+	defer span.End()
+
+	ancestorID, err := r.parseID(c, "id")
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	descendantID, err := r.parseID(c, "other_id")
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	var (
+		ancestorExists, descendantExists bool
+		depth                            sql.NullInt64
+		exceeded                         bool
+	)
+
+	if err := r.db.QueryRowContext(ctx, ancestryQuery, ancestorID, descendantID, r.maxDepth).
+		Scan(&ancestorExists, &descendantExists, &depth, &exceeded); err != nil {
+		return r.tenantQueryErrorResponse(c, err)
+	}
+
+	if !ancestorExists || !descendantExists {
+		return v1TenantNotFoundResponse(c, sql.ErrNoRows)
+	}
+
+	if exceeded && !depth.Valid {
+		return r.maxDepthExceededResponse(c, descendantID)
+	}
+
+	return v1TenantAncestryResponse(c, depth.Valid, int(depth.Int64))
+}
+
+// tenantContains reports whether the other tenant is within the subtree of
+// the tenant, such as to check a tenant is in scope of another. A subtree
+// includes the tenant itself, so unlike the ancestor check, a tenant contains
+// itself.
+//
+// The check uses the materialized path of the other tenant, so only the two
+// tenants are queried.
+func (r *Router) tenantContains(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantContains")
+	defer span.End()
+
+	tenantID, err := r.parseID(c, "id")
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	otherID, err := r.parseID(c, "other_id")
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	ts, err := models.Tenants(
+		qm.Select(models.TenantColumns.ID, models.TenantColumns.Path),
+		tenantIDsQueryMod([]gidx.PrefixedID{tenantID, otherID}),
+	).All(ctx, r.db)
+	if err != nil {
+		return r.tenantQueryErrorResponse(c, err)
+	}
+
+	paths := make(map[gidx.PrefixedID]string, len(ts))
+
+	for _, t := range ts {
+		paths[t.ID] = t.Path
+	}
+
+	_, ok := paths[tenantID]
+	otherPath, otherOK := paths[otherID]
+
+	if !ok || !otherOK {
+		return v1TenantNotFoundResponse(c, sql.ErrNoRows)
+	}
+
+	return v1TenantContainsResponse(c, isPathWithin(otherPath, tenantID))
+}
+
+// tenantLowestCommonAncestor responds with the deepest tenant which is an
+// ancestor of both tenants, or a null tenant when they are in different
+// trees. A tenant is considered an ancestor of itself, so when one tenant is
+// an ancestor of the other it is the lowest common ancestor.
+//
+// The ancestor chains are compared using the tenants' materialized paths,
+// so only the two tenants and their common ancestor are queried.
+func (r *Router) tenantLowestCommonAncestor(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantLowestCommonAncestor")
+	defer span.End()
+
+	a, err := r.parseQueryID(c, "a")
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	b, err := r.parseQueryID(c, "b")
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	ts, err := models.Tenants(
+		qm.Select(models.TenantColumns.ID, models.TenantColumns.Path),
+		qm.Where(models.TenantTableColumns.ID+" = ANY(?)", pq.Array([]string{string(a), string(b)})),
+	).All(ctx, r.db)
+	if err != nil {
+		return r.tenantQueryErrorResponse(c, err)
+	}
+
+	paths := make(map[gidx.PrefixedID]string, len(ts))
+
+	for _, t := range ts {
+		paths[t.ID] = t.Path
+	}
+
+	pathA, okA := paths[a]
+	pathB, okB := paths[b]
+
+	if !okA || !okB {
+		return v1TenantNotFoundResponse(c, sql.ErrNoRows)
+	}
+
+	ancestorID := commonAncestor(pathA, pathB)
+	if ancestorID == "" {
+		return v1TenantOrNullResponse(c, nil)
+	}
+
+	ancestor, err := models.FindTenant(ctx, r.db, ancestorID)
+	if err != nil {
+		return r.tenantQueryErrorResponse(c, err)
+	}
+
+	return v1TenantOrNullResponse(c, ancestor)
+}
+
+// commonAncestor returns the last tenant shared by both materialized paths,
+// or an empty ID when the paths start from different roots.
+func commonAncestor(a, b string) gidx.PrefixedID {
+	idsA := strings.Split(a, tenantPathSeparator)
+	idsB := strings.Split(b, tenantPathSeparator)
+
+	var ancestor gidx.PrefixedID
+
+	for i := 0; i < len(idsA) && i < len(idsB) && idsA[i] == idsB[i]; i++ {
+		ancestor = gidx.PrefixedID(idsA[i])
+	}
+
+	return ancestor
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/x/gidx"
+)
+
+func TestTenantIsAncestorOf(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	tree := buildTree(t, srv)
+
+	ancestryPath := func(ancestor, descendant gidx.PrefixedID) string {
+		return "/v1/tenants/" + string(ancestor) + "/is-ancestor-of/" + string(descendant)
+	}
+
+	testCases := []struct {
+		name       string
+		ancestor   string
+		descendant string
+		expected   bool
+		depth      int
+	}{
+		{"parent", "t1a", "t1a1", true, 1},
+		{"root of deep descendant", "t1", "t1a1b", true, 3},
+		{"intermediate", "t1b", "t1b1a", true, 2},
+		{"descendant of ancestor", "t1a1a", "t1", false, 0},
+		{"sibling branch", "t1a", "t1b1", false, 0},
+		{"different root", "t1", "t2a", false, 0},
+		{"self", "t1a", "t1a", false, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var result *v1TenantAncestryResponseBody
+
+			resp, err := srv.Request(http.MethodGet, ancestryPath(tree.tenantsByName[tc.ancestor].ID, tree.tenantsByName[tc.descendant].ID), nil, nil, &result)
+			require.NoError(t, err, "no error expected for ancestry check")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+			require.NotNil(t, result, "expected ancestry response")
+			assert.Equal(t, tc.expected, result.Ancestor, "unexpected ancestor result")
+			assert.Equal(t, tc.depth, result.Depth, "unexpected depth")
+		})
+	}
+
+	t.Run("unknown tenants", func(t *testing.T) {
+		known := tree.tenantsByName["t1"].ID
+		unknown := gidx.MustNewID(TenantIDPrefix)
+
+		for _, path := range []string{ancestryPath(unknown, known), ancestryPath(known, unknown)} {
+			resp, err := srv.Request(http.MethodGet, path, nil, nil, nil)
+			require.NoError(t, err, "no error expected for ancestry check")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode, "unexpected status code returned")
+		}
+	})
+}
+
+func TestTenantContains(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	tree := buildTree(t, srv)
+
+	containsPath := func(id, other gidx.PrefixedID) string {
+		return "/v1/tenants/" + string(id) + "/contains/" + string(other)
+	}
+
+	testCases := []struct {
+		name     string
+		tenant   string
+		other    string
+		expected bool
+	}{
+		{"child", "t1a", "t1a1", true},
+		{"deep descendant", "t1", "t1a1b", true},
+		{"self", "t1a", "t1a", true},
+		{"ancestor", "t1a1a", "t1", false},
+		{"sibling branch", "t1a", "t1b1", false},
+		{"different root", "t1", "t2a", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var result *v1TenantContainsResponseBody
+
+			resp, err := srv.Request(http.MethodGet, containsPath(tree.tenantsByName[tc.tenant].ID, tree.tenantsByName[tc.other].ID), nil, nil, &result)
+			require.NoError(t, err, "no error expected for contains check")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+			require.NotNil(t, result, "expected contains response")
+			assert.Equal(t, tc.expected, result.Contains, "unexpected contains result")
+		})
+	}
+
+	t.Run("unknown tenants", func(t *testing.T) {
+		known := tree.tenantsByName["t1"].ID
+		unknown := gidx.MustNewID(TenantIDPrefix)
+
+		for _, path := range []string{containsPath(unknown, known), containsPath(known, unknown)} {
+			resp, err := srv.Request(http.MethodGet, path, nil, nil, nil)
+			require.NoError(t, err, "no error expected for contains check")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode, "unexpected status code returned")
+		}
+	})
+}
+
+func TestCommonAncestor(t *testing.T) {
+	testCases := []struct {
+		name     string
+		a        string
+		b        string
+		expected gidx.PrefixedID
+	}{
+		{"siblings", "root.a.b", "root.a.c", "a"},
+		{"ancestor", "root.a", "root.a.b.c", "a"},
+		{"self", "root.a", "root.a", "a"},
+		{"shared root", "root.a", "root.b", "root"},
+		{"different roots", "root.a", "other.a", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, commonAncestor(tc.a, tc.b))
+		})
+	}
+}
+
+func TestTenantLowestCommonAncestor(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	tree := buildTree(t, srv)
+
+	lca := func(t *testing.T, a, b string) (*http.Response, *v1TenantResponse) {
+		t.Helper()
+
+		var result *v1TenantResponse
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/lca?a="+a+"&b="+b, nil, nil, &result)
+		require.NoError(t, err, "no error expected for lowest common ancestor")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp, result
+	}
+
+	testCases := []struct {
+		name     string
+		a        string
+		b        string
+		expected string
+	}{
+		{"siblings", "t1a1a", "t1a1b", "t1a1"},
+		{"cousins", "t1a1a", "t1b1a", "t1"},
+		{"ancestor", "t1a", "t1a1b", "t1a"},
+		{"descendant", "t1a1b", "t1a", "t1a"},
+		{"self", "t1b1", "t1b1", "t1b1"},
+		{"disjoint trees", "t1a1a", "t2a", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, result := lca(t, string(tree.tenantsByName[tc.a].ID), string(tree.tenantsByName[tc.b].ID))
+			require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+			require.NotNil(t, result, "expected tenant response")
+
+			if tc.expected == "" {
+				assert.Nil(t, result.Tenant, "expected no common ancestor")
+
+				return
+			}
+
+			require.NotNil(t, result.Tenant, "expected common ancestor")
+			assert.Equal(t, tree.tenantsByName[tc.expected].ID, result.Tenant.ID, "unexpected common ancestor")
+		})
+	}
+
+	t.Run("invalid tenants", func(t *testing.T) {
+		known := string(tree.tenantsByName["t1"].ID)
+
+		resp, _ := lca(t, known, "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "expected missing tenant to be rejected")
+
+		resp, _ = lca(t, "not-valid", known)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "expected invalid tenant to be rejected")
+
+		resp, _ = lca(t, known, string(gidx.MustNewID(TenantIDPrefix)))
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, "expected unknown tenant to be not found")
+	})
+}
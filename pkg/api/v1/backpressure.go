@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.infratographer.com/tenant-api/internal/pubsub"
+)
+
+// publishRetryAfter is how long clients are asked to wait, before jitter,
+// before retrying changes rejected while the publish buffer is full.
+const publishRetryAfter = time.Second
+
+// publishBackpressure rejects requests making changes while event publishes
+// are being rejected because the publish buffer is full, so that no changes
+// are committed whose events would then be dropped. Reads are always served.
+func (r *Router) publishBackpressure(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		switch c.Request().Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return next(c)
+		}
+
+		if r.pubsub.RejectingPublishes() {
+			r.setRetryAfter(c, publishRetryAfter)
+
+			return v1ServiceUnavailableResponse(c, pubsub.ErrBufferFull)
+		}
+
+		return next(c)
+	}
+}
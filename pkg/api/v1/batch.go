@@ -0,0 +1,326 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/tenant-api/internal/pubsub"
+	"go.infratographer.com/x/gidx"
+	"go.uber.org/zap"
+)
+
+const (
+	// batchModeTransactional creates every tenant of the batch or none of them.
+	batchModeTransactional = "transactional"
+
+	// batchModePartial creates each tenant of the batch independently.
+	batchModePartial = "partial"
+)
+
+// batchCreateResult is the outcome of creating a single tenant of a partial batch.
+type batchCreateResult struct {
+	Status int     `json:"status"`
+	Tenant *tenant `json:"tenant,omitempty"`
+	Error  string  `json:"error,omitempty"`
+	Code   string  `json:"code,omitempty"`
+}
+
+// tenantBatchCreate creates many tenants, in the order requested. By default
+// the batch is created in a single transaction, and if any tenant is
+// rejected no tenants are created. With mode=partial each tenant is created
+// independently, responding with the result of each tenant.
+//
+// Create events are published for every created tenant once it has been
+// committed.
+func (r *Router) tenantBatchCreate(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantBatchCreate")
+	defer span.End()
+
+	mode := c.QueryParam("mode")
+
+	switch mode {
+	case "", batchModeTransactional, batchModePartial:
+	default:
+		return v1BadRequestResponse(c, fmt.Errorf("%w: mode must be %s or %s", ErrInvalidQueryParam, batchModeTransactional, batchModePartial))
+	}
+
+	payload := new(batchCreateRequest)
+
+	if err := bindRequest(c, payload); err != nil {
+		r.requestLogger(c).Error("failed to bind batch create request", zap.Error(err))
+
+		return v1BadRequestResponse(c, err)
+	}
+
+	if err := payload.validate(); err != nil {
+		r.requestLogger(c).Error("invalid batch create request", zap.Error(err))
+
+		return v1BadRequestResponse(c, err)
+	}
+
+	if mode == batchModePartial {
+		return r.tenantBatchCreatePartial(ctx, c, payload)
+	}
+
+	parentIDs := make([]gidx.PrefixedID, len(payload.Tenants))
+
+	for i := range payload.Tenants {
+		if err := payload.Tenants[i].validate(); err != nil {
+			r.requestLogger(c).Error("invalid batch create request", zap.Error(err))
+
+			return v1BadRequestResponse(c, fmt.Errorf("tenant %d: %w", i, err))
+		}
+
+		parentID, err := r.batchCreateParentID(&payload.Tenants[i])
+		if err != nil {
+			r.requestLogger(c).Error("invalid batch create request", zap.Error(err))
+
+			return v1BadRequestResponse(c, fmt.Errorf("tenant %d: %w", i, err))
+		}
+
+		parentIDs[i] = parentID
+	}
+
+	actor := r.actor(c)
+
+	var (
+		ts      []*models.Tenant
+		created []bool
+	)
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		ts = make([]*models.Tenant, len(payload.Tenants))
+		created = make([]bool, len(payload.Tenants))
+
+		for i := range payload.Tenants {
+			item := &payload.Tenants[i]
+
+			t, err := r.insertTenantTx(ctx, tx, parentIDs[i], actor, &item.createTenantRequest)
+			if errors.Is(err, ErrNameConflict) && r.idempotentCreate {
+				// With global name uniqueness the conflicting tenant may not be a
+				// child of the parent, which remains a conflict.
+				if existing, qErr := existingChild(ctx, tx, parentIDs[i], item.Name); !errors.Is(qErr, sql.ErrNoRows) {
+					t, err = existing, qErr
+				}
+			} else {
+				created[i] = err == nil
+			}
+
+			if err != nil {
+				return fmt.Errorf("tenant %d: %w", i, err)
+			}
+
+			ts[i] = t
+		}
+
+		return nil
+	})
+	if err != nil {
+		return r.tenantBatchCreateErrorResponse(c, err)
+	}
+
+	if r.aggregateBatchEvents {
+		var ids []gidx.PrefixedID
+
+		for i, t := range ts {
+			if created[i] {
+				ids = append(ids, t.ID)
+			}
+		}
+
+		r.publishBatch(c, actor, pubsub.BatchCreateOperation, ids)
+
+		return v1TenantsCreatedResponse(c, ts)
+	}
+
+	for i, t := range ts {
+		if created[i] {
+			r.publishCreate(ctx, c, t, payload.Tenants[i].Labels)
+		}
+	}
+
+	return v1TenantsCreatedResponse(c, ts)
+}
+
+// tenantBatchCreatePartial creates each tenant of the batch in its own
+// transaction, responding with the result of each tenant.
+func (r *Router) tenantBatchCreatePartial(ctx context.Context, c echo.Context, payload *batchCreateRequest) error {
+	results := make([]batchCreateResult, len(payload.Tenants))
+
+	var created []gidx.PrefixedID
+
+	for i := range payload.Tenants {
+		item := &payload.Tenants[i]
+
+		t, status, err := r.batchCreateTenant(ctx, r.actor(c), item)
+		if err != nil {
+			if status == http.StatusInternalServerError {
+				r.requestLogger(c).Error("failed to create batch tenant", zap.Int("index", i), zap.Error(err))
+			}
+
+			results[i] = batchCreateResult{
+				Status: status,
+				Error:  err.Error(),
+				Code:   batchCreateErrorCode(err),
+			}
+
+			continue
+		}
+
+		if status == http.StatusCreated {
+			if r.aggregateBatchEvents {
+				created = append(created, t.ID)
+			} else {
+				r.publishCreate(ctx, c, t, item.Labels)
+			}
+		}
+
+		results[i] = batchCreateResult{
+			Status: status,
+			Tenant: v1Tenant(t),
+		}
+	}
+
+	if r.aggregateBatchEvents {
+		r.publishBatch(c, r.actor(c), pubsub.BatchCreateOperation, created)
+	}
+
+	return v1TenantBatchCreateResponse(c, results)
+}
+
+// batchCreateTenant creates a single tenant of a partial batch, returning the
+// status describing the outcome. When creates are idempotent, a tenant
+// conflicting with an existing child of the parent results in the existing
+// child.
+func (r *Router) batchCreateTenant(ctx context.Context, actor string, item *batchCreateTenant) (*models.Tenant, int, error) {
+	if err := item.validate(); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	parentID, err := r.batchCreateParentID(item)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	t, err := r.createTenant(ctx, parentID, actor, &item.createTenantRequest)
+	if err == nil {
+		return t, http.StatusCreated, nil
+	}
+
+	if errors.Is(err, ErrNameConflict) && r.idempotentCreate {
+		if existing, qErr := existingChild(ctx, r.db, parentID, item.Name); qErr == nil {
+			return existing, http.StatusOK, nil
+		}
+	}
+
+	return nil, batchCreateErrorStatus(err), err
+}
+
+// batchCreateParentID returns the parent of the batch tenant, which is empty
+// for root tenants.
+func (r *Router) batchCreateParentID(item *batchCreateTenant) (gidx.PrefixedID, error) {
+	if item.ParentTenantID == nil {
+		return "", nil
+	}
+
+	return r.parseTenantID(string(*item.ParentTenantID))
+}
+
+// tenantBatchCreateErrorResponse responds to a rejected or failed batch create.
+func (r *Router) tenantBatchCreateErrorResponse(c echo.Context, err error) error {
+	status := batchCreateErrorStatus(err)
+
+	switch status {
+	case http.StatusNotFound:
+		return v1TenantNotFoundResponse(c, err)
+	case http.StatusInternalServerError:
+		r.requestLogger(c).Error("failed to create tenants", zap.Error(err))
+
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	if errors.Is(err, ErrValidationFailed) {
+		r.requestLogger(c).Error("tenant create rejected by validator", zap.Error(err))
+	}
+
+	return v1ErrorCodeResponse(c, status, batchCreateErrorMessages[status], batchCreateErrorCode(err), err)
+}
+
+// batchCreateErrorMessages are the messages of the rejected batch create statuses.
+var batchCreateErrorMessages = map[int]string{
+	http.StatusBadRequest:          "bad request",
+	http.StatusConflict:            "conflict",
+	http.StatusUnprocessableEntity: "unprocessable entity",
+}
+
+// batchCreateErrorStatus returns the status of a rejected or failed tenant create.
+func batchCreateErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, ErrValidationFailed):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrNameConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrChildLimitExceeded), errors.Is(err, ErrNamePatternMismatch), errors.Is(err, ErrTenantNameReserved), errors.Is(err, ErrInvalidKind):
+		return http.StatusUnprocessableEntity
+	}
+
+	return http.StatusInternalServerError
+}
+
+// batchCreateErrorCode returns the error code of a rejected tenant create.
+func batchCreateErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrNameConflict):
+		return errorCodeNameConflict
+	case errors.Is(err, ErrChildLimitExceeded):
+		return errorCodeChildLimitExceeded
+	case errors.Is(err, ErrNamePatternMismatch):
+		return errorCodeNamePatternMismatch
+	case errors.Is(err, ErrTenantNameReserved):
+		return errorCodeNameReserved
+	case errors.Is(err, ErrInvalidKind):
+		return errorCodeInvalidKind
+	}
+
+	return ""
+}
+
+// publishBatch publishes a single aggregate event of the tenants affected by
+// a batch operation, used in place of an event for each tenant when batch
+// events are aggregated. Nothing is published when no tenants were affected.
+func (r *Router) publishBatch(c echo.Context, actor, operation string, ids []gidx.PrefixedID) {
+	if len(ids) == 0 {
+		return
+	}
+
+	ctx := c.Request().Context()
+
+	msg, err := pubsub.BatchTenantMessage(gidx.PrefixedID(actor), operation, ids)
+	if err != nil {
+		// TODO: add status to reconcile and requeue this
+		r.requestLogger(c).Error("failed to create, batch tenant message", zap.Error(err))
+
+		return
+	}
+
+	switch operation {
+	case pubsub.BatchCreateOperation:
+		err = r.pubsub.PublishCreate(ctx, "tenants", "global", msg)
+	case pubsub.BatchDeleteOperation:
+		err = r.pubsub.PublishDelete(ctx, "tenants", "global", msg)
+	default:
+		err = r.pubsub.PublishUpdate(ctx, "tenants", "global", msg)
+	}
+
+	if err != nil {
+		// TODO: add status to reconcile and requeue this
+		r.requestLogger(c).Error("failed to publish, batch tenant message", zap.Error(err))
+	}
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/x/gidx"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxBatchGetIDs is the maximum number of tenants which may be fetched in a single batch.
+	maxBatchGetIDs = 100
+
+	// batchGetIncludeParent includes each tenant's parent.
+	batchGetIncludeParent = "parent"
+
+	// batchGetIncludePath includes each tenant's ancestry, from its root tenant down to the tenant.
+	batchGetIncludePath = "path"
+)
+
+// batchGetTenant is a tenant of a batch get, with the requested expansions.
+type batchGetTenant struct {
+	*tenant
+	Parent *tenant     `json:"parent,omitempty"`
+	Path   tenantSlice `json:"path,omitempty"`
+}
+
+// tenantBatchGet fetches many tenants by ID, in the order requested, along
+// with the expansions listed in include. The expansions are computed from
+// the materialized paths of the tenants, so all of the ancestors are fetched
+// in a single query, however many tenants are requested.
+//
+// Requested tenants which do not exist, or are deleted, are listed as missing.
+func (r *Router) tenantBatchGet(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantBatchGet")
+	defer span.End()
+
+	payload := new(batchGetRequest)
+
+	if err := bindRequest(c, payload); err != nil {
+		r.requestLogger(c).Error("failed to bind batch get request", zap.Error(err))
+
+		return v1BadRequestResponse(c, err)
+	}
+
+	if err := payload.validate(); err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	ids := make([]gidx.PrefixedID, 0, len(payload.IDs))
+	seen := make(map[gidx.PrefixedID]bool, len(payload.IDs))
+
+	for _, value := range payload.IDs {
+		id, err := r.parseTenantID(string(value))
+		if err != nil {
+			return v1BadRequestResponse(c, err)
+		}
+
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	ts, err := models.Tenants(tenantIDsQueryMod(ids)).All(ctx, r.db)
+	if err != nil {
+		return r.tenantQueryErrorResponse(c, err)
+	}
+
+	found := make(map[gidx.PrefixedID]*models.Tenant, len(ts))
+
+	for _, t := range ts {
+		found[t.ID] = t
+	}
+
+	ancestors := make(map[gidx.PrefixedID]*models.Tenant)
+
+	if payload.includes(batchGetIncludeParent) || payload.includes(batchGetIncludePath) {
+		if ancestors, err = r.batchGetAncestors(ctx, ts); err != nil {
+			return r.tenantQueryErrorResponse(c, err)
+		}
+	}
+
+	var (
+		results = make([]batchGetTenant, 0, len(ts))
+		missing []gidx.PrefixedID
+	)
+
+	for _, id := range ids {
+		t, ok := found[id]
+		if !ok {
+			missing = append(missing, id)
+
+			continue
+		}
+
+		result := batchGetTenant{tenant: v1Tenant(t)}
+
+		if payload.includes(batchGetIncludeParent) && t.ParentTenantID.Valid {
+			if parent, ok := ancestors[t.ParentTenantID.PrefixedID]; ok {
+				result.Parent = v1Tenant(parent)
+			}
+		}
+
+		if payload.includes(batchGetIncludePath) {
+			for _, ancestorID := range strings.Split(t.Path, tenantPathSeparator) {
+				if ancestor, ok := ancestors[gidx.PrefixedID(ancestorID)]; ok {
+					result.Path = append(result.Path, v1Tenant(ancestor))
+				}
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return v1TenantBatchGetResponse(c, results, missing)
+}
+
+// batchGetAncestors fetches the tenants in the materialized paths of the
+// provided tenants, including the tenants themselves, by ID. Deleted
+// ancestors are not included.
+func (r *Router) batchGetAncestors(ctx context.Context, ts models.TenantSlice) (map[gidx.PrefixedID]*models.Tenant, error) {
+	var ids []gidx.PrefixedID
+
+	seen := make(map[gidx.PrefixedID]bool)
+
+	for _, t := range ts {
+		for _, id := range strings.Split(t.Path, tenantPathSeparator) {
+			if id != "" && !seen[gidx.PrefixedID(id)] {
+				seen[gidx.PrefixedID(id)] = true
+				ids = append(ids, gidx.PrefixedID(id))
+			}
+		}
+	}
+
+	ancestors := make(map[gidx.PrefixedID]*models.Tenant, len(ids))
+
+	if len(ids) == 0 {
+		return ancestors, nil
+	}
+
+	found, err := models.Tenants(tenantIDsQueryMod(ids)).All(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range found {
+		ancestors[t.ID] = t
+	}
+
+	return ancestors, nil
+}
+
+// tenantIDsQueryMod matches the tenants with the provided IDs.
+func tenantIDsQueryMod(ids []gidx.PrefixedID) qm.QueryMod {
+	params := make([]string, len(ids))
+
+	for i, id := range ids {
+		params[i] = string(id)
+	}
+
+	return qm.Where(models.TenantTableColumns.ID+" = ANY(?)", pq.Array(params))
+}
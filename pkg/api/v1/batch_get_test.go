@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/x/gidx"
+)
+
+func TestTenantBatchGet(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	tree := buildTree(t, srv)
+
+	batchGet := func(t *testing.T, ids []gidx.PrefixedID, include []string, out interface{}) *http.Response {
+		t.Helper()
+
+		body, err := json.Marshal(batchGetRequest{IDs: ids, Include: include})
+		require.NoError(t, err, "no error expected encoding batch get request")
+
+		resp, err := srv.Request(http.MethodPost, "/v1/tenants/batch-get", nil, strings.NewReader(string(body)), out)
+		require.NoError(t, err, "no error expected for batch get")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp
+	}
+
+	leaf := tree.tenantsByName["t1a1a"]
+	root := tree.tenantsByName["t2"]
+	unknown := gidx.MustNewID(TenantIDPrefix)
+
+	t.Run("expansions", func(t *testing.T) {
+		var result *v1TenantBatchGetResponseBody
+
+		resp := batchGet(t, []gidx.PrefixedID{leaf.ID, unknown, root.ID, leaf.ID}, []string{batchGetIncludeParent, batchGetIncludePath}, &result)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected batch get response")
+		require.Len(t, result.Tenants, 2, "expected each found tenant once")
+		assert.Equal(t, []gidx.PrefixedID{unknown}, result.Missing, "expected unknown tenant to be missing")
+
+		assert.Equal(t, leaf.ID, result.Tenants[0].ID, "expected tenants in the requested order")
+		require.NotNil(t, result.Tenants[0].Parent, "expected parent")
+		assert.Equal(t, tree.tenantsByName["t1a1"].ID, result.Tenants[0].Parent.ID, "unexpected parent")
+		assert.Equal(t, tenantIDs([]*tenant{
+			tree.tenantsByName["t1"],
+			tree.tenantsByName["t1a"],
+			tree.tenantsByName["t1a1"],
+			leaf,
+		}), tenantIDs(result.Tenants[0].Path), "expected path from the root to the tenant")
+
+		assert.Equal(t, root.ID, result.Tenants[1].ID, "expected tenants in the requested order")
+		assert.Nil(t, result.Tenants[1].Parent, "expected root tenant to have no parent")
+		assert.Equal(t, []gidx.PrefixedID{root.ID}, tenantIDs(result.Tenants[1].Path), "expected root tenant path")
+	})
+
+	t.Run("parent only", func(t *testing.T) {
+		var result *v1TenantBatchGetResponseBody
+
+		resp := batchGet(t, []gidx.PrefixedID{leaf.ID}, []string{batchGetIncludeParent}, &result)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		require.Len(t, result.Tenants, 1, "expected tenant")
+		assert.NotNil(t, result.Tenants[0].Parent, "expected parent")
+		assert.Empty(t, result.Tenants[0].Path, "expected no path")
+	})
+
+	t.Run("no expansions", func(t *testing.T) {
+		var result *v1TenantBatchGetResponseBody
+
+		resp := batchGet(t, []gidx.PrefixedID{leaf.ID}, nil, &result)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		require.Len(t, result.Tenants, 1, "expected tenant")
+		assert.Equal(t, leaf.Name, result.Tenants[0].Name)
+		assert.Nil(t, result.Tenants[0].Parent, "expected no parent")
+		assert.Empty(t, result.Tenants[0].Path, "expected no path")
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		tooMany := make([]gidx.PrefixedID, maxBatchGetIDs+1)
+
+		for i := range tooMany {
+			tooMany[i] = gidx.MustNewID(TenantIDPrefix)
+		}
+
+		testCases := []struct {
+			name    string
+			ids     []gidx.PrefixedID
+			include []string
+		}{
+			{"no ids", nil, nil},
+			{"too many ids", tooMany, nil},
+			{"invalid id", []gidx.PrefixedID{"invalid"}, nil},
+			{"unknown include", []gidx.PrefixedID{leaf.ID}, []string{"children"}},
+		}
+
+		for _, tc := range testCases {
+			resp := batchGet(t, tc.ids, tc.include, nil)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned for %s", tc.name)
+		}
+	})
+}
@@ -0,0 +1,320 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	nats "github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/tenant-api/internal/pubsub"
+	"go.infratographer.com/tenant-api/internal/x/nullx"
+	"go.infratographer.com/x/gidx"
+	"go.infratographer.com/x/pubsubx"
+)
+
+func TestTenantBatchCreate(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	ctx := context.Background()
+
+	parent := srv.createTenant(t, "", "parent")
+	srv.createTenant(t, parent.ID, "existing")
+
+	batchCreate := func(t *testing.T, query, body string, out interface{}) *http.Response {
+		t.Helper()
+
+		resp, err := srv.Request(http.MethodPost, "/v1/tenants/batch"+query, nil, strings.NewReader(body), out)
+		require.NoError(t, err, "no error expected for batch create")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp
+	}
+
+	childCount := func(t *testing.T) int64 {
+		t.Helper()
+
+		count, err := models.Tenants(models.TenantWhere.ParentTenantID.EQ(nullx.PrefixedIDFrom(parent.ID))).Count(ctx, srv.db)
+		require.NoError(t, err, "no error expected counting children")
+
+		return count
+	}
+
+	t.Run("invalid mode", func(t *testing.T) {
+		resp := batchCreate(t, "?mode=some", `{"tenants": [{"name": "a"}]}`, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		resp := batchCreate(t, "?mode=partial", `{"tenants": []}`, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+	})
+
+	t.Run("transactional rollback", func(t *testing.T) {
+		var result *v1ErrorResponseBody
+
+		resp := batchCreate(t, "", fmt.Sprintf(`{"tenants": [
+			{"name": "first", "parent_tenant_id": "%s"},
+			{"name": "existing", "parent_tenant_id": "%s"}
+		]}`, parent.ID, parent.ID), &result)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected error response")
+		assert.Equal(t, errorCodeNameConflict, result.Code, "unexpected error code")
+
+		assert.Equal(t, int64(1), childCount(t), "expected no tenants to be created")
+	})
+
+	t.Run("transactional", func(t *testing.T) {
+		var result *v1TenantSliceResponse
+
+		resp := batchCreate(t, "?mode=transactional", fmt.Sprintf(`{"tenants": [
+			{"name": "first", "parent_tenant_id": "%s"},
+			{"name": "second", "parent_tenant_id": "%s"}
+		]}`, parent.ID, parent.ID), &result)
+		require.Equal(t, http.StatusCreated, resp.StatusCode, "unexpected status code returned")
+
+		require.Len(t, result.Tenants, 2, "expected created tenants")
+		assert.Equal(t, "first", result.Tenants[0].Name)
+		assert.Equal(t, "second", result.Tenants[1].Name)
+
+		assert.Equal(t, int64(3), childCount(t), "expected tenants to be created")
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		subscriber := newPubSubClient(t, srv.logger, srv.nats.ClientURL())
+		msgChan := make(chan *nats.Msg, 10)
+
+		subscription, err := subscriber.ChanSubscribe(ctx, "com.infratographer.events.tenants.>", msgChan, "tenant-api-test")
+		require.NoError(t, err)
+
+		defer func() {
+			if err := subscription.Unsubscribe(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		var result *v1TenantBatchCreateResponseBody
+
+		resp := batchCreate(t, "?mode=partial", fmt.Sprintf(`{"tenants": [
+			{"name": "third", "parent_tenant_id": "%s"},
+			{"name": "existing", "parent_tenant_id": "%s"},
+			{"name": "orphan", "parent_tenant_id": "%s"},
+			{"name": "", "parent_tenant_id": "%s"},
+			{"name": "root"}
+		]}`, parent.ID, parent.ID, gidx.MustNewID(TenantIDPrefix), parent.ID), &result)
+		require.Equal(t, http.StatusMultiStatus, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected batch create response")
+		require.Len(t, result.Results, 5, "expected a result for each tenant")
+
+		expected := []int{
+			http.StatusCreated,
+			http.StatusConflict,
+			http.StatusNotFound,
+			http.StatusBadRequest,
+			http.StatusCreated,
+		}
+
+		for i, status := range expected {
+			assert.Equal(t, status, result.Results[i].Status, "unexpected status for tenant %d", i)
+
+			if status == http.StatusCreated {
+				require.NotNil(t, result.Results[i].Tenant, "expected created tenant %d", i)
+				assert.Empty(t, result.Results[i].Error, "expected no error for tenant %d", i)
+			} else {
+				assert.Nil(t, result.Results[i].Tenant, "expected no tenant for tenant %d", i)
+				assert.NotEmpty(t, result.Results[i].Error, "expected error for tenant %d", i)
+			}
+		}
+
+		assert.Equal(t, errorCodeNameConflict, result.Results[1].Code, "unexpected error code")
+
+		assert.Equal(t, int64(4), childCount(t), "expected only the valid child to be created")
+
+		for _, i := range []int{0, 4} {
+			select {
+			case msg := <-msgChan:
+				pMsg := &pubsubx.ChangeMessage{}
+				require.NoError(t, json.Unmarshal(msg.Data, pMsg))
+
+				assert.Equal(t, tenantSubjectCreate, msg.Subject, "expected nats subject to be tenant create subject")
+				assert.Equal(t, result.Results[i].Tenant.ID, pMsg.SubjectID, "expected events for created tenants in order")
+			case <-time.After(natsMsgSubTimeout):
+				t.Error("failed to receive nats message")
+			}
+		}
+
+		select {
+		case msg := <-msgChan:
+			t.Errorf("unexpected nats message on %s", msg.Subject)
+		case <-time.After(natsMsgSubTimeout):
+		}
+	})
+}
+
+func TestAggregateBatchEvents(t *testing.T) {
+	srv, err := newTestServer(t, &testServerConfig{
+		routerOpts: []RouterOption{
+			WithAggregateBatchEvents(true),
+		},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	ctx := context.Background()
+
+	tree := buildTree(t, srv)
+
+	subscriber := newPubSubClient(t, srv.logger, srv.nats.ClientURL())
+	msgChan := make(chan *nats.Msg, 10)
+
+	subscription, err := subscriber.ChanSubscribe(ctx, "com.infratographer.events.tenants.>", msgChan, "tenant-api-test")
+	require.NoError(t, err)
+
+	defer func() {
+		if err := subscription.Unsubscribe(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	request := func(t *testing.T, method, path, body string, out interface{}) *http.Response {
+		t.Helper()
+
+		resp, err := srv.Request(method, path, nil, strings.NewReader(body), out)
+		require.NoError(t, err, "no error expected for %s %s", method, path)
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp
+	}
+
+	// receiveOnly returns the single event published, asserting no others follow it.
+	receiveOnly := func(t *testing.T, subject string) *pubsubx.ChangeMessage {
+		t.Helper()
+
+		pMsg := &pubsubx.ChangeMessage{}
+
+		select {
+		case msg := <-msgChan:
+			assert.Equal(t, subject, msg.Subject, "unexpected nats subject")
+			require.NoError(t, json.Unmarshal(msg.Data, pMsg))
+		case <-time.After(natsMsgSubTimeout):
+			t.Fatal("failed to receive nats message")
+		}
+
+		select {
+		case msg := <-msgChan:
+			t.Errorf("unexpected nats message on %s, expected a single aggregate event", msg.Subject)
+		case <-time.After(natsMsgSubTimeout):
+		}
+
+		return pMsg
+	}
+
+	parent := tree.tenantsByName["t2"]
+
+	t.Run("batch create", func(t *testing.T) {
+		var result *v1TenantSliceResponse
+
+		resp := request(t, http.MethodPost, "/v1/tenants/batch", fmt.Sprintf(`{"tenants": [
+			{"name": "first", "parent_tenant_id": "%s"},
+			{"name": "second", "parent_tenant_id": "%s"},
+			{"name": "third"}
+		]}`, parent.ID, parent.ID), &result)
+		require.Equal(t, http.StatusCreated, resp.StatusCode, "unexpected status code returned")
+		require.Len(t, result.Tenants, 3, "expected created tenants")
+
+		msg := receiveOnly(t, tenantSubjectCreate)
+		assert.Equal(t, tenantIDs(result.Tenants), msg.AdditionalSubjectIDs, "expected every created tenant in the aggregate event")
+		assert.Equal(t, result.Tenants[0].ID, msg.SubjectID, "expected the first created tenant as the subject")
+		assert.Equal(t, pubsub.BatchCreateOperation, msg.AdditionalData[pubsub.BatchOperationKey], "unexpected batch operation")
+	})
+
+	t.Run("partial batch create", func(t *testing.T) {
+		var result *v1TenantBatchCreateResponseBody
+
+		resp := request(t, http.MethodPost, "/v1/tenants/batch?mode=partial", fmt.Sprintf(`{"tenants": [
+			{"name": "fourth", "parent_tenant_id": "%s"},
+			{"name": "first", "parent_tenant_id": "%s"},
+			{"name": "fifth", "parent_tenant_id": "%s"}
+		]}`, parent.ID, parent.ID, parent.ID), &result)
+		require.Equal(t, http.StatusMultiStatus, resp.StatusCode, "unexpected status code returned")
+		require.Len(t, result.Results, 3, "expected a result for each tenant")
+
+		msg := receiveOnly(t, tenantSubjectCreate)
+		assert.Equal(t, []gidx.PrefixedID{
+			result.Results[0].Tenant.ID,
+			result.Results[2].Tenant.ID,
+		}, msg.AdditionalSubjectIDs, "expected only the created tenants in the aggregate event")
+	})
+
+	t.Run("batch move", func(t *testing.T) {
+		moved := []gidx.PrefixedID{tree.tenantsByName["t1a1"].ID, tree.tenantsByName["t1b1"].ID}
+
+		resp := request(t, http.MethodPost, "/v1/tenants/batch-move", fmt.Sprintf(`{"moves": [
+			{"id": "%s", "parent_tenant_id": "%s"},
+			{"id": "%s", "parent_tenant_id": "%s"}
+		]}`, moved[0], parent.ID, moved[1], parent.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		msg := receiveOnly(t, tenantSubjectUpdate)
+		assert.ElementsMatch(t, moved, msg.AdditionalSubjectIDs, "expected every moved tenant in the aggregate event")
+		assert.Equal(t, pubsub.BatchMoveOperation, msg.AdditionalData[pubsub.BatchOperationKey], "unexpected batch operation")
+	})
+
+	t.Run("reparent children", func(t *testing.T) {
+		children := []gidx.PrefixedID{tree.tenantsByName["t1a1a"].ID, tree.tenantsByName["t1a1b"].ID}
+
+		resp := request(t, http.MethodPost, "/v1/tenants/"+string(tree.tenantsByName["t1a1"].ID)+"/reparent-children",
+			fmt.Sprintf(`{"new_parent_id": "%s"}`, tree.tenantsByName["t1b"].ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		var moved []gidx.PrefixedID
+
+		for range children {
+			select {
+			case msg := <-msgChan:
+				pMsg := &pubsubx.ChangeMessage{}
+				require.NoError(t, json.Unmarshal(msg.Data, pMsg))
+
+				assert.Equal(t, tenantSubjectUpdate, msg.Subject, "unexpected nats subject")
+				assert.NotContains(t, pMsg.AdditionalData, pubsub.BatchOperationKey, "expected an event for each reparented tenant")
+
+				moved = append(moved, pMsg.SubjectID)
+			case <-time.After(natsMsgSubTimeout):
+				t.Fatal("failed to receive nats message")
+			}
+		}
+
+		assert.ElementsMatch(t, children, moved, "expected a move event for each reparented child")
+	})
+
+	t.Run("cascade delete", func(t *testing.T) {
+		root := tree.tenantsByName["t1"]
+
+		var preview *tenantDeletePreview
+
+		resp := request(t, http.MethodGet, "/v1/tenants/"+string(root.ID)+"/delete-preview", "", &preview)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned previewing delete")
+		require.NotNil(t, preview, "expected delete preview")
+		require.Greater(t, preview.Count, 1, "expected descendants to be deleted")
+
+		resp = request(t, http.MethodDelete, "/v1/tenants/"+string(root.ID)+"?cascade=true", "", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		msg := receiveOnly(t, tenantSubjectDelete)
+		assert.Equal(t, preview.Tenants, msg.AdditionalSubjectIDs, "expected every deleted tenant in the aggregate event")
+		assert.Equal(t, root.ID, msg.SubjectID, "expected the deleted subtree's root as the subject")
+		assert.Equal(t, pubsub.BatchDeleteOperation, msg.AdditionalData[pubsub.BatchOperationKey], "unexpected batch operation")
+	})
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.infratographer.com/x/versionx"
+)
+
+// buildDate is the time the binary was built, set at build time using:
+//
+//	-X go.infratographer.com/tenant-api/pkg/api/v1.buildDate=DATE
+//
+// versionx only reports its own build date when it fails to parse, so the
+// date is provided to the api separately.
+var buildDate = ""
+
+// buildInfo responds with the build details of the running binary, which are
+// set at build time through linker flags.
+func (r *Router) buildInfo(c echo.Context) error {
+	details := versionx.BuildDetails()
+
+	return render(c, http.StatusOK, v1BuildInfoResponse{
+		Version:    details.Version,
+		Commit:     details.Commit,
+		BuildDate:  buildDate,
+		APIVersion: apiVersion,
+	})
+}
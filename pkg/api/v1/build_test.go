@@ -0,0 +1,37 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildInfo(t *testing.T) {
+	defer func(date string) { buildDate = date }(buildDate)
+
+	buildDate = "2023-01-02T03:04:05Z"
+
+	e := echo.New()
+	e.GET("/version", (&Router{}).buildInfo, negotiateContentType)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code, "unexpected status code returned")
+
+	var result map[string]string
+
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+
+	for _, field := range []string{"version", "commit", "build_date", "api_version"} {
+		assert.Contains(t, result, field, "expected build info field")
+	}
+
+	assert.Equal(t, apiVersion, result["api_version"], "unexpected api version")
+	assert.Equal(t, buildDate, result["build_date"], "unexpected build date")
+}
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/tenant-api/internal/pubsub"
+	"go.infratographer.com/x/gidx"
+	"go.uber.org/zap"
+)
+
+// subtreeWalkQuery selects the tenant $1 and its live descendants, with
+// their lock, by walking the parent pointers down at most $2 levels. Tenants
+// at the last level with live children are truncated. The tenant is listed
+// first.
+const subtreeWalkQuery = `
+	WITH RECURSIVE subtree AS (
+		SELECT id, locked, 0 AS depth
+		FROM tenants
+		WHERE
+			id = $1
+			AND deleted_at IS NULL
+
+		UNION ALL
+
+		SELECT t.id, t.locked, s.depth + 1
+		FROM tenants t
+		INNER JOIN subtree s ON t.parent_tenant_id = s.id
+		WHERE
+			s.depth < $2
+			AND t.deleted_at IS NULL
+	)
+	SELECT
+		id,
+		locked,
+		depth = $2 AND EXISTS (
+			SELECT 1 FROM tenants children
+			WHERE children.parent_tenant_id = subtree.id AND children.deleted_at IS NULL
+		) AS truncated
+	FROM subtree
+	ORDER BY depth, id
+`
+
+// tenantDeletePreview lists the tenants a cascade delete of a tenant removes.
+type tenantDeletePreview struct {
+	ID gidx.PrefixedID `json:"id"`
+	// Tenants are the tenant and its descendants, the tenant first.
+	Tenants []gidx.PrefixedID `json:"tenants"`
+	Count   int               `json:"count"`
+	// Locked are the listed tenants which are locked, preventing the delete.
+	Locked []gidx.PrefixedID `json:"locked"`
+}
+
+// parseCascade parses the optional cascade query parameter.
+func parseCascade(c echo.Context) (bool, error) {
+	value := c.QueryParam("cascade")
+	if value == "" {
+		return false, nil
+	}
+
+	cascade, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%w: cascade must be true or false", ErrInvalidQueryParam)
+	}
+
+	return cascade, nil
+}
+
+// walkSubtree loads the tenant and its live descendants, the tenants a
+// cascade delete of the tenant removes, as its preview. A sql.ErrNoRows
+// error is returned when the tenant doesn't exist, ErrMaxDepthExceeded when
+// its subtree is deeper than the walked levels and ErrResultTooLarge, once
+// read past the limit, when it has more than the maximum number of tenants.
+func (r *Router) walkSubtree(ctx context.Context, exec boil.ContextExecutor, id gidx.PrefixedID) (tenantDeletePreview, error) {
+	preview := tenantDeletePreview{
+		ID:      id,
+		Tenants: []gidx.PrefixedID{},
+		Locked:  []gidx.PrefixedID{},
+	}
+
+	rows, err := exec.QueryContext(ctx, subtreeWalkQuery, id, r.maxDepth)
+	if err != nil {
+		return preview, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			tenantID  gidx.PrefixedID
+			locked    bool
+			truncated bool
+		)
+
+		if err := rows.Scan(&tenantID, &locked, &truncated); err != nil {
+			return preview, err
+		}
+
+		// Tenants at the maximum depth are walked, the subtree is only
+		// deeper than the limit when they have live children.
+		if truncated {
+			return preview, fmt.Errorf("%w: %s has descendants more than %d levels deep", ErrMaxDepthExceeded, id, r.maxDepth)
+		}
+
+		if r.maxResultSize > 0 && len(preview.Tenants) == r.maxResultSize {
+			return preview, r.resultTooLargeError(id)
+		}
+
+		preview.Tenants = append(preview.Tenants, tenantID)
+
+		if locked {
+			preview.Locked = append(preview.Locked, tenantID)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return preview, err
+	}
+
+	if len(preview.Tenants) == 0 {
+		return preview, sql.ErrNoRows
+	}
+
+	preview.Count = len(preview.Tenants)
+
+	return preview, nil
+}
+
+// tenantDeletePreview responds with the tenants a cascade delete of the
+// tenant would remove, without deleting anything.
+func (r *Router) tenantDeletePreview(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantDeletePreview")
+	defer span.End()
+
+	tenantID, err := r.parseID(c, "id")
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	preview, err := r.walkSubtree(ctx, r.db, tenantID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrMaxDepthExceeded):
+			return r.maxDepthExceededResponse(c, tenantID)
+		case errors.Is(err, ErrResultTooLarge):
+			return v1ResultTooLargeResponse(c, err)
+		}
+
+		return r.tenantQueryErrorResponse(c, err)
+	}
+
+	return v1TenantDeletePreviewResponse(c, preview)
+}
+
+// cascadeDelete soft deletes the tenant and its descendants in a
+// transaction, the same tenants listed by the delete preview. Nothing is
+// deleted when any of them is locked or rejected by a validator. A delete
+// event is published for each deleted tenant once committed, or a single
+// event of the deleted tenants when batch events are aggregated.
+func (r *Router) cascadeDelete(ctx context.Context, c echo.Context, tenantID gidx.PrefixedID) error {
+	var preview tenantDeletePreview
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+
+		preview, err = r.walkSubtree(ctx, tx, tenantID)
+		if err != nil {
+			return err
+		}
+
+		if len(preview.Locked) != 0 {
+			return fmt.Errorf("%w: %s can't be deleted", ErrTenantLocked, preview.Locked[0])
+		}
+
+		ts, err := models.Tenants(tenantIDsQueryMod(preview.Tenants)).All(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range ts {
+			if err := r.validateDelete(ctx, t); err != nil {
+				return err
+			}
+		}
+
+		_, err = ts.DeleteAll(ctx, tx, false)
+
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return v1TenantNotFoundResponse(c, err)
+		case errors.Is(err, ErrTenantLocked):
+			return v1TenantLockedResponse(c, err)
+		case errors.Is(err, ErrValidationFailed):
+			r.requestLogger(c).Error("tenant delete rejected by validator", zap.Error(err))
+
+			return v1BadRequestResponse(c, err)
+		case errors.Is(err, ErrMaxDepthExceeded):
+			return r.maxDepthExceededResponse(c, tenantID)
+		case errors.Is(err, ErrResultTooLarge):
+			return v1ResultTooLargeResponse(c, err)
+		}
+
+		r.requestLogger(c).Error("failed to delete tenants", zap.Error(err))
+
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	actor := r.actor(c)
+
+	if r.aggregateBatchEvents {
+		r.publishBatch(c, actor, pubsub.BatchDeleteOperation, preview.Tenants)
+
+		return nil
+	}
+
+	for _, id := range preview.Tenants {
+		msg, err := pubsub.DeleteTenantMessage(
+			gidx.PrefixedID(actor),
+			id,
+		)
+		if err != nil {
+			// TODO: add status to reconcile and requeue this
+			r.requestLogger(c).Error("failed to create, delete tenant message", zap.Error(err))
+		}
+
+		if err := r.pubsub.PublishDelete(ctx, "tenants", "global", msg); err != nil {
+			// TODO: add status to reconcile and requeue this
+			r.requestLogger(c).Error("failed to publish, delete tenant message", zap.Error(err))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/x/gidx"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxChildCountIDs is the maximum number of tenants child counts may be requested for at once.
+	maxChildCountIDs = 100
+
+	// maxInlineChildren is the maximum number of direct children included inline with a tenant.
+	maxInlineChildren = 100
+
+	// getIncludeChildren includes the direct children of a fetched tenant.
+	getIncludeChildren = "children"
+)
+
+const (
+	// lockTenantQuery locks the tenant row, serializing changes to its children.
+	lockTenantQuery = `SELECT id FROM tenants WHERE id = $1 FOR UPDATE`
+
+	// childCountQuery counts the direct children of a tenant, stopping once
+	// the limit is reached so the count stays cheap for large parents.
+	childCountQuery = `
+		SELECT count(*)
+		FROM (
+			SELECT 1
+			FROM tenants
+			WHERE parent_tenant_id = $1 AND deleted_at IS NULL
+			LIMIT $2
+		)
+	`
+
+	// childCountsQuery counts the direct children of each of the requested tenants.
+	childCountsQuery = `
+		SELECT parent_tenant_id, count(*)
+		FROM tenants
+		WHERE parent_tenant_id = ANY($1) AND deleted_at IS NULL
+		GROUP BY parent_tenant_id
+	`
+)
+
+// checkChildLimit returns ErrChildLimitExceeded when the parent already has the
+// maximum number of direct children. The parent row is locked so concurrent
+// requests can't exceed the limit, which requires exec to be a transaction.
+func (r *Router) checkChildLimit(ctx context.Context, exec boil.ContextExecutor, parentID gidx.PrefixedID) error {
+	if r.maxChildren <= 0 {
+		return nil
+	}
+
+	var id string
+
+	if err := exec.QueryRowContext(ctx, lockTenantQuery, parentID).Scan(&id); err != nil {
+		return err
+	}
+
+	var count int
+
+	if err := exec.QueryRowContext(ctx, childCountQuery, parentID, r.maxChildren).Scan(&count); err != nil {
+		return err
+	}
+
+	if count >= r.maxChildren {
+		return fmt.Errorf("%w: %s has %d children", ErrChildLimitExceeded, parentID, count)
+	}
+
+	return nil
+}
+
+// tenantChildCounts returns the number of direct children of each tenant
+// requested with the repeated id query parameter. Every requested tenant is
+// included, with tenants which have no children or do not exist counted as zero.
+func (r *Router) tenantChildCounts(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantChildCounts")
+	defer span.End()
+
+	values := c.QueryParams()["id"]
+
+	switch {
+	case len(values) == 0:
+		return v1BadRequestResponse(c, fmt.Errorf("%w: at least one id is required", ErrInvalidQueryParam))
+	case len(values) > maxChildCountIDs:
+		return v1BadRequestResponse(c, fmt.Errorf("%w: at most %d ids may be requested", ErrInvalidQueryParam, maxChildCountIDs))
+	}
+
+	counts := make(map[gidx.PrefixedID]int64, len(values))
+	params := make([]string, 0, len(values))
+
+	for _, value := range values {
+		id, err := r.parseTenantID(value)
+		if err != nil {
+			return v1BadRequestResponse(c, err)
+		}
+
+		if _, ok := counts[id]; ok {
+			continue
+		}
+
+		counts[id] = 0
+		params = append(params, string(id))
+	}
+
+	rows, err := r.db.QueryContext(ctx, childCountsQuery, pq.Array(params))
+	if err != nil {
+		r.requestLogger(c).Error("failed to count tenant children", zap.Error(err))
+
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id    gidx.PrefixedID
+			count int64
+		)
+
+		if err := rows.Scan(&id, &count); err != nil {
+			return v1InternalServerErrorResponse(c, err)
+		}
+
+		counts[id] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		r.requestLogger(c).Error("failed to read tenant child counts", zap.Error(err))
+
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	return v1TenantChildCountsResponse(c, counts)
+}
+
+// parseGetInclude parses the optional include query parameter of a tenant
+// get, returning whether the tenant's direct children are included.
+func parseGetInclude(c echo.Context) (bool, error) {
+	switch value := c.QueryParam("include"); value {
+	case "":
+		return false, nil
+	case getIncludeChildren:
+		return true, nil
+	default:
+		return false, fmt.Errorf("%w: unknown include %q, include %s", ErrInvalidQueryParam, value, getIncludeChildren)
+	}
+}
+
+// loadInlineChildren eager loads the first live direct children of the
+// tenant in the default list order, at most maxInlineChildren of them,
+// returning whether the tenant has more children than were loaded.
+func (r *Router) loadInlineChildren(ctx context.Context, t *models.Tenant) (bool, error) {
+	// One more child than is included is loaded to tell whether there are more.
+	err := t.L.LoadParentTenantTenants(ctx, r.db, true, t, qm.Expr(
+		DefaultListSort.queryMod(),
+		qm.Limit(maxInlineChildren+1),
+	))
+	if err != nil {
+		return false, err
+	}
+
+	if len(t.R.ParentTenantTenants) > maxInlineChildren {
+		t.R.ParentTenantTenants = t.R.ParentTenantTenants[:maxInlineChildren]
+
+		return true, nil
+	}
+
+	return false, nil
+}
@@ -0,0 +1,305 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// DefaultCompressionMinLength is the minimum size in bytes of compressed responses when none is configured.
+	DefaultCompressionMinLength = 1024
+
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+// CompressionConfig defines the compression of API responses.
+type CompressionConfig struct {
+	// Enabled enables compressing responses.
+	Enabled bool
+	// Level is the compression level, as defined by compress/flate. Zero
+	// uses the default compression level.
+	Level int
+	// MinLength is the minimum size in bytes of responses which are compressed.
+	MinLength int
+}
+
+// CompressionMiddleware returns the middleware compressing responses with
+// gzip or deflate, as accepted by the request's Accept-Encoding header.
+// Responses smaller than the minimum length are sent uncompressed, as the
+// compression overhead outweighs the savings. nil is returned when
+// compression is disabled.
+func CompressionMiddleware(config CompressionConfig) (echo.MiddlewareFunc, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+
+	level := config.Level
+	if level == 0 {
+		level = flate.DefaultCompression
+	}
+
+	// The level is validated up front, so writers never fail to be created.
+	if _, err := flate.NewWriter(io.Discard, level); err != nil {
+		return nil, err
+	}
+
+	minLength := config.MinLength
+	if minLength <= 0 {
+		minLength = DefaultCompressionMinLength
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			res := c.Response()
+
+			res.Header().Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+
+			encoding := acceptedEncoding(c.Request().Header.Get(echo.HeaderAcceptEncoding))
+			if encoding == "" {
+				return next(c)
+			}
+
+			w := &compressWriter{
+				ResponseWriter: res.Writer,
+				encoding:       encoding,
+				level:          level,
+				minLength:      minLength,
+			}
+
+			res.Writer = w
+
+			err := next(c)
+
+			// Errors returned without a response are written by the echo
+			// error handler, after the middleware returns.
+			if !w.written {
+				res.Writer = w.ResponseWriter
+
+				return err
+			}
+
+			if cerr := w.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+
+			res.Writer = w.ResponseWriter
+
+			return err
+		}
+	}, nil
+}
+
+// acceptedEncoding returns the supported encoding with the highest quality in
+// the Accept-Encoding header. When several encodings share the highest
+// quality, the first listed is used. The * coding matches the supported
+// encodings which aren't listed, so an encoding listed with q=0 is never
+// used. An empty string is returned when no supported encoding is accepted.
+func acceptedEncoding(accept string) string {
+	var (
+		listed   = make(map[string]float64)
+		order    []string
+		wildcard = -1.0
+	)
+
+	for _, value := range strings.Split(accept, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(value), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+
+		switch coding {
+		case encodingGzip, encodingDeflate, "*":
+		default:
+			continue
+		}
+
+		qty := 1.0
+
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+
+			if qty, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		if coding == "*" {
+			if wildcard < 0 {
+				wildcard = qty
+
+				order = append(order, coding)
+			}
+
+			continue
+		}
+
+		if _, ok := listed[coding]; !ok {
+			listed[coding] = qty
+
+			order = append(order, coding)
+		}
+	}
+
+	var (
+		best    string
+		bestQty float64
+	)
+
+	for _, coding := range order {
+		if coding != "*" {
+			if listed[coding] > bestQty {
+				best, bestQty = coding, listed[coding]
+			}
+
+			continue
+		}
+
+		for _, encoding := range []string{encodingGzip, encodingDeflate} {
+			if _, ok := listed[encoding]; !ok && wildcard > bestQty {
+				best, bestQty = encoding, wildcard
+			}
+		}
+	}
+
+	return best
+}
+
+// compressWriter buffers the response until it reaches the minimum length,
+// compressing it once it does. Responses which never reach the minimum length
+// are written uncompressed when the writer is closed.
+type compressWriter struct {
+	http.ResponseWriter
+
+	encoding  string
+	level     int
+	minLength int
+
+	buf        bytes.Buffer
+	status     int
+	written    bool
+	compressor io.WriteCloser
+}
+
+// WriteHeader records the status code, which is written with the headers once
+// it is known whether the response is compressed.
+func (w *compressWriter) WriteHeader(code int) {
+	w.status = code
+	w.written = true
+}
+
+// Write buffers the response until the minimum length is reached.
+func (w *compressWriter) Write(b []byte) (int, error) {
+	w.written = true
+
+	if w.compressor != nil {
+		return w.compressor.Write(b)
+	}
+
+	w.buf.Write(b)
+
+	if w.buf.Len() < w.minLength {
+		return len(b), nil
+	}
+
+	if err := w.startCompression(); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+// Flush writes the buffered response. Responses which are not yet being
+// compressed are written uncompressed from then on.
+func (w *compressWriter) Flush() {
+	if w.compressor == nil {
+		w.writeUncompressed() //nolint:errcheck // Flush can't return errors.
+	} else if f, ok := w.compressor.(interface{ Flush() error }); ok {
+		f.Flush() //nolint:errcheck // Flush can't return errors.
+	}
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker.
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Close completes the response, writing responses below the minimum length
+// uncompressed.
+func (w *compressWriter) Close() error {
+	if w.compressor != nil {
+		return w.compressor.Close()
+	}
+
+	return w.writeUncompressed()
+}
+
+func (w *compressWriter) startCompression() error {
+	header := w.Header()
+
+	// Responses which are already encoded are written as is.
+	if header.Get(echo.HeaderContentEncoding) != "" {
+		w.compressor = nopWriteCloser{w.ResponseWriter}
+	} else {
+		header.Set(echo.HeaderContentEncoding, w.encoding)
+		header.Del(echo.HeaderContentLength)
+
+		if w.encoding == encodingGzip {
+			w.compressor, _ = gzip.NewWriterLevel(w.ResponseWriter, w.level)
+		} else {
+			w.compressor, _ = flate.NewWriter(w.ResponseWriter, w.level)
+		}
+	}
+
+	w.writeStatus()
+
+	_, err := w.compressor.Write(w.buf.Bytes())
+
+	w.buf.Reset()
+
+	return err
+}
+
+func (w *compressWriter) writeUncompressed() error {
+	w.writeStatus()
+
+	w.compressor = nopWriteCloser{w.ResponseWriter}
+
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+
+	w.buf.Reset()
+
+	return err
+}
+
+func (w *compressWriter) writeStatus() {
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+		w.status = 0
+	}
+}
+
+// nopWriteCloser writes directly to the underlying writer.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close implements io.Closer.
+func (nopWriteCloser) Close() error {
+	return nil
+}
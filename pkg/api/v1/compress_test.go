@@ -0,0 +1,134 @@
+package api
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptedEncoding(t *testing.T) {
+	testCases := []struct {
+		name     string
+		accept   string
+		expected string
+	}{
+		{"empty", "", ""},
+		{"gzip", "gzip", encodingGzip},
+		{"deflate", "deflate", encodingDeflate},
+		{"any", "*", encodingGzip},
+		{"first listed wins", "deflate, gzip", encodingDeflate},
+		{"quality preferred", "gzip;q=0.5, deflate", encodingDeflate},
+		{"case insensitive", "GZIP", encodingGzip},
+		{"zero quality", "gzip;q=0", ""},
+		{"unsupported", "br, identity", ""},
+		{"zero quality over any", "gzip;q=0, *", encodingDeflate},
+		{"zero quality after any", "*, gzip;q=0", encodingDeflate},
+		{"all zero quality over any", "gzip;q=0, deflate;q=0, *", ""},
+		{"any zero quality", "*;q=0", ""},
+		{"listed preferred over any", "*;q=0.5, deflate", encodingDeflate},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, acceptedEncoding(tc.accept))
+		})
+	}
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	mdw, err := CompressionMiddleware(CompressionConfig{})
+	require.NoError(t, err, "no error expected for disabled compression")
+	assert.Nil(t, mdw, "expected no middleware when compression is disabled")
+
+	_, err = CompressionMiddleware(CompressionConfig{Enabled: true, Level: 10})
+	assert.Error(t, err, "expected invalid level to be rejected")
+
+	mdw, err = CompressionMiddleware(CompressionConfig{Enabled: true, MinLength: 100})
+	require.NoError(t, err, "no error expected for compression middleware")
+
+	large := strings.Repeat("tenant", 100)
+
+	e := echo.New()
+	e.Use(mdw)
+
+	e.GET("/small", func(c echo.Context) error {
+		return c.String(http.StatusOK, "small")
+	})
+	e.GET("/large", func(c echo.Context) error {
+		return c.String(http.StatusOK, large)
+	})
+	e.GET("/error", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusTeapot, large)
+	})
+
+	request := func(path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if accept != "" {
+			req.Header.Set(echo.HeaderAcceptEncoding, accept)
+		}
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	t.Run("small responses are not compressed", func(t *testing.T) {
+		rec := request("/small", "gzip")
+
+		assert.Equal(t, http.StatusOK, rec.Code, "unexpected status code returned")
+		assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding), "expected no content encoding")
+		assert.Equal(t, "small", rec.Body.String())
+		assert.Equal(t, echo.HeaderAcceptEncoding, rec.Header().Get(echo.HeaderVary))
+	})
+
+	t.Run("gzip", func(t *testing.T) {
+		rec := request("/large", "gzip, deflate")
+
+		assert.Equal(t, http.StatusOK, rec.Code, "unexpected status code returned")
+		assert.Equal(t, encodingGzip, rec.Header().Get(echo.HeaderContentEncoding))
+
+		r, err := gzip.NewReader(rec.Body)
+		require.NoError(t, err, "no error expected reading gzip response")
+
+		body, err := io.ReadAll(r)
+		require.NoError(t, err, "no error expected reading gzip response")
+		assert.Equal(t, large, string(body))
+	})
+
+	t.Run("deflate", func(t *testing.T) {
+		rec := request("/large", "deflate")
+
+		assert.Equal(t, http.StatusOK, rec.Code, "unexpected status code returned")
+		assert.Equal(t, encodingDeflate, rec.Header().Get(echo.HeaderContentEncoding))
+
+		body, err := io.ReadAll(flate.NewReader(rec.Body))
+		require.NoError(t, err, "no error expected reading deflate response")
+		assert.Equal(t, large, string(body))
+	})
+
+	t.Run("not accepted", func(t *testing.T) {
+		rec := request("/large", "")
+
+		assert.Equal(t, http.StatusOK, rec.Code, "unexpected status code returned")
+		assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding), "expected no content encoding")
+		assert.Equal(t, large, rec.Body.String())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		rec := request("/error", "gzip")
+
+		assert.Equal(t, http.StatusTeapot, rec.Code, "unexpected status code returned")
+		assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding), "expected error handler response not to be compressed")
+		assert.Contains(t, rec.Body.String(), "tenant")
+	})
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/tenant-api/internal/x/nullx"
+	"go.infratographer.com/x/gidx"
+)
+
+const (
+	// uniqueViolationCode is the SQLSTATE returned when a write violates a
+	// unique constraint. The unique constraints on tenants besides the
+	// primary key limit parents to a single live child with each name, and
+	// with global name uniqueness, all live tenants to unique names.
+	uniqueViolationCode = "23505"
+
+	// serializationFailureCode is the SQLSTATE returned when a transaction
+	// conflicts with a concurrent transaction and must be retried.
+	serializationFailureCode = "40001"
+
+	// duplicateNamesQuery counts the names shared by multiple live tenants.
+	duplicateNamesQuery = `
+		SELECT count(*) FROM (
+			SELECT name
+			FROM tenants
+			WHERE deleted_at IS NULL
+			GROUP BY name
+			HAVING count(*) > 1
+		)
+	`
+	createGlobalNameIndexQuery = `CREATE UNIQUE INDEX IF NOT EXISTS tenants_name_key ON tenants (name) WHERE deleted_at IS NULL`
+	dropGlobalNameIndexQuery   = `DROP INDEX IF EXISTS tenants@tenants_name_key`
+	globalNameIndexExistsQuery = `SELECT count(*) FROM pg_indexes WHERE tablename = 'tenants' AND indexname = 'tenants_name_key'`
+)
+
+// ApplyNameUniqueness creates the unique index enforcing global name
+// uniqueness when global is set, and drops it when it is not, so the mode
+// may be switched between deployments. Enabling global uniqueness fails
+// without changes while multiple live tenants share a name, as the
+// duplicates must be renamed first. It is run by the name-uniqueness
+// command once, rather than by each replica as it starts serving.
+func ApplyNameUniqueness(ctx context.Context, db *sql.DB, global bool) error {
+	if !global {
+		_, err := db.ExecContext(ctx, dropGlobalNameIndexQuery)
+
+		return err
+	}
+
+	var duplicates int64
+
+	if err := db.QueryRowContext(ctx, duplicateNamesQuery).Scan(&duplicates); err != nil {
+		return err
+	}
+
+	if duplicates != 0 {
+		return fmt.Errorf("%w: %d names are used by multiple tenants", ErrDuplicateNames, duplicates)
+	}
+
+	_, err := db.ExecContext(ctx, createGlobalNameIndexQuery)
+
+	return err
+}
+
+// ValidateNameUniqueness ensures the index enforcing global name uniqueness
+// exists only when global name uniqueness is enabled, without changing the
+// schema. ApplyNameUniqueness must be run to switch the mode otherwise.
+func (r *Router) ValidateNameUniqueness(ctx context.Context) error {
+	var indexes int64
+
+	if err := r.db.QueryRowContext(ctx, globalNameIndexExistsQuery).Scan(&indexes); err != nil {
+		return err
+	}
+
+	switch {
+	case r.globalNames && indexes == 0:
+		return fmt.Errorf("%w: global name uniqueness is enabled but the index does not exist", ErrNameUniquenessMismatch)
+	case !r.globalNames && indexes != 0:
+		return fmt.Errorf("%w: global name uniqueness is disabled but the index exists", ErrNameUniquenessMismatch)
+	}
+
+	return nil
+}
+
+// checkNameConflict returns ErrNameConflict when the parent already has a
+// child, other than the provided tenant, with the provided name. With
+// global name uniqueness, any other live tenant with the name conflicts.
+func (r *Router) checkNameConflict(ctx context.Context, exec boil.ContextExecutor, tenantID, parentID gidx.PrefixedID, name string) error {
+	mods := []qm.QueryMod{
+		models.TenantWhere.Name.EQ(name),
+		models.TenantWhere.ID.NEQ(tenantID),
+	}
+
+	if !r.globalNames {
+		mods = append(mods, models.TenantWhere.ParentTenantID.EQ(nullx.PrefixedIDFrom(parentID)))
+	}
+
+	exists, err := models.Tenants(mods...).Exists(ctx, exec)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return fmt.Errorf("%w: %q already exists", ErrNameConflict, name)
+	}
+
+	return nil
+}
+
+// isUniqueViolation reports whether the error is a unique constraint violation.
+func isUniqueViolation(err error) bool {
+	return hasErrorCode(err, uniqueViolationCode)
+}
+
+// isSerializationFailure reports whether the error is a transaction
+// serialization failure which may succeed when retried.
+func isSerializationFailure(err error) bool {
+	return hasErrorCode(err, serializationFailureCode)
+}
+
+func hasErrorCode(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
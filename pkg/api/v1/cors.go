@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+var (
+	// DefaultCORSAllowedMethods are the methods allowed for cross-origin requests when none are configured.
+	DefaultCORSAllowedMethods = []string{
+		http.MethodGet,
+		http.MethodPost,
+		http.MethodPatch,
+		http.MethodDelete,
+	}
+
+	// DefaultCORSAllowedHeaders are the request headers allowed for cross-origin requests when none are configured.
+	DefaultCORSAllowedHeaders = []string{
+		echo.HeaderAccept,
+		HeaderAcceptLanguage,
+		echo.HeaderAuthorization,
+		echo.HeaderContentType,
+		echo.HeaderXRequestID,
+		HeaderXCorrelationID,
+	}
+)
+
+// CORSConfig defines the cross-origin resource sharing policy for the API.
+type CORSConfig struct {
+	// AllowedOrigins are the origins allowed to make cross-origin requests.
+	AllowedOrigins []string
+	// AllowedMethods are the methods allowed for cross-origin requests.
+	AllowedMethods []string
+	// AllowedHeaders are the request headers allowed for cross-origin requests.
+	AllowedHeaders []string
+}
+
+// CORSMiddleware returns the middleware applying the CORS policy. Cross-origin
+// requests are denied by default, so nil is returned when no origins are allowed.
+//
+// The middleware must be added to the echo server rather than the router, so
+// preflight requests are answered before reaching the authentication middleware.
+func CORSMiddleware(config CORSConfig) echo.MiddlewareFunc {
+	if len(config.AllowedOrigins) == 0 {
+		return nil
+	}
+
+	methods := config.AllowedMethods
+	if len(methods) == 0 {
+		methods = DefaultCORSAllowedMethods
+	}
+
+	headers := config.AllowedHeaders
+	if len(headers) == 0 {
+		headers = DefaultCORSAllowedHeaders
+	}
+
+	return middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins:  config.AllowedOrigins,
+		AllowMethods:  methods,
+		AllowHeaders:  headers,
+		ExposeHeaders: []string{echo.HeaderXRequestID, HeaderXCorrelationID, HeaderContentLanguage, headerLink, headerTotalCount},
+	})
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/x/echojwtx"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	assert.Nil(t, CORSMiddleware(CORSConfig{}), "expected cors to be denied without allowed origins")
+
+	_, issuer, close := echojwtx.TestOAuthClient("testing-actor", "")
+	defer close()
+
+	auth, err := echojwtx.NewAuth(context.Background(), echojwtx.AuthConfig{Issuer: issuer})
+	require.NoError(t, err, "no error expected initializing auth")
+
+	e := echo.New()
+	e.Use(CORSMiddleware(CORSConfig{
+		AllowedOrigins: []string{"https://admin.example.com"},
+	}))
+
+	v1 := e.Group("/v1", auth.Middleware())
+	v1.GET("/tenants", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	request := func(method, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/v1/tenants", nil)
+		req.Header.Set(echo.HeaderOrigin, origin)
+
+		if method == http.MethodOptions {
+			req.Header.Set(echo.HeaderAccessControlRequestMethod, http.MethodGet)
+		}
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	t.Run("preflight skips auth", func(t *testing.T) {
+		rec := request(http.MethodOptions, "https://admin.example.com")
+
+		assert.Equal(t, http.StatusNoContent, rec.Code, "unexpected status code returned")
+		assert.Equal(t, "https://admin.example.com", rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+		assert.Contains(t, rec.Header().Get(echo.HeaderAccessControlAllowMethods), http.MethodPatch)
+		assert.Contains(t, rec.Header().Get(echo.HeaderAccessControlAllowHeaders), echo.HeaderAuthorization)
+	})
+
+	t.Run("disallowed origin", func(t *testing.T) {
+		rec := request(http.MethodOptions, "https://evil.example.com")
+
+		assert.Empty(t, rec.Header().Get(echo.HeaderAccessControlAllowOrigin), "expected origin to be denied")
+	})
+
+	t.Run("request still requires auth", func(t *testing.T) {
+		rec := request(http.MethodGet, "https://admin.example.com")
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, "unexpected status code returned")
+		assert.Equal(t, "https://admin.example.com", rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+	})
+}
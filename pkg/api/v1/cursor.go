@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/x/gidx"
+)
+
+// listCursor is the position in a tenant list a page resumes after, the
+// sorted field's value and the ID of the last tenant of the previous page.
+// Cursors are opaque to clients, encoded as base64 JSON.
+type listCursor struct {
+	Sort  string          `json:"s"`
+	Value string          `json:"v"`
+	ID    gidx.PrefixedID `json:"id"`
+}
+
+// String returns the list order as it is provided in the sort query parameter.
+func (s ListSort) String() string {
+	if s.Descending {
+		return "-" + s.Field
+	}
+
+	return s.Field
+}
+
+// newListCursor returns the cursor of the page following the tenant in the
+// list order.
+func newListCursor(order ListSort, t *models.Tenant) string {
+	cursor := listCursor{
+		Sort: order.String(),
+		ID:   t.ID,
+	}
+
+	switch order.Field {
+	case "updated_at":
+		cursor.Value = t.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	case "name":
+		cursor.Value = t.Name
+	default:
+		cursor.Value = t.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	// Marshaling a struct of strings can't fail.
+	b, _ := json.Marshal(cursor)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// parseCursor parses the optional cursor query parameter into the predicate
+// of the tenants listed after it, ordered by the sorted field and then by ID.
+// Unlike page offsets, the position is kept when tenants listed on earlier
+// pages are deleted. The cursor must have been returned for the same order.
+func parseCursor(c echo.Context, order ListSort) (qm.QueryMod, error) {
+	value := c.QueryParam("cursor")
+	if value == "" {
+		return nil, nil
+	}
+
+	invalid := fmt.Errorf("%w: cursor must be a cursor returned by a previous page", ErrInvalidQueryParam)
+
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, invalid
+	}
+
+	var cursor listCursor
+
+	if err := json.Unmarshal(b, &cursor); err != nil || cursor.ID == "" {
+		return nil, invalid
+	}
+
+	if cursor.Sort != order.String() {
+		return nil, fmt.Errorf("%w: cursor was returned for sort %s, not %s", ErrInvalidQueryParam, cursor.Sort, order)
+	}
+
+	var arg interface{} = cursor.Value
+
+	if order.Field != "name" {
+		at, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, invalid
+		}
+
+		arg = at
+	}
+
+	operator := ">"
+	if order.Descending {
+		operator = "<"
+	}
+
+	return qm.Where(
+		fmt.Sprintf("(%s, %s) %s (?, ?)", sortColumns[order.Field], models.TenantTableColumns.ID, operator),
+		arg, cursor.ID,
+	), nil
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/x/gidx"
+)
+
+func TestParseCursor(t *testing.T) {
+	parse := func(cursor string, order ListSort) error {
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/v1/tenants?cursor="+cursor, nil), httptest.NewRecorder())
+
+		_, err := parseCursor(c, order)
+
+		return err
+	}
+
+	tenant := &models.Tenant{
+		ID:        gidx.MustNewID(TenantIDPrefix),
+		Name:      "tenant",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	for _, order := range []ListSort{
+		{Field: "created_at"},
+		{Field: "updated_at", Descending: true},
+		{Field: "name"},
+	} {
+		assert.NoError(t, parse(newListCursor(order, tenant), order), "expected %s cursor to be parsed", order)
+	}
+
+	t.Run("none", func(t *testing.T) {
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/v1/tenants", nil), httptest.NewRecorder())
+
+		mod, err := parseCursor(c, DefaultListSort)
+		require.NoError(t, err)
+		assert.Nil(t, mod, "expected no predicate without a cursor")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.ErrorIs(t, parse("not-a-cursor", DefaultListSort), ErrInvalidQueryParam)
+		assert.ErrorIs(t, parse("e30", DefaultListSort), ErrInvalidQueryParam, "expected a cursor without an id to be rejected")
+	})
+
+	t.Run("other sort", func(t *testing.T) {
+		cursor := newListCursor(ListSort{Field: "name"}, tenant)
+
+		assert.ErrorIs(t, parse(cursor, ListSort{Field: "name", Descending: true}), ErrInvalidQueryParam)
+	})
+}
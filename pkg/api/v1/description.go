@@ -0,0 +1,144 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/tenant-api/internal/pubsub"
+	"go.infratographer.com/x/gidx"
+	"go.uber.org/zap"
+)
+
+// tenantBatchDescription sets the descriptions of many tenants in a single
+// transaction, with a null description clearing it. If any of the tenants
+// don't exist, the batch is rejected, listing the indices of the missing
+// tenants, and no descriptions are changed.
+//
+// Update events are published for every tenant whose description changed
+// once the batch has been committed.
+func (r *Router) tenantBatchDescription(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantBatchDescription")
+	defer span.End()
+
+	payload := new(batchDescriptionRequest)
+
+	if err := bindRequest(c, payload); err != nil {
+		r.requestLogger(c).Error("failed to bind batch description request", zap.Error(err))
+
+		return v1BadRequestResponse(c, err)
+	}
+
+	if err := payload.validate(); err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	ids := make([]gidx.PrefixedID, len(payload.Updates))
+
+	for i, update := range payload.Updates {
+		ids[i] = update.ID
+	}
+
+	actor := r.actor(c)
+
+	var (
+		ts      []*models.Tenant
+		updated []gidx.PrefixedID
+	)
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		found, err := models.Tenants(tenantIDsQueryMod(ids)).All(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		byID := make(map[gidx.PrefixedID]*models.Tenant, len(found))
+
+		for _, t := range found {
+			byID[t.ID] = t
+		}
+
+		var missing []string
+
+		for i, id := range ids {
+			if _, ok := byID[id]; !ok {
+				missing = append(missing, fmt.Sprintf("%d (%s)", i, id))
+			}
+		}
+
+		if len(missing) != 0 {
+			return fmt.Errorf("%w: updates %s", ErrBatchTenantsNotFound, strings.Join(missing, ", "))
+		}
+
+		ts = make([]*models.Tenant, 0, len(payload.Updates))
+		updated = nil
+
+		for _, update := range payload.Updates {
+			t := byID[update.ID]
+
+			ts = append(ts, t)
+
+			if t.Description == update.Description {
+				continue
+			}
+
+			current := *t
+
+			t.Description = update.Description
+			t.UpdatedBy = actorColumn(actor)
+
+			if err := r.validateUpdate(ctx, &current, t); err != nil {
+				return fmt.Errorf("updating %s: %w", t.ID, err)
+			}
+
+			if _, err := t.Update(ctx, tx, boil.Infer()); err != nil {
+				return fmt.Errorf("updating %s: %w", t.ID, err)
+			}
+
+			updated = append(updated, t.ID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrBatchTenantsNotFound):
+			return v1TenantNotFoundResponse(c, err)
+		case errors.Is(err, ErrValidationFailed):
+			r.requestLogger(c).Error("tenant update rejected by validator", zap.Error(err))
+
+			return v1BadRequestResponse(c, err)
+		}
+
+		r.requestLogger(c).Error("failed to update tenant descriptions", zap.Error(err))
+
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	r.publishDescriptionUpdates(c, actor, updated)
+
+	return v1TenantsUpdatedResponse(c, ts)
+}
+
+// publishDescriptionUpdates publishes update events for the tenants whose
+// descriptions changed.
+func (r *Router) publishDescriptionUpdates(c echo.Context, actor string, ids []gidx.PrefixedID) {
+	ctx := c.Request().Context()
+
+	for _, id := range ids {
+		msg, err := pubsub.UpdateTenantMessage(gidx.PrefixedID(actor), id)
+		if err != nil {
+			// TODO: add status to reconcile and requeue this
+			r.requestLogger(c).Error("failed to create, update tenant message", zap.Error(err))
+		}
+
+		if err := r.pubsub.PublishUpdate(ctx, "tenants", "global", msg); err != nil {
+			// TODO: add status to reconcile and requeue this
+			r.requestLogger(c).Error("failed to publish, update tenant message", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	nats "github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/x/gidx"
+	"go.infratographer.com/x/pubsubx"
+)
+
+func TestBatchDescriptionRequestValidate(t *testing.T) {
+	id := gidx.MustNewID(TenantIDPrefix)
+	description := null.StringFrom("documented")
+
+	tooMany := make([]descriptionUpdate, maxBatchDescriptionSize+1)
+	for i := range tooMany {
+		tooMany[i] = descriptionUpdate{ID: gidx.MustNewID(TenantIDPrefix)}
+	}
+
+	testCases := []struct {
+		name    string
+		updates []descriptionUpdate
+		wantErr error
+	}{
+		{"valid", []descriptionUpdate{{ID: id, Description: description}}, nil},
+		{"cleared", []descriptionUpdate{{ID: id}}, nil},
+		{"empty", nil, ErrInvalidBatchDescription},
+		{"too many", tooMany, ErrInvalidBatchDescription},
+		{"invalid id", []descriptionUpdate{{ID: "not-valid", Description: description}}, ErrInvalidID},
+		{"too long", []descriptionUpdate{{ID: id, Description: null.StringFrom(strings.Repeat("a", maxTenantDescriptionLength+1))}}, ErrTenantDescriptionTooLong},
+		{"duplicate", []descriptionUpdate{{ID: id, Description: description}, {ID: id}}, ErrInvalidBatchDescription},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := batchDescriptionRequest{Updates: tc.updates}
+
+			err := req.validate()
+
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestTenantDescription(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	request := func(t *testing.T, method, path, body string) (*http.Response, *v1TenantResponse) {
+		t.Helper()
+
+		var result *v1TenantResponse
+
+		resp, err := srv.Request(method, path, nil, strings.NewReader(body), &result)
+		require.NoError(t, err, "no error expected for request")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp, result
+	}
+
+	t.Run("too long rejected", func(t *testing.T) {
+		resp, _ := request(t, http.MethodPost, "/v1/tenants", fmt.Sprintf(`{"name": "verbose", "description": %q}`, strings.Repeat("a", maxTenantDescriptionLength+1)))
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+	})
+
+	resp, result := request(t, http.MethodPost, "/v1/tenants", `{"name": "described", "description": "the first"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, "unexpected status code returned")
+	require.NotNil(t, result.Tenant.Description, "expected description")
+	assert.Equal(t, "the first", *result.Tenant.Description, "unexpected description")
+
+	tenantPath := "/v1/tenants/" + string(result.Tenant.ID)
+
+	t.Run("omitted description unchanged", func(t *testing.T) {
+		resp, result := request(t, http.MethodPatch, tenantPath, `{"name": "renamed"}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result.Tenant.Description, "expected description")
+		assert.Equal(t, "the first", *result.Tenant.Description, "unexpected description")
+	})
+
+	t.Run("cleared", func(t *testing.T) {
+		resp, result := request(t, http.MethodPatch, tenantPath, `{"description": null}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		assert.Nil(t, result.Tenant.Description, "expected description to be cleared")
+	})
+}
+
+func TestTenantBatchDescription(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	ctx := context.Background()
+
+	first := srv.createTenant(t, "", "first")
+	second := srv.createTenant(t, first.ID, "second")
+
+	batchDescription := func(t *testing.T, body string, out interface{}) *http.Response {
+		t.Helper()
+
+		resp, err := srv.Request(http.MethodPatch, "/v1/tenants/batch", nil, strings.NewReader(body), out)
+		require.NoError(t, err, "no error expected for batch description")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp
+	}
+
+	assertDescription := func(t *testing.T, id gidx.PrefixedID, description null.String) {
+		t.Helper()
+
+		stored, err := models.FindTenant(ctx, srv.db, id)
+		require.NoError(t, err, "no error expected finding tenant")
+		assert.Equal(t, description, stored.Description, "unexpected description")
+	}
+
+	t.Run("missing tenant rolls back", func(t *testing.T) {
+		missing := gidx.MustNewID(TenantIDPrefix)
+
+		var result *v1ErrorResponseBody
+
+		resp := batchDescription(t, fmt.Sprintf(`{"updates": [
+			{"id": "%s", "description": "first"},
+			{"id": "%s", "description": "missing"},
+			{"id": "%s", "description": "second"}
+		]}`, first.ID, missing, second.ID), &result)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected error response")
+		assert.Contains(t, result.Error, fmt.Sprintf("1 (%s)", missing), "expected the missing tenant's index")
+
+		assertDescription(t, first.ID, null.String{})
+		assertDescription(t, second.ID, null.String{})
+	})
+
+	t.Run("descriptions applied together", func(t *testing.T) {
+		subscriber := newPubSubClient(t, srv.logger, srv.nats.ClientURL())
+		msgChan := make(chan *nats.Msg, 10)
+
+		subscription, err := subscriber.ChanSubscribe(ctx, "com.infratographer.events.tenants.>", msgChan, "tenant-api-test")
+		require.NoError(t, err)
+
+		defer func() {
+			if err := subscription.Unsubscribe(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		var result *v1TenantSliceResponse
+
+		resp := batchDescription(t, fmt.Sprintf(`{"updates": [
+			{"id": "%s", "description": "first"},
+			{"id": "%s", "description": "second"}
+		]}`, first.ID, second.ID), &result)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		assert.Len(t, result.Tenants, 2, "expected updated tenants")
+
+		assertDescription(t, first.ID, null.StringFrom("first"))
+		assertDescription(t, second.ID, null.StringFrom("second"))
+
+		for _, id := range []gidx.PrefixedID{first.ID, second.ID} {
+			select {
+			case msg := <-msgChan:
+				pMsg := &pubsubx.ChangeMessage{}
+				require.NoError(t, json.Unmarshal(msg.Data, pMsg))
+
+				assert.Equal(t, tenantSubjectUpdate, msg.Subject, "expected nats subject to be tenant update subject")
+				assert.Equal(t, id, pMsg.SubjectID, "expected events in update order")
+			case <-time.After(natsMsgSubTimeout):
+				t.Error("failed to receive nats message")
+			}
+		}
+	})
+
+	t.Run("cleared", func(t *testing.T) {
+		resp := batchDescription(t, fmt.Sprintf(`{"updates": [{"id": "%s", "description": null}]}`, first.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		assertDescription(t, first.ID, null.String{})
+		assertDescription(t, second.ID, null.StringFrom("second"))
+	})
+}
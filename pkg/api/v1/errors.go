@@ -6,9 +6,153 @@ var (
 	// ErrInvalidID is returned when a ID is invalid
 	ErrInvalidID = errors.New("invalid ID")
 
+	// ErrInvalidIDPrefix is returned when the configured tenant ID prefix is not a valid gidx prefix.
+	ErrInvalidIDPrefix = errors.New("invalid tenant ID prefix")
+
+	// ErrWrongIDPrefix is returned when a requested ID has a prefix other than the tenant prefix.
+	ErrWrongIDPrefix = errors.New("ID does not have the tenant prefix")
+
+	// ErrIDPrefixMismatch is returned when existing tenant IDs do not have the configured prefix.
+	ErrIDPrefixMismatch = errors.New("existing tenant IDs do not match the configured prefix")
+
+	// ErrInvalidIsolationLevel is returned when the configured transaction isolation level is not supported.
+	ErrInvalidIsolationLevel = errors.New("invalid transaction isolation level")
+
+	// ErrInvalidExternalBaseURL is returned when the configured external base URL is not an absolute URL.
+	ErrInvalidExternalBaseURL = errors.New("invalid external base url")
+
+	// ErrDuplicateNames is returned when enabling global name uniqueness while existing tenants share names.
+	ErrDuplicateNames = errors.New("existing tenant names are not unique")
+
+	// ErrNameUniquenessMismatch is returned when the database does not enforce the configured name uniqueness.
+	ErrNameUniquenessMismatch = errors.New("database name uniqueness does not match configuration")
+
 	// ErrIDNotFound is returned when a ID is not found in the path
 	ErrIDNotFound = errors.New("ID not found in path")
 
 	// ErrTenantNameMissing is returned when the Tenant Name is not defined.
 	ErrTenantNameMissing = errors.New("tenant name is missing")
+
+	// ErrTenantNameTooLong is returned when the Tenant Name is longer than the maximum length.
+	ErrTenantNameTooLong = errors.New("tenant name is too long")
+
+	// ErrTenantNameInvalid is returned when the Tenant Name contains control characters or invalid UTF-8.
+	ErrTenantNameInvalid = errors.New("tenant name contains invalid characters")
+
+	// ErrInvalidQueryParam is returned when a query parameter has an invalid value.
+	ErrInvalidQueryParam = errors.New("invalid query parameter")
+
+	// ErrUnresolvedPath is returned when a tenant's ancestry does not lead to a root tenant.
+	ErrUnresolvedPath = errors.New("unable to resolve tenant path")
+
+	// ErrMaxDepthExceeded is returned when a tenant's ancestry is deeper than the maximum depth.
+	ErrMaxDepthExceeded = errors.New("tenant ancestry exceeds maximum depth")
+
+	// ErrResultTooLarge is returned when a recursive result would have more than the maximum number of tenants.
+	ErrResultTooLarge = errors.New("result has too many tenants")
+
+	// ErrNotAcceptable is returned when the request does not accept any of the supported content types.
+	ErrNotAcceptable = errors.New("no supported content type accepted")
+
+	// ErrInvalidPath is returned when a tenant name path is malformed.
+	ErrInvalidPath = errors.New("invalid tenant path")
+
+	// ErrPathTooLong is returned when a tenant name path has more names than allowed.
+	ErrPathTooLong = errors.New("tenant path has too many names")
+
+	// ErrAmbiguousPath is returned when a tenant name path matches more than one tenant.
+	ErrAmbiguousPath = errors.New("tenant path matches multiple tenants")
+
+	// ErrLabelValueMissing is returned when setting a label without a value.
+	ErrLabelValueMissing = errors.New("label value is missing")
+
+	// ErrInvalidLabelKey is returned when a label key is empty, too long or contains unsupported characters.
+	ErrInvalidLabelKey = errors.New("invalid label key")
+
+	// ErrLabelNotFound is returned when a tenant label does not exist.
+	ErrLabelNotFound = errors.New("label not found")
+
+	// ErrValidationFailed is returned when a registered validator rejects a tenant mutation.
+	ErrValidationFailed = errors.New("tenant validation failed")
+
+	// ErrChildLimitExceeded is returned when a tenant already has the maximum number of direct children.
+	ErrChildLimitExceeded = errors.New("tenant child limit exceeded")
+
+	// ErrInvalidImport is returned when an import document does not describe a valid subtree.
+	ErrInvalidImport = errors.New("invalid tenant import")
+
+	// ErrTenantCycle is returned when a tenant would become its own ancestor.
+	ErrTenantCycle = errors.New("tenant can't be moved under itself")
+
+	// ErrNamePatternMismatch is returned when a tenant name does not match the configured name pattern.
+	ErrNamePatternMismatch = errors.New("tenant name does not match the required pattern")
+
+	// ErrTenantNameReserved is returned when a tenant name could not be resolved by name path.
+	ErrTenantNameReserved = errors.New("tenant name is reserved")
+
+	// ErrNameConflict is returned when the parent already has a child with the same name.
+	ErrNameConflict = errors.New("tenant name already exists under parent")
+
+	// ErrInvalidReparent is returned when a reparent children request is invalid.
+	ErrInvalidReparent = errors.New("invalid reparent children request")
+
+	// ErrInvalidBatchMove is returned when a batch move request is invalid.
+	ErrInvalidBatchMove = errors.New("invalid batch move")
+
+	// ErrInvalidSwap is returned when a swap parents request is invalid.
+	ErrInvalidSwap = errors.New("invalid swap parents request")
+
+	// ErrInvalidBatchCreate is returned when a batch create request is invalid.
+	ErrInvalidBatchCreate = errors.New("invalid batch create")
+
+	// ErrInvalidBatchGet is returned when a batch get request is invalid.
+	ErrInvalidBatchGet = errors.New("invalid batch get")
+
+	// ErrMissingScope is returned when the request token does not include a required scope.
+	ErrMissingScope = errors.New("missing required scope")
+
+	// ErrTenantLocked is returned when deleting or moving a locked tenant.
+	ErrTenantLocked = errors.New("tenant is locked")
+
+	// ErrTenantHasChildren is returned when deleting a tenant with children only if it is childless.
+	ErrTenantHasChildren = errors.New("tenant has children")
+
+	// ErrInvalidQuota is returned when a tenant quota is negative.
+	ErrInvalidQuota = errors.New("tenant quota must not be negative")
+
+	// ErrTenantDescriptionTooLong is returned when a tenant description is longer than allowed.
+	ErrTenantDescriptionTooLong = errors.New("tenant description is too long")
+
+	// ErrInvalidBatchDescription is returned when a batch description request is invalid.
+	ErrInvalidBatchDescription = errors.New("invalid batch description update")
+
+	// ErrBatchTenantsNotFound is returned when tenants of a batch update do not exist.
+	ErrBatchTenantsNotFound = errors.New("batch tenants not found")
+
+	// ErrParentMismatch is returned when the parent of a create body disagrees with the parent of the path.
+	ErrParentMismatch = errors.New("parent_tenant_id does not match the path")
+
+	// ErrInvalidRepair is returned when a repair request is invalid.
+	ErrInvalidRepair = errors.New("invalid repair")
+
+	// ErrNoIntegrityIssue is returned when a repair targets a tenant whose parent pointer is sound.
+	ErrNoIntegrityIssue = errors.New("tenant has no integrity issue")
+
+	// ErrInvalidRequestBody is returned when a request body can't be decoded, such as when it includes unknown fields.
+	ErrInvalidRequestBody = errors.New("invalid request body")
+
+	// ErrInvalidRouteTimeout is returned when a route timeout override is invalid.
+	ErrInvalidRouteTimeout = errors.New("invalid route timeout")
+
+	// ErrInvalidKind is returned when a tenant kind is not one of the configured kinds.
+	ErrInvalidKind = errors.New("invalid tenant kind")
+
+	// ErrInvalidSort is returned when a tenant list order is not supported.
+	ErrInvalidSort = errors.New("invalid sort")
+
+	// ErrInvalidFieldNaming is returned when a JSON field naming strategy is not supported.
+	ErrInvalidFieldNaming = errors.New("invalid field naming")
+
+	// ErrInvalidMessageCatalog is returned when a message catalog file is not a JSON object of strings.
+	ErrInvalidMessageCatalog = errors.New("invalid message catalog")
 )
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.infratographer.com/tenant-api/internal/models"
+)
+
+const (
+	headerETag        = "ETag"
+	headerIfNoneMatch = "If-None-Match"
+)
+
+// collectionETag returns a weak ETag for a list of tenants, derived from the
+// number of tenants and the most recent update time within the list, and the
+// representation the list is encoded in, so JSON and protobuf encodings of a
+// list don't share a validator.
+//
+// The ETag is weak as it does not identify the exact tenants listed. Any
+// change to a listed tenant changes its update time, and adding or removing
+// tenants changes the number listed, but a tenant being replaced by an older
+// tenant, such as when a tenant is deleted and another moves onto the page,
+// may leave the ETag unchanged.
+func collectionETag(ts []*models.Tenant, representation string) string {
+	var latest int64
+
+	for _, t := range ts {
+		if updated := t.UpdatedAt.UnixNano(); updated > latest {
+			latest = updated
+		}
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(representation)) //nolint:errcheck // Writing to a hash never fails.
+
+	return fmt.Sprintf(`W/"%d-%x-%x"`, len(ts), latest, h.Sum32())
+}
+
+// responseRepresentation returns the representation responses to the request are
+// encoded in, the negotiated content type and, for JSON, the field naming.
+func responseRepresentation(c echo.Context) string {
+	contentType, _ := c.Get(contentTypeKey).(string)
+	if contentType == MIMEApplicationProtobuf {
+		return contentType
+	}
+
+	return echo.MIMEApplicationJSON + ";" + string(fieldNaming(c))
+}
+
+// etagMatches reports whether the If-None-Match header matches the ETag,
+// using the weak comparison required for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
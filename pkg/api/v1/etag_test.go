@@ -0,0 +1,152 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/tenant-api/internal/models"
+)
+
+func TestCollectionETag(t *testing.T) {
+	updated := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	ts := []*models.Tenant{
+		{UpdatedAt: updated},
+		{UpdatedAt: updated.Add(-time.Hour)},
+	}
+
+	const json = echo.MIMEApplicationJSON
+
+	etag := collectionETag(ts, json)
+
+	assert.True(t, strings.HasPrefix(etag, `W/"`), "expected a weak etag")
+	assert.Equal(t, etag, collectionETag(ts, json), "expected a stable etag")
+	assert.NotEqual(t, etag, collectionETag(ts[:1], json), "expected the etag to change with the count")
+	assert.NotEqual(t, etag, collectionETag([]*models.Tenant{{UpdatedAt: updated.Add(time.Second)}, ts[1]}, json), "expected the etag to change with the update time")
+	assert.NotEqual(t, collectionETag(nil, json), etag, "expected empty lists to have a distinct etag")
+	assert.NotEqual(t, etag, collectionETag(ts, MIMEApplicationProtobuf), "expected the etag to change with the representation")
+}
+
+func TestResponseRepresentation(t *testing.T) {
+	representation := func(contentType string, naming FieldNaming) string {
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+		c.Set(contentTypeKey, contentType)
+
+		if naming != "" {
+			c.Set(fieldNamingKey, naming)
+		}
+
+		return responseRepresentation(c)
+	}
+
+	json := representation(echo.MIMEApplicationJSON, "")
+
+	assert.Equal(t, json, representation(echo.MIMEApplicationJSON, FieldNamingSnakeCase), "expected snake_case by default")
+	assert.NotEqual(t, json, representation(echo.MIMEApplicationJSON, FieldNamingCamelCase), "expected field naming to change the representation")
+	assert.NotEqual(t, json, representation(MIMEApplicationProtobuf, ""), "expected protobuf to change the representation")
+}
+
+func TestETagMatches(t *testing.T) {
+	etag := `W/"2-abc"`
+
+	testCases := []struct {
+		name        string
+		ifNoneMatch string
+		expected    bool
+	}{
+		{"empty", "", false},
+		{"exact", `W/"2-abc"`, true},
+		{"strong", `"2-abc"`, true},
+		{"wildcard", "*", true},
+		{"list", `"other", W/"2-abc"`, true},
+		{"different", `W/"3-abc"`, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, etagMatches(tc.ifNoneMatch, etag))
+		})
+	}
+}
+
+func TestTenantListETag(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	root := srv.createTenant(t, "", "root")
+	srv.createTenant(t, root.ID, "child")
+
+	childrenPath := "/v1/tenants/" + string(root.ID) + "/tenants"
+
+	list := func(t *testing.T, etag string) *http.Response {
+		t.Helper()
+
+		headers := http.Header{}
+		if etag != "" {
+			headers.Set(headerIfNoneMatch, etag)
+		}
+
+		resp, err := srv.Request(http.MethodGet, childrenPath, headers, nil, nil)
+		require.NoError(t, err, "no error expected for tenant list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp
+	}
+
+	resp := list(t, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+	etag := resp.Header.Get(headerETag)
+	require.NotEmpty(t, etag, "expected etag header")
+
+	t.Run("not modified", func(t *testing.T) {
+		resp := list(t, etag)
+
+		assert.Equal(t, http.StatusNotModified, resp.StatusCode, "unexpected status code returned")
+		assert.Equal(t, etag, resp.Header.Get(headerETag), "expected unchanged etag")
+	})
+
+	t.Run("varies by encoding", func(t *testing.T) {
+		headers := http.Header{}
+		headers.Set(headerIfNoneMatch, etag)
+		headers.Set(echo.HeaderAccept, MIMEApplicationProtobuf)
+
+		resp, err := srv.Request(http.MethodGet, childrenPath, headers, nil, nil)
+		require.NoError(t, err, "no error expected for tenant list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "expected the JSON etag not to match the protobuf list")
+		assert.NotEqual(t, etag, resp.Header.Get(headerETag), "expected a distinct protobuf etag")
+		assert.Contains(t, resp.Header.Values(echo.HeaderVary), echo.HeaderAccept, "expected the list to vary by accept")
+	})
+
+	t.Run("modified after create", func(t *testing.T) {
+		srv.createTenant(t, root.ID, "second")
+
+		resp := list(t, etag)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		assert.NotEqual(t, etag, resp.Header.Get(headerETag), "expected etag to change")
+
+		etag = resp.Header.Get(headerETag)
+	})
+
+	t.Run("modified after update", func(t *testing.T) {
+		child := srv.listTenants(t, childrenPath)[0]
+
+		resp, err := srv.Request(http.MethodPatch, "/v1/tenants/"+string(child.ID), nil, strings.NewReader(`{"name": "renamed"}`), nil)
+		require.NoError(t, err, "no error expected for tenant update")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		assert.Equal(t, http.StatusOK, list(t, etag).StatusCode, "unexpected status code returned")
+	})
+}
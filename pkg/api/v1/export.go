@@ -0,0 +1,250 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/volatiletech/null/v8"
+	"go.infratographer.com/x/gidx"
+	"go.uber.org/zap"
+)
+
+const (
+	// exportFormatVersion is the version of the export document format,
+	// incremented whenever the format changes incompatibly.
+	exportFormatVersion = 1
+
+	// exportFlushInterval is the number of tenants written between flushes
+	// of the export response.
+	exportFlushInterval = 100
+
+	// exportQuery returns the tenant and all of its descendants ordered by
+	// path, so that parents are always returned before their children, with
+	// a row for each of a tenant's labels.
+	exportQuery = `
+		SELECT t.id, t.parent_tenant_id, t.name, t.description, t.quota, t.kind, t.created_at, t.updated_at, l.key, l.value
+		FROM tenants t
+		LEFT JOIN tenant_labels l ON l.tenant_id = t.id
+		WHERE t.deleted_at IS NULL AND (t.id = $1 OR t.path LIKE $2)
+		ORDER BY t.path, l.key
+	`
+)
+
+// tenantExport is the document produced by the export endpoint.
+//
+// Tenants are ordered so that every tenant appears after its parent. The
+// exported root tenant has no parent, while every other tenant's parent is
+// included in the document, so the subtree may be recreated from the document
+// alone.
+type tenantExport struct {
+	Version    int              `json:"version"`
+	RootID     gidx.PrefixedID  `json:"root_id"`
+	ExportedAt time.Time        `json:"exported_at"`
+	Tenants    []exportedTenant `json:"tenants"`
+}
+
+// exportedTenant is a tenant within an export document.
+type exportedTenant struct {
+	ID             gidx.PrefixedID   `json:"id"`
+	ParentTenantID *gidx.PrefixedID  `json:"parent_tenant_id,omitempty"`
+	Name           string            `json:"name"`
+	Description    *string           `json:"description,omitempty"`
+	Quota          *int64            `json:"quota,omitempty"`
+	Kind           *string           `json:"kind,omitempty"`
+	Labels         map[string]string `json:"labels"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// tenantExport streams the tenant and all of its descendants as a tenantExport
+// document. Descendants of deleted tenants are not included.
+//
+// The document is written as the tenants are read, so an error part way
+// through the export can only be reported by ending the response early,
+// leaving the document incomplete.
+func (r *Router) tenantExport(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantExport")
+	defer span.End()
+
+	tenantID, err := r.parseID(c, "id")
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	if contentType, _ := c.Get(contentTypeKey).(string); contentType != echo.MIMEApplicationJSON {
+		return v1NotAcceptableResponse(c, fmt.Errorf("%w: exports are only available as %s", ErrNotAcceptable, echo.MIMEApplicationJSON))
+	}
+
+	path, err := r.lookupTenantPath(ctx, r.db, tenantID)
+	if err != nil {
+		return r.tenantQueryErrorResponse(c, err)
+	}
+
+	// The size is checked before the document is started, as errors can't be
+	// reported once it is being written.
+	if err := r.checkSubtreeSize(ctx, r.db, tenantID, path); err != nil {
+		if errors.Is(err, ErrResultTooLarge) {
+			return v1ResultTooLargeResponse(c, err)
+		}
+
+		return r.tenantQueryErrorResponse(c, err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, exportQuery, tenantID, subtreePathPattern(path))
+	if err != nil {
+		r.requestLogger(c).Error("failed to query tenant export", zap.Error(err))
+
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	defer rows.Close()
+
+	w := &exportWriter{
+		resp:     c.Response(),
+		naming:   fieldNaming(c),
+		exported: make(map[gidx.PrefixedID]bool),
+	}
+
+	if err := w.start(tenantID, time.Now().UTC()); err != nil {
+		r.requestLogger(c).Error("failed to write tenant export", zap.Error(err))
+
+		return nil
+	}
+
+	var current *exportedTenant
+
+	for rows.Next() {
+		var (
+			t           exportedTenant
+			parentID    sql.NullString
+			description null.String
+			quota       null.Int64
+			kind        null.String
+			key, value  sql.NullString
+		)
+
+		if err := rows.Scan(&t.ID, &parentID, &t.Name, &description, &quota, &kind, &t.CreatedAt, &t.UpdatedAt, &key, &value); err != nil {
+			r.requestLogger(c).Error("failed to scan tenant export", zap.Error(err))
+
+			return nil
+		}
+
+		if current == nil || current.ID != t.ID {
+			if err := w.write(current); err != nil {
+				r.requestLogger(c).Error("failed to write tenant export", zap.Error(err))
+
+				return nil
+			}
+
+			if parentID.Valid && t.ID != tenantID {
+				parent := gidx.PrefixedID(parentID.String)
+				t.ParentTenantID = &parent
+			}
+
+			t.Description = description.Ptr()
+			t.Quota = quota.Ptr()
+			t.Kind = kind.Ptr()
+			t.Labels = make(map[string]string)
+			current = &t
+		}
+
+		if key.Valid {
+			current.Labels[key.String] = value.String
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		r.requestLogger(c).Error("failed to read tenant export", zap.Error(err))
+
+		return nil
+	}
+
+	if err := w.write(current); err != nil {
+		r.requestLogger(c).Error("failed to write tenant export", zap.Error(err))
+
+		return nil
+	}
+
+	if err := w.end(); err != nil {
+		r.requestLogger(c).Error("failed to write tenant export", zap.Error(err))
+	}
+
+	return nil
+}
+
+// exportWriter incrementally writes a tenantExport document to the response.
+type exportWriter struct {
+	resp     *echo.Response
+	naming   FieldNaming
+	count    int
+	exported map[gidx.PrefixedID]bool
+}
+
+func (w *exportWriter) start(rootID gidx.PrefixedID, exportedAt time.Time) error {
+	root, err := json.Marshal(rootID)
+	if err != nil {
+		return err
+	}
+
+	ts, err := json.Marshal(exportedAt)
+	if err != nil {
+		return err
+	}
+
+	w.resp.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+	w.resp.WriteHeader(http.StatusOK)
+
+	_, err = fmt.Fprintf(w.resp, `{"version":%d,%q:%s,%q:%s,"tenants":[`,
+		exportFormatVersion, w.naming.name("root_id"), root, w.naming.name("exported_at"), ts)
+
+	return err
+}
+
+// write adds the tenant to the document. Tenants whose parent has not been
+// written are skipped, as their parent has been deleted.
+func (w *exportWriter) write(t *exportedTenant) error {
+	if t == nil {
+		return nil
+	}
+
+	if t.ParentTenantID != nil && !w.exported[*t.ParentTenantID] {
+		return nil
+	}
+
+	b, err := w.naming.marshal(t)
+	if err != nil {
+		return err
+	}
+
+	if w.count > 0 {
+		b = append([]byte(","), b...)
+	}
+
+	if _, err := w.resp.Write(b); err != nil {
+		return err
+	}
+
+	w.exported[t.ID] = true
+	w.count++
+
+	if w.count%exportFlushInterval == 0 {
+		w.resp.Flush()
+	}
+
+	return nil
+}
+
+func (w *exportWriter) end() error {
+	if _, err := w.resp.Write([]byte("]}")); err != nil {
+		return err
+	}
+
+	w.resp.Flush()
+
+	return nil
+}
@@ -0,0 +1,457 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/x/gidx"
+)
+
+func TestExportWriter(t *testing.T) {
+	rootID := gidx.MustNewID(TenantIDPrefix)
+	childID := gidx.MustNewID(TenantIDPrefix)
+	orphanParentID := gidx.MustNewID(TenantIDPrefix)
+	exportedAt := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	rec := httptest.NewRecorder()
+
+	w := &exportWriter{
+		resp:     echo.NewResponse(rec, echo.New()),
+		exported: make(map[gidx.PrefixedID]bool),
+	}
+
+	require.NoError(t, w.start(rootID, exportedAt))
+	require.NoError(t, w.write(nil), "expected writing no tenant to be a no-op")
+	require.NoError(t, w.write(&exportedTenant{ID: rootID, Name: "root"}))
+	require.NoError(t, w.write(&exportedTenant{ID: childID, ParentTenantID: &rootID, Name: "child", Labels: map[string]string{"tier": "gold"}}))
+	require.NoError(t, w.write(&exportedTenant{ID: gidx.MustNewID(TenantIDPrefix), ParentTenantID: &orphanParentID, Name: "orphan"}))
+	require.NoError(t, w.end())
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get(echo.HeaderContentType), echo.MIMEApplicationJSON)
+
+	var result tenantExport
+
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result), "expected a valid export document")
+
+	assert.Equal(t, exportFormatVersion, result.Version)
+	assert.Equal(t, rootID, result.RootID)
+	assert.Equal(t, exportedAt, result.ExportedAt)
+
+	require.Len(t, result.Tenants, 2, "expected tenants without an exported parent to be skipped")
+	assert.Equal(t, rootID, result.Tenants[0].ID)
+	assert.Equal(t, childID, result.Tenants[1].ID)
+	assert.Equal(t, map[string]string{"tier": "gold"}, result.Tenants[1].Labels)
+}
+
+func TestTenantExport(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	parent := srv.createTenant(t, "", "parent")
+	root := srv.createTenant(t, parent.ID, "root")
+	child := srv.createTenant(t, root.ID, "child")
+	grandchild := srv.createTenant(t, child.ID, "grandchild")
+	deleted := srv.createTenant(t, root.ID, "deleted")
+	srv.createTenant(t, deleted.ID, "orphaned")
+	srv.createTenant(t, parent.ID, "sibling")
+
+	resp, err := srv.Request(http.MethodPut, "/v1/tenants/"+string(child.ID)+"/labels/tier", nil, strings.NewReader(`{"value": "gold"}`), nil)
+	require.NoError(t, err, "no error expected for setting label")
+	resp.Body.Close() //nolint:errcheck // Not needed
+	require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+	resp, err = srv.Request(http.MethodDelete, "/v1/tenants/"+string(deleted.ID), nil, nil, nil)
+	require.NoError(t, err, "no error expected for deleting tenant")
+	resp.Body.Close() //nolint:errcheck // Not needed
+	require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+	t.Run("subtree", func(t *testing.T) {
+		var result *tenantExport
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(root.ID)+"/export", nil, nil, &result)
+		require.NoError(t, err, "no error expected for tenant export")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		require.NotNil(t, result, "expected export result")
+
+		assert.Equal(t, exportFormatVersion, result.Version, "unexpected export version")
+		assert.Equal(t, root.ID, result.RootID, "unexpected export root")
+		assert.False(t, result.ExportedAt.IsZero(), "expected exported at to be set")
+
+		require.Len(t, result.Tenants, 3, "expected root and its live descendants")
+
+		assert.Equal(t, root.ID, result.Tenants[0].ID, "expected root first")
+		assert.Nil(t, result.Tenants[0].ParentTenantID, "expected root to have no parent")
+		assert.Equal(t, "root", result.Tenants[0].Name)
+
+		assert.Equal(t, child.ID, result.Tenants[1].ID, "expected child after root")
+		require.NotNil(t, result.Tenants[1].ParentTenantID)
+		assert.Equal(t, root.ID, *result.Tenants[1].ParentTenantID)
+		assert.Equal(t, map[string]string{"tier": "gold"}, result.Tenants[1].Labels)
+
+		assert.Equal(t, grandchild.ID, result.Tenants[2].ID, "expected grandchild after child")
+		require.NotNil(t, result.Tenants[2].ParentTenantID)
+		assert.Equal(t, child.ID, *result.Tenants[2].ParentTenantID)
+		assert.Empty(t, result.Tenants[2].Labels)
+	})
+
+	t.Run("leaf", func(t *testing.T) {
+		var result *tenantExport
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(grandchild.ID)+"/export", nil, nil, &result)
+		require.NoError(t, err, "no error expected for tenant export")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		require.Len(t, result.Tenants, 1, "expected only the exported tenant")
+		assert.Equal(t, grandchild.ID, result.Tenants[0].ID)
+	})
+
+	t.Run("unknown tenant", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(gidx.MustNewID(TenantIDPrefix))+"/export", nil, nil, nil)
+		require.NoError(t, err, "no error expected for tenant export")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, "unexpected status code returned")
+	})
+
+	t.Run("protobuf not supported", func(t *testing.T) {
+		headers := http.Header{}
+		headers.Set("Accept", MIMEApplicationProtobuf)
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(root.ID)+"/export", headers, nil, nil)
+		require.NoError(t, err, "no error expected for tenant export")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusNotAcceptable, resp.StatusCode, "unexpected status code returned")
+	})
+}
+
+func TestTenantExportMaxResultSize(t *testing.T) {
+	srv, err := newTestServer(t, &testServerConfig{
+		routerOpts: []RouterOption{WithMaxResultSize(3)},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	root := srv.createTenant(t, "", "root")
+	child := srv.createTenant(t, root.ID, "child")
+	srv.createTenant(t, child.ID, "grandchild")
+	srv.createTenant(t, root.ID, "sibling")
+
+	request := func(t *testing.T, path string, out interface{}) *http.Response {
+		t.Helper()
+
+		resp, err := srv.Request(http.MethodGet, path, nil, nil, out)
+		require.NoError(t, err, "no error expected for request")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp
+	}
+
+	t.Run("export exceeding the cap", func(t *testing.T) {
+		var result *v1ErrorResponseBody
+
+		resp := request(t, "/v1/tenants/"+string(root.ID)+"/export", &result)
+		require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected error response")
+		assert.Equal(t, errorCodeResultTooLarge, result.Code, "unexpected error code")
+		assert.Contains(t, result.Error, "by page", "expected pagination to be suggested")
+	})
+
+	t.Run("delete preview exceeding the cap", func(t *testing.T) {
+		resp := request(t, "/v1/tenants/"+string(root.ID)+"/delete-preview", nil)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode, "unexpected status code returned")
+	})
+
+	t.Run("subtree at the cap", func(t *testing.T) {
+		var result *tenantExport
+
+		resp := request(t, "/v1/tenants/"+string(child.ID)+"/export", &result)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		assert.Len(t, result.Tenants, 2, "expected the subtree to be exported")
+
+		resp = request(t, "/v1/tenants/"+string(child.ID)+"/delete-preview", nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+	})
+}
+
+func TestTenantExportValidate(t *testing.T) {
+	rootID := gidx.MustNewID(TenantIDPrefix)
+	childID := gidx.MustNewID(TenantIDPrefix)
+	unknownID := gidx.MustNewID(TenantIDPrefix)
+	negative := int64(-1)
+	longDescription := strings.Repeat("d", maxTenantDescriptionLength+1)
+
+	testCases := []struct {
+		name    string
+		doc     tenantExport
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			doc: tenantExport{
+				Version: exportFormatVersion,
+				RootID:  rootID,
+				Tenants: []exportedTenant{
+					{ID: rootID, Name: "root"},
+					{ID: childID, ParentTenantID: &rootID, Name: "child"},
+				},
+			},
+		},
+		{
+			name:    "unsupported version",
+			doc:     tenantExport{Version: exportFormatVersion + 1, RootID: rootID, Tenants: []exportedTenant{{ID: rootID, Name: "root"}}},
+			wantErr: true,
+		},
+		{
+			name:    "no tenants",
+			doc:     tenantExport{Version: exportFormatVersion, RootID: rootID},
+			wantErr: true,
+		},
+		{
+			name:    "root not first",
+			doc:     tenantExport{Version: exportFormatVersion, RootID: rootID, Tenants: []exportedTenant{{ID: childID, Name: "child"}}},
+			wantErr: true,
+		},
+		{
+			name: "missing name",
+			doc: tenantExport{
+				Version: exportFormatVersion,
+				RootID:  rootID,
+				Tenants: []exportedTenant{{ID: rootID}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate tenant",
+			doc: tenantExport{
+				Version: exportFormatVersion,
+				RootID:  rootID,
+				Tenants: []exportedTenant{
+					{ID: rootID, Name: "root"},
+					{ID: rootID, ParentTenantID: &rootID, Name: "root"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "second root",
+			doc: tenantExport{
+				Version: exportFormatVersion,
+				RootID:  rootID,
+				Tenants: []exportedTenant{
+					{ID: rootID, Name: "root"},
+					{ID: childID, Name: "child"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative quota",
+			doc: tenantExport{
+				Version: exportFormatVersion,
+				RootID:  rootID,
+				Tenants: []exportedTenant{{ID: rootID, Name: "root", Quota: &negative}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "description too long",
+			doc: tenantExport{
+				Version: exportFormatVersion,
+				RootID:  rootID,
+				Tenants: []exportedTenant{{ID: rootID, Name: "root", Description: &longDescription}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown parent",
+			doc: tenantExport{
+				Version: exportFormatVersion,
+				RootID:  rootID,
+				Tenants: []exportedTenant{
+					{ID: rootID, Name: "root"},
+					{ID: childID, ParentTenantID: &unknownID, Name: "child"},
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.doc.validate()
+
+			if tc.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidImport)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTenantImport(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	root := srv.createTenant(t, "", "root")
+	target := srv.createTenant(t, "", "target")
+
+	var created *v1TenantResponse
+
+	resp, err := srv.Request(http.MethodPost, "/v1/tenants/"+string(root.ID)+"/tenants", nil,
+		strings.NewReader(`{"name": "child", "description": "the child", "quota": 5, "kind": "team"}`), &created)
+	require.NoError(t, err, "no error expected creating tenant")
+	resp.Body.Close() //nolint:errcheck // Not needed
+	require.Equal(t, http.StatusCreated, resp.StatusCode, "unexpected status code creating tenant")
+
+	child := created.Tenant
+	srv.createTenant(t, child.ID, "grandchild")
+
+	resp, err = srv.Request(http.MethodPut, "/v1/tenants/"+string(child.ID)+"/labels/tier", nil, strings.NewReader(`{"value": "gold"}`), nil)
+	require.NoError(t, err, "no error expected for setting label")
+	resp.Body.Close() //nolint:errcheck // Not needed
+	require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+	var export *tenantExport
+
+	resp, err = srv.Request(http.MethodGet, "/v1/tenants/"+string(root.ID)+"/export", nil, nil, &export)
+	require.NoError(t, err, "no error expected for tenant export")
+	resp.Body.Close() //nolint:errcheck // Not needed
+	require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+	require.Len(t, export.Tenants, 3, "expected the root and its descendants to be exported")
+	require.NotNil(t, export.Tenants[1].Description, "expected the description to be exported")
+	assert.Equal(t, "the child", *export.Tenants[1].Description)
+	require.NotNil(t, export.Tenants[1].Quota, "expected the quota to be exported")
+	assert.Equal(t, int64(5), *export.Tenants[1].Quota)
+	require.NotNil(t, export.Tenants[1].Kind, "expected the kind to be exported")
+	assert.Equal(t, "team", *export.Tenants[1].Kind)
+
+	body, err := json.Marshal(export)
+	require.NoError(t, err, "no error expected encoding export")
+
+	importPath := "/v1/tenants/import?parent_id=" + string(target.ID)
+
+	t.Run("dry run", func(t *testing.T) {
+		var result *v1TenantImportResponseBody
+
+		resp, err := srv.Request(http.MethodPost, importPath+"&dry_run=true", nil, strings.NewReader(string(body)), &result)
+		require.NoError(t, err, "no error expected for tenant import")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		assert.True(t, result.DryRun, "expected dry run")
+		assert.Len(t, result.Tenants, 3, "expected all tenants to be planned")
+
+		assert.Empty(t, srv.listTenants(t, "/v1/tenants/"+string(target.ID)+"/tenants"), "expected nothing to be imported")
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		var result *v1TenantImportResponseBody
+
+		resp, err := srv.Request(http.MethodPost, importPath, nil, strings.NewReader(string(body)), &result)
+		require.NoError(t, err, "no error expected for tenant import")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusCreated, resp.StatusCode, "unexpected status code returned")
+
+		assert.False(t, result.DryRun, "expected import to be written")
+		require.Len(t, result.IDs, 3, "expected an id for every imported tenant")
+
+		for from, to := range result.IDs {
+			assert.NotEqual(t, from, to, "expected imported tenants to have new ids")
+		}
+
+		var reexport *tenantExport
+
+		resp, err = srv.Request(http.MethodGet, "/v1/tenants/"+string(result.IDs[root.ID])+"/export", nil, nil, &reexport)
+		require.NoError(t, err, "no error expected for tenant export")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		require.Len(t, reexport.Tenants, len(export.Tenants), "expected the same subtree")
+
+		for i, original := range export.Tenants {
+			imported := reexport.Tenants[i]
+
+			assert.Equal(t, result.IDs[original.ID], imported.ID, "unexpected imported tenant id")
+			assert.Equal(t, original.Name, imported.Name, "unexpected imported tenant name")
+			assert.Equal(t, original.Labels, imported.Labels, "unexpected imported tenant labels")
+			assert.Equal(t, original.Description, imported.Description, "unexpected imported tenant description")
+			assert.Equal(t, original.Quota, imported.Quota, "unexpected imported tenant quota")
+			assert.Equal(t, original.Kind, imported.Kind, "unexpected imported tenant kind")
+
+			if original.ParentTenantID != nil {
+				require.NotNil(t, imported.ParentTenantID)
+				assert.Equal(t, result.IDs[*original.ParentTenantID], *imported.ParentTenantID, "expected remapped parent")
+			}
+		}
+
+		children := srv.listTenants(t, "/v1/tenants/"+string(target.ID)+"/tenants")
+		require.Len(t, children, 1, "expected the imported root under the target")
+		assert.Equal(t, result.IDs[root.ID], children[0].ID)
+	})
+
+	t.Run("unknown parent", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodPost, "/v1/tenants/import?parent_id="+string(gidx.MustNewID(TenantIDPrefix)), nil, strings.NewReader(string(body)), nil)
+		require.NoError(t, err, "no error expected for tenant import")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, "unexpected status code returned")
+	})
+
+	t.Run("duplicate root name", func(t *testing.T) {
+		for _, query := range []string{"", "?dry_run=true"} {
+			resp, err := srv.Request(http.MethodPost, "/v1/tenants/import"+query, nil, strings.NewReader(string(body)), nil)
+			require.NoError(t, err, "no error expected for tenant import")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			assert.Equal(t, http.StatusConflict, resp.StatusCode, "expected a conflict with the existing root for query %q", query)
+		}
+
+		roots := srv.listTenants(t, "/v1/tenants")
+
+		var named int
+
+		for _, root := range roots {
+			if root.Name == "root" {
+				named++
+			}
+		}
+
+		assert.Equal(t, 1, named, "expected no duplicate root to be imported")
+	})
+
+	t.Run("invalid label key", func(t *testing.T) {
+		invalid := *export
+		invalid.Tenants = append([]exportedTenant(nil), export.Tenants...)
+		invalid.Tenants[0].Labels = map[string]string{"-invalid": "value"}
+
+		body, err := json.Marshal(invalid)
+		require.NoError(t, err, "no error expected encoding export")
+
+		resp, err := srv.Request(http.MethodPost, importPath, nil, strings.NewReader(string(body)), nil)
+		require.NoError(t, err, "no error expected for tenant import")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+	})
+
+	t.Run("invalid document", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodPost, "/v1/tenants/import", nil, strings.NewReader(`{"version": 1, "tenants": []}`), nil)
+		require.NoError(t, err, "no error expected for tenant import")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+	})
+}
@@ -0,0 +1,308 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"go.infratographer.com/tenant-api/internal/models"
+)
+
+const (
+	// hasChildrenClause matches tenants which have at least one child tenant which has not been deleted.
+	hasChildrenClause = `EXISTS (
+		SELECT 1 FROM tenants children
+		WHERE children.parent_tenant_id = tenants.id AND children.deleted_at IS NULL
+	)`
+
+	// hasDescriptionClause matches tenants with a non-empty description.
+	hasDescriptionClause = `(tenants.description IS NOT NULL AND tenants.description <> '')`
+
+	// nameGlobClause matches tenants whose name matches a LIKE pattern, in
+	// which a backslash escapes the following character.
+	nameGlobClause = `tenants.name LIKE ?`
+
+	// depthClause matches tenants at the depth, the number of separators in
+	// their materialized path. Tenants without a path, which are missing a
+	// reindex, are not matched.
+	depthClause = `tenants.path <> '' AND length(tenants.path) - length(replace(tenants.path, '` + tenantPathSeparator + `', '')) = ?`
+
+	// maxParentIDs is the maximum number of parents which may be listed with the parent_id query parameter.
+	maxParentIDs = 100
+
+	// rootParentID is the parent_id query parameter value selecting root tenants.
+	rootParentID = "null"
+
+	// maxKinds is the maximum number of kinds which may be listed with the kind query parameter.
+	maxKinds = 100
+
+	// maxExcludedSubtrees is the maximum number of subtrees which may be excluded with the exclude_subtree query parameter.
+	maxExcludedSubtrees = 100
+
+	// excludedSubtreeClause matches tenants whose materialized path includes
+	// the tenant, which are the tenant and its descendants. The path is
+	// wrapped in separators so every tenant in it is matched the same way.
+	excludedSubtreeClause = `'` + tenantPathSeparator + `' || tenants.path || '` + tenantPathSeparator + `' LIKE ?`
+)
+
+// parseListFilters parses the optional filter query parameters for the list endpoints.
+func parseListFilters(c echo.Context) ([]qm.QueryMod, error) {
+	var mods []qm.QueryMod
+
+	if value := c.QueryParam("has_children"); value != "" {
+		hasChildren, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: has_children must be true or false", ErrInvalidQueryParam)
+		}
+
+		if hasChildren {
+			mods = append(mods, qm.Where(hasChildrenClause))
+		} else {
+			mods = append(mods, qm.Where("NOT "+hasChildrenClause))
+		}
+	}
+
+	if value := c.QueryParam("is_root"); value != "" {
+		isRoot, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: is_root must be true or false", ErrInvalidQueryParam)
+		}
+
+		if isRoot {
+			mods = append(mods, models.TenantWhere.ParentTenantID.IsNull())
+		} else {
+			mods = append(mods, models.TenantWhere.ParentTenantID.IsNotNull())
+		}
+	}
+
+	if value := c.QueryParam("has_description"); value != "" {
+		hasDescription, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: has_description must be true or false", ErrInvalidQueryParam)
+		}
+
+		// Empty descriptions are treated as missing, as they don't document the tenant either.
+		if hasDescription {
+			mods = append(mods, qm.Where(hasDescriptionClause))
+		} else {
+			mods = append(mods, qm.Where("NOT "+hasDescriptionClause))
+		}
+	}
+
+	if values := c.QueryParams()["kind"]; len(values) != 0 {
+		if len(values) > maxKinds {
+			return nil, fmt.Errorf("%w: at most %d kind values may be provided", ErrInvalidQueryParam, maxKinds)
+		}
+
+		mods = append(mods, qm.Where(models.TenantTableColumns.Kind+" = ANY(?)", pq.Array(values)))
+	}
+
+	if value := c.QueryParam("name"); value != "" {
+		mods = append(mods, models.TenantWhere.Name.EQ(value))
+	}
+
+	if value := c.QueryParam("name_glob"); value != "" {
+		mods = append(mods, qm.Where(nameGlobClause, globToLike(value)))
+	}
+
+	if value := c.QueryParam("updated_by"); value != "" {
+		actor, err := parseGID(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: updated_by must be an actor id", ErrInvalidQueryParam)
+		}
+
+		mods = append(mods, models.TenantWhere.UpdatedBy.EQ(null.StringFrom(string(actor))))
+	}
+
+	return mods, nil
+}
+
+// globToLike translates the glob pattern into a LIKE pattern: * matches any
+// characters and ? matches a single character. A backslash matches the
+// character following it literally, and the LIKE wildcards % and _ are
+// escaped so they only match themselves.
+func globToLike(glob string) string {
+	var (
+		b       strings.Builder
+		escaped bool
+	)
+
+	literal := func(c rune) {
+		switch c {
+		case '%', '_', '\\':
+			b.WriteRune('\\')
+		}
+
+		b.WriteRune(c)
+	}
+
+	for _, c := range glob {
+		switch {
+		case escaped:
+			literal(c)
+
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '*':
+			b.WriteRune('%')
+		case c == '?':
+			b.WriteRune('_')
+		default:
+			literal(c)
+		}
+	}
+
+	// A trailing backslash has nothing to escape, so it matches itself.
+	if escaped {
+		literal('\\')
+	}
+
+	return b.String()
+}
+
+// likeEscaper escapes the LIKE wildcards % and _, and the backslash escaping them.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike returns a LIKE pattern matching only the literal value.
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+// parseParentFilter parses the repeated parent_id query parameter, returning
+// a query mod selecting the direct children of any of the listed parents.
+// The null value selects root tenants. No query mod is returned when the
+// parameter is not provided.
+func (r *Router) parseParentFilter(c echo.Context) (qm.QueryMod, error) {
+	values := c.QueryParams()["parent_id"]
+
+	switch {
+	case len(values) == 0:
+		return nil, nil
+	case len(values) > maxParentIDs:
+		return nil, fmt.Errorf("%w: at most %d parent_id values may be provided", ErrInvalidQueryParam, maxParentIDs)
+	}
+
+	var (
+		roots bool
+		ids   []string
+	)
+
+	for _, value := range values {
+		if value == rootParentID {
+			roots = true
+
+			continue
+		}
+
+		id, err := r.parseTenantID(value)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, string(id))
+	}
+
+	column := models.TenantTableColumns.ParentTenantID
+
+	switch {
+	case len(ids) == 0:
+		return models.TenantWhere.ParentTenantID.IsNull(), nil
+	case roots:
+		return qm.Where(fmt.Sprintf("(%s IS NULL OR %s = ANY(?))", column, column), pq.Array(ids)), nil
+	default:
+		return qm.Where(column+" = ANY(?)", pq.Array(ids)), nil
+	}
+}
+
+// parseDepthFilter parses the depth query parameter, returning a query mod
+// selecting the tenants at the depth below their root, roots being at depth
+// zero. The depth is computed from the materialized paths. No query mod is
+// returned when the parameter is not provided.
+func (r *Router) parseDepthFilter(c echo.Context) (qm.QueryMod, error) {
+	value := c.QueryParam("depth")
+	if value == "" {
+		return nil, nil
+	}
+
+	depth, err := strconv.Atoi(value)
+	if err != nil || depth < 0 || depth > r.maxDepth {
+		return nil, fmt.Errorf("%w: depth must be between 0 and %d", ErrInvalidQueryParam, r.maxDepth)
+	}
+
+	return qm.Where(depthClause, depth), nil
+}
+
+// parseExcludeSubtreeFilter parses the repeated exclude_subtree query
+// parameter, returning a query mod removing each listed tenant and its
+// descendants. Subtrees are matched using the materialized paths, so tenants
+// without a path are not excluded until a reindex. No query mod is returned
+// when the parameter is not provided.
+func (r *Router) parseExcludeSubtreeFilter(c echo.Context) (qm.QueryMod, error) {
+	values := c.QueryParams()["exclude_subtree"]
+
+	switch {
+	case len(values) == 0:
+		return nil, nil
+	case len(values) > maxExcludedSubtrees:
+		return nil, fmt.Errorf("%w: at most %d exclude_subtree values may be provided", ErrInvalidQueryParam, maxExcludedSubtrees)
+	}
+
+	clauses := make([]string, len(values))
+	args := make([]interface{}, len(values))
+
+	for i, value := range values {
+		id, err := r.parseTenantID(value)
+		if err != nil {
+			return nil, err
+		}
+
+		clauses[i] = excludedSubtreeClause
+		args[i] = "%" + escapeLike(tenantPathSeparator+string(id)+tenantPathSeparator) + "%"
+	}
+
+	return qm.Where("NOT ("+strings.Join(clauses, " OR ")+")", args...), nil
+}
+
+// parseIDRangeFilter parses the optional id_gte and id_lt query parameters,
+// returning query mods selecting the tenants whose IDs are in the lexical
+// range [id_gte, id_lt) of the primary key. Adjacent ranges don't overlap,
+// so tenants may be scanned in parallel shards.
+func (r *Router) parseIDRangeFilter(c echo.Context) ([]qm.QueryMod, error) {
+	var (
+		mods     []qm.QueryMod
+		from, to string
+	)
+
+	if value := c.QueryParam("id_gte"); value != "" {
+		id, err := r.parseTenantID(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: id_gte must be a tenant id", ErrInvalidQueryParam)
+		}
+
+		from = string(id)
+
+		mods = append(mods, models.TenantWhere.ID.GTE(id))
+	}
+
+	if value := c.QueryParam("id_lt"); value != "" {
+		id, err := r.parseTenantID(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: id_lt must be a tenant id", ErrInvalidQueryParam)
+		}
+
+		to = string(id)
+
+		mods = append(mods, models.TenantWhere.ID.LT(id))
+	}
+
+	if from != "" && to != "" && from >= to {
+		return nil, fmt.Errorf("%w: id_gte must be less than id_lt", ErrInvalidQueryParam)
+	}
+
+	return mods, nil
+}
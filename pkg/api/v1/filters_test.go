@@ -0,0 +1,50 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobToLike(t *testing.T) {
+	for _, tc := range []struct {
+		glob string
+		like string
+	}{
+		{glob: "prod-*", like: `prod-%`},
+		{glob: "prod-?", like: `prod-_`},
+		{glob: "*-db-??", like: `%-db-__`},
+		{glob: "tenant", like: `tenant`},
+		{glob: "100%", like: `100\%`},
+		{glob: "prod_*", like: `prod\_%`},
+		{glob: `back\slash`, like: `backslash`},
+		{glob: `literal\*`, like: `literal*`},
+		{glob: `literal\?`, like: `literal?`},
+		{glob: `\\`, like: `\\`},
+		{glob: `trailing\`, like: `trailing\\`},
+		{glob: `\%`, like: `\%`},
+		{glob: "ünïcode-*", like: `ünïcode-%`},
+	} {
+		assert.Equal(t, tc.like, globToLike(tc.glob), "unexpected LIKE pattern for %q", tc.glob)
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		like  string
+	}{
+		{value: "tenant", like: `tenant`},
+		{value: "100%", like: `100\%`},
+		{value: "prod_db", like: `prod\_db`},
+		{value: `back\slash`, like: `back\\slash`},
+		{value: `%_\`, like: `\%\_\\`},
+	} {
+		assert.Equal(t, tc.like, escapeLike(tc.value), "unexpected LIKE pattern for %q", tc.value)
+	}
+}
+
+func TestSubtreePathPattern(t *testing.T) {
+	assert.Equal(t, `tnntten-a.tnntten-b.%`, subtreePathPattern("tnntten-a.tnntten-b"))
+	assert.Equal(t, `tnntten-a\_b.%`, subtreePathPattern("tnntten-a_b"), "expected wildcards in the path to be escaped")
+}
@@ -1,26 +1,64 @@
 package api
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/labstack/echo/v4"
 	"go.infratographer.com/x/gidx"
 )
 
-// parseID parses and validates a GID from the request path if the path param is found
-func parseID(c echo.Context, path string) (gidx.PrefixedID, error) {
+// parseID parses and validates a tenant GID from the request path if the path
+// param is found. The GID must have the configured tenant prefix.
+func (r *Router) parseID(c echo.Context, path string) (gidx.PrefixedID, error) {
 	var id string
 	if err := echo.PathParamsBinder(c).String(path, &id).BindError(); err != nil {
 		return "", err
 	}
 
-	if id != "" {
-		gid, err := gidx.Parse(id)
+	if id == "" {
+		return "", ErrIDNotFound
+	}
+
+	return r.parseTenantID(id)
+}
+
+// parseQueryID parses and validates a required tenant GID from the query
+// param. The GID must have the configured tenant prefix.
+func (r *Router) parseQueryID(c echo.Context, param string) (gidx.PrefixedID, error) {
+	id := c.QueryParam(param)
+	if id == "" {
+		return "", fmt.Errorf("%w: %s is required", ErrInvalidQueryParam, param)
+	}
+
+	return r.parseTenantID(id)
+}
+
+// parseTenantID validates the provided GID has the configured tenant prefix.
+func (r *Router) parseTenantID(id string) (gidx.PrefixedID, error) {
+	gid, err := parseGID(id)
+	if err != nil {
+		return "", err
+	}
+
+	if gid.Prefix() != r.idPrefix {
+		return "", ErrInvalidID
+	}
+
+	return gid, nil
+}
 
-		if err != nil {
-			return "", ErrInvalidID
-		}
+// parseGID validates the provided GID.
+func parseGID(id string) (gidx.PrefixedID, error) {
+	// gidx.Parse panics on values without a separator matching the prefix length.
+	if !strings.Contains(id, "-") {
+		return "", ErrInvalidID
+	}
 
-		return gid, nil
+	gid, err := gidx.Parse(id)
+	if err != nil {
+		return "", ErrInvalidID
 	}
 
-	return "", ErrIDNotFound
+	return gid, nil
 }
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// importPathSuffix is the path suffix of the import route. The IDs of an
+// imported document are only references within the document, as the
+// tenants are assigned new IDs, so they aren't checked.
+const importPathSuffix = "/tenants/import"
+
+// idPrefixFields are the request body fields holding tenant IDs, at any level.
+var idPrefixFields = map[string]bool{
+	"id":               true,
+	"ids":              true,
+	"new_parent_id":    true,
+	"parent_tenant_id": true,
+	"tenant_id":        true,
+}
+
+// idPrefixSkippedFields are the request body fields which are not walked, as
+// their keys are chosen by clients.
+var idPrefixSkippedFields = map[string]bool{
+	"labels": true,
+}
+
+// requireIDPrefix rejects requests with an ID of another prefix in a path
+// param or a JSON body field holding tenant IDs, when strict ID prefixes are
+// enabled. Values which aren't formatted as IDs are left to the handlers to
+// validate, and bodies which can't be decoded are left to be rejected when
+// they are bound.
+func (r *Router) requireIDPrefix(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !r.strictIDPrefix {
+			return next(c)
+		}
+
+		for _, name := range c.ParamNames() {
+			if name != "id" && !strings.HasSuffix(name, "_id") {
+				continue
+			}
+
+			if err := r.checkIDPrefix(name, c.Param(name)); err != nil {
+				return v1BadRequestResponse(c, err)
+			}
+		}
+
+		if err := r.checkBodyIDPrefixes(c); err != nil {
+			return v1BadRequestResponse(c, err)
+		}
+
+		return next(c)
+	}
+}
+
+// checkIDPrefix returns ErrWrongIDPrefix when the value is an ID of another prefix.
+func (r *Router) checkIDPrefix(field, value string) error {
+	gid, err := parseGID(value)
+	if err != nil {
+		return nil //nolint:nilerr // Values which aren't IDs are validated by the handlers.
+	}
+
+	if gid.Prefix() != r.idPrefix {
+		return fmt.Errorf("%w: %s %s must have prefix %s", ErrWrongIDPrefix, field, value, r.idPrefix)
+	}
+
+	return nil
+}
+
+// checkBodyIDPrefixes checks the tenant ID fields of the request's JSON
+// body. The body is read in full and then restored for the handler.
+func (r *Router) checkBodyIDPrefixes(c echo.Context) error {
+	req := c.Request()
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+
+	if req.ContentLength == 0 || req.Body == nil ||
+		!strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) ||
+		strings.HasSuffix(c.Path(), importPathSuffix) {
+		return nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidRequestBody, err)
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	var doc interface{}
+
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil //nolint:nilerr // Invalid bodies are rejected when bound.
+	}
+
+	return r.checkValueIDPrefixes("", doc)
+}
+
+// checkValueIDPrefixes walks the decoded JSON value, checking the strings
+// held by tenant ID fields, either directly or in an array. Object fields
+// are walked in order, so the first wrong ID reported is stable. Labels
+// aren't walked.
+func (r *Router) checkValueIDPrefixes(field string, value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		if idPrefixFields[field] {
+			return r.checkIDPrefix(field, v)
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := r.checkValueIDPrefixes(field, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+
+		for key := range v {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			// Fields are checked in either naming strategy.
+			field := snakeCase(key)
+
+			if idPrefixSkippedFields[field] {
+				continue
+			}
+
+			if err := r.checkValueIDPrefixes(field, v[key]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
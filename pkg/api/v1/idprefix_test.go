@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/x/gidx"
+)
+
+func TestRequireIDPrefix(t *testing.T) {
+	tenantID := string(gidx.MustNewID(TenantIDPrefix))
+	otherID := string(gidx.MustNewID("testing"))
+
+	serve := func(strict bool, method, path, id, body string) (*httptest.ResponseRecorder, string) {
+		r := NewRouter(nil, nil, WithStrictIDPrefix(strict))
+
+		req := httptest.NewRequest(method, "/", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		rec := httptest.NewRecorder()
+
+		c := echo.New().NewContext(req, rec)
+		c.SetPath(path)
+
+		if id != "" {
+			c.SetParamNames("id")
+			c.SetParamValues(id)
+		}
+
+		var handled string
+
+		_ = r.requireIDPrefix(func(c echo.Context) error {
+			b, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return err
+			}
+
+			handled = string(b)
+
+			return c.NoContent(http.StatusOK)
+		})(c)
+
+		return rec, handled
+	}
+
+	t.Run("wrong path prefix", func(t *testing.T) {
+		rec, _ := serve(true, http.MethodGet, "/v1/tenants/:id", otherID, "")
+		require.Equal(t, http.StatusBadRequest, rec.Code, "expected wrong prefix to be rejected")
+
+		var result v1ErrorResponseBody
+
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		assert.Contains(t, result.Error, ErrWrongIDPrefix.Error(), "unexpected error returned")
+	})
+
+	t.Run("name path", func(t *testing.T) {
+		rec, _ := serve(true, http.MethodGet, "/v1/tenants/:id", "t1.t1a", "")
+		assert.Equal(t, http.StatusOK, rec.Code, "expected name paths to be handled")
+	})
+
+	t.Run("tenant prefix", func(t *testing.T) {
+		body := fmt.Sprintf(`{"parent_tenant_id": %q}`, tenantID)
+
+		rec, handled := serve(true, http.MethodPatch, "/v1/tenants/:id", tenantID, body)
+		assert.Equal(t, http.StatusOK, rec.Code, "expected tenant ids to be handled")
+		assert.Equal(t, body, handled, "expected body to be restored for the handler")
+	})
+
+	bodies := map[string]string{
+		"parent":      `{"name": "new", "parent_tenant_id": %q}`,
+		"batch get":   `{"ids": ["` + tenantID + `", %q]}`,
+		"batch move":  `{"moves": [{"id": %q}]}`,
+		"new parent":  `{"new_parent_id": %q}`,
+		"repair":      `{"tenant_id": %q}`,
+		"description": `{"updates": [{"id": %q, "description": null}]}`,
+		"camel case":  `{"name": "new", "parentTenantId": %q}`,
+	}
+
+	for name, body := range bodies {
+		t.Run("wrong body prefix "+name, func(t *testing.T) {
+			rec, handled := serve(true, http.MethodPost, "/v1/tenants", "", fmt.Sprintf(body, otherID))
+			assert.Equal(t, http.StatusBadRequest, rec.Code, "expected wrong prefix to be rejected")
+			assert.Empty(t, handled, "expected request not to be handled")
+		})
+	}
+
+	t.Run("labels", func(t *testing.T) {
+		rec, _ := serve(true, http.MethodPost, "/v1/tenants", "", fmt.Sprintf(`{"name": "new", "labels": {"id": %q}}`, otherID))
+		assert.Equal(t, http.StatusOK, rec.Code, "expected labels not to be checked")
+	})
+
+	t.Run("import", func(t *testing.T) {
+		rec, _ := serve(true, http.MethodPost, "/v1/tenants/import", "", fmt.Sprintf(`{"tenants": [{"id": %q}]}`, otherID))
+		assert.Equal(t, http.StatusOK, rec.Code, "expected imported ids not to be checked")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		rec, _ := serve(false, http.MethodPost, "/v1/tenants/:id", otherID, fmt.Sprintf(`{"parent_tenant_id": %q}`, otherID))
+		assert.Equal(t, http.StatusOK, rec.Code, "expected ids not to be checked")
+	})
+}
@@ -0,0 +1,368 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/tenant-api/internal/pubsub"
+	"go.infratographer.com/tenant-api/internal/x/nullx"
+	"go.infratographer.com/x/gidx"
+	"go.uber.org/zap"
+)
+
+// importPlan describes the tenants to be created by an import.
+type importPlan struct {
+	// tenants are the tenants to create, ordered so parents are created before their children.
+	tenants []*models.Tenant
+
+	// labels are the labels of the tenants to create, keyed by new tenant ID.
+	labels map[gidx.PrefixedID]map[string]string
+
+	// ids maps the exported tenant IDs to the IDs of the tenants created.
+	ids map[gidx.PrefixedID]gidx.PrefixedID
+}
+
+// tenantImport recreates the subtree described by a tenantExport document
+// under the tenant provided as `parent_id`, or as a new root tenant when no
+// parent is provided. All tenants are created with new IDs, preserving the
+// relationships between the tenants in the document.
+//
+// The import is performed within a single transaction and create events are
+// published for every tenant once committed. When `dry_run` is true the
+// document is validated, including the checks of tenant creates such as name
+// conflicts, and the tenants which would be created are returned without
+// writing anything.
+func (r *Router) tenantImport(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantImport")
+	defer span.End()
+
+	var (
+		dryRun   bool
+		parentID gidx.PrefixedID
+		err      error
+	)
+
+	if value := c.QueryParam("dry_run"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			return v1BadRequestResponse(c, fmt.Errorf("%w: dry_run must be true or false", ErrInvalidQueryParam))
+		}
+	}
+
+	if value := c.QueryParam("parent_id"); value != "" {
+		if parentID, err = r.parseTenantID(value); err != nil {
+			return v1BadRequestResponse(c, err)
+		}
+	}
+
+	doc := new(tenantExport)
+
+	if err := bindRequest(c, doc); err != nil {
+		r.requestLogger(c).Error("failed to bind tenant import request", zap.Error(err))
+
+		return v1BadRequestResponse(c, err)
+	}
+
+	if err := doc.validate(); err != nil {
+		r.requestLogger(c).Error("invalid tenant import request", zap.Error(err))
+
+		return v1BadRequestResponse(c, err)
+	}
+
+	if err := doc.checkChildLimit(r.maxChildren); err != nil {
+		return v1ChildLimitExceededResponse(c, err)
+	}
+
+	var (
+		plan  *importPlan
+		actor = r.actor(c)
+	)
+
+	err = r.inTx(ctx, func(tx *sql.Tx) error {
+		var (
+			parentPath string
+			err        error
+		)
+
+		if parentID != "" {
+			if parentPath, err = r.lookupTenantPath(ctx, tx, parentID); err != nil {
+				return err
+			}
+
+			if err := r.checkChildLimit(ctx, tx, parentID); err != nil {
+				return err
+			}
+		}
+
+		if plan, err = doc.plan(r.idPrefix, parentID, parentPath, time.Now().UTC()); err != nil {
+			return fmt.Errorf("planning tenant import: %w", err)
+		}
+
+		// Names are checked within the document too, as none of its tenants
+		// exist yet.
+		names := make(map[string]bool, len(plan.tenants))
+
+		for _, t := range plan.tenants {
+			t.CreatedBy = actorColumn(actor)
+			t.UpdatedBy = actorColumn(actor)
+
+			if err := r.checkNamePattern(t.Name); err != nil {
+				return err
+			}
+
+			if err := r.checkNameResolvable(t.Name); err != nil {
+				return err
+			}
+
+			if err := r.checkKind(t.Kind.Ptr()); err != nil {
+				return err
+			}
+
+			name := t.Name
+			if !r.globalNames {
+				name = string(t.ParentTenantID.PrefixedID) + tenantPathSeparator + t.Name
+			}
+
+			if names[name] {
+				return fmt.Errorf("%w: %q already exists", ErrNameConflict, t.Name)
+			}
+
+			names[name] = true
+
+			if err := r.checkImportNameConflict(ctx, tx, t); err != nil {
+				return err
+			}
+
+			if err := r.validateCreate(ctx, t); err != nil {
+				return err
+			}
+		}
+
+		// Dry runs are validated without inserting anything.
+		if dryRun {
+			return nil
+		}
+
+		for _, t := range plan.tenants {
+			if err := t.Insert(ctx, tx, boil.Infer()); err != nil {
+				if isUniqueViolation(err) {
+					return fmt.Errorf("%w: %q already exists", ErrNameConflict, t.Name)
+				}
+
+				return fmt.Errorf("inserting imported tenant: %w", err)
+			}
+
+			for key, value := range plan.labels[t.ID] {
+				if _, err := tx.ExecContext(ctx, setLabelQuery, t.ID, key, value, t.CreatedAt); err != nil {
+					return fmt.Errorf("inserting imported tenant label: %w", err)
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return r.tenantCreateErrorResponse(c, parentID, "", false, err)
+	}
+
+	if dryRun {
+		return v1TenantImportResponse(c, http.StatusOK, plan, true)
+	}
+
+	for _, t := range plan.tenants {
+		var additionalGID []gidx.PrefixedID
+
+		if t.ParentTenantID.Valid {
+			additionalGID = append(additionalGID, t.ParentTenantID.PrefixedID)
+		}
+
+		msg, err := pubsub.NewTenantMessage(
+			gidx.PrefixedID(actor),
+			t.ID,
+			additionalGID...,
+		)
+		if err != nil {
+			// TODO: add status to reconcile and requeue this
+			r.requestLogger(c).Error("failed to create tenant message", zap.Error(err))
+		}
+
+		if err := r.pubsub.PublishCreate(ctx, "tenants", "global", msg); err != nil {
+			// TODO: add status to reconcile and requeue this
+			r.requestLogger(c).Error("failed to publish tenant message", zap.Error(err))
+		}
+	}
+
+	return v1TenantImportResponse(c, http.StatusCreated, plan, false)
+}
+
+// checkImportNameConflict returns ErrNameConflict when an imported tenant's
+// name conflicts with an existing tenant. Unlike other creates, an imported
+// root tenant also conflicts with an existing root tenant of the same name.
+func (r *Router) checkImportNameConflict(ctx context.Context, exec boil.ContextExecutor, t *models.Tenant) error {
+	if t.ParentTenantID.Valid || r.globalNames {
+		return r.checkNameConflict(ctx, exec, t.ID, t.ParentTenantID.PrefixedID, t.Name)
+	}
+
+	// The unique index doesn't cover root tenants, as their parent is null.
+	exists, err := models.Tenants(
+		models.TenantWhere.Name.EQ(t.Name),
+		models.TenantWhere.ParentTenantID.IsNull(),
+	).Exists(ctx, exec)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return fmt.Errorf("%w: %q already exists", ErrNameConflict, t.Name)
+	}
+
+	return nil
+}
+
+// validate ensures the document describes a single subtree, with every
+// tenant following its parent.
+func (e *tenantExport) validate() error {
+	if e.Version != exportFormatVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidImport, e.Version)
+	}
+
+	if len(e.Tenants) == 0 {
+		return fmt.Errorf("%w: no tenants", ErrInvalidImport)
+	}
+
+	if root := e.Tenants[0]; root.ID != e.RootID || root.ParentTenantID != nil {
+		return fmt.Errorf("%w: first tenant must be the root tenant %s", ErrInvalidImport, e.RootID)
+	}
+
+	seen := make(map[gidx.PrefixedID]bool, len(e.Tenants))
+
+	for i, t := range e.Tenants {
+		if err := validateTenantName(t.Name); err != nil {
+			return fmt.Errorf("%w: %w: %s", ErrInvalidImport, err, t.ID)
+		}
+
+		if err := validateDescription(t.Description); err != nil {
+			return fmt.Errorf("%w: %w: %s", ErrInvalidImport, err, t.ID)
+		}
+
+		if err := validateQuota(t.Quota); err != nil {
+			return fmt.Errorf("%w: %w: %s", ErrInvalidImport, err, t.ID)
+		}
+
+		keys := make([]string, 0, len(t.Labels))
+
+		for key := range t.Labels {
+			keys = append(keys, key)
+		}
+
+		// Labels are reported in a stable order.
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if err := validateLabelKey(key); err != nil {
+				return fmt.Errorf("%w: %w: %s", ErrInvalidImport, err, t.ID)
+			}
+		}
+
+		if seen[t.ID] {
+			return fmt.Errorf("%w: duplicate tenant %s", ErrInvalidImport, t.ID)
+		}
+
+		if i > 0 {
+			if t.ParentTenantID == nil {
+				return fmt.Errorf("%w: tenant %s has no parent", ErrInvalidImport, t.ID)
+			}
+
+			if !seen[*t.ParentTenantID] {
+				return fmt.Errorf("%w: parent of tenant %s must precede it", ErrInvalidImport, t.ID)
+			}
+		}
+
+		seen[t.ID] = true
+	}
+
+	return nil
+}
+
+// checkChildLimit returns ErrChildLimitExceeded when a tenant in the document
+// has more than the maximum number of direct children.
+func (e *tenantExport) checkChildLimit(maxChildren int) error {
+	if maxChildren <= 0 {
+		return nil
+	}
+
+	children := make(map[gidx.PrefixedID]int)
+
+	for _, t := range e.Tenants {
+		if t.ParentTenantID == nil {
+			continue
+		}
+
+		children[*t.ParentTenantID]++
+
+		if children[*t.ParentTenantID] > maxChildren {
+			return fmt.Errorf("%w: %s has more than %d children", ErrChildLimitExceeded, *t.ParentTenantID, maxChildren)
+		}
+	}
+
+	return nil
+}
+
+// plan assigns new IDs with the provided prefix to the tenants in a validated
+// document, placing the root tenant under the provided parent.
+func (e *tenantExport) plan(idPrefix string, parentID gidx.PrefixedID, parentPath string, now time.Time) (*importPlan, error) {
+	plan := &importPlan{
+		labels: make(map[gidx.PrefixedID]map[string]string),
+		ids:    make(map[gidx.PrefixedID]gidx.PrefixedID, len(e.Tenants)),
+	}
+
+	paths := make(map[gidx.PrefixedID]string, len(e.Tenants))
+
+	for _, et := range e.Tenants {
+		id, err := gidx.NewID(idPrefix)
+		if err != nil {
+			return nil, err
+		}
+
+		t := &models.Tenant{
+			ID:          id,
+			Name:        et.Name,
+			Description: null.StringFromPtr(et.Description),
+			Quota:       null.Int64FromPtr(et.Quota),
+			Kind:        null.StringFromPtr(et.Kind),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+
+		if et.ParentTenantID != nil {
+			newParentID := plan.ids[*et.ParentTenantID]
+
+			t.ParentTenantID = nullx.PrefixedIDFrom(newParentID)
+			t.Path = tenantPath(paths[newParentID], id)
+		} else {
+			if parentID != "" {
+				t.ParentTenantID = nullx.PrefixedIDFrom(parentID)
+			}
+
+			t.Path = tenantPath(parentPath, id)
+		}
+
+		plan.ids[et.ID] = id
+		plan.tenants = append(plan.tenants, t)
+		paths[id] = t.Path
+
+		if len(et.Labels) != 0 {
+			plan.labels[id] = et.Labels
+		}
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"go.infratographer.com/x/gidx"
+	"go.uber.org/zap"
+)
+
+const (
+	// missingParentsQuery finds the tenants whose parent does not exist.
+	missingParentsQuery = `
+		SELECT t.id
+		FROM tenants t
+		LEFT JOIN tenants p ON p.id = t.parent_tenant_id
+		WHERE t.parent_tenant_id IS NOT NULL AND p.id IS NULL
+		ORDER BY t.id
+	`
+
+	// cyclicTenantsQuery finds the tenants which are their own ancestor, by
+	// walking the parent pointers of every tenant, or only of the tenant $2
+	// when provided, until the root, the tenant itself or $1 levels are
+	// reached. Tenants whose ancestry only leads into a cycle stop at the
+	// limit without being reported.
+	cyclicTenantsQuery = `
+		WITH RECURSIVE ancestry AS (
+			SELECT id AS tenant_id, parent_tenant_id AS ancestor_id, 1 AS depth
+			FROM tenants
+			WHERE
+				parent_tenant_id IS NOT NULL
+				AND ($2::STRING IS NULL OR id = $2)
+
+			UNION ALL
+
+			SELECT a.tenant_id, t.parent_tenant_id, a.depth + 1
+			FROM tenants t
+			INNER JOIN ancestry a ON t.id = a.ancestor_id
+			WHERE
+				t.parent_tenant_id IS NOT NULL
+				AND a.ancestor_id <> a.tenant_id
+				AND a.depth < $1
+		)
+		SELECT DISTINCT tenant_id
+		FROM ancestry
+		WHERE ancestor_id = tenant_id
+		ORDER BY tenant_id
+	`
+)
+
+// tenantIntegrityReport lists the tenants whose parent pointers break the
+// hierarchy.
+type tenantIntegrityReport struct {
+	// MissingParents are the tenants whose parent does not exist.
+	MissingParents []gidx.PrefixedID `json:"missing_parents"`
+	// Cycles are the tenants which are their own ancestor.
+	Cycles []gidx.PrefixedID `json:"cycles"`
+}
+
+// tenantIntegrity scans the parent pointers of all tenants, including
+// deleted tenants, reporting tenants whose parent does not exist and
+// tenants which are part of a cycle. Both break the recursive hierarchy
+// queries. The report is a diagnostic, the tenants are left as is.
+func (r *Router) tenantIntegrity(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantIntegrity")
+	defer span.End()
+
+	missing, err := queryTenantIDs(ctx, r.db, missingParentsQuery)
+	if err != nil {
+		r.requestLogger(c).Error("failed to query tenants with missing parents", zap.Error(err))
+
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	cycles, err := queryTenantIDs(ctx, r.db, cyclicTenantsQuery, r.maxDepth, nil)
+	if err != nil {
+		r.requestLogger(c).Error("failed to query cyclic tenants", zap.Error(err))
+
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	if len(missing) != 0 || len(cycles) != 0 {
+		r.requestLogger(c).Warn("tenant hierarchy integrity issues found",
+			zap.Int("integrity.missing_parents", len(missing)),
+			zap.Int("integrity.cycles", len(cycles)),
+		)
+	}
+
+	return v1TenantIntegrityResponse(c, tenantIntegrityReport{
+		MissingParents: missing,
+		Cycles:         cycles,
+	})
+}
+
+// queryTenantIDs runs a query selecting tenant IDs, returning an empty
+// slice rather than nil when no tenants match.
+func queryTenantIDs(ctx context.Context, exec boil.ContextExecutor, query string, args ...interface{}) ([]gidx.PrefixedID, error) {
+	rows, err := exec.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ids := []gidx.PrefixedID{}
+
+	for rows.Next() {
+		var id gidx.PrefixedID
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
@@ -0,0 +1,397 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/tenant-api/internal/pubsub"
+	"go.infratographer.com/x/gidx"
+	"go.uber.org/zap"
+)
+
+// maxLabelKeyLength is the maximum length of a label key.
+const maxLabelKeyLength = 128
+
+// labelKeyPattern matches the supported label keys, which start and end with
+// an alphanumeric character and may contain dots, dashes, underscores and
+// slashes in between.
+var labelKeyPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]*[A-Za-z0-9])?$`)
+
+const (
+	labelsQuery = `
+		SELECT key, value
+		FROM tenant_labels
+		WHERE tenant_id = $1
+		ORDER BY key
+	`
+	labelValueQuery = `
+		SELECT value
+		FROM tenant_labels
+		WHERE tenant_id = $1 AND key = $2
+		FOR UPDATE
+	`
+	setLabelQuery = `
+		INSERT INTO tenant_labels (tenant_id, key, value, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (tenant_id, key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+	`
+	subtreeLabelValuesQuery = `
+		SELECT tenant_id, value
+		FROM tenant_labels
+		WHERE tenant_id = ANY($1) AND key = $2
+		FOR UPDATE
+	`
+	deleteLabelQuery = `
+		DELETE FROM tenant_labels
+		WHERE tenant_id = $1 AND key = $2
+		RETURNING value
+	`
+)
+
+func (r *Router) tenantLabelsList(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantLabelsList")
+	defer span.End()
+
+	tenantID, err := r.parseID(c, "id")
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	if _, err := models.FindTenant(ctx, r.db, tenantID, models.TenantColumns.ID); err != nil {
+		return r.tenantQueryErrorResponse(c, err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, labelsQuery, tenantID)
+	if err != nil {
+		r.requestLogger(c).Error("failed to query tenant labels", zap.Error(err))
+
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	defer rows.Close()
+
+	labels := make(map[string]string)
+
+	for rows.Next() {
+		var key, value string
+
+		if err := rows.Scan(&key, &value); err != nil {
+			return v1InternalServerErrorResponse(c, err)
+		}
+
+		labels[key] = value
+	}
+
+	if err := rows.Err(); err != nil {
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	return v1TenantLabelsResponse(c, labels)
+}
+
+func (r *Router) tenantLabelSet(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantLabelSet")
+	defer span.End()
+
+	tenantID, err := r.parseID(c, "id")
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	if _, err := models.FindTenant(ctx, r.db, tenantID, models.TenantColumns.ID); err != nil {
+		return r.tenantQueryErrorResponse(c, err)
+	}
+
+	key := c.Param("key")
+
+	if err := validateLabelKey(key); err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	payload := new(setLabelRequest)
+
+	if err := bindRequest(c, payload); err != nil {
+		r.requestLogger(c).Error("failed to bind set label request", zap.Error(err))
+
+		return v1BadRequestResponse(c, err)
+	}
+
+	if err := payload.validate(); err != nil {
+		r.requestLogger(c).Error("invalid set label request", zap.Error(err))
+
+		return v1BadRequestResponse(c, err)
+	}
+
+	var (
+		previous  string
+		unchanged bool
+	)
+
+	err = r.inTx(ctx, func(tx *sql.Tx) error {
+		previous, unchanged = "", false
+
+		err := tx.QueryRowContext(ctx, labelValueQuery, tenantID, key).Scan(&previous)
+
+		switch {
+		case err == nil && previous == *payload.Value:
+			// Setting a label to its current value is a no-op and emits no event.
+			unchanged = true
+
+			return nil
+		case err != nil && !errors.Is(err, sql.ErrNoRows):
+			return fmt.Errorf("querying tenant label: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, setLabelQuery, tenantID, key, *payload.Value, time.Now().UTC())
+
+		return err
+	})
+	if err != nil {
+		r.requestLogger(c).Error("failed to set tenant label", zap.Error(err))
+
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	if unchanged {
+		return v1TenantLabelResponse(c, key, previous)
+	}
+
+	r.publishLabelChange(ctx, c, tenantID, key, previous, *payload.Value)
+
+	return v1TenantLabelResponse(c, key, *payload.Value)
+}
+
+func (r *Router) tenantLabelDelete(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantLabelDelete")
+	defer span.End()
+
+	tenantID, err := r.parseID(c, "id")
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	if _, err := models.FindTenant(ctx, r.db, tenantID, models.TenantColumns.ID); err != nil {
+		return r.tenantQueryErrorResponse(c, err)
+	}
+
+	key := c.Param("key")
+
+	var previous string
+
+	if err := r.db.QueryRowContext(ctx, deleteLabelQuery, tenantID, key).Scan(&previous); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return v1LabelNotFoundResponse(c, ErrLabelNotFound)
+		}
+
+		r.requestLogger(c).Error("failed to delete tenant label", zap.Error(err))
+
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	r.publishLabelChange(ctx, c, tenantID, key, previous, "")
+
+	return nil
+}
+
+// subtreeLabelChange is a tenant whose label is changed by setting a label
+// on its subtree, with the label's previous value.
+type subtreeLabelChange struct {
+	tenantID gidx.PrefixedID
+	previous string
+}
+
+// tenantSubtreeLabelSet sets a label on the tenant and all of its
+// descendants in a single transaction, emitting an update event for each
+// tenant whose label changed. Tenants already labelled with the value are
+// left unchanged. The subtree is bounded by the maximum depth and result
+// size. When `dry_run` is true, the tenants which would be changed are
+// returned without writing anything.
+func (r *Router) tenantSubtreeLabelSet(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantSubtreeLabelSet")
+	defer span.End()
+
+	tenantID, err := r.parseID(c, "id")
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	var dryRun bool
+
+	if value := c.QueryParam("dry_run"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			return v1BadRequestResponse(c, fmt.Errorf("%w: dry_run must be true or false", ErrInvalidQueryParam))
+		}
+	}
+
+	payload := new(subtreeLabelRequest)
+
+	if err := bindRequest(c, payload); err != nil {
+		r.requestLogger(c).Error("failed to bind subtree label request", zap.Error(err))
+
+		return v1BadRequestResponse(c, err)
+	}
+
+	if err := payload.validate(); err != nil {
+		r.requestLogger(c).Error("invalid subtree label request", zap.Error(err))
+
+		return v1BadRequestResponse(c, err)
+	}
+
+	var changes []subtreeLabelChange
+
+	err = r.inTx(ctx, func(tx *sql.Tx) error {
+		changes = nil
+
+		subtree, err := r.walkSubtree(ctx, tx, tenantID)
+		if err != nil {
+			return err
+		}
+
+		previous, err := subtreeLabelValues(ctx, tx, subtree.Tenants, payload.Key)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range subtree.Tenants {
+			if value, ok := previous[id]; ok && value == *payload.Value {
+				continue
+			}
+
+			changes = append(changes, subtreeLabelChange{tenantID: id, previous: previous[id]})
+		}
+
+		if dryRun {
+			return nil
+		}
+
+		now := time.Now().UTC()
+
+		for _, change := range changes {
+			if _, err := tx.ExecContext(ctx, setLabelQuery, change.tenantID, payload.Key, *payload.Value, now); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return v1TenantNotFoundResponse(c, err)
+		case errors.Is(err, ErrMaxDepthExceeded):
+			return r.maxDepthExceededResponse(c, tenantID)
+		case errors.Is(err, ErrResultTooLarge):
+			return v1ResultTooLargeResponse(c, err)
+		}
+
+		r.requestLogger(c).Error("failed to set subtree label", zap.Error(err))
+
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	ids := make([]gidx.PrefixedID, len(changes))
+
+	for i, change := range changes {
+		ids[i] = change.tenantID
+
+		if !dryRun {
+			r.publishLabelChange(ctx, c, change.tenantID, payload.Key, change.previous, *payload.Value)
+		}
+	}
+
+	return v1TenantSubtreeLabelResponse(c, tenantID, payload.Key, *payload.Value, ids, dryRun)
+}
+
+// subtreeLabelValues locks and returns the values of the label on the
+// tenants which have it.
+func subtreeLabelValues(ctx context.Context, exec boil.ContextExecutor, ids []gidx.PrefixedID, key string) (map[gidx.PrefixedID]string, error) {
+	params := make([]string, len(ids))
+
+	for i, id := range ids {
+		params[i] = string(id)
+	}
+
+	rows, err := exec.QueryContext(ctx, subtreeLabelValuesQuery, pq.Array(params), key)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	values := make(map[gidx.PrefixedID]string)
+
+	for rows.Next() {
+		var (
+			id    gidx.PrefixedID
+			value string
+		)
+
+		if err := rows.Scan(&id, &value); err != nil {
+			return nil, err
+		}
+
+		values[id] = value
+	}
+
+	return values, rows.Err()
+}
+
+// validateLabelKey ensures the label key is supported.
+func validateLabelKey(key string) error {
+	if len(key) > maxLabelKeyLength || !labelKeyPattern.MatchString(key) {
+		return fmt.Errorf("%w: %q", ErrInvalidLabelKey, key)
+	}
+
+	return nil
+}
+
+// insertLabels sets the labels of a newly created tenant, ordered by key.
+func insertLabels(ctx context.Context, exec boil.ContextExecutor, tenantID gidx.PrefixedID, labels map[string]string, now time.Time) error {
+	keys := make([]string, 0, len(labels))
+
+	for key := range labels {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if _, err := exec.ExecContext(ctx, setLabelQuery, tenantID, key, labels[key], now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// publishLabelChange emits an update event for the tenant carrying the changed label.
+func (r *Router) publishLabelChange(ctx context.Context, c echo.Context, tenantID gidx.PrefixedID, key, previous, current string) {
+	actor := r.actor(c)
+
+	msg, err := pubsub.LabelChangeMessage(
+		gidx.PrefixedID(actor),
+		tenantID,
+		key,
+		previous,
+		current,
+	)
+	if err != nil {
+		// TODO: add status to reconcile and requeue this
+		r.requestLogger(c).Error("failed to create, label change message", zap.Error(err))
+	}
+
+	if err := r.pubsub.PublishUpdate(ctx, "tenants", "global", msg); err != nil {
+		// TODO: add status to reconcile and requeue this
+		r.requestLogger(c).Error("failed to publish, label change message", zap.Error(err))
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+const (
+	// labelKeysQuery counts the live tenants with each label key, ordered by
+	// key, listing $1 keys after the first $2.
+	labelKeysQuery = `
+		SELECT l.key, count(*)
+		FROM tenant_labels l
+		INNER JOIN tenants t ON t.id = l.tenant_id
+		WHERE t.deleted_at IS NULL
+		GROUP BY l.key
+		ORDER BY l.key
+		LIMIT $1 OFFSET $2
+	`
+
+	// labelValuesQuery counts the live tenants with each value of the label
+	// key $1, ordered by value, listing $2 values after the first $3.
+	labelValuesQuery = `
+		SELECT l.value, count(*)
+		FROM tenant_labels l
+		INNER JOIN tenants t ON t.id = l.tenant_id
+		WHERE l.key = $1 AND t.deleted_at IS NULL
+		GROUP BY l.value
+		ORDER BY l.value
+		LIMIT $2 OFFSET $3
+	`
+)
+
+// labelKeyCount is the number of tenants with a label key.
+type labelKeyCount struct {
+	Key   string `json:"key"`
+	Count int64  `json:"count"`
+}
+
+// labelValueCount is the number of tenants with a value of a label key.
+type labelValueCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// tenantLabelKeys responds with the distinct label keys of live tenants and
+// the number of tenants with each, ordered by key.
+func (r *Router) tenantLabelKeys(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantLabelKeys")
+	defer span.End()
+
+	pagination := parsePagination(c)
+
+	rows, err := r.db.QueryContext(ctx, labelKeysQuery, pagination.limitUsed(), pagination.offset())
+	if err != nil {
+		r.requestLogger(c).Error("failed to query label keys", zap.Error(err))
+
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	defer rows.Close()
+
+	keys := []labelKeyCount{}
+
+	for rows.Next() {
+		var key labelKeyCount
+
+		if err := rows.Scan(&key.Key, &key.Count); err != nil {
+			return v1InternalServerErrorResponse(c, err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	return v1TenantLabelKeysResponse(c, keys, pagination)
+}
+
+// tenantLabelValues responds with the distinct values of the required key
+// query parameter's label on live tenants and the number of tenants with
+// each, ordered by value.
+func (r *Router) tenantLabelValues(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantLabelValues")
+	defer span.End()
+
+	pagination := parsePagination(c)
+
+	key := c.QueryParam("key")
+	if key == "" {
+		return v1BadRequestResponse(c, fmt.Errorf("%w: key is required", ErrInvalidQueryParam))
+	}
+
+	if err := validateLabelKey(key); err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, labelValuesQuery, key, pagination.limitUsed(), pagination.offset())
+	if err != nil {
+		r.requestLogger(c).Error("failed to query label values", zap.Error(err))
+
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	defer rows.Close()
+
+	values := []labelValueCount{}
+
+	for rows.Next() {
+		var value labelValueCount
+
+		if err := rows.Scan(&value.Value, &value.Count); err != nil {
+			return v1InternalServerErrorResponse(c, err)
+		}
+
+		values = append(values, value)
+	}
+
+	if err := rows.Err(); err != nil {
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	return v1TenantLabelValuesResponse(c, key, values, pagination)
+}
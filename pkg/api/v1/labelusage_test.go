@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantLabelUsage(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	setLabel := func(t *testing.T, path, key, value string) {
+		t.Helper()
+
+		resp, err := srv.Request(http.MethodPut, path+"/labels/"+key, nil, strings.NewReader(`{"value": "`+value+`"}`), nil)
+		require.NoError(t, err, "no error expected for setting label")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+	}
+
+	first := "/v1/tenants/" + string(srv.createTenant(t, "", "first").ID)
+	second := "/v1/tenants/" + string(srv.createTenant(t, "", "second").ID)
+	third := "/v1/tenants/" + string(srv.createTenant(t, "", "third").ID)
+	deleted := "/v1/tenants/" + string(srv.createTenant(t, "", "deleted").ID)
+
+	setLabel(t, first, "tier", "gold")
+	setLabel(t, first, "region", "east")
+	setLabel(t, second, "tier", "gold")
+	setLabel(t, third, "tier", "silver")
+	setLabel(t, deleted, "tier", "bronze")
+	setLabel(t, deleted, "retired", "true")
+
+	resp, err := srv.Request(http.MethodDelete, deleted, nil, nil, nil)
+	require.NoError(t, err, "no error expected for deleting tenant")
+	resp.Body.Close() //nolint:errcheck // Not needed
+	require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+	t.Run("keys", func(t *testing.T) {
+		var result *v1TenantLabelKeysResponseBody
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/labels/keys", nil, nil, &result)
+		require.NoError(t, err, "no error expected for listing label keys")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		require.NotNil(t, result, "expected label keys result")
+
+		assert.Equal(t, []labelKeyCount{
+			{Key: "region", Count: 1},
+			{Key: "tier", Count: 3},
+		}, result.Keys, "expected the keys of live tenants")
+	})
+
+	t.Run("values", func(t *testing.T) {
+		var result *v1TenantLabelValuesResponseBody
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/labels/values?key=tier", nil, nil, &result)
+		require.NoError(t, err, "no error expected for listing label values")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		require.NotNil(t, result, "expected label values result")
+
+		assert.Equal(t, "tier", result.Key, "unexpected label key")
+		assert.Equal(t, []labelValueCount{
+			{Value: "gold", Count: 2},
+			{Value: "silver", Count: 1},
+		}, result.Values, "expected the values of live tenants")
+	})
+
+	t.Run("values paginated", func(t *testing.T) {
+		var result *v1TenantLabelValuesResponseBody
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/labels/values?key=tier&limit=1&page=2", nil, nil, &result)
+		require.NoError(t, err, "no error expected for listing label values")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		require.NotNil(t, result, "expected label values result")
+
+		assert.Equal(t, []labelValueCount{{Value: "silver", Count: 1}}, result.Values, "expected the second page of values")
+	})
+
+	t.Run("unused key", func(t *testing.T) {
+		var result *v1TenantLabelValuesResponseBody
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/labels/values?key=retired", nil, nil, &result)
+		require.NoError(t, err, "no error expected for listing label values")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		require.NotNil(t, result, "expected label values result")
+
+		assert.Empty(t, result.Values, "expected no values for a key of deleted tenants only")
+	})
+
+	for _, query := range []string{"", "?key=", "?key=-invalid"} {
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/labels/values"+query, nil, nil, nil)
+		require.NoError(t, err, "no error expected for listing label values")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "expected bad request for values query %q", query)
+	}
+}
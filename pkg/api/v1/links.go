@@ -0,0 +1,121 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// headerLink is the header pagination links are set in.
+const headerLink = "Link"
+
+// ParseExternalBaseURL parses the external base URL of the api, such as
+// https://example.com/tenant-api when a reverse proxy serves the api under a
+// path prefix. The URL must be absolute.
+func ParseExternalBaseURL(value string) (*url.URL, error) {
+	base, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidExternalBaseURL, err)
+	}
+
+	if base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("%w: %q must include a scheme and host", ErrInvalidExternalBaseURL, value)
+	}
+
+	if base.RawQuery != "" || base.Fragment != "" {
+		return nil, fmt.Errorf("%w: %q must not include a query or fragment", ErrInvalidExternalBaseURL, value)
+	}
+
+	return base, nil
+}
+
+// setPaginationLinks sets the Link header of a page of a list with the next
+// and previous pages, when there are any. The next page is known to exist
+// from the total when it was counted, and otherwise assumed to exist when
+// the page is full. Pages requested by cursor only link the next page, by
+// the cursor returned for it, as do lists of changes, by the returned
+// sequence number.
+func (r *Router) setPaginationLinks(c echo.Context, pagination PaginationParams, count int, total *int64) {
+	if c.QueryParam("cursor") != "" {
+		if pagination.Cursor != "" {
+			c.Response().Header().Set(headerLink, fmt.Sprintf(`<%s>; rel="next"`, r.cursorURL(c, pagination.Cursor)))
+		}
+
+		return
+	}
+
+	// Lists of changes are continued since the returned sequence number.
+	if c.QueryParam("since_seq") != "" {
+		if pagination.SinceSeq != nil && count == pagination.limitUsed() {
+			query := c.Request().URL.Query()
+			query.Set("since_seq", strconv.FormatInt(*pagination.SinceSeq, 10))
+
+			c.Response().Header().Set(headerLink, fmt.Sprintf(`<%s>; rel="next"`, r.listURL(c, query)))
+		}
+
+		return
+	}
+
+	page := pagination.page()
+
+	hasNext := count == pagination.limitUsed()
+	if total != nil {
+		hasNext = int64(pagination.getPageOffset()+count) < *total
+	}
+
+	var links []string
+
+	if hasNext {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, r.pageURL(c, page+1)))
+	}
+
+	if page > 1 {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, r.pageURL(c, page-1)))
+	}
+
+	if len(links) != 0 {
+		c.Response().Header().Set(headerLink, strings.Join(links, ", "))
+	}
+}
+
+// pageURL returns the URL of the page of the request's list, keeping the
+// request's other query parameters. The URL is relative to the host the
+// request was made to, unless an external base URL is configured, in which
+// case the request path is resolved under the base URL's path.
+func (r *Router) pageURL(c echo.Context, page int) string {
+	query := c.Request().URL.Query()
+	query.Set("page", strconv.Itoa(page))
+
+	return r.listURL(c, query)
+}
+
+// cursorURL returns the URL of the page of the request's list following the
+// cursor, keeping the request's other query parameters.
+func (r *Router) cursorURL(c echo.Context, cursor string) string {
+	query := c.Request().URL.Query()
+	query.Set("cursor", cursor)
+
+	return r.listURL(c, query)
+}
+
+// listURL returns the URL of the request's list with the query.
+func (r *Router) listURL(c echo.Context, query url.Values) string {
+	req := c.Request().URL
+
+	link := url.URL{
+		Path:     req.Path,
+		RawQuery: query.Encode(),
+	}
+
+	if base := r.externalBaseURL; base != nil {
+		link.Scheme = base.Scheme
+		link.User = base.User
+		link.Host = base.Host
+		link.Path = strings.TrimSuffix(base.Path, "/") + req.Path
+	}
+
+	return link.String()
+}
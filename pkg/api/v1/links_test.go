@@ -0,0 +1,107 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExternalBaseURL(t *testing.T) {
+	base, err := ParseExternalBaseURL("https://example.com/tenant-api/")
+	require.NoError(t, err)
+	assert.Equal(t, "example.com", base.Host)
+
+	for _, value := range []string{"/tenant-api", "example.com", "https://example.com/?page=1", "://"} {
+		_, err := ParseExternalBaseURL(value)
+		assert.ErrorIs(t, err, ErrInvalidExternalBaseURL, "expected %q to be rejected", value)
+	}
+}
+
+func TestPaginationLinks(t *testing.T) {
+	links := func(r *Router, target string, count int, total *int64) string {
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, target, nil), httptest.NewRecorder())
+
+		r.setPaginationLinks(c, parsePagination(c), count, total)
+
+		return c.Response().Header().Get(headerLink)
+	}
+
+	total := int64(5)
+
+	t.Run("relative", func(t *testing.T) {
+		r := NewRouter(nil, nil)
+
+		assert.Equal(t,
+			`</v1/tenants?limit=2&page=3>; rel="next", </v1/tenants?limit=2&page=1>; rel="prev"`,
+			links(r, "/v1/tenants?limit=2&page=2", 2, &total),
+		)
+	})
+
+	t.Run("external base url", func(t *testing.T) {
+		base, err := ParseExternalBaseURL("https://example.com/tenant-api/")
+		require.NoError(t, err)
+
+		r := NewRouter(nil, nil, WithExternalBaseURL(base))
+
+		assert.Equal(t,
+			`<https://example.com/tenant-api/v1/tenants?has_children=true&limit=2&page=2>; rel="next"`,
+			links(r, "/v1/tenants?limit=2&has_children=true", 2, &total),
+			"expected the link under the external base url keeping the query",
+		)
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		r := NewRouter(nil, nil)
+
+		assert.Equal(t, `</v1/tenants?limit=2&page=2>; rel="prev"`, links(r, "/v1/tenants?limit=2&page=3", 1, &total))
+		assert.Empty(t, links(r, "/v1/tenants?limit=10", 5, &total), "expected no links for a single page")
+	})
+
+	t.Run("without total", func(t *testing.T) {
+		r := NewRouter(nil, nil)
+
+		assert.Equal(t, `</v1/tenants?limit=2&page=2>; rel="next"`, links(r, "/v1/tenants?limit=2", 2, nil), "expected a next link for a full page")
+		assert.Empty(t, links(r, "/v1/tenants?limit=2", 1, nil), "expected no next link for a partial page")
+	})
+	t.Run("cursor", func(t *testing.T) {
+		r := NewRouter(nil, nil)
+
+		cursorLinks := func(next string) string {
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/v1/tenants?limit=2&cursor=first", nil), httptest.NewRecorder())
+
+			pagination := parsePagination(c)
+			pagination.Cursor = next
+
+			r.setPaginationLinks(c, pagination, 2, &total)
+
+			return c.Response().Header().Get(headerLink)
+		}
+
+		assert.Equal(t, `</v1/tenants?cursor=second&limit=2>; rel="next"`, cursorLinks("second"), "expected only a next link by cursor")
+		assert.Empty(t, cursorLinks(""), "expected no links for the last page")
+	})
+
+	t.Run("since seq", func(t *testing.T) {
+		r := NewRouter(nil, nil)
+
+		seqLinks := func(count int) string {
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/v1/tenants?limit=2&since_seq=10", nil), httptest.NewRecorder())
+
+			next := int64(12)
+
+			pagination := parsePagination(c)
+			pagination.SinceSeq = &next
+
+			r.setPaginationLinks(c, pagination, count, nil)
+
+			return c.Response().Header().Get(headerLink)
+		}
+
+		assert.Equal(t, `</v1/tenants?limit=2&since_seq=12>; rel="next"`, seqLinks(2), "expected only a next link by sequence number")
+		assert.Empty(t, seqLinks(1), "expected no links once the changes are listed")
+	})
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/x/gidx"
+)
+
+const (
+	// DefaultMaxPathSegments is the default limit on the number of names in
+	// a name path.
+	DefaultMaxPathSegments = 100
+
+	// namePathSeparator separates the tenant names within a name path.
+	namePathSeparator = "."
+
+	// namePathEscape escapes a separator or escape within a name of a name
+	// path, as tenant names may contain them, so "tenant1\.a" is the name
+	// "tenant1.a" rather than the names "tenant1" and "a".
+	namePathEscape = "\\"
+
+	// namePathQuery resolves a name path, such as "root.child.grandchild",
+	// by matching each name against the children of the previous tenant,
+	// starting from the root tenants.
+	namePathQuery = `
+		WITH RECURSIVE lookup AS (
+			SELECT id, 1 AS depth
+			FROM tenants
+			WHERE
+				parent_tenant_id IS NULL
+				AND name = ($1::STRING[])[1]
+				AND deleted_at IS NULL
+
+			UNION ALL
+
+			SELECT t.id, l.depth + 1
+			FROM tenants t
+			INNER JOIN lookup l ON t.parent_tenant_id = l.id
+			WHERE
+				l.depth < $2
+				AND t.name = ($1::STRING[])[l.depth + 1]
+				AND t.deleted_at IS NULL
+		)
+		SELECT id
+		FROM lookup
+		WHERE depth = $2
+		LIMIT 2
+	`
+)
+
+// isTenantID returns true when the reference is formatted as a tenant ID
+// rather than a name path. Tenant IDs always start with the configured tenant
+// prefix followed by the gidx separator.
+func (r *Router) isTenantID(ref string) bool {
+	if !strings.HasPrefix(ref, r.idPrefix+"-") {
+		return false
+	}
+
+	_, err := gidx.Parse(ref)
+
+	return err == nil
+}
+
+// parseNamePath splits a name path into the tenant names from the root down,
+// unescaping separators and escapes within names. Paths with more names than
+// the maximum are rejected as soon as the limit is passed, as each name adds
+// a level to the recursive lookup.
+func (r *Router) parseNamePath(ref string) ([]string, error) {
+	var (
+		names   []string
+		name    strings.Builder
+		escaped bool
+	)
+
+	for _, ch := range ref {
+		switch s := string(ch); {
+		case escaped:
+			if s != namePathSeparator && s != namePathEscape {
+				return nil, fmt.Errorf("%w: only %q and %q may be escaped", ErrInvalidPath, namePathSeparator, namePathEscape)
+			}
+
+			name.WriteString(s)
+
+			escaped = false
+		case s == namePathEscape:
+			escaped = true
+		case s == namePathSeparator:
+			if name.Len() == 0 {
+				return nil, ErrInvalidPath
+			}
+
+			if len(names)+1 >= r.maxPathSegments {
+				return nil, fmt.Errorf("%w: at most %d names are allowed", ErrPathTooLong, r.maxPathSegments)
+			}
+
+			names = append(names, name.String())
+
+			name.Reset()
+		default:
+			name.WriteString(s)
+		}
+	}
+
+	if escaped || name.Len() == 0 {
+		return nil, ErrInvalidPath
+	}
+
+	return append(names, name.String()), nil
+}
+
+// tenantByNamePath returns the tenant at the provided name path. If more than
+// one tenant matches the path, ErrAmbiguousPath is returned.
+func (r *Router) tenantByNamePath(ctx context.Context, names []string) (*models.Tenant, error) {
+	rows, err := r.db.QueryContext(ctx, namePathQuery, pq.Array(names), len(names))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var ids []gidx.PrefixedID
+
+	for rows.Next() {
+		var id gidx.PrefixedID
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	switch len(ids) {
+	case 0:
+		return nil, sql.ErrNoRows
+	case 1:
+		return models.FindTenant(ctx, r.db, ids[0])
+	default:
+		return nil, ErrAmbiguousPath
+	}
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// defaultLanguage is the language of the messages the api is written with.
+	defaultLanguage = "en"
+
+	// messageLanguageKey is the echo context key the negotiated message language is stored under.
+	messageLanguageKey = "api.message-language"
+
+	// messageCatalogKey is the echo context key the negotiated message catalog is stored under.
+	messageCatalogKey = "api.message-catalog"
+)
+
+// MessageCatalog maps error message keys to the messages of a language.
+// Errors with a code are keyed by the code, and other errors by their English
+// message, such as "tenant not found".
+type MessageCatalog map[string]string
+
+// WithMessageCatalog registers the catalog error messages are localized with
+// for requests preferring the language in their Accept-Language header.
+// Messages missing from the catalog, and requests preferring no catalog
+// language, fall back to English.
+func WithMessageCatalog(language string, catalog MessageCatalog) RouterOption {
+	return func(r *Router) {
+		if r.catalogs == nil {
+			r.catalogs = make(map[string]MessageCatalog)
+		}
+
+		r.catalogs[strings.ToLower(language)] = catalog
+	}
+}
+
+// LoadMessageCatalogs reads the message catalogs in the directory, which are
+// JSON objects named after their language, such as es.json or pt-BR.json,
+// returning the option registering them.
+func LoadMessageCatalogs(dir string) (RouterOption, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	opts := make([]RouterOption, 0, len(paths))
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var catalog MessageCatalog
+
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return nil, fmt.Errorf("%w: %s: %s", ErrInvalidMessageCatalog, path, err)
+		}
+
+		opts = append(opts, WithMessageCatalog(strings.TrimSuffix(filepath.Base(path), ".json"), catalog))
+	}
+
+	return func(r *Router) {
+		for _, opt := range opts {
+			opt(r)
+		}
+	}, nil
+}
+
+// negotiateLanguage selects the message catalog of the language most
+// preferred by the Accept-Language header, used to localize error messages.
+func (r *Router) negotiateLanguage(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if len(r.catalogs) == 0 {
+			return next(c)
+		}
+
+		c.Response().Header().Add(echo.HeaderVary, HeaderAcceptLanguage)
+
+		if language := r.acceptedLanguage(c.Request().Header.Get(HeaderAcceptLanguage)); language != defaultLanguage {
+			c.Set(messageLanguageKey, language)
+			c.Set(messageCatalogKey, r.catalogs[language])
+		}
+
+		return next(c)
+	}
+}
+
+// acceptedLanguage returns the catalog language with the highest quality in
+// the Accept-Language header, or English when none is accepted. A language
+// range matches a catalog of the same language, or of its primary subtag, so
+// es-MX is served by an es catalog. When several languages share the highest
+// quality, the first listed is used.
+func (r *Router) acceptedLanguage(accept string) string {
+	var (
+		best    = defaultLanguage
+		bestQty float64
+	)
+
+	for _, value := range strings.Split(accept, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(value), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+
+		language, ok := r.catalogLanguage(tag)
+		if !ok {
+			continue
+		}
+
+		qty := 1.0
+
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+
+			if qty, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		if qty > bestQty {
+			best, bestQty = language, qty
+		}
+	}
+
+	return best
+}
+
+// catalogLanguage returns the language of the catalog serving the language tag.
+func (r *Router) catalogLanguage(tag string) (string, bool) {
+	for {
+		if tag == defaultLanguage {
+			return defaultLanguage, true
+		}
+
+		if _, ok := r.catalogs[tag]; ok {
+			return tag, true
+		}
+
+		i := strings.LastIndex(tag, "-")
+		if i < 0 {
+			return "", false
+		}
+
+		tag = tag[:i]
+	}
+}
+
+// localizedMessage returns the message of the error in the negotiated
+// catalog, setting the response's Content-Language, falling back to the
+// English message.
+func localizedMessage(c echo.Context, message, code string) string {
+	catalog, _ := c.Get(messageCatalogKey).(MessageCatalog)
+
+	key := code
+	if key == "" {
+		key = message
+	}
+
+	if localized, ok := catalog[key]; ok {
+		if language, _ := c.Get(messageLanguageKey).(string); language != "" {
+			c.Response().Header().Set(HeaderContentLanguage, language)
+		}
+
+		return localized
+	}
+
+	return message
+}
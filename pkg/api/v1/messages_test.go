@@ -0,0 +1,159 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/x/gidx"
+)
+
+// spanishCatalog is a partial Spanish message catalog.
+var spanishCatalog = MessageCatalog{
+	"tenant not found":    "inquilino no encontrado",
+	errorCodeNameConflict: "ya existe un inquilino con ese nombre",
+}
+
+func TestAcceptedLanguage(t *testing.T) {
+	r := NewRouter(nil, nil,
+		WithMessageCatalog("es", spanishCatalog),
+		WithMessageCatalog("pt-BR", MessageCatalog{}),
+	)
+
+	testCases := []struct {
+		name     string
+		accept   string
+		expected string
+	}{
+		{"empty", "", "en"},
+		{"catalog", "es", "es"},
+		{"region falls back to language", "es-MX", "es"},
+		{"region catalog", "pt-BR", "pt-br"},
+		{"language without region catalog", "pt", "en"},
+		{"case insensitive", "ES", "es"},
+		{"first listed wins", "es, en", "es"},
+		{"quality preferred", "es;q=0.5, en", "en"},
+		{"unsupported skipped", "fr, es;q=0.8", "es"},
+		{"unsupported", "fr", "en"},
+		{"zero quality", "es;q=0", "en"},
+		{"invalid quality", "es;q=high", "en"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, r.acceptedLanguage(tc.accept))
+		})
+	}
+}
+
+func TestLocalizedErrorMessage(t *testing.T) {
+	r := NewRouter(nil, nil, WithMessageCatalog("es", spanishCatalog))
+
+	serve := func(language string, handler echo.HandlerFunc) (*httptest.ResponseRecorder, *v1ErrorResponseBody) {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if language != "" {
+			req.Header.Set(HeaderAcceptLanguage, language)
+		}
+
+		rec := httptest.NewRecorder()
+
+		require.NoError(t, r.negotiateLanguage(handler)(e.NewContext(req, rec)))
+
+		var body *v1ErrorResponseBody
+
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+		return rec, body
+	}
+
+	notFound := func(c echo.Context) error {
+		return v1TenantNotFoundResponse(c, ErrIDNotFound)
+	}
+
+	nameConflict := func(c echo.Context) error {
+		return v1NameConflictResponse(c, ErrNameConflict)
+	}
+
+	t.Run("localized", func(t *testing.T) {
+		rec, body := serve("es-ES, en;q=0.5", notFound)
+
+		assert.Equal(t, "inquilino no encontrado", body.Message, "expected a spanish message")
+		assert.Equal(t, ErrIDNotFound.Error(), body.Error, "expected the error not to be localized")
+		assert.Equal(t, "es", rec.Header().Get(HeaderContentLanguage))
+		assert.Equal(t, HeaderAcceptLanguage, rec.Header().Get(echo.HeaderVary))
+	})
+
+	t.Run("keyed by code", func(t *testing.T) {
+		_, body := serve("es", nameConflict)
+
+		assert.Equal(t, "ya existe un inquilino con ese nombre", body.Message, "expected a spanish message")
+		assert.Equal(t, errorCodeNameConflict, body.Code, "expected the code not to be localized")
+	})
+
+	t.Run("missing message falls back to english", func(t *testing.T) {
+		rec, body := serve("es", func(c echo.Context) error {
+			return v1BadRequestResponse(c, ErrInvalidID)
+		})
+
+		assert.Equal(t, "bad request", body.Message, "expected an english message")
+		assert.Empty(t, rec.Header().Get(HeaderContentLanguage), "expected no content language")
+	})
+
+	t.Run("english", func(t *testing.T) {
+		for _, language := range []string{"", "en-US", "fr"} {
+			_, body := serve(language, notFound)
+
+			assert.Equal(t, "tenant not found", body.Message, "expected an english message for %q", language)
+		}
+	})
+}
+
+func TestLoadMessageCatalogs(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "es.json"), []byte(`{"tenant not found": "inquilino no encontrado"}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte(`not a catalog`), 0o600))
+
+	opt, err := LoadMessageCatalogs(dir)
+	require.NoError(t, err, "no error expected loading catalogs")
+
+	r := NewRouter(nil, nil, opt)
+
+	assert.Equal(t, map[string]MessageCatalog{"es": {"tenant not found": "inquilino no encontrado"}}, r.catalogs)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "de.json"), []byte(`["nicht gefunden"]`), 0o600))
+
+	_, err = LoadMessageCatalogs(dir)
+	assert.True(t, errors.Is(err, ErrInvalidMessageCatalog), "expected invalid catalog error, got %v", err)
+}
+
+func TestTenantGetLocalized(t *testing.T) {
+	srv, err := newTestServer(t, &testServerConfig{
+		routerOpts: []RouterOption{WithMessageCatalog("es", spanishCatalog)},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	headers := make(http.Header)
+	headers.Set(HeaderAcceptLanguage, "es")
+
+	var result *v1ErrorResponseBody
+
+	resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(gidx.MustNewID(TenantIDPrefix)), headers, nil, &result)
+	require.NoError(t, err, "no error expected for tenant get")
+	resp.Body.Close() //nolint:errcheck // Not needed
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "unexpected status code returned")
+	require.NotNil(t, result, "expected error response")
+	assert.Equal(t, "inquilino no encontrado", result.Message, "expected a spanish message")
+}
@@ -0,0 +1,480 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/tenant-api/internal/pubsub"
+	"go.infratographer.com/tenant-api/internal/x/nullx"
+	"go.infratographer.com/x/gidx"
+	"go.uber.org/zap"
+)
+
+// tenantBatchMove moves many tenants in a single transaction. The batch is
+// checked against the hierarchy as the whole batch leaves it, regardless of
+// the order the moves are requested in, so cycles, name conflicts and child
+// limits introduced by the batch as a whole are rejected, while moves which
+// only conflict with a tenant moved away by the batch, such as tenants
+// trading names between parents, are accepted. If any move is rejected, no
+// tenants are moved.
+//
+// Move events are published for every tenant whose parent changed once the
+// batch has been committed, or a single event of the moved tenants when
+// batch events are aggregated.
+func (r *Router) tenantBatchMove(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantBatchMove")
+	defer span.End()
+
+	payload := new(batchMoveRequest)
+
+	if err := bindRequest(c, payload); err != nil {
+		r.requestLogger(c).Error("failed to bind batch move request", zap.Error(err))
+
+		return v1BadRequestResponse(c, err)
+	}
+
+	if err := payload.validate(); err != nil {
+		r.requestLogger(c).Error("invalid batch move request", zap.Error(err))
+
+		return v1BadRequestResponse(c, err)
+	}
+
+	actor := r.actor(c)
+
+	var (
+		ts        []*models.Tenant
+		movedFrom map[gidx.PrefixedID]gidx.PrefixedID
+	)
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		ts = make([]*models.Tenant, 0, len(payload.Moves))
+		movedFrom = make(map[gidx.PrefixedID]gidx.PrefixedID, len(payload.Moves))
+
+		type batchMoveStep struct {
+			t       *models.Tenant
+			current models.Tenant
+			parent  nullx.PrefixedID
+		}
+
+		var steps []*batchMoveStep
+
+		for _, move := range payload.Moves {
+			t, err := models.FindTenant(ctx, tx, move.ID)
+			if err != nil {
+				return fmt.Errorf("moving %s: %w", move.ID, err)
+			}
+
+			ts = append(ts, t)
+
+			// Tenants already under the requested parent are left as is.
+			if move.ParentTenantID.Value == t.ParentTenantID {
+				continue
+			}
+
+			steps = append(steps, &batchMoveStep{t: t, current: *t, parent: move.ParentTenantID.Value})
+		}
+
+		apply := func(t *models.Tenant, move func() error) error {
+			// An earlier step may have rewritten the path, when the tenant is a
+			// descendant of another moved tenant.
+			stored, err := models.FindTenant(ctx, tx, t.ID, models.TenantColumns.Path)
+			if err != nil {
+				return fmt.Errorf("moving %s: %w", t.ID, err)
+			}
+
+			t.Path = stored.Path
+
+			if err := move(); err != nil {
+				return fmt.Errorf("moving %s: %w", t.ID, err)
+			}
+
+			t.UpdatedBy = actorColumn(actor)
+
+			if _, err := t.Update(ctx, tx, boil.Infer()); err != nil {
+				if isUniqueViolation(err) {
+					return fmt.Errorf("moving %s: %w: %q already exists", t.ID, ErrNameConflict, t.Name)
+				}
+
+				return fmt.Errorf("moving %s: %w", t.ID, err)
+			}
+
+			return nil
+		}
+
+		// Every moved tenant is made a root tenant before any is moved, so
+		// no move conflicts with a tenant the batch moves away. The hierarchy
+		// then only ever holds parents of the final hierarchy, so a cycle or
+		// conflict is found once the last move involved is checked.
+		for _, step := range steps {
+			if err := apply(step.t, func() error { return r.moveTenant(ctx, tx, step.t, nullx.PrefixedID{}) }); err != nil {
+				return err
+			}
+		}
+
+		for _, step := range steps {
+			if err := apply(step.t, func() error { return r.moveTenant(ctx, tx, step.t, step.parent) }); err != nil {
+				return err
+			}
+		}
+
+		for _, step := range steps {
+			if err := r.validateUpdate(ctx, &step.current, step.t); err != nil {
+				return fmt.Errorf("moving %s: %w", step.t.ID, err)
+			}
+
+			movedFrom[step.t.ID] = step.current.ParentTenantID.PrefixedID
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrValidationFailed) {
+			r.requestLogger(c).Error("tenant move rejected by validator", zap.Error(err))
+
+			return v1BadRequestResponse(c, err)
+		}
+
+		return r.tenantMoveErrorResponse(c, err)
+	}
+
+	if r.aggregateBatchEvents {
+		ids := make([]gidx.PrefixedID, 0, len(movedFrom))
+
+		for _, t := range ts {
+			if _, ok := movedFrom[t.ID]; ok {
+				ids = append(ids, t.ID)
+			}
+		}
+
+		r.publishBatch(c, actor, pubsub.BatchMoveOperation, ids)
+	} else {
+		r.publishMoves(c, actor, ts, movedFrom)
+	}
+
+	return v1TenantsMovedResponse(c, ts)
+}
+
+// tenantReparentChildren moves every direct child of the tenant under the
+// tenant provided as `new_parent_id` in a single transaction, such as when
+// merging organizations. Each child is checked as with a move, so the move
+// is rejected if the new parent is within the subtree of a child, or already
+// has a child with the name of a moved child. If any child can't be moved,
+// no children are moved.
+//
+// Move events are published for every moved child once committed.
+func (r *Router) tenantReparentChildren(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantReparentChildren")
+	defer span.End()
+
+	tenantID, err := r.parseID(c, "id")
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	payload := new(reparentChildrenRequest)
+
+	if err := bindRequest(c, payload); err != nil {
+		r.requestLogger(c).Error("failed to bind reparent children request", zap.Error(err))
+
+		return v1BadRequestResponse(c, err)
+	}
+
+	if err := payload.validate(tenantID); err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	actor := r.actor(c)
+
+	var (
+		children  models.TenantSlice
+		movedFrom map[gidx.PrefixedID]gidx.PrefixedID
+	)
+
+	err = r.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := models.FindTenant(ctx, tx, tenantID, models.TenantColumns.ID); err != nil {
+			return err
+		}
+
+		found, err := models.Tenants(
+			models.TenantWhere.ParentTenantID.EQ(nullx.PrefixedIDFrom(tenantID)),
+			qm.OrderBy(models.TenantColumns.ID),
+		).All(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		children = found
+
+		movedFrom = make(map[gidx.PrefixedID]gidx.PrefixedID, len(children))
+
+		for _, t := range children {
+			current := *t
+
+			if err := r.moveTenant(ctx, tx, t, nullx.PrefixedIDFrom(payload.NewParentID)); err != nil {
+				return fmt.Errorf("moving %s: %w", t.ID, err)
+			}
+
+			t.UpdatedBy = actorColumn(actor)
+
+			if err := r.validateUpdate(ctx, &current, t); err != nil {
+				return fmt.Errorf("moving %s: %w", t.ID, err)
+			}
+
+			if _, err := t.Update(ctx, tx, boil.Infer()); err != nil {
+				if isUniqueViolation(err) {
+					return fmt.Errorf("moving %s: %w: %q already exists", t.ID, ErrNameConflict, t.Name)
+				}
+
+				return fmt.Errorf("moving %s: %w", t.ID, err)
+			}
+
+			movedFrom[t.ID] = tenantID
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrValidationFailed) {
+			r.requestLogger(c).Error("tenant move rejected by validator", zap.Error(err))
+
+			return v1BadRequestResponse(c, err)
+		}
+
+		return r.tenantMoveErrorResponse(c, err)
+	}
+
+	r.publishMoves(c, actor, children, movedFrom)
+
+	return v1TenantsMovedResponse(c, children)
+}
+
+// tenantSwapParents exchanges the parents of the two tenants provided as
+// `ids` in a single transaction. Either may be a root tenant, in which case
+// the other becomes a root tenant. The swap is checked as a whole rather than
+// as two moves, so a name or child limit conflict only the intermediate
+// hierarchy would have doesn't reject it, while a cycle in the resulting
+// hierarchy does. Tenants with the same parent are left as is.
+//
+// Move events are published for both tenants once committed.
+func (r *Router) tenantSwapParents(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantSwapParents")
+	defer span.End()
+
+	payload := new(swapParentsRequest)
+
+	if err := bindRequest(c, payload); err != nil {
+		r.requestLogger(c).Error("failed to bind swap parents request", zap.Error(err))
+
+		return v1BadRequestResponse(c, err)
+	}
+
+	if err := payload.validate(); err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	actor := r.actor(c)
+
+	var (
+		ts        []*models.Tenant
+		movedFrom map[gidx.PrefixedID]gidx.PrefixedID
+	)
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		ts = make([]*models.Tenant, 0, len(payload.IDs))
+		movedFrom = make(map[gidx.PrefixedID]gidx.PrefixedID, len(payload.IDs))
+
+		for _, id := range payload.IDs {
+			t, err := models.FindTenant(ctx, tx, id)
+			if err != nil {
+				return fmt.Errorf("swapping %s: %w", id, err)
+			}
+
+			ts = append(ts, t)
+		}
+
+		a, b := ts[0], ts[1]
+
+		if a.ParentTenantID == b.ParentTenantID {
+			return nil
+		}
+
+		currentA, currentB := *a, *b
+
+		// The second tenant is made a root tenant while the first is moved,
+		// so it can't conflict with the first under its own parent. Each move
+		// is then checked against the hierarchy as the swap leaves it.
+		steps := []struct {
+			t      *models.Tenant
+			parent nullx.PrefixedID
+		}{
+			{b, nullx.PrefixedID{}},
+			{a, currentB.ParentTenantID},
+			{b, currentA.ParentTenantID},
+		}
+
+		for _, step := range steps {
+			// An earlier step may have rewritten the path, when the tenant is a
+			// descendant of the other tenant.
+			stored, err := models.FindTenant(ctx, tx, step.t.ID, models.TenantColumns.Path)
+			if err != nil {
+				return fmt.Errorf("swapping %s: %w", step.t.ID, err)
+			}
+
+			step.t.Path = stored.Path
+
+			if err := r.moveTenant(ctx, tx, step.t, step.parent); err != nil {
+				return fmt.Errorf("swapping %s: %w", step.t.ID, err)
+			}
+
+			step.t.UpdatedBy = actorColumn(actor)
+
+			if _, err := step.t.Update(ctx, tx, boil.Infer()); err != nil {
+				if isUniqueViolation(err) {
+					return fmt.Errorf("swapping %s: %w: %q already exists", step.t.ID, ErrNameConflict, step.t.Name)
+				}
+
+				return fmt.Errorf("swapping %s: %w", step.t.ID, err)
+			}
+		}
+
+		if err := r.validateUpdate(ctx, &currentA, a); err != nil {
+			return fmt.Errorf("swapping %s: %w", a.ID, err)
+		}
+
+		if err := r.validateUpdate(ctx, &currentB, b); err != nil {
+			return fmt.Errorf("swapping %s: %w", b.ID, err)
+		}
+
+		movedFrom[a.ID] = currentA.ParentTenantID.PrefixedID
+		movedFrom[b.ID] = currentB.ParentTenantID.PrefixedID
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrValidationFailed) {
+			r.requestLogger(c).Error("tenant swap rejected by validator", zap.Error(err))
+
+			return v1BadRequestResponse(c, err)
+		}
+
+		return r.tenantMoveErrorResponse(c, err)
+	}
+
+	r.publishMoves(c, actor, ts, movedFrom)
+
+	return v1TenantsMovedResponse(c, ts)
+}
+
+// publishMoves publishes move events for the tenants whose parent changed,
+// from the previous parents recorded in movedFrom.
+func (r *Router) publishMoves(c echo.Context, actor string, ts []*models.Tenant, movedFrom map[gidx.PrefixedID]gidx.PrefixedID) {
+	ctx := c.Request().Context()
+
+	for _, t := range ts {
+		previousParent, ok := movedFrom[t.ID]
+		if !ok {
+			continue
+		}
+
+		msg, err := pubsub.MoveTenantMessage(
+			gidx.PrefixedID(actor),
+			t.ID,
+			previousParent,
+			t.ParentTenantID.PrefixedID,
+		)
+		if err != nil {
+			// TODO: add status to reconcile and requeue this
+			r.requestLogger(c).Error("failed to create, move tenant message", zap.Error(err))
+		}
+
+		if err := r.pubsub.PublishUpdate(ctx, "tenants", "global", msg); err != nil {
+			// TODO: add status to reconcile and requeue this
+			r.requestLogger(c).Error("failed to publish, move tenant message", zap.Error(err))
+		}
+	}
+}
+
+// rewriteSubtreePathsQuery replaces the path prefix of a moved tenant's
+// descendants, including deleted descendants.
+const rewriteSubtreePathsQuery = `
+	UPDATE tenants
+	SET path = $1 || substring(path FROM $2)
+	WHERE path LIKE $3
+`
+
+// moveTenant places the tenant under the provided parent, or makes it a root
+// tenant when the parent is null, updating the paths of the tenant and its
+// descendants. The tenant itself is not saved.
+//
+// The move is rejected with ErrTenantCycle when the parent is the tenant or
+// one of its descendants, ErrNameConflict when the parent already has a child
+// with the tenant's name and ErrChildLimitExceeded when the parent already
+// has the maximum number of children. Root tenants are not required to have
+// unique names.
+func (r *Router) moveTenant(ctx context.Context, exec boil.ContextExecutor, t *models.Tenant, parentID nullx.PrefixedID) error {
+	if t.Locked {
+		return fmt.Errorf("%w: %s can't be moved", ErrTenantLocked, t.ID)
+	}
+
+	parentPath := ""
+
+	if parentID.Valid {
+		if parentID.PrefixedID == t.ID {
+			return fmt.Errorf("%w: %s can't be its own parent", ErrTenantCycle, t.ID)
+		}
+
+		path, err := r.lookupTenantPath(ctx, exec, parentID.PrefixedID)
+		if err != nil {
+			return err
+		}
+
+		if isPathWithin(path, t.ID) {
+			return fmt.Errorf("%w: %s is a descendant of %s", ErrTenantCycle, parentID.PrefixedID, t.ID)
+		}
+
+		if err := r.checkChildLimit(ctx, exec, parentID.PrefixedID); err != nil {
+			return err
+		}
+
+		if err := r.checkNameConflict(ctx, exec, t.ID, parentID.PrefixedID, t.Name); err != nil {
+			return err
+		}
+
+		parentPath = path
+	}
+
+	newPath := tenantPath(parentPath, t.ID)
+
+	// Descendant paths can only be rewritten from a known prefix, tenants
+	// without a path are fixed by a reindex.
+	if t.Path != "" {
+		oldPrefix := t.Path + tenantPathSeparator
+
+		if _, err := exec.ExecContext(ctx, rewriteSubtreePathsQuery, newPath+tenantPathSeparator, len(oldPrefix)+1, subtreePathPattern(t.Path)); err != nil {
+			return err
+		}
+	}
+
+	t.ParentTenantID = parentID
+	t.Path = newPath
+
+	return nil
+}
+
+// isPathWithin reports whether the path includes the tenant.
+func isPathWithin(path string, tenantID gidx.PrefixedID) bool {
+	for _, id := range strings.Split(path, tenantPathSeparator) {
+		if id == string(tenantID) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,529 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	nats "github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/tenant-api/internal/x/nullx"
+	"go.infratographer.com/x/gidx"
+	"go.infratographer.com/x/pubsubx"
+)
+
+func TestOptionalPrefixedID(t *testing.T) {
+	id := gidx.MustNewID(TenantIDPrefix)
+
+	testCases := []struct {
+		name  string
+		body  string
+		set   bool
+		valid bool
+	}{
+		{"omitted", `{}`, false, false},
+		{"null", `{"parent_tenant_id": null}`, true, false},
+		{"id", fmt.Sprintf(`{"parent_tenant_id": "%s"}`, id), true, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var req updateTenantRequest
+
+			require.NoError(t, json.Unmarshal([]byte(tc.body), &req))
+
+			assert.Equal(t, tc.set, req.ParentTenantID.Set, "unexpected set")
+			assert.Equal(t, tc.valid, req.ParentTenantID.Value.Valid, "unexpected valid")
+		})
+	}
+}
+
+func TestIsPathWithin(t *testing.T) {
+	a := gidx.MustNewID(TenantIDPrefix)
+	b := gidx.MustNewID(TenantIDPrefix)
+	c := gidx.MustNewID(TenantIDPrefix)
+
+	path := tenantPath(tenantPath(string(a), b), c)
+
+	assert.True(t, isPathWithin(path, a))
+	assert.True(t, isPathWithin(path, c))
+	assert.False(t, isPathWithin(path, gidx.MustNewID(TenantIDPrefix)))
+}
+
+func TestTenantMove(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	ctx := context.Background()
+
+	source := srv.createTenant(t, "", "source")
+	destination := srv.createTenant(t, "", "destination")
+	moving := srv.createTenant(t, source.ID, "moving")
+	descendant := srv.createTenant(t, moving.ID, "descendant")
+
+	move := func(t *testing.T, id gidx.PrefixedID, body string, out interface{}) *http.Response {
+		t.Helper()
+
+		resp, err := srv.Request(http.MethodPatch, "/v1/tenants/"+string(id), nil, strings.NewReader(body), out)
+		require.NoError(t, err, "no error expected for tenant update")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp
+	}
+
+	t.Run("name conflict", func(t *testing.T) {
+		srv.createTenant(t, destination.ID, "moving")
+
+		var result *v1ErrorResponseBody
+
+		resp := move(t, moving.ID, fmt.Sprintf(`{"parent_tenant_id": "%s"}`, destination.ID), &result)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected error response")
+		assert.Equal(t, errorCodeNameConflict, result.Code, "unexpected error code")
+
+		stored, err := models.FindTenant(ctx, srv.db, moving.ID)
+		require.NoError(t, err, "no error expected finding tenant")
+		assert.Equal(t, source.ID, stored.ParentTenantID.PrefixedID, "expected tenant not to be moved")
+	})
+
+	t.Run("rename resolves conflict", func(t *testing.T) {
+		var result *v1TenantResponse
+
+		resp := move(t, moving.ID, fmt.Sprintf(`{"name": "moved", "parent_tenant_id": "%s"}`, destination.ID), &result)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result.Tenant.ParentTenantID, "expected parent tenant id")
+		assert.Equal(t, destination.ID, *result.Tenant.ParentTenantID, "expected tenant to be moved")
+
+		stored, err := models.FindTenant(ctx, srv.db, descendant.ID)
+		require.NoError(t, err, "no error expected finding tenant")
+		assert.Equal(t, tenantPath(tenantPath(string(destination.ID), moving.ID), descendant.ID), stored.Path, "expected descendant path to be rewritten")
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		resp := move(t, moving.ID, fmt.Sprintf(`{"parent_tenant_id": "%s"}`, descendant.ID), nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+
+		resp = move(t, moving.ID, fmt.Sprintf(`{"parent_tenant_id": "%s"}`, moving.ID), nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+	})
+
+	t.Run("unknown parent", func(t *testing.T) {
+		resp := move(t, moving.ID, fmt.Sprintf(`{"parent_tenant_id": "%s"}`, gidx.MustNewID(TenantIDPrefix)), nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, "unexpected status code returned")
+	})
+
+	t.Run("to root", func(t *testing.T) {
+		var result *v1TenantResponse
+
+		resp := move(t, moving.ID, `{"parent_tenant_id": null}`, &result)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		assert.Nil(t, result.Tenant.ParentTenantID, "expected a root tenant")
+
+		stored, err := models.FindTenant(ctx, srv.db, descendant.ID)
+		require.NoError(t, err, "no error expected finding tenant")
+		assert.Equal(t, tenantPath(string(moving.ID), descendant.ID), stored.Path, "expected descendant path to be rewritten")
+	})
+
+	t.Run("omitted parent is unchanged", func(t *testing.T) {
+		var result *v1TenantResponse
+
+		resp := move(t, descendant.ID, `{"name": "renamed"}`, &result)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result.Tenant.ParentTenantID, "expected parent tenant id")
+		assert.Equal(t, moving.ID, *result.Tenant.ParentTenantID, "expected parent to be unchanged")
+	})
+}
+
+func TestBatchMoveRequestValidate(t *testing.T) {
+	id := gidx.MustNewID(TenantIDPrefix)
+	parent := optionalPrefixedID{Value: nullx.PrefixedIDFrom(gidx.MustNewID(TenantIDPrefix)), Set: true}
+
+	testCases := []struct {
+		name    string
+		moves   []tenantMove
+		wantErr error
+	}{
+		{"valid", []tenantMove{{ID: id, ParentTenantID: parent}}, nil},
+		{"to root", []tenantMove{{ID: id, ParentTenantID: optionalPrefixedID{Set: true}}}, nil},
+		{"empty", nil, ErrInvalidBatchMove},
+		{"missing parent", []tenantMove{{ID: id}}, ErrInvalidBatchMove},
+		{"invalid id", []tenantMove{{ID: "not-valid", ParentTenantID: parent}}, ErrInvalidID},
+		{"duplicate", []tenantMove{{ID: id, ParentTenantID: parent}, {ID: id, ParentTenantID: parent}}, ErrInvalidBatchMove},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := batchMoveRequest{Moves: tc.moves}
+
+			err := req.validate()
+
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestTenantBatchMove(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	ctx := context.Background()
+
+	a := srv.createTenant(t, "", "a")
+	b := srv.createTenant(t, "", "b")
+	destination := srv.createTenant(t, "", "destination")
+	first := srv.createTenant(t, a.ID, "same")
+	second := srv.createTenant(t, b.ID, "same")
+
+	batchMove := func(t *testing.T, body string, out interface{}) *http.Response {
+		t.Helper()
+
+		resp, err := srv.Request(http.MethodPost, "/v1/tenants/batch-move", nil, strings.NewReader(body), out)
+		require.NoError(t, err, "no error expected for batch move")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp
+	}
+
+	assertParent := func(t *testing.T, id, parentID gidx.PrefixedID) {
+		t.Helper()
+
+		stored, err := models.FindTenant(ctx, srv.db, id)
+		require.NoError(t, err, "no error expected finding tenant")
+		assert.Equal(t, parentID, stored.ParentTenantID.PrefixedID, "unexpected parent")
+	}
+
+	t.Run("collective cycle", func(t *testing.T) {
+		// Each move is valid on its own, but together a and b become each other's ancestors.
+		resp := batchMove(t, fmt.Sprintf(`{"moves": [
+			{"id": "%s", "parent_tenant_id": "%s"},
+			{"id": "%s", "parent_tenant_id": "%s"}
+		]}`, a.ID, b.ID, b.ID, first.ID), nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+
+		assertParent(t, a.ID, "")
+		assertParent(t, b.ID, "")
+	})
+
+	t.Run("collective name conflict", func(t *testing.T) {
+		var result *v1ErrorResponseBody
+
+		resp := batchMove(t, fmt.Sprintf(`{"moves": [
+			{"id": "%s", "parent_tenant_id": "%s"},
+			{"id": "%s", "parent_tenant_id": "%s"}
+		]}`, first.ID, destination.ID, second.ID, destination.ID), &result)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected error response")
+		assert.Equal(t, errorCodeNameConflict, result.Code, "unexpected error code")
+
+		assertParent(t, first.ID, a.ID)
+		assertParent(t, second.ID, b.ID)
+	})
+
+	t.Run("names traded", func(t *testing.T) {
+		// Either move alone conflicts with the other tenant named same, while
+		// the batch leaves one under each parent.
+		resp := batchMove(t, fmt.Sprintf(`{"moves": [
+			{"id": "%s", "parent_tenant_id": "%s"},
+			{"id": "%s", "parent_tenant_id": "%s"}
+		]}`, first.ID, b.ID, second.ID, a.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		assertParent(t, first.ID, b.ID)
+		assertParent(t, second.ID, a.ID)
+
+		resp = batchMove(t, fmt.Sprintf(`{"moves": [
+			{"id": "%s", "parent_tenant_id": "%s"},
+			{"id": "%s", "parent_tenant_id": "%s"}
+		]}`, first.ID, a.ID, second.ID, b.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		assertParent(t, first.ID, a.ID)
+		assertParent(t, second.ID, b.ID)
+	})
+
+	t.Run("moves applied together", func(t *testing.T) {
+		subscriber := newPubSubClient(t, srv.logger, srv.nats.ClientURL())
+		msgChan := make(chan *nats.Msg, 10)
+
+		subscription, err := subscriber.ChanSubscribe(ctx, "com.infratographer.events.tenants.>", msgChan, "tenant-api-test")
+		require.NoError(t, err)
+
+		defer func() {
+			if err := subscription.Unsubscribe(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		var result *v1TenantSliceResponse
+
+		resp := batchMove(t, fmt.Sprintf(`{"moves": [
+			{"id": "%s", "parent_tenant_id": "%s"},
+			{"id": "%s", "parent_tenant_id": "%s"}
+		]}`, a.ID, destination.ID, b.ID, a.ID), &result)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		assert.Len(t, result.Tenants, 2, "expected moved tenants")
+
+		assertParent(t, a.ID, destination.ID)
+		assertParent(t, b.ID, a.ID)
+
+		stored, err := models.FindTenant(ctx, srv.db, second.ID)
+		require.NoError(t, err, "no error expected finding tenant")
+		assert.Equal(t, tenantPath(tenantPath(tenantPath(string(destination.ID), a.ID), b.ID), second.ID), stored.Path, "expected paths to reflect all moves")
+
+		for _, id := range []gidx.PrefixedID{a.ID, b.ID} {
+			select {
+			case msg := <-msgChan:
+				pMsg := &pubsubx.ChangeMessage{}
+				require.NoError(t, json.Unmarshal(msg.Data, pMsg))
+
+				assert.Equal(t, tenantSubjectUpdate, msg.Subject, "expected nats subject to be tenant update subject")
+				assert.Equal(t, id, pMsg.SubjectID, "expected events in move order")
+				require.Len(t, pMsg.FieldChanges, 1, "expected parent field change")
+				assert.Equal(t, "parent_tenant_id", pMsg.FieldChanges[0].Field)
+			case <-time.After(natsMsgSubTimeout):
+				t.Error("failed to receive nats message")
+			}
+		}
+	})
+}
+
+func TestTenantReparentChildren(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	ctx := context.Background()
+
+	source := srv.createTenant(t, "", "source")
+	destination := srv.createTenant(t, "", "destination")
+	first := srv.createTenant(t, source.ID, "first")
+	second := srv.createTenant(t, source.ID, "second")
+	grandchild := srv.createTenant(t, second.ID, "grandchild")
+	existing := srv.createTenant(t, destination.ID, "second")
+
+	reparent := func(t *testing.T, id gidx.PrefixedID, body string, out interface{}) *http.Response {
+		t.Helper()
+
+		resp, err := srv.Request(http.MethodPost, "/v1/tenants/"+string(id)+"/reparent-children", nil, strings.NewReader(body), out)
+		require.NoError(t, err, "no error expected for reparenting children")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp
+	}
+
+	assertParent := func(t *testing.T, id, parentID gidx.PrefixedID) {
+		t.Helper()
+
+		stored, err := models.FindTenant(ctx, srv.db, id)
+		require.NoError(t, err, "no error expected finding tenant")
+		assert.Equal(t, parentID, stored.ParentTenantID.PrefixedID, "unexpected parent")
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, body := range []string{`{}`, `{"new_parent_id": "invalid"}`, fmt.Sprintf(`{"new_parent_id": "%s"}`, source.ID)} {
+			resp := reparent(t, source.ID, body, nil)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned for %s", body)
+		}
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		resp := reparent(t, source.ID, fmt.Sprintf(`{"new_parent_id": "%s"}`, grandchild.ID), nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "expected moving a child under its own descendant to be rejected")
+
+		assertParent(t, first.ID, source.ID)
+		assertParent(t, second.ID, source.ID)
+	})
+
+	t.Run("collision", func(t *testing.T) {
+		var result *v1ErrorResponseBody
+
+		resp := reparent(t, source.ID, fmt.Sprintf(`{"new_parent_id": "%s"}`, destination.ID), &result)
+		require.Equal(t, http.StatusConflict, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected error response")
+		assert.Equal(t, errorCodeNameConflict, result.Code, "unexpected error code")
+		assert.Contains(t, result.Error, string(second.ID), "expected the colliding child in the error")
+		assert.Contains(t, result.Error, `"second"`, "expected the colliding name in the error")
+
+		assertParent(t, first.ID, source.ID)
+		assertParent(t, second.ID, source.ID)
+	})
+
+	t.Run("reparented", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodPatch, "/v1/tenants/"+string(existing.ID), nil, strings.NewReader(`{"name": "renamed"}`), nil)
+		require.NoError(t, err, "no error expected for tenant update")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		subscriber := newPubSubClient(t, srv.logger, srv.nats.ClientURL())
+		msgChan := make(chan *nats.Msg, 10)
+
+		subscription, err := subscriber.ChanSubscribe(ctx, "com.infratographer.events.tenants.>", msgChan, "tenant-api-test")
+		require.NoError(t, err)
+
+		defer func() {
+			if err := subscription.Unsubscribe(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		var result *v1TenantSliceResponse
+
+		resp = reparent(t, source.ID, fmt.Sprintf(`{"new_parent_id": "%s"}`, destination.ID), &result)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		assert.Len(t, result.Tenants, 2, "expected moved children")
+
+		assertParent(t, first.ID, destination.ID)
+		assertParent(t, second.ID, destination.ID)
+
+		stored, err := models.FindTenant(ctx, srv.db, grandchild.ID)
+		require.NoError(t, err, "no error expected finding tenant")
+		assert.Equal(t, tenantPath(tenantPath(string(destination.ID), second.ID), grandchild.ID), stored.Path, "expected descendant paths to be rewritten")
+
+		moved := make(map[gidx.PrefixedID]bool)
+
+		for i := 0; i < 2; i++ {
+			select {
+			case msg := <-msgChan:
+				pMsg := &pubsubx.ChangeMessage{}
+				require.NoError(t, json.Unmarshal(msg.Data, pMsg))
+
+				assert.Equal(t, tenantSubjectUpdate, msg.Subject, "expected nats subject to be tenant update subject")
+				require.Len(t, pMsg.FieldChanges, 1, "expected parent field change")
+				assert.Equal(t, "parent_tenant_id", pMsg.FieldChanges[0].Field)
+
+				moved[pMsg.SubjectID] = true
+			case <-time.After(natsMsgSubTimeout):
+				t.Error("failed to receive nats message")
+			}
+		}
+
+		assert.Equal(t, map[gidx.PrefixedID]bool{first.ID: true, second.ID: true}, moved, "expected a move event per child")
+	})
+}
+
+func TestSwapParentsRequestValidate(t *testing.T) {
+	a := gidx.MustNewID(TenantIDPrefix)
+	b := gidx.MustNewID(TenantIDPrefix)
+
+	testCases := []struct {
+		name    string
+		ids     []gidx.PrefixedID
+		wantErr error
+	}{
+		{"valid", []gidx.PrefixedID{a, b}, nil},
+		{"one", []gidx.PrefixedID{a}, ErrInvalidSwap},
+		{"three", []gidx.PrefixedID{a, b, gidx.MustNewID(TenantIDPrefix)}, ErrInvalidSwap},
+		{"invalid id", []gidx.PrefixedID{a, "not-valid"}, ErrInvalidID},
+		{"same tenant", []gidx.PrefixedID{a, a}, ErrInvalidSwap},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := swapParentsRequest{IDs: tc.ids}
+
+			err := req.validate()
+
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestTenantSwapParents(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	ctx := context.Background()
+
+	a := srv.createTenant(t, "", "a")
+	b := srv.createTenant(t, "", "b")
+	first := srv.createTenant(t, a.ID, "same")
+	second := srv.createTenant(t, b.ID, "same")
+	grandchild := srv.createTenant(t, first.ID, "grandchild")
+
+	swap := func(t *testing.T, x, y gidx.PrefixedID, out interface{}) *http.Response {
+		t.Helper()
+
+		body := fmt.Sprintf(`{"ids": ["%s", "%s"]}`, x, y)
+
+		resp, err := srv.Request(http.MethodPost, "/v1/tenants/swap-parents", nil, strings.NewReader(body), out)
+		require.NoError(t, err, "no error expected for swapping parents")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp
+	}
+
+	assertParent := func(t *testing.T, id, parentID gidx.PrefixedID) {
+		t.Helper()
+
+		stored, err := models.FindTenant(ctx, srv.db, id)
+		require.NoError(t, err, "no error expected finding tenant")
+		assert.Equal(t, parentID, stored.ParentTenantID.PrefixedID, "unexpected parent")
+	}
+
+	t.Run("invalid mid step", func(t *testing.T) {
+		// Moving either tenant first would put two tenants named same under
+		// one parent, while the swap leaves one under each parent.
+		var result *v1TenantSliceResponse
+
+		r := swap(t, first.ID, second.ID, &result)
+		require.Equal(t, http.StatusOK, r.StatusCode, "unexpected status code returned")
+		assert.Len(t, result.Tenants, 2, "expected swapped tenants")
+
+		assertParent(t, first.ID, b.ID)
+		assertParent(t, second.ID, a.ID)
+
+		stored, err := models.FindTenant(ctx, srv.db, grandchild.ID)
+		require.NoError(t, err, "no error expected finding tenant")
+		assert.Equal(t, tenantPath(tenantPath(string(b.ID), first.ID), grandchild.ID), stored.Path, "expected descendant paths to follow the swap")
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		// b is first's parent, so first would become its own parent.
+		r := swap(t, b.ID, first.ID, nil)
+		assert.Equal(t, http.StatusBadRequest, r.StatusCode, "unexpected status code returned")
+
+		assertParent(t, b.ID, "")
+		assertParent(t, first.ID, b.ID)
+	})
+
+	t.Run("root", func(t *testing.T) {
+		root := srv.createTenant(t, "", "root")
+
+		r := swap(t, second.ID, root.ID, nil)
+		require.Equal(t, http.StatusOK, r.StatusCode, "unexpected status code returned")
+
+		assertParent(t, second.ID, "")
+		assertParent(t, root.ID, a.ID)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		r := swap(t, a.ID, gidx.MustNewID(TenantIDPrefix), nil)
+		assert.Equal(t, http.StatusNotFound, r.StatusCode, "unexpected status code returned")
+	})
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/x/gidx"
+	"go.uber.org/zap"
+)
+
+const (
+	insertNameChangeQuery = `
+		INSERT INTO tenant_name_history (tenant_id, old_name, new_name, actor, changed_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	nameHistoryQuery = `
+		SELECT old_name, new_name, actor, changed_at
+		FROM tenant_name_history
+		WHERE tenant_id = $1
+		ORDER BY changed_at, id
+		LIMIT $2 OFFSET $3
+	`
+)
+
+// recordNameChange records a tenant rename in the name history.
+func recordNameChange(ctx context.Context, exec boil.ContextExecutor, tenantID gidx.PrefixedID, change tenantNameChange) error {
+	_, err := exec.ExecContext(ctx, insertNameChangeQuery, tenantID, change.OldName, change.NewName, change.Actor, change.ChangedAt)
+
+	return err
+}
+
+func (r *Router) tenantNamesList(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantNamesList")
+	defer span.End()
+
+	pagination := parsePagination(c)
+
+	tenantID, err := r.parseID(c, "id")
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	if _, err := models.FindTenant(ctx, r.db, tenantID, models.TenantColumns.ID); err != nil {
+		return r.tenantQueryErrorResponse(c, err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, nameHistoryQuery, tenantID, pagination.limitUsed(), pagination.offset())
+	if err != nil {
+		r.requestLogger(c).Error("failed to query tenant name history", zap.Error(err))
+
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	defer rows.Close()
+
+	names := []*tenantNameChange{}
+
+	for rows.Next() {
+		change := new(tenantNameChange)
+
+		if err := rows.Scan(&change.OldName, &change.NewName, &change.Actor, &change.ChangedAt); err != nil {
+			return v1InternalServerErrorResponse(c, err)
+		}
+
+		change.ChangedAt = change.ChangedAt.UTC()
+
+		names = append(names, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	return v1TenantNamesResponse(c, names, pagination)
+}
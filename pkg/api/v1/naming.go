@@ -0,0 +1,211 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/labstack/echo/v4"
+)
+
+// FieldNaming is the naming strategy of JSON response fields.
+type FieldNaming string
+
+const (
+	// FieldNamingSnakeCase names fields in snake_case, such as parent_tenant_id.
+	FieldNamingSnakeCase FieldNaming = "snake_case"
+
+	// FieldNamingCamelCase names fields in camelCase, such as parentTenantId.
+	FieldNamingCamelCase FieldNaming = "camelCase"
+
+	// fieldNamingKey is the echo context key the field naming of responses is stored under.
+	fieldNamingKey = "api.field-naming"
+)
+
+// fieldNamingSkippedFields are the fields holding objects whose keys are
+// data rather than field names, such as label keys and tenant IDs, so they
+// are never renamed.
+var fieldNamingSkippedFields = map[string]bool{
+	"labels": true,
+	"counts": true,
+	"ids":    true,
+}
+
+// ParseFieldNaming parses the name of a field naming strategy, snake_case or camelCase.
+func ParseFieldNaming(name string) (FieldNaming, error) {
+	switch naming := FieldNaming(name); naming {
+	case FieldNamingSnakeCase, FieldNamingCamelCase:
+		return naming, nil
+	}
+
+	return "", fmt.Errorf("%w: %q must be %s or %s", ErrInvalidFieldNaming, name, FieldNamingSnakeCase, FieldNamingCamelCase)
+}
+
+// setFieldNaming stores the configured field naming for the responses of the request.
+func (r *Router) setFieldNaming(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Set(fieldNamingKey, r.fieldNaming)
+
+		return next(c)
+	}
+}
+
+// fieldNaming returns the field naming of the request's responses, snake_case
+// unless otherwise configured.
+func fieldNaming(c echo.Context) FieldNaming {
+	if naming, _ := c.Get(fieldNamingKey).(FieldNaming); naming != "" {
+		return naming
+	}
+
+	return FieldNamingSnakeCase
+}
+
+// name returns the snake_case field name in the naming strategy.
+func (n FieldNaming) name(field string) string {
+	if n == FieldNamingCamelCase {
+		return camelCase(field)
+	}
+
+	return field
+}
+
+// marshal encodes the value as JSON with its fields named in the naming strategy.
+func (n FieldNaming) marshal(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil || n != FieldNamingCamelCase {
+		return b, err
+	}
+
+	return renameJSONFields(b, camelCase)
+}
+
+// camelCase converts the snake_case name to camelCase.
+func camelCase(name string) string {
+	parts := strings.Split(name, "_")
+
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+
+	return strings.Join(parts, "")
+}
+
+// snakeCase converts the camelCase name to snake_case. Runs of capitals are
+// treated as a single word, so tenantID is named tenant_id. Names already
+// in snake_case are unchanged.
+func snakeCase(name string) string {
+	var b strings.Builder
+
+	runes := []rune(name)
+
+	for i, c := range runes {
+		if unicode.IsUpper(c) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+				b.WriteByte('_')
+			}
+
+			c = unicode.ToLower(c)
+		}
+
+		b.WriteRune(c)
+	}
+
+	return b.String()
+}
+
+// renameJSONFields renames the object fields of the JSON document, keeping
+// their order. Fields in fieldNamingSkippedFields are renamed, but the keys
+// of the objects they hold are kept.
+func renameJSONFields(b []byte, rename func(string) string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var buf bytes.Buffer
+
+	if err := renameJSONValue(dec, &buf, rename, false); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// renameJSONValue copies the next JSON value from the decoder to the
+// buffer, renaming the fields of objects unless keep is set.
+func renameJSONValue(dec *json.Decoder, buf *bytes.Buffer, rename func(string) string, keep bool) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		buf.WriteByte('{')
+
+		for i := 0; dec.More(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			keyTok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+
+			key, _ := keyTok.(string)
+
+			name := key
+			if !keep {
+				name = rename(key)
+			}
+
+			if err := writeJSONToken(buf, name); err != nil {
+				return err
+			}
+
+			buf.WriteByte(':')
+
+			if err := renameJSONValue(dec, buf, rename, !keep && fieldNamingSkippedFields[snakeCase(key)]); err != nil {
+				return err
+			}
+		}
+
+		buf.WriteByte('}')
+	case json.Delim('['):
+		buf.WriteByte('[')
+
+		for i := 0; dec.More(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			if err := renameJSONValue(dec, buf, rename, false); err != nil {
+				return err
+			}
+		}
+
+		buf.WriteByte(']')
+	default:
+		return writeJSONToken(buf, tok)
+	}
+
+	// Consumes the closing delimiter of the object or array.
+	_, err = dec.Token()
+
+	return err
+}
+
+// writeJSONToken writes the scalar token or field name as JSON.
+func writeJSONToken(buf *bytes.Buffer, tok interface{}) error {
+	b, err := json.Marshal(tok)
+	if err != nil {
+		return err
+	}
+
+	buf.Write(b)
+
+	return nil
+}
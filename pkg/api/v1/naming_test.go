@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/tenant-api/internal/x/nullx"
+	"go.infratographer.com/x/gidx"
+)
+
+func TestParseFieldNaming(t *testing.T) {
+	for _, naming := range []FieldNaming{FieldNamingSnakeCase, FieldNamingCamelCase} {
+		parsed, err := ParseFieldNaming(string(naming))
+		require.NoError(t, err)
+		assert.Equal(t, naming, parsed)
+	}
+
+	_, err := ParseFieldNaming("PascalCase")
+	assert.ErrorIs(t, err, ErrInvalidFieldNaming)
+}
+
+func TestFieldNameCasing(t *testing.T) {
+	for snake, camel := range map[string]string{
+		"name":             "name",
+		"parent_tenant_id": "parentTenantId",
+		"ids_only":         "idsOnly",
+	} {
+		assert.Equal(t, camel, camelCase(snake), "unexpected camelCase of %s", snake)
+		assert.Equal(t, snake, snakeCase(camel), "unexpected snake_case of %s", camel)
+		assert.Equal(t, snake, snakeCase(snake), "expected %s to be unchanged", snake)
+	}
+
+	assert.Equal(t, "tenant_id", snakeCase("tenantID"), "expected capitals to be a single word")
+}
+
+func TestRenderFieldNaming(t *testing.T) {
+	parentID := gidx.MustNewID(TenantIDPrefix)
+
+	ts := []*models.Tenant{{
+		ID:             gidx.MustNewID(TenantIDPrefix),
+		Name:           "child",
+		ParentTenantID: nullx.PrefixedIDFrom(parentID),
+	}}
+
+	render := func(t *testing.T, naming FieldNaming) []map[string]json.RawMessage {
+		t.Helper()
+
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+		c.Set(fieldNamingKey, naming)
+
+		require.NoError(t, v1TenantsResponse(c, ts, nil, PaginationParams{}))
+
+		var body struct {
+			Tenants []map[string]json.RawMessage `json:"tenants"`
+		}
+
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		require.Len(t, body.Tenants, 1)
+
+		return body.Tenants
+	}
+
+	t.Run("snake case", func(t *testing.T) {
+		tenant := render(t, FieldNamingSnakeCase)[0]
+		assert.Contains(t, tenant, "parent_tenant_id")
+		assert.Contains(t, tenant, "created_at")
+	})
+
+	t.Run("camel case", func(t *testing.T) {
+		tenant := render(t, FieldNamingCamelCase)[0]
+		assert.Contains(t, tenant, "parentTenantId")
+		assert.Contains(t, tenant, "createdAt")
+		assert.NotContains(t, tenant, "parent_tenant_id")
+	})
+}
+
+func TestFieldNamingRoundTrip(t *testing.T) {
+	parentID := gidx.MustNewID(TenantIDPrefix)
+	description := "a <tenant>"
+
+	sent := batchCreateRequest{
+		Tenants: []batchCreateTenant{{
+			createTenantRequest: createTenantRequest{
+				Name:        "tenant",
+				Labels:      map[string]string{"cost_center": "a", "costCenter": "b"},
+				Description: &description,
+			},
+			ParentTenantID: &parentID,
+		}},
+	}
+
+	for _, naming := range []FieldNaming{FieldNamingSnakeCase, FieldNamingCamelCase} {
+		t.Run(string(naming), func(t *testing.T) {
+			b, err := naming.marshal(sent)
+			require.NoError(t, err)
+
+			assert.Contains(t, string(b), `"`+naming.name("parent_tenant_id")+`"`, "expected fields in the naming strategy")
+			assert.Contains(t, string(b), `"labels":{"costCenter":"b","cost_center":"a"}`, "expected label keys to be kept")
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(b)))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+			received := new(batchCreateRequest)
+
+			require.NoError(t, bindRequest(echo.New().NewContext(req, httptest.NewRecorder()), received))
+			assert.Equal(t, sent, *received, "expected the request to round-trip")
+		})
+	}
+}
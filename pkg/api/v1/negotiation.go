@@ -0,0 +1,112 @@
+package api
+
+import (
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// MIMEApplicationProtobuf is the content type of protobuf encoded responses.
+	MIMEApplicationProtobuf = "application/x-protobuf"
+
+	// contentTypeKey is the echo context key the negotiated response content type is stored under.
+	contentTypeKey = "api.content-type"
+)
+
+// acceptedMediaTypes maps the media types clients may accept to the content type served.
+var acceptedMediaTypes = map[string]string{
+	echo.MIMEApplicationJSON: echo.MIMEApplicationJSON,
+	"application/*":          echo.MIMEApplicationJSON,
+	"*/*":                    echo.MIMEApplicationJSON,
+	MIMEApplicationProtobuf:  MIMEApplicationProtobuf,
+	"application/protobuf":   MIMEApplicationProtobuf,
+}
+
+// negotiateContentType selects the response content type from the Accept
+// header, rejecting requests which accept none of the supported types before
+// the handler is run. JSON is served when no Accept header is provided.
+// Responses vary by the Accept header, so caches store each encoding apart.
+func negotiateContentType(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
+
+		accept := c.Request().Header.Get(echo.HeaderAccept)
+
+		contentType, ok := acceptedContentType(accept)
+		if !ok {
+			return v1NotAcceptableResponse(c, fmt.Errorf("%w: %s", ErrNotAcceptable, accept))
+		}
+
+		c.Set(contentTypeKey, contentType)
+
+		return next(c)
+	}
+}
+
+// acceptedContentType returns the supported content type with the highest
+// quality in the Accept header. When several types share the highest
+// quality, the first listed is used.
+func acceptedContentType(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return echo.MIMEApplicationJSON, true
+	}
+
+	var (
+		best    string
+		bestQty float64
+	)
+
+	for _, value := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+
+		contentType, ok := acceptedMediaTypes[mediaType]
+		if !ok {
+			continue
+		}
+
+		qty := 1.0
+
+		if q, ok := params["q"]; ok {
+			if qty, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		if qty > bestQty {
+			best, bestQty = contentType, qty
+		}
+	}
+
+	return best, best != ""
+}
+
+// render writes the response body using the negotiated content type. JSON
+// fields are named in the configured naming strategy.
+func render(c echo.Context, status int, body protoMarshaler) error {
+	if contentType, _ := c.Get(contentTypeKey).(string); contentType == MIMEApplicationProtobuf {
+		b, err := protoMarshalOptions.Marshal(body.toProto())
+		if err != nil {
+			return err
+		}
+
+		return c.Blob(status, MIMEApplicationProtobuf, b)
+	}
+
+	if naming := fieldNaming(c); naming != FieldNamingSnakeCase {
+		b, err := naming.marshal(body)
+		if err != nil {
+			return err
+		}
+
+		return c.JSONBlob(status, b)
+	}
+
+	return c.JSON(status, body)
+}
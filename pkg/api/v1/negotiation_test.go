@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/tenant-api/pkg/api/v1/tenantpb"
+	"go.infratographer.com/x/gidx"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestAcceptedContentType(t *testing.T) {
+	testCases := []struct {
+		name     string
+		accept   string
+		expected string
+		ok       bool
+	}{
+		{"empty", "", echo.MIMEApplicationJSON, true},
+		{"json", "application/json", echo.MIMEApplicationJSON, true},
+		{"any", "*/*", echo.MIMEApplicationJSON, true},
+		{"any application", "application/*", echo.MIMEApplicationJSON, true},
+		{"protobuf", "application/x-protobuf", MIMEApplicationProtobuf, true},
+		{"protobuf alias", "application/protobuf", MIMEApplicationProtobuf, true},
+		{"first listed wins", "application/x-protobuf, application/json", MIMEApplicationProtobuf, true},
+		{"quality preferred", "application/json;q=0.5, application/x-protobuf", MIMEApplicationProtobuf, true},
+		{"wildcard fallback", "text/html, */*;q=0.1", echo.MIMEApplicationJSON, true},
+		{"zero quality", "application/json;q=0", "", false},
+		{"unsupported", "text/html", "", false},
+		{"invalid", ";;", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			contentType, ok := acceptedContentType(tc.accept)
+
+			assert.Equal(t, tc.ok, ok, "unexpected acceptance")
+			assert.Equal(t, tc.expected, contentType, "unexpected content type")
+		})
+	}
+}
+
+func TestNegotiateContentType(t *testing.T) {
+	parentID := gidx.MustNewID(TenantIDPrefix)
+	createdAt := time.Date(2023, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	body := v1TenantResponse{
+		Tenant: &tenant{
+			ID:             gidx.MustNewID(TenantIDPrefix),
+			Name:           "test",
+			ParentTenantID: &parentID,
+			CreatedAt:      createdAt,
+			UpdatedAt:      createdAt,
+		},
+		Version: apiVersion,
+	}
+
+	serve := func(accept string) *httptest.ResponseRecorder {
+		e := echo.New()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if accept != "" {
+			req.Header.Set(echo.HeaderAccept, accept)
+		}
+
+		rec := httptest.NewRecorder()
+
+		handler := negotiateContentType(func(c echo.Context) error {
+			return render(c, http.StatusOK, body)
+		})
+
+		require.NoError(t, handler(e.NewContext(req, rec)))
+
+		return rec
+	}
+
+	t.Run("json by default", func(t *testing.T) {
+		rec := serve("")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get(echo.HeaderContentType), echo.MIMEApplicationJSON)
+		assert.Equal(t, echo.HeaderAccept, rec.Header().Get(echo.HeaderVary))
+
+		var result v1TenantResponse
+
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		assert.Equal(t, body.Tenant.ID, result.Tenant.ID)
+	})
+
+	t.Run("protobuf", func(t *testing.T) {
+		rec := serve(MIMEApplicationProtobuf)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, MIMEApplicationProtobuf, rec.Header().Get(echo.HeaderContentType))
+
+		var response tenantpb.TenantResponse
+
+		require.NoError(t, proto.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, apiVersion, response.Version)
+
+		require.NotNil(t, response.Tenant, "expected a tenant")
+		assert.Equal(t, string(body.Tenant.ID), response.Tenant.Id)
+		assert.Equal(t, "test", response.Tenant.Name)
+		assert.Equal(t, string(parentID), response.Tenant.GetParentTenantId())
+		assert.Nil(t, response.Tenant.DeletedAt, "expected no deleted_at")
+		assert.Equal(t, createdAt, response.Tenant.CreatedAt.AsTime())
+	})
+
+	t.Run("not acceptable", func(t *testing.T) {
+		rec := serve("text/html")
+
+		assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+
+		var result v1ErrorResponseBody
+
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		assert.Equal(t, http.StatusNotAcceptable, result.Status)
+	})
+}
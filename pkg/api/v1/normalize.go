@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/tenant-api/internal/pubsub"
+	"go.infratographer.com/x/gidx"
+	"go.uber.org/zap"
+	"golang.org/x/text/unicode/norm"
+)
+
+// normalizationReasonInvalid is the reason a normalized name which is not a
+// valid tenant name, such as a name of only whitespace or one not matching the
+// name pattern, is not applied.
+const normalizationReasonInvalid = "invalid_name"
+
+// normalizationReasonValidationFailed is the reason a normalized name rejected
+// by a registered validator is not applied.
+const normalizationReasonValidationFailed = "validation_failed"
+
+// normalizeTenantName returns the NFC normalized name without surrounding whitespace.
+func normalizeTenantName(name string) string {
+	return strings.TrimSpace(norm.NFC.String(name))
+}
+
+// tenantNormalizeNames normalizes the names of all tenants, in batches
+// ordered by ID, as with reindexing. Names are NFC normalized and trimmed.
+//
+// A normalized name which would conflict with another tenant's name, is not
+// a valid name or is rejected by a registered validator, is not applied and is reported as a conflict instead, so
+// the tenant can be renamed by hand. Normalizing is idempotent, so it may be
+// rerun to resume, or after resolving the conflicts.
+func (r *Router) tenantNormalizeNames(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantNormalizeNames")
+	defer span.End()
+
+	after, batchSize, maxBatches, err := parseBatchParams(c)
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	actor := r.actor(c)
+
+	result := nameNormalizationResult{}
+
+	for batch := 0; maxBatches == 0 || batch < maxBatches; batch++ {
+		// Whole tenants are loaded, as validators are given the tenant.
+		ts, err := models.Tenants(
+			models.TenantWhere.ID.GT(after),
+			qm.OrderBy(models.TenantColumns.ID),
+			qm.Limit(batchSize),
+		).All(ctx, r.db)
+		if err != nil {
+			r.requestLogger(c).Error("failed to query tenants for name normalization", zap.Error(err))
+
+			return v1InternalServerErrorResponse(c, err)
+		}
+
+		if len(ts) == 0 {
+			return v1NameNormalizationResponse(c, result)
+		}
+
+		updated, conflicts, err := r.normalizeNamesBatch(ctx, ts, actor)
+		if err != nil {
+			r.requestLogger(c).Error("failed to normalize tenant names", zap.String("normalize.after", string(after)), zap.Error(err))
+
+			return v1InternalServerErrorResponse(c, err)
+		}
+
+		r.publishNormalizedNames(c, actor, updated)
+
+		result.Processed += len(ts)
+		result.Updated += len(updated)
+		result.Conflicting += len(conflicts)
+		result.Conflicts = append(result.Conflicts, conflicts...)
+
+		after = ts[len(ts)-1].ID
+
+		r.requestLogger(c).Debug("normalized tenant name batch",
+			zap.String("normalize.last_id", string(after)),
+			zap.Int("normalize.processed", result.Processed),
+			zap.Int("normalize.updated", result.Updated),
+			zap.Int("normalize.conflicting", result.Conflicting),
+		)
+
+		if len(ts) < batchSize {
+			return v1NameNormalizationResponse(c, result)
+		}
+	}
+
+	result.Next = after
+
+	return v1NameNormalizationResponse(c, result)
+}
+
+// normalizeNamesBatch applies the normalized names of the provided tenants
+// within a transaction, recording the name changes. It returns the IDs of the
+// renamed tenants and the names which could not be applied.
+func (r *Router) normalizeNamesBatch(ctx context.Context, ts models.TenantSlice, actor string) ([]gidx.PrefixedID, []nameNormalizationConflict, error) {
+	var (
+		updated   []gidx.PrefixedID
+		conflicts []nameNormalizationConflict
+	)
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		// A retried batch starts over.
+		updated, conflicts = nil, nil
+
+		for _, t := range ts {
+			name := normalizeTenantName(t.Name)
+			if name == t.Name {
+				continue
+			}
+
+			conflict := nameNormalizationConflict{
+				ID:             t.ID,
+				Name:           t.Name,
+				NormalizedName: name,
+			}
+
+			if err := validateTenantName(name); err != nil || r.checkNamePattern(name) != nil || r.checkNameResolvable(name) != nil {
+				conflict.Reason = normalizationReasonInvalid
+				conflicts = append(conflicts, conflict)
+
+				continue
+			}
+
+			// Names renamed earlier in the batch are seen by the check, so
+			// tenants normalizing to the same name conflict with each other.
+			if err := r.checkNameConflict(ctx, tx, t.ID, t.ParentTenantID.PrefixedID, name); err != nil {
+				if !errors.Is(err, ErrNameConflict) {
+					return err
+				}
+
+				conflict.Reason = errorCodeNameConflict
+				conflicts = append(conflicts, conflict)
+
+				continue
+			}
+
+			now := time.Now().UTC()
+
+			renamed := *t
+			renamed.Name = name
+			renamed.UpdatedAt = now
+			renamed.UpdatedBy = actorColumn(actor)
+
+			if err := r.validateUpdate(ctx, t, &renamed); err != nil {
+				conflict.Reason = normalizationReasonValidationFailed
+				conflicts = append(conflicts, conflict)
+
+				continue
+			}
+
+			if _, err := models.Tenants(models.TenantWhere.ID.EQ(t.ID)).UpdateAll(ctx, tx, models.M{
+				models.TenantColumns.Name:      name,
+				models.TenantColumns.UpdatedAt: now,
+				models.TenantColumns.UpdatedBy: actorColumn(actor),
+			}); err != nil {
+				return err
+			}
+
+			change := tenantNameChange{
+				OldName:   t.Name,
+				NewName:   name,
+				Actor:     actor,
+				ChangedAt: now,
+			}
+
+			if err := recordNameChange(ctx, tx, t.ID, change); err != nil {
+				return err
+			}
+
+			updated = append(updated, t.ID)
+		}
+
+		return nil
+	})
+
+	return updated, conflicts, err
+}
+
+// publishNormalizedNames publishes update events for the renamed tenants.
+func (r *Router) publishNormalizedNames(c echo.Context, actor string, ids []gidx.PrefixedID) {
+	ctx := c.Request().Context()
+
+	for _, id := range ids {
+		msg, err := pubsub.UpdateTenantMessage(gidx.PrefixedID(actor), id)
+		if err != nil {
+			// TODO: add status to reconcile and requeue this
+			r.requestLogger(c).Error("failed to create, update tenant message", zap.Error(err))
+		}
+
+		if err := r.pubsub.PublishUpdate(ctx, "tenants", "global", msg); err != nil {
+			// TODO: add status to reconcile and requeue this
+			r.requestLogger(c).Error("failed to publish, update tenant message", zap.Error(err))
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/labstack/echo/v4"
@@ -15,13 +16,22 @@ var (
 	defaultPaginationSize = 100
 )
 
-// PaginationParams allow you to paginate the results
+// headerTotalCount is the header the total number of listed tenants is set in.
+const headerTotalCount = "X-Total-Count"
+
+// PaginationParams allow you to paginate the results. Lists are paged by
+// either page number or cursor. Cursors are recommended, as rows deleted
+// while paging shift the following pages' offsets, skipping rows. In
+// responses, the cursor is the cursor of the next page. Lists of tenants
+// changed since a sequence number are paged by the since_seq returned in
+// their responses instead.
 type PaginationParams struct {
-	Limit   int    `json:"limit,omitempty"`
-	Page    int    `json:"page,omitempty"`
-	Cursor  string `json:"cursor,omitempty"`
-	Preload bool   `json:"preload,omitempty"`
-	OrderBy string `json:"orderby,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+	Page     int    `json:"page,omitempty"`
+	Cursor   string `json:"cursor,omitempty"`
+	Preload  bool   `json:"preload,omitempty"`
+	OrderBy  string `json:"orderby,omitempty"`
+	SinceSeq *int64 `json:"since_seq,omitempty"`
 }
 
 func parsePagination(c echo.Context) PaginationParams {
@@ -48,6 +58,31 @@ func parsePagination(c echo.Context) PaginationParams {
 	}
 }
 
+// parseIncludeTotal parses the include_total query parameter, which allows
+// clients to skip counting the total number of results. The total is included
+// by default.
+func parseIncludeTotal(c echo.Context) (bool, error) {
+	value := c.QueryParam("include_total")
+	if value == "" {
+		return true, nil
+	}
+
+	includeTotal, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%w: include_total must be true or false", ErrInvalidQueryParam)
+	}
+
+	return includeTotal, nil
+}
+
+// setTotalCount sets the total number of listed tenants in the response
+// headers, in addition to the body, when the total was counted.
+func setTotalCount(c echo.Context, total *int64) {
+	if total != nil {
+		c.Response().Header().Set(headerTotalCount, strconv.FormatInt(*total, 10))
+	}
+}
+
 func (p *PaginationParams) limitUsed() int {
 	var limit int
 
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/x/gidx"
+	"go.uber.org/zap"
+)
+
+const (
+	// tenantPathSeparator separates the tenant IDs within a materialized path.
+	tenantPathSeparator = "."
+
+	// DefaultMaxDepth is the default limit on how many levels the recursive
+	// hierarchy queries walk, protecting them from cycles in the parent pointers.
+	DefaultMaxDepth = 1000
+
+	// tenantPathsQuery resolves the materialized path of the requested tenants
+	// by walking their parent pointers up to the root tenant, walking at most
+	// $2 levels. Tenants whose ancestry is deeper than the limit are returned
+	// with a parent, while tenants whose ancestry otherwise does not end at a
+	// root are not returned.
+	tenantPathsQuery = `
+		WITH RECURSIVE ancestry AS (
+			SELECT id AS tenant_id, parent_tenant_id, id::TEXT AS path, 1 AS depth
+			FROM tenants
+			WHERE id = ANY($1)
+
+			UNION ALL
+
+			SELECT a.tenant_id, t.parent_tenant_id, t.id || '.' || a.path, a.depth + 1
+			FROM tenants t
+			INNER JOIN ancestry a ON t.id = a.parent_tenant_id
+			WHERE a.depth < $2
+		)
+		SELECT tenant_id, path, parent_tenant_id IS NOT NULL
+		FROM ancestry
+		WHERE parent_tenant_id IS NULL OR depth = $2
+	`
+)
+
+// tenantPath returns the materialized path for a tenant with the provided parent path.
+func tenantPath(parentPath string, id gidx.PrefixedID) string {
+	if parentPath == "" {
+		return string(id)
+	}
+
+	return parentPath + tenantPathSeparator + string(id)
+}
+
+// subtreeQueryMod matches all descendants of the tenant with the provided path.
+func subtreeQueryMod(path string) qm.QueryMod {
+	return qm.Where("tenants.path LIKE ?", subtreePathPattern(path))
+}
+
+// subtreePathPattern returns the LIKE pattern matching the paths of all
+// descendants of the tenant with the provided path. Wildcards in the path
+// are escaped, so only paths beginning with it are matched.
+func subtreePathPattern(path string) string {
+	return escapeLike(path+tenantPathSeparator) + "%"
+}
+
+// resolveTenantPaths computes the materialized paths of the provided tenants from
+// their parent pointers, without relying on any previously stored paths.
+// Tenants whose ancestry exceeds the maximum depth are logged and not resolved.
+func (r *Router) resolveTenantPaths(ctx context.Context, exec boil.ContextExecutor, ids []gidx.PrefixedID) (map[gidx.PrefixedID]string, error) {
+	paths := make(map[gidx.PrefixedID]string, len(ids))
+
+	if len(ids) == 0 {
+		return paths, nil
+	}
+
+	params := make([]string, len(ids))
+
+	for i, id := range ids {
+		params[i] = string(id)
+	}
+
+	rows, err := exec.QueryContext(ctx, tenantPathsQuery, pq.Array(params), r.maxDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id       gidx.PrefixedID
+			path     string
+			exceeded bool
+		)
+
+		if err := rows.Scan(&id, &path, &exceeded); err != nil {
+			return nil, err
+		}
+
+		if exceeded {
+			r.logger.Error("tenant ancestry exceeds maximum depth",
+				zap.String("tenant-id", string(id)),
+				zap.Error(ErrMaxDepthExceeded),
+				zap.Int("max-depth", r.maxDepth),
+			)
+
+			continue
+		}
+
+		paths[id] = path
+	}
+
+	return paths, rows.Err()
+}
+
+// lookupTenantPath returns the materialized path of the provided tenant.
+// If the tenant's path has not been populated yet, it is resolved from the hierarchy.
+func (r *Router) lookupTenantPath(ctx context.Context, exec boil.ContextExecutor, tenantID gidx.PrefixedID) (string, error) {
+	t, err := models.FindTenant(ctx, exec, tenantID)
+	if err != nil {
+		return "", err
+	}
+
+	if t.Path != "" {
+		return t.Path, nil
+	}
+
+	paths, err := r.resolveTenantPaths(ctx, exec, []gidx.PrefixedID{t.ID})
+	if err != nil {
+		return "", err
+	}
+
+	path, ok := paths[t.ID]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnresolvedPath, t.ID)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,466 @@
+package api
+
+import (
+	"go.infratographer.com/tenant-api/pkg/api/v1/tenantpb"
+	"go.infratographer.com/x/gidx"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// protoMarshalOptions encode responses deterministically, with map fields
+// ordered by key.
+var protoMarshalOptions = proto.MarshalOptions{Deterministic: true}
+
+// protoMarshaler is implemented by response bodies which can be encoded as
+// the protobuf messages generated from tenantpb/tenant.proto.
+type protoMarshaler interface {
+	toProto() proto.Message
+}
+
+// toProto maps the tenant onto a Tenant message.
+func (t *tenant) toProto() *tenantpb.Tenant {
+	msg := &tenantpb.Tenant{
+		Id:          string(t.ID),
+		Name:        t.Name,
+		CreatedAt:   timestamppb.New(t.CreatedAt),
+		UpdatedAt:   timestamppb.New(t.UpdatedAt),
+		Locked:      t.Locked,
+		CreatedBy:   t.CreatedBy,
+		UpdatedBy:   t.UpdatedBy,
+		Quota:       t.Quota,
+		Description: t.Description,
+		Kind:        t.Kind,
+	}
+
+	if t.ParentTenantID != nil {
+		parentID := string(*t.ParentTenantID)
+		msg.ParentTenantId = &parentID
+	}
+
+	if t.DeletedAt != nil {
+		msg.DeletedAt = timestamppb.New(*t.DeletedAt)
+	}
+
+	return msg
+}
+
+// toProto maps the tenants onto Tenant messages.
+func (ts tenantSlice) toProto() []*tenantpb.Tenant {
+	msgs := make([]*tenantpb.Tenant, len(ts))
+
+	for i, t := range ts {
+		msgs[i] = t.toProto()
+	}
+
+	return msgs
+}
+
+// toProto maps the response onto a TenantResponse message.
+func (r v1TenantResponse) toProto() proto.Message {
+	msg := &tenantpb.TenantResponse{Version: r.Version}
+
+	if r.Tenant != nil {
+		msg.Tenant = r.Tenant.toProto()
+	}
+
+	return msg
+}
+
+// toProto maps the response onto a TenantResponse message, with the
+// children fields set.
+func (r v1TenantWithChildrenResponse) toProto() proto.Message {
+	return &tenantpb.TenantResponse{
+		Tenant:            r.Tenant.toProto(),
+		Version:           r.Version,
+		Children:          r.Children.toProto(),
+		ChildrenTruncated: r.ChildrenTruncated,
+	}
+}
+
+// toProto maps the response onto a TenantSliceResponse message.
+func (r v1TenantSliceResponse) toProto() proto.Message {
+	return &tenantpb.TenantSliceResponse{
+		Tenants:  r.Tenants.toProto(),
+		Version:  r.Version,
+		Limit:    int64(r.Limit),
+		Page:     int64(r.Page),
+		Total:    r.Total,
+		Cursor:   r.Cursor,
+		SinceSeq: r.SinceSeq,
+	}
+}
+
+// toProto maps the response onto a TenantDepthSliceResponse message.
+func (r v1TenantDepthSliceResponse) toProto() proto.Message {
+	tenants := make([]*tenantpb.DepthTenant, len(r.Tenants))
+
+	for i, t := range r.Tenants {
+		tenants[i] = &tenantpb.DepthTenant{
+			Tenant:        t.tenant.toProto(),
+			RelativeDepth: int64(t.RelativeDepth),
+		}
+	}
+
+	return &tenantpb.TenantDepthSliceResponse{
+		Tenants:  tenants,
+		Version:  r.Version,
+		Limit:    int64(r.Limit),
+		Page:     int64(r.Page),
+		Total:    r.Total,
+		Cursor:   r.Cursor,
+		SinceSeq: r.SinceSeq,
+	}
+}
+
+// toProto maps the response onto a TenantIDSliceResponse message.
+func (r v1TenantIDSliceResponse) toProto() proto.Message {
+	return &tenantpb.TenantIDSliceResponse{
+		Ids:      protoIDs(r.IDs),
+		Version:  r.Version,
+		Limit:    int64(r.Limit),
+		Page:     int64(r.Page),
+		Total:    r.Total,
+		Cursor:   r.Cursor,
+		SinceSeq: r.SinceSeq,
+	}
+}
+
+// toProto maps the response onto a TenantNameSliceResponse message.
+func (r v1TenantNameSliceResponse) toProto() proto.Message {
+	names := make([]*tenantpb.TenantNameChange, len(r.Names))
+
+	for i, n := range r.Names {
+		names[i] = &tenantpb.TenantNameChange{
+			OldName:   n.OldName,
+			NewName:   n.NewName,
+			Actor:     n.Actor,
+			ChangedAt: timestamppb.New(n.ChangedAt),
+		}
+	}
+
+	return &tenantpb.TenantNameSliceResponse{
+		Names:   names,
+		Version: r.Version,
+		Limit:   int64(r.Limit),
+		Page:    int64(r.Page),
+	}
+}
+
+// toProto maps the response onto a TenantLabelsResponse message.
+func (r v1TenantLabelsResponseBody) toProto() proto.Message {
+	return &tenantpb.TenantLabelsResponse{
+		Labels:  r.Labels,
+		Version: r.Version,
+	}
+}
+
+// toProto maps the response onto a TenantLabelKeysResponse message.
+func (r v1TenantLabelKeysResponseBody) toProto() proto.Message {
+	keys := make([]*tenantpb.LabelKeyCount, len(r.Keys))
+
+	for i, key := range r.Keys {
+		keys[i] = &tenantpb.LabelKeyCount{Key: key.Key, Count: key.Count}
+	}
+
+	return &tenantpb.TenantLabelKeysResponse{
+		Keys:    keys,
+		Version: r.Version,
+		Limit:   int64(r.Limit),
+		Page:    int64(r.Page),
+	}
+}
+
+// toProto maps the response onto a TenantLabelValuesResponse message.
+func (r v1TenantLabelValuesResponseBody) toProto() proto.Message {
+	values := make([]*tenantpb.LabelValueCount, len(r.Values))
+
+	for i, value := range r.Values {
+		values[i] = &tenantpb.LabelValueCount{Value: value.Value, Count: value.Count}
+	}
+
+	return &tenantpb.TenantLabelValuesResponse{
+		Key:     r.Key,
+		Values:  values,
+		Version: r.Version,
+		Limit:   int64(r.Limit),
+		Page:    int64(r.Page),
+	}
+}
+
+// toProto maps the response onto a TenantLabelResponse message.
+func (r v1TenantLabelResponseBody) toProto() proto.Message {
+	return &tenantpb.TenantLabelResponse{
+		Key:     r.Key,
+		Value:   r.Value,
+		Version: r.Version,
+	}
+}
+
+// toProto maps the response onto a TenantSubtreeLabelResponse message.
+func (r v1TenantSubtreeLabelResponseBody) toProto() proto.Message {
+	return &tenantpb.TenantSubtreeLabelResponse{
+		Id:      string(r.ID),
+		Key:     r.Key,
+		Value:   r.Value,
+		Tenants: protoIDs(r.Tenants),
+		Count:   int64(r.Count),
+		DryRun:  r.DryRun,
+		Version: r.Version,
+	}
+}
+
+// toProto maps the response onto a TenantImportResponse message.
+func (r v1TenantImportResponseBody) toProto() proto.Message {
+	ids := make(map[string]string, len(r.IDs))
+
+	for from, to := range r.IDs {
+		ids[string(from)] = string(to)
+	}
+
+	return &tenantpb.TenantImportResponse{
+		Tenants: r.Tenants.toProto(),
+		Ids:     ids,
+		DryRun:  r.DryRun,
+		Version: r.Version,
+	}
+}
+
+// toProto maps the response onto a TenantChildCountsResponse message.
+func (r v1TenantChildCountsResponseBody) toProto() proto.Message {
+	counts := make(map[string]int64, len(r.Counts))
+
+	for id, count := range r.Counts {
+		counts[string(id)] = count
+	}
+
+	return &tenantpb.TenantChildCountsResponse{
+		Counts:  counts,
+		Version: r.Version,
+	}
+}
+
+// toProto maps the response onto a TenantAncestryResponse message.
+func (r v1TenantAncestryResponseBody) toProto() proto.Message {
+	return &tenantpb.TenantAncestryResponse{
+		Ancestor: r.Ancestor,
+		Depth:    int64(r.Depth),
+		Version:  r.Version,
+	}
+}
+
+// toProto maps the response onto a TenantContainsResponse message.
+func (r v1TenantContainsResponseBody) toProto() proto.Message {
+	return &tenantpb.TenantContainsResponse{
+		Contains: r.Contains,
+		Version:  r.Version,
+	}
+}
+
+// toProto maps the response onto a TenantNameValidationResponse message.
+func (r v1TenantNameValidationResponseBody) toProto() proto.Message {
+	return &tenantpb.TenantNameValidationResponse{
+		Valid:   r.Valid,
+		Reason:  r.Reason,
+		Message: r.Message,
+		Version: r.Version,
+	}
+}
+
+// toProto maps the response onto a TenantBatchCreateResponse message.
+func (r v1TenantBatchCreateResponseBody) toProto() proto.Message {
+	results := make([]*tenantpb.BatchCreateResult, len(r.Results))
+
+	for i, result := range r.Results {
+		results[i] = &tenantpb.BatchCreateResult{
+			Status: int64(result.Status),
+			Error:  result.Error,
+			Code:   result.Code,
+		}
+
+		if result.Tenant != nil {
+			results[i].Tenant = result.Tenant.toProto()
+		}
+	}
+
+	return &tenantpb.TenantBatchCreateResponse{
+		Results: results,
+		Version: r.Version,
+	}
+}
+
+// toProto maps the response onto a TenantBatchGetResponse message.
+func (r v1TenantBatchGetResponseBody) toProto() proto.Message {
+	tenants := make([]*tenantpb.BatchGetTenant, len(r.Tenants))
+
+	for i, t := range r.Tenants {
+		tenants[i] = &tenantpb.BatchGetTenant{
+			Tenant: t.tenant.toProto(),
+			Path:   t.Path.toProto(),
+		}
+
+		if t.Parent != nil {
+			tenants[i].Parent = t.Parent.toProto()
+		}
+	}
+
+	return &tenantpb.TenantBatchGetResponse{
+		Tenants: tenants,
+		Missing: protoIDs(r.Missing),
+		Version: r.Version,
+	}
+}
+
+// toProto maps the response onto a TenantStatsResponse message.
+func (r v1TenantStatsResponseBody) toProto() proto.Message {
+	return &tenantpb.TenantStatsResponse{
+		Descendants: r.Descendants,
+		MaxDepth:    int64(r.MaxDepth),
+		Leaves:      r.Leaves,
+		Version:     r.Version,
+	}
+}
+
+// toProto maps the response onto a TenantDepthHistogramResponse message.
+func (r v1TenantDepthHistogramResponseBody) toProto() proto.Message {
+	levels := make([]*tenantpb.DepthCount, len(r.Levels))
+
+	for i, level := range r.Levels {
+		levels[i] = &tenantpb.DepthCount{Depth: int64(level.Depth), Count: level.Count}
+	}
+
+	return &tenantpb.TenantDepthHistogramResponse{
+		Levels:  levels,
+		Version: r.Version,
+	}
+}
+
+// toProto maps the response onto a TenantCreationTimeseriesResponse message.
+func (r v1TenantCreationTimeseriesResponseBody) toProto() proto.Message {
+	buckets := make([]*tenantpb.TimeseriesBucket, len(r.Buckets))
+
+	for i, bucket := range r.Buckets {
+		buckets[i] = &tenantpb.TimeseriesBucket{Start: timestamppb.New(bucket.Start), Count: bucket.Count}
+	}
+
+	return &tenantpb.TenantCreationTimeseriesResponse{
+		Interval: string(r.Interval),
+		From:     timestamppb.New(r.From),
+		To:       timestamppb.New(r.To),
+		Buckets:  buckets,
+		Version:  r.Version,
+	}
+}
+
+// toProto maps the response onto a ReindexResponse message.
+func (r v1ReindexResponseBody) toProto() proto.Message {
+	return &tenantpb.ReindexResponse{
+		Processed:  int64(r.Processed),
+		Updated:    int64(r.Updated),
+		Unresolved: protoIDs(r.Unresolved),
+		Next:       string(r.Next),
+		Version:    r.Version,
+	}
+}
+
+// toProto maps the response onto a TenantIntegrityResponse message.
+func (r v1TenantIntegrityResponseBody) toProto() proto.Message {
+	return &tenantpb.TenantIntegrityResponse{
+		MissingParents: protoIDs(r.MissingParents),
+		Cycles:         protoIDs(r.Cycles),
+		Version:        r.Version,
+	}
+}
+
+// toProto maps the response onto a TenantDeletePreviewResponse message.
+func (r v1TenantDeletePreviewResponseBody) toProto() proto.Message {
+	return &tenantpb.TenantDeletePreviewResponse{
+		Id:      string(r.ID),
+		Tenants: protoIDs(r.Tenants),
+		Count:   int64(r.Count),
+		Locked:  protoIDs(r.Locked),
+		Version: r.Version,
+	}
+}
+
+// toProto maps the response onto a TenantRepairResponse message.
+func (r v1TenantRepairResponseBody) toProto() proto.Message {
+	return &tenantpb.TenantRepairResponse{
+		Id:               string(r.ID),
+		Issue:            r.Issue,
+		PreviousParentId: string(r.PreviousParentID),
+		PathsUpdated:     int64(r.PathsUpdated),
+		Version:          r.Version,
+	}
+}
+
+// toProto maps the response onto a NameNormalizationResponse message.
+func (r v1NameNormalizationResponseBody) toProto() proto.Message {
+	conflicts := make([]*tenantpb.NameNormalizationConflict, len(r.Conflicts))
+
+	for i, conflict := range r.Conflicts {
+		conflicts[i] = &tenantpb.NameNormalizationConflict{
+			Id:             string(conflict.ID),
+			Name:           conflict.Name,
+			NormalizedName: conflict.NormalizedName,
+			Reason:         conflict.Reason,
+		}
+	}
+
+	return &tenantpb.NameNormalizationResponse{
+		Processed:   int64(r.Processed),
+		Updated:     int64(r.Updated),
+		Conflicting: int64(r.Conflicting),
+		Conflicts:   conflicts,
+		Next:        string(r.Next),
+		Version:     r.Version,
+	}
+}
+
+// toProto maps the response onto a VersionResponse message.
+func (r v1VersionResponse) toProto() proto.Message {
+	return &tenantpb.VersionResponse{Version: r.Version}
+}
+
+// toProto maps the response onto a BuildInfoResponse message.
+func (r v1BuildInfoResponse) toProto() proto.Message {
+	return &tenantpb.BuildInfoResponse{
+		Version:    r.Version,
+		Commit:     r.Commit,
+		BuildDate:  r.BuildDate,
+		ApiVersion: r.APIVersion,
+	}
+}
+
+// toProto maps the response onto an ErrorResponse message.
+func (r v1ErrorResponseBody) toProto() proto.Message {
+	msg := &tenantpb.ErrorResponse{
+		Version:    r.Version,
+		Message:    r.Message,
+		Error:      r.Error,
+		Status:     int64(r.Status),
+		RequestId:  r.RequestID,
+		Code:       r.Code,
+		ChildCount: r.ChildCount,
+	}
+
+	for _, f := range r.Fields {
+		msg.Fields = append(msg.Fields, &tenantpb.FieldError{Field: f.Field, Error: f.Error})
+	}
+
+	return msg
+}
+
+// protoIDs converts the IDs to the strings of repeated string fields.
+func protoIDs(ids []gidx.PrefixedID) []string {
+	if ids == nil {
+		return nil
+	}
+
+	s := make([]string, len(ids))
+
+	for i, id := range ids {
+		s[i] = string(id)
+	}
+
+	return s
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/tenant-api/pkg/api/v1/tenantpb"
+	"go.infratographer.com/x/gidx"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestProtoRoundTrip(t *testing.T) {
+	var (
+		id        = gidx.MustNewID(TenantIDPrefix)
+		parentID  = gidx.MustNewID(TenantIDPrefix)
+		createdAt = time.Date(2023, 1, 2, 3, 4, 5, 6, time.UTC)
+		deletedAt = createdAt.Add(time.Hour)
+		zero      = int64(0)
+		actor     = "user"
+		kind      = "org"
+	)
+
+	full := &tenant{
+		ID:             id,
+		Name:           "full",
+		ParentTenantID: &parentID,
+		CreatedAt:      createdAt,
+		UpdatedAt:      createdAt,
+		DeletedAt:      &deletedAt,
+		Locked:         true,
+		CreatedBy:      &actor,
+		UpdatedBy:      &actor,
+		Quota:          &zero,
+		Kind:           &kind,
+	}
+
+	fullProto := &tenantpb.Tenant{
+		Id:             string(id),
+		Name:           "full",
+		ParentTenantId: proto.String(string(parentID)),
+		CreatedAt:      timestamppb.New(createdAt),
+		UpdatedAt:      timestamppb.New(createdAt),
+		DeletedAt:      timestamppb.New(deletedAt),
+		Locked:         true,
+		CreatedBy:      proto.String(actor),
+		UpdatedBy:      proto.String(actor),
+		Quota:          proto.Int64(0),
+		Kind:           proto.String(kind),
+	}
+
+	testCases := []struct {
+		name     string
+		body     protoMarshaler
+		decoded  proto.Message
+		expected proto.Message
+	}{
+		{
+			name:     "tenant",
+			body:     v1TenantResponse{Tenant: full, Version: apiVersion},
+			decoded:  &tenantpb.TenantResponse{},
+			expected: &tenantpb.TenantResponse{Tenant: fullProto, Version: apiVersion},
+		},
+		{
+			name: "tenant with children",
+			body: v1TenantWithChildrenResponse{
+				Tenant:            full,
+				Children:          tenantSlice{full},
+				ChildrenTruncated: true,
+				Version:           apiVersion,
+			},
+			decoded: &tenantpb.TenantResponse{},
+			expected: &tenantpb.TenantResponse{
+				Tenant:            fullProto,
+				Children:          []*tenantpb.Tenant{fullProto},
+				ChildrenTruncated: true,
+				Version:           apiVersion,
+			},
+		},
+		{
+			name: "optional zero values",
+			body: v1TenantSliceResponse{
+				Tenants: tenantSlice{full},
+				Version: apiVersion,
+				Total:   &zero,
+				PaginationParams: PaginationParams{
+					Limit:    10,
+					SinceSeq: &zero,
+				},
+			},
+			decoded: &tenantpb.TenantSliceResponse{},
+			expected: &tenantpb.TenantSliceResponse{
+				Tenants:  []*tenantpb.Tenant{fullProto},
+				Version:  apiVersion,
+				Limit:    10,
+				Total:    proto.Int64(0),
+				SinceSeq: proto.Int64(0),
+			},
+		},
+		{
+			name: "map",
+			body: v1TenantChildCountsResponseBody{
+				Counts:  map[gidx.PrefixedID]int64{id: 2, parentID: 0},
+				Version: apiVersion,
+			},
+			decoded: &tenantpb.TenantChildCountsResponse{},
+			expected: &tenantpb.TenantChildCountsResponse{
+				Counts:  map[string]int64{string(id): 2, string(parentID): 0},
+				Version: apiVersion,
+			},
+		},
+		{
+			name: "nested",
+			body: v1TenantBatchGetResponseBody{
+				Tenants: []batchGetTenant{{tenant: full, Parent: full, Path: tenantSlice{full}}},
+				Missing: []gidx.PrefixedID{parentID},
+				Version: apiVersion,
+			},
+			decoded: &tenantpb.TenantBatchGetResponse{},
+			expected: &tenantpb.TenantBatchGetResponse{
+				Tenants: []*tenantpb.BatchGetTenant{{Tenant: fullProto, Parent: fullProto, Path: []*tenantpb.Tenant{fullProto}}},
+				Missing: []string{string(parentID)},
+				Version: apiVersion,
+			},
+		},
+		{
+			name: "error",
+			body: v1ErrorResponseBody{
+				Version:    apiVersion,
+				Message:    "invalid request",
+				Error:      "bad request",
+				Status:     http.StatusBadRequest,
+				Code:       "invalid",
+				ChildCount: &zero,
+				Fields:     []v1FieldError{{Field: "name", Error: "required"}},
+			},
+			decoded: &tenantpb.ErrorResponse{},
+			expected: &tenantpb.ErrorResponse{
+				Version:    apiVersion,
+				Message:    "invalid request",
+				Error:      "bad request",
+				Status:     http.StatusBadRequest,
+				Code:       "invalid",
+				ChildCount: proto.Int64(0),
+				Fields:     []*tenantpb.FieldError{{Field: "name", Error: "required"}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(echo.HeaderAccept, MIMEApplicationProtobuf)
+
+			rec := httptest.NewRecorder()
+
+			handler := negotiateContentType(func(c echo.Context) error {
+				return render(c, http.StatusOK, tc.body)
+			})
+
+			require.NoError(t, handler(echo.New().NewContext(req, rec)))
+			require.Equal(t, MIMEApplicationProtobuf, rec.Header().Get(echo.HeaderContentType))
+
+			require.NoError(t, proto.Unmarshal(rec.Body.Bytes(), tc.decoded))
+			assert.True(t, proto.Equal(tc.expected, tc.decoded), "expected %v, got %v", tc.expected, tc.decoded)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"go.infratographer.com/tenant-api/internal/models"
+)
+
+// preOrderQueryMod orders a subtree by the materialized paths, which lists
+// every tenant directly before its descendants, with siblings ordered by ID.
+// Tenant IDs have the same length, so a path always sorts before the paths
+// extending it.
+var preOrderQueryMod = qm.OrderBy(models.TenantTableColumns.Path + " ASC")
+
+// depthTenant is a tenant of a recursive subtenant list, with its depth
+// below the listed tenant, its children being at depth 1.
+type depthTenant struct {
+	*tenant
+	RelativeDepth int `json:"relative_depth"`
+}
+
+// parseRecursive parses the optional recursive and with_depth query
+// parameters of the subtenant list. Recursive lists include all
+// descendants, and with_depth includes the depth of each of them, which
+// requires a recursive list.
+func parseRecursive(c echo.Context) (recursive, withDepth bool, err error) {
+	if value := c.QueryParam("recursive"); value != "" {
+		if recursive, err = strconv.ParseBool(value); err != nil {
+			return false, false, fmt.Errorf("%w: recursive must be true or false", ErrInvalidQueryParam)
+		}
+	}
+
+	if value := c.QueryParam("with_depth"); value != "" {
+		if withDepth, err = strconv.ParseBool(value); err != nil {
+			return false, false, fmt.Errorf("%w: with_depth must be true or false", ErrInvalidQueryParam)
+		}
+	}
+
+	if withDepth && !recursive {
+		return false, false, fmt.Errorf("%w: with_depth can only be used with recursive", ErrInvalidQueryParam)
+	}
+
+	return recursive, withDepth, nil
+}
+
+// v1DepthTenantSlice converts the descendants of the tenant with the
+// provided path, adding the depth of each below the tenant.
+func v1DepthTenantSlice(ts []*models.Tenant, parentPath string) []depthTenant {
+	parentDepth := strings.Count(parentPath, tenantPathSeparator)
+
+	result := make([]depthTenant, len(ts))
+
+	for i, t := range ts {
+		result[i] = depthTenant{
+			tenant:        v1Tenant(t),
+			RelativeDepth: strings.Count(t.Path, tenantPathSeparator) - parentDepth,
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/x/gidx"
+)
+
+func TestV1DepthTenantSlice(t *testing.T) {
+	root := gidx.MustNewID(TenantIDPrefix)
+	child := gidx.MustNewID(TenantIDPrefix)
+	grandchild := gidx.MustNewID(TenantIDPrefix)
+
+	parentPath := tenantPath("", root)
+	childPath := tenantPath(parentPath, child)
+
+	result := v1DepthTenantSlice([]*models.Tenant{
+		{ID: child, Path: childPath},
+		{ID: grandchild, Path: tenantPath(childPath, grandchild)},
+	}, parentPath)
+
+	require.Len(t, result, 2)
+	assert.Equal(t, child, result[0].ID)
+	assert.Equal(t, 1, result[0].RelativeDepth, "expected children at depth 1")
+	assert.Equal(t, grandchild, result[1].ID)
+	assert.Equal(t, 2, result[1].RelativeDepth, "expected grandchildren at depth 2")
+}
+
+func TestTenantListRecursive(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	tree := buildTree(t, srv)
+
+	list := func(t *testing.T, path string, expectedStatus int) *v1TenantDepthSliceResponse {
+		t.Helper()
+
+		var result *v1TenantDepthSliceResponse
+
+		resp, err := srv.Request(http.MethodGet, path, nil, nil, &result)
+		require.NoError(t, err, "no error expected for tenant list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, expectedStatus, resp.StatusCode, "unexpected status code returned for %s", path)
+
+		return result
+	}
+
+	// namePaths maps the tenant IDs of the fixture to their name paths, such as t1.t1a.
+	namePaths := make(map[gidx.PrefixedID]string, len(tree.tenantsByPath))
+
+	for path, tenant := range tree.tenantsByPath {
+		namePaths[tenant.ID] = path
+	}
+
+	t.Run("pre-order with depth", func(t *testing.T) {
+		root := tree.tenantsByName["t1"]
+
+		result := list(t, "/v1/tenants/"+string(root.ID)+"/tenants?recursive=true&with_depth=true", http.StatusOK)
+		require.NotNil(t, result, "expected a list")
+
+		var expected []gidx.PrefixedID
+
+		for path, tenant := range tree.tenantsByPath {
+			if strings.HasPrefix(path, "t1.") {
+				expected = append(expected, tenant.ID)
+			}
+		}
+
+		listed := make([]gidx.PrefixedID, len(result.Tenants))
+
+		for i, row := range result.Tenants {
+			listed[i] = row.ID
+
+			path := namePaths[row.ID]
+
+			assert.Equal(t, strings.Count(path, namePathSeparator), row.RelativeDepth, "unexpected depth for %s", path)
+
+			// In pre-order, a tenant's parent is listed before it, and
+			// every row between them is within the parent's subtree.
+			parentPath := path[:strings.LastIndex(path, namePathSeparator)]
+			if parentPath == "t1" {
+				continue
+			}
+
+			parentIndex := -1
+
+			for j := 0; j < i; j++ {
+				if namePaths[result.Tenants[j].ID] == parentPath {
+					parentIndex = j
+				}
+			}
+
+			require.NotEqual(t, -1, parentIndex, "expected %s to be listed after its parent", path)
+
+			for j := parentIndex + 1; j < i; j++ {
+				assert.True(t, strings.HasPrefix(namePaths[result.Tenants[j].ID], parentPath+namePathSeparator),
+					"expected only descendants of %s between it and %s", parentPath, path)
+			}
+		}
+
+		assert.ElementsMatch(t, expected, listed, "expected every descendant")
+	})
+
+	t.Run("subtrees contiguous", func(t *testing.T) {
+		root := tree.tenantsByName["t1"]
+
+		result := list(t, "/v1/tenants/"+string(root.ID)+"/tenants?recursive=true&with_depth=true", http.StatusOK)
+		require.NotNil(t, result, "expected a list")
+
+		// Each subtree is a single run of rows starting with its root.
+		for i, row := range result.Tenants {
+			prefix := namePaths[row.ID] + namePathSeparator
+
+			end := i + 1
+			for end < len(result.Tenants) && result.Tenants[end].RelativeDepth > row.RelativeDepth {
+				assert.True(t, strings.HasPrefix(namePaths[result.Tenants[end].ID], prefix),
+					"expected %s to be within %s", namePaths[result.Tenants[end].ID], namePaths[row.ID])
+
+				end++
+			}
+
+			descendants := 0
+
+			for path := range tree.tenantsByPath {
+				if strings.HasPrefix(path, prefix) {
+					descendants++
+				}
+			}
+
+			assert.Equal(t, descendants, end-i-1, "expected every descendant of %s in its run", namePaths[row.ID])
+		}
+	})
+
+	t.Run("stable pages", func(t *testing.T) {
+		root := tree.tenantsByName["t1"]
+		base := "/v1/tenants/" + string(root.ID) + "/tenants?recursive=true&with_depth=true"
+
+		all := list(t, base, http.StatusOK)
+		require.NotNil(t, all, "expected a list")
+
+		var paged []depthTenant
+
+		for page := 1; page <= 4; page++ {
+			result := list(t, base+"&limit=2&page="+strconv.Itoa(page), http.StatusOK)
+			paged = append(paged, result.Tenants...)
+		}
+
+		require.Len(t, paged, len(all.Tenants), "expected pages to cover the list")
+
+		for i := range paged {
+			assert.Equal(t, all.Tenants[i].ID, paged[i].ID, "expected pages in the same order")
+		}
+	})
+
+	t.Run("recursive without depth", func(t *testing.T) {
+		tenants := srv.listTenants(t, "/v1/tenants/"+string(tree.tenantsByName["t2"].ID)+"/tenants?recursive=true")
+		assert.ElementsMatch(t, []gidx.PrefixedID{tree.tenantsByName["t2a"].ID}, tenantIDs(tenants))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		root := "/v1/tenants/" + string(tree.tenantsByName["t1"].ID) + "/tenants"
+
+		list(t, root+"?with_depth=true", http.StatusBadRequest)
+		list(t, root+"?recursive=maybe", http.StatusBadRequest)
+		list(t, root+"?recursive=true&sort=name", http.StatusBadRequest)
+		list(t, root+"?recursive=true&with_depth=true&ids_only=true", http.StatusBadRequest)
+		list(t, "/v1/tenants?recursive=true", http.StatusBadRequest)
+		list(t, "/v1/tenants/"+string(gidx.MustNewID(TenantIDPrefix))+"/tenants?recursive=true", http.StatusNotFound)
+	})
+}
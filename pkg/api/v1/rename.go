@@ -0,0 +1,130 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/labstack/echo/v4"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/tenant-api/internal/pubsub"
+	"go.infratographer.com/x/gidx"
+	"go.uber.org/zap"
+)
+
+// tenantRename renames the tenant in a single transaction, holding the
+// tenant's row lock while the name is checked, updated and recorded in the
+// name history, so concurrent renames are applied one after the other and
+// the history never disagrees with the name. Materialized paths are made of
+// tenant IDs, so the names of descendants and their paths are unaffected.
+// Once committed, an update event is published with the name change.
+// Renaming a tenant to its current name changes nothing.
+func (r *Router) tenantRename(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantRename")
+	defer span.End()
+
+	tenantID, err := r.parseID(c, "id")
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	payload := new(renameTenantRequest)
+
+	if err := bindRequest(c, payload); err != nil {
+		r.requestLogger(c).Error("failed to bind rename tenant request", zap.Error(err))
+
+		return v1BadRequestResponse(c, err)
+	}
+
+	if err := payload.validate(); err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	actor := r.actor(c)
+
+	var (
+		t        *models.Tenant
+		previous string
+	)
+
+	err = r.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+
+		t, err = models.Tenants(models.TenantWhere.ID.EQ(tenantID), qm.For("UPDATE")).One(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		previous = t.Name
+
+		if payload.Name == previous {
+			return nil
+		}
+
+		if err := r.checkNamePattern(payload.Name); err != nil {
+			return err
+		}
+
+		if err := r.checkNameResolvable(payload.Name); err != nil {
+			return err
+		}
+
+		if err := r.checkNameConflict(ctx, tx, t.ID, t.ParentTenantID.PrefixedID, payload.Name); err != nil {
+			return err
+		}
+
+		current := *t
+
+		t.Name = payload.Name
+		t.UpdatedBy = actorColumn(actor)
+
+		if err := r.validateUpdate(ctx, &current, t); err != nil {
+			return err
+		}
+
+		if _, err := t.Update(ctx, tx, boil.Whitelist(
+			models.TenantColumns.Name,
+			models.TenantColumns.UpdatedAt,
+			models.TenantColumns.UpdatedBy,
+		)); err != nil {
+			if isUniqueViolation(err) {
+				return fmt.Errorf("%w: %q already exists", ErrNameConflict, t.Name)
+			}
+
+			return err
+		}
+
+		change := tenantNameChange{
+			OldName:   previous,
+			NewName:   t.Name,
+			Actor:     actor,
+			ChangedAt: t.UpdatedAt,
+		}
+
+		return recordNameChange(ctx, tx, t.ID, change)
+	})
+	if err != nil {
+		return r.tenantUpdateErrorResponse(c, err)
+	}
+
+	if t.Name != previous {
+		msg, err := pubsub.RenameTenantMessage(
+			gidx.PrefixedID(actor),
+			t.ID,
+			previous,
+			t.Name,
+		)
+		if err != nil {
+			// TODO: add status to reconcile and requeue this
+			r.requestLogger(c).Error("failed to create, rename tenant message", zap.Error(err))
+		}
+
+		if err := r.pubsub.PublishUpdate(ctx, "tenants", "global", msg); err != nil {
+			// TODO: add status to reconcile and requeue this
+			r.requestLogger(c).Error("failed to publish, rename tenant message", zap.Error(err))
+		}
+	}
+
+	return v1TenantGetResponse(c, t)
+}
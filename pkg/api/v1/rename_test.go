@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	nats "github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/x/gidx"
+	"go.infratographer.com/x/pubsubx"
+)
+
+func TestTenantRename(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	ctx := context.Background()
+
+	tree := buildTree(t, srv)
+
+	subscriber := newPubSubClient(t, srv.logger, srv.nats.ClientURL())
+	msgChan := make(chan *nats.Msg, 10)
+
+	subscription, err := subscriber.ChanSubscribe(ctx, "com.infratographer.events.tenants.>", msgChan, "tenant-api-test")
+	require.NoError(t, err)
+
+	defer func() {
+		if err := subscription.Unsubscribe(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	rename := func(t *testing.T, id gidx.PrefixedID, name string, out interface{}) *http.Response {
+		t.Helper()
+
+		body, err := json.Marshal(renameTenantRequest{Name: name})
+		require.NoError(t, err, "no error expected encoding rename request")
+
+		resp, err := srv.Request(http.MethodPost, "/v1/tenants/"+string(id)+"/rename", nil, strings.NewReader(string(body)), out)
+		require.NoError(t, err, "no error expected for renaming tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp
+	}
+
+	lookup := func(t *testing.T, path string) (*tenant, int) {
+		t.Helper()
+
+		var result *v1TenantResponse
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+path, nil, nil, &result)
+		require.NoError(t, err, "no error expected for tenant get")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		if result == nil {
+			return nil, resp.StatusCode
+		}
+
+		return result.Tenant, resp.StatusCode
+	}
+
+	names := func(t *testing.T, id gidx.PrefixedID) []*tenantNameChange {
+		t.Helper()
+
+		var result *v1TenantNameSliceResponse
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(id)+"/names", nil, nil, &result)
+		require.NoError(t, err, "no error expected for tenant name history")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		return result.Names
+	}
+
+	receive := func(t *testing.T) *pubsubx.ChangeMessage {
+		t.Helper()
+
+		select {
+		case msg := <-msgChan:
+			assert.Equal(t, tenantSubjectUpdate, msg.Subject, "expected nats subject to be tenant update subject")
+
+			pMsg := &pubsubx.ChangeMessage{}
+			require.NoError(t, json.Unmarshal(msg.Data, pMsg))
+
+			return pMsg
+		case <-time.After(natsMsgSubTimeout):
+			t.Fatal("failed to receive nats message")
+		}
+
+		return nil
+	}
+
+	target := tree.tenantsByName["t1a"]
+
+	t.Run("derived data consistent", func(t *testing.T) {
+		var result *v1TenantResponse
+
+		resp := rename(t, target.ID, "t1a-renamed", &result)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected tenant response")
+		assert.Equal(t, "t1a-renamed", result.Tenant.Name, "expected the new name")
+		assert.Equal(t, target.ParentTenantID, result.Tenant.ParentTenantID, "expected the parent unchanged")
+
+		renamed, status := lookup(t, "t1.t1a-renamed")
+		require.Equal(t, http.StatusOK, status, "expected the new name path to resolve")
+		assert.Equal(t, target.ID, renamed.ID, "unexpected tenant for the new name path")
+
+		_, status = lookup(t, "t1.t1a")
+		assert.Equal(t, http.StatusNotFound, status, "expected the old name path not to resolve")
+
+		descendant, status := lookup(t, "t1.t1a-renamed.t1a1.t1a1b")
+		require.Equal(t, http.StatusOK, status, "expected descendant name paths through the new name to resolve")
+		assert.Equal(t, tree.tenantsByName["t1a1b"].ID, descendant.ID, "unexpected descendant")
+
+		ancestors := srv.listTenants(t, "/v1/tenants/"+string(tree.tenantsByName["t1a1b"].ID)+"/parents")
+		assert.Contains(t, tenantIDs(ancestors), target.ID, "expected the ancestry unchanged")
+
+		history := names(t, target.ID)
+		require.Len(t, history, 1, "expected the rename recorded once")
+		assert.Equal(t, "t1a", history[0].OldName, "unexpected old name")
+		assert.Equal(t, "t1a-renamed", history[0].NewName, "unexpected new name")
+		assert.True(t, history[0].ChangedAt.Equal(result.Tenant.UpdatedAt), "expected the rename recorded at the update")
+
+		msg := receive(t)
+		assert.Equal(t, target.ID, msg.SubjectID, "expected event for the renamed tenant")
+		assert.Equal(t, []pubsubx.FieldChange{
+			{Field: "name", PreviousValue: "t1a", CurrentValue: "t1a-renamed"},
+		}, msg.FieldChanges, "expected the name change in the event")
+	})
+
+	t.Run("same name", func(t *testing.T) {
+		resp := rename(t, target.ID, "t1a-renamed", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		assert.Len(t, names(t, target.ID), 1, "expected no rename recorded")
+
+		select {
+		case <-msgChan:
+			t.Error("expected no event for renaming to the same name")
+		case <-time.After(natsMsgSubTimeout):
+		}
+	})
+
+	t.Run("conflict leaves everything unchanged", func(t *testing.T) {
+		var result *v1ErrorResponseBody
+
+		resp := rename(t, target.ID, "t1b", &result)
+		require.Equal(t, http.StatusConflict, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected error response")
+		assert.Equal(t, errorCodeNameConflict, result.Code, "unexpected error code")
+
+		current, status := lookup(t, string(target.ID))
+		require.Equal(t, http.StatusOK, status, "unexpected status code returned")
+		assert.Equal(t, "t1a-renamed", current.Name, "expected the name unchanged")
+
+		assert.Len(t, names(t, target.ID), 1, "expected no rename recorded")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		resp := rename(t, target.ID, "", nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+
+		resp = rename(t, gidx.MustNewID(TenantIDPrefix), "unknown", nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, "unexpected status code returned")
+	})
+}
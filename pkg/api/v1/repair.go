@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/x/gidx"
+	"go.uber.org/zap"
+)
+
+const (
+	// repairIssueMissingParent is the issue of a tenant whose parent does not exist.
+	repairIssueMissingParent = "missing_parent"
+
+	// repairIssueCycle is the issue of a tenant which is its own ancestor.
+	repairIssueCycle = "cycle"
+
+	// repairSubtreeQuery selects the tenant $1 and its descendants, including
+	// deleted descendants, by walking the parent pointers down at most $2
+	// levels.
+	repairSubtreeQuery = `
+		WITH RECURSIVE subtree AS (
+			SELECT id, path, 0 AS depth
+			FROM tenants
+			WHERE id = $1
+
+			UNION ALL
+
+			SELECT t.id, t.path, s.depth + 1
+			FROM tenants t
+			INNER JOIN subtree s ON t.parent_tenant_id = s.id
+			WHERE s.depth < $2
+		)
+		SELECT id, path
+		FROM subtree
+	`
+)
+
+type repairRequest struct {
+	TenantID gidx.PrefixedID `json:"tenant_id"`
+}
+
+func (c *repairRequest) validate() error {
+	if c.TenantID == "" {
+		return fmt.Errorf("%w: tenant_id is required", ErrInvalidRepair)
+	}
+
+	_, err := parseGID(string(c.TenantID))
+
+	return err
+}
+
+// tenantRepair describes the change made by a repair.
+type tenantRepair struct {
+	ID gidx.PrefixedID `json:"id"`
+	// Issue is the integrity issue which was repaired, missing_parent or cycle.
+	Issue string `json:"issue"`
+	// PreviousParentID is the parent pointer which was removed.
+	PreviousParentID gidx.PrefixedID `json:"previous_parent_id"`
+	// PathsUpdated is the number of tenants in the repaired tenant's subtree
+	// whose materialized path was recomputed.
+	PathsUpdated int `json:"paths_updated"`
+}
+
+// tenantRepairIntegrity repairs an integrity issue reported for the tenant
+// provided as `tenant_id`, a missing parent or a cycle, by removing its
+// parent pointer in a transaction, promoting it to a root tenant. The
+// materialized paths of the tenant and its descendants are then recomputed.
+// Removing the parent pointer of any tenant of a cycle breaks the cycle.
+//
+// Tenants which don't have an integrity issue are rejected, so the repair
+// can't be used to move tenants. The tenant's name isn't checked against
+// the other roots, though registered validators may reject the repair as
+// they would a move. A move event is published for the tenant once committed.
+func (r *Router) tenantRepairIntegrity(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantRepairIntegrity")
+	defer span.End()
+
+	payload := new(repairRequest)
+
+	if err := bindRequest(c, payload); err != nil {
+		r.requestLogger(c).Error("failed to bind repair request", zap.Error(err))
+
+		return v1BadRequestResponse(c, err)
+	}
+
+	if err := payload.validate(); err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	actor := r.actor(c)
+
+	var (
+		t      *models.Tenant
+		repair tenantRepair
+	)
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		found, err := models.Tenants(qm.WithDeleted(), models.TenantWhere.ID.EQ(payload.TenantID)).One(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		t = found
+
+		issue, err := r.integrityIssue(ctx, tx, t)
+		if err != nil {
+			return err
+		}
+
+		repair = tenantRepair{
+			ID:               t.ID,
+			Issue:            issue,
+			PreviousParentID: t.ParentTenantID.PrefixedID,
+		}
+
+		current := *t
+
+		t.ParentTenantID.Valid = false
+		t.ParentTenantID.PrefixedID = ""
+		t.UpdatedAt = time.Now().UTC()
+		t.UpdatedBy = actorColumn(actor)
+
+		if err := r.validateUpdate(ctx, &current, t); err != nil {
+			return err
+		}
+
+		if _, err := models.Tenants(qm.WithDeleted(), models.TenantWhere.ID.EQ(t.ID)).UpdateAll(ctx, tx, models.M{
+			models.TenantColumns.ParentTenantID: nil,
+			models.TenantColumns.UpdatedAt:      t.UpdatedAt,
+			models.TenantColumns.UpdatedBy:      t.UpdatedBy,
+		}); err != nil {
+			return err
+		}
+
+		subtree, err := r.repairSubtree(ctx, tx, t.ID)
+		if err != nil {
+			return err
+		}
+
+		repair.PathsUpdated, _, err = r.reindexPaths(ctx, tx, subtree)
+
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return v1TenantNotFoundResponse(c, err)
+		case errors.Is(err, ErrNoIntegrityIssue):
+			return v1ConflictResponse(c, err)
+		case errors.Is(err, ErrValidationFailed):
+			r.requestLogger(c).Error("tenant repair rejected by validator", zap.Error(err))
+
+			return v1BadRequestResponse(c, err)
+		}
+
+		r.requestLogger(c).Error("failed to repair tenant", zap.Error(err))
+
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	r.requestLogger(c).Warn("repaired tenant integrity issue",
+		zap.String("repair.issue", repair.Issue),
+		zap.String("repair.previous_parent_id", string(repair.PreviousParentID)),
+		zap.Int("repair.paths_updated", repair.PathsUpdated),
+	)
+
+	r.publishMoves(c, actor, []*models.Tenant{t}, map[gidx.PrefixedID]gidx.PrefixedID{t.ID: repair.PreviousParentID})
+
+	return v1TenantRepairResponse(c, repair)
+}
+
+// integrityIssue returns the integrity issue of the tenant, or
+// ErrNoIntegrityIssue when its parent pointer is sound.
+func (r *Router) integrityIssue(ctx context.Context, exec boil.ContextExecutor, t *models.Tenant) (string, error) {
+	if !t.ParentTenantID.Valid {
+		return "", fmt.Errorf("%w: %s is a root tenant", ErrNoIntegrityIssue, t.ID)
+	}
+
+	exists, err := models.Tenants(qm.WithDeleted(), models.TenantWhere.ID.EQ(t.ParentTenantID.PrefixedID)).Exists(ctx, exec)
+	if err != nil {
+		return "", err
+	}
+
+	if !exists {
+		return repairIssueMissingParent, nil
+	}
+
+	cycles, err := queryTenantIDs(ctx, exec, cyclicTenantsQuery, r.maxDepth, t.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if len(cycles) != 0 {
+		return repairIssueCycle, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrNoIntegrityIssue, t.ID)
+}
+
+// repairSubtree loads the ID and path of the tenant and its descendants.
+func (r *Router) repairSubtree(ctx context.Context, exec boil.ContextExecutor, id gidx.PrefixedID) (models.TenantSlice, error) {
+	rows, err := exec.QueryContext(ctx, repairSubtreeQuery, id, r.maxDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var ts models.TenantSlice
+
+	for rows.Next() {
+		t := new(models.Tenant)
+
+		if err := rows.Scan(&t.ID, &t.Path); err != nil {
+			return nil, err
+		}
+
+		ts = append(ts, t)
+	}
+
+	return ts, rows.Err()
+}
@@ -1,25 +1,515 @@
 package api
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/volatiletech/null/v8"
+	"go.infratographer.com/tenant-api/internal/x/nullx"
+	"go.infratographer.com/x/gidx"
+)
+
+// maxTenantNameLength is the maximum number of characters in a tenant name.
+const maxTenantNameLength = 255
+
+// validateTenantName ensures the tenant name is present, no longer than the
+// maximum length and free of control characters.
+func validateTenantName(name string) error {
+	switch {
+	case name == "":
+		return ErrTenantNameMissing
+	case utf8.RuneCountInString(name) > maxTenantNameLength:
+		return fmt.Errorf("%w: at most %d characters are allowed", ErrTenantNameTooLong, maxTenantNameLength)
+	case !utf8.ValidString(name) || strings.IndexFunc(name, unicode.IsControl) != -1:
+		return ErrTenantNameInvalid
+	}
+
+	return nil
+}
+
+// checkNamePattern ensures the tenant name matches the configured name
+// pattern, if any, returning ErrNamePatternMismatch with the pattern when it
+// doesn't.
+func (r *Router) checkNamePattern(name string) error {
+	if r.namePattern != nil && !r.namePattern.MatchString(name) {
+		return fmt.Errorf("%w: %q must match %s", ErrNamePatternMismatch, name, r.namePattern)
+	}
+
+	return nil
+}
+
+// reservedTenantNames are the static segments routed under /v1/tenants/,
+// which are matched ahead of a tenant name path of the same name.
+var reservedTenantNames = map[string]bool{
+	"import":              true,
+	"batch":               true,
+	"batch-move":          true,
+	"swap-parents":        true,
+	"batch-get":           true,
+	"validate-name":       true,
+	"child-counts":        true,
+	"lca":                 true,
+	"depth-histogram":     true,
+	"creation-timeseries": true,
+	"labels":              true,
+}
+
+// checkNameResolvable ensures the tenant name can be resolved by name path,
+// returning ErrTenantNameReserved when it is a reserved route segment or is
+// formatted as a tenant ID. Names are checked at every depth, as a tenant
+// may later be moved to the root.
+func (r *Router) checkNameResolvable(name string) error {
+	switch {
+	case reservedTenantNames[name]:
+		return fmt.Errorf("%w: %q is a reserved route", ErrTenantNameReserved, name)
+	case r.isTenantID(name):
+		return fmt.Errorf("%w: %q is formatted as a tenant ID", ErrTenantNameReserved, name)
+	}
+
+	return nil
+}
+
+// checkKind ensures the tenant kind, when set, is one of the configured
+// kinds, returning ErrInvalidKind with the allowed kinds when it isn't. Any
+// non-empty kind is allowed when no kinds are configured.
+func (r *Router) checkKind(kind *string) error {
+	switch {
+	case kind == nil:
+		return nil
+	case *kind == "":
+		return fmt.Errorf("%w: kind must not be empty, omit it to create a tenant without a kind", ErrInvalidKind)
+	case len(r.kinds) == 0:
+		return nil
+	}
+
+	for _, allowed := range r.kinds {
+		if *kind == allowed {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %q must be one of %s", ErrInvalidKind, *kind, strings.Join(r.kinds, ", "))
+}
+
+// validateQuota ensures the tenant quota, when set, is not negative. The
+// quota is carried for downstream services and isn't enforced.
+func validateQuota(quota *int64) error {
+	if quota != nil && *quota < 0 {
+		return ErrInvalidQuota
+	}
+
+	return nil
+}
+
+// maxTenantDescriptionLength is the maximum number of characters in a tenant description.
+const maxTenantDescriptionLength = 1024
+
+// validateDescription ensures the tenant description, when set, is no longer
+// than the maximum length.
+func validateDescription(description *string) error {
+	if description != nil && utf8.RuneCountInString(*description) > maxTenantDescriptionLength {
+		return fmt.Errorf("%w: at most %d characters are allowed", ErrTenantDescriptionTooLong, maxTenantDescriptionLength)
+	}
+
+	return nil
+}
+
 type createTenantRequest struct {
-	Name string `json:"name"`
+	Name        string            `json:"name"`
+	Labels      map[string]string `json:"labels"`
+	Quota       *int64            `json:"quota"`
+	Description *string           `json:"description"`
+	Kind        *string           `json:"kind"`
 }
 
 func (c *createTenantRequest) validate() error {
-	if c.Name == "" {
-		return ErrTenantNameMissing
+	return c.fieldErrors().err()
+}
+
+// fieldErrors validates each field of the request.
+func (c *createTenantRequest) fieldErrors() fieldErrors {
+	var errs fieldErrors
+
+	errs.add("name", validateTenantName(c.Name))
+	errs.add("quota", validateQuota(c.Quota))
+	errs.add("description", validateDescription(c.Description))
+
+	keys := make([]string, 0, len(c.Labels))
+
+	for key := range c.Labels {
+		keys = append(keys, key)
+	}
+
+	// Labels are reported in a stable order.
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		errs.add("labels."+key, validateLabelKey(key))
+	}
+
+	return errs
+}
+
+// pathCreateTenantRequest is the request of a single tenant create, whose
+// parent comes from the path. The body may repeat the parent as
+// parent_tenant_id, which is rejected when it disagrees with the path, null
+// being the parent of root tenants.
+type pathCreateTenantRequest struct {
+	createTenantRequest
+	ParentTenantID optionalPrefixedID `json:"parent_tenant_id"`
+}
+
+// validate validates the request for a create under the parent of the
+// path, which is empty for root tenants.
+func (c *pathCreateTenantRequest) validate(parentID gidx.PrefixedID) error {
+	errs := c.fieldErrors()
+
+	if c.ParentTenantID.Set {
+		errs.add("parent_tenant_id", validatePathParent(c.ParentTenantID.Value, parentID))
+	}
+
+	return errs.err()
+}
+
+// validatePathParent ensures the parent of a create body is the parent of the path.
+func validatePathParent(bodyParent nullx.PrefixedID, pathParent gidx.PrefixedID) error {
+	switch {
+	case !bodyParent.Valid && pathParent == "":
+		return nil
+	case !bodyParent.Valid:
+		return fmt.Errorf("%w: null does not match the path parent %s", ErrParentMismatch, pathParent)
+	case pathParent == "":
+		return fmt.Errorf("%w: %s does not match the path, which creates root tenants", ErrParentMismatch, bodyParent.PrefixedID)
+	case bodyParent.PrefixedID != pathParent:
+		return fmt.Errorf("%w: %s does not match the path parent %s", ErrParentMismatch, bodyParent.PrefixedID, pathParent)
 	}
 
 	return nil
 }
 
 type updateTenantRequest struct {
-	Name *string `json:"name"`
+	Name           *string            `json:"name"`
+	ParentTenantID optionalPrefixedID `json:"parent_tenant_id"`
+	Locked         *bool              `json:"locked"`
+	Quota          optionalInt64      `json:"quota"`
+	Description    optionalString     `json:"description"`
+}
+
+// optionalPrefixedID is a nullable ID which records whether it was included
+// in the request, distinguishing an explicit null from an omitted field.
+type optionalPrefixedID struct {
+	Value nullx.PrefixedID
+	Set   bool
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (o *optionalPrefixedID) UnmarshalJSON(data []byte) error {
+	o.Set = true
+
+	return o.Value.UnmarshalJSON(data)
+}
+
+// optionalInt64 is a nullable integer which records whether it was included
+// in the request, distinguishing an explicit null from an omitted field.
+type optionalInt64 struct {
+	Value null.Int64
+	Set   bool
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (o *optionalInt64) UnmarshalJSON(data []byte) error {
+	o.Set = true
+
+	return o.Value.UnmarshalJSON(data)
+}
+
+// optionalString is a nullable string which records whether it was included
+// in the request, distinguishing an explicit null from an omitted field.
+type optionalString struct {
+	Value null.String
+	Set   bool
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (o *optionalString) UnmarshalJSON(data []byte) error {
+	o.Set = true
+
+	return o.Value.UnmarshalJSON(data)
 }
 
 func (c *updateTenantRequest) validate() error {
-	if c.Name != nil && *c.Name == "" {
-		return ErrTenantNameMissing
+	var errs fieldErrors
+
+	if c.Name != nil {
+		errs.add("name", validateTenantName(*c.Name))
+	}
+
+	if c.ParentTenantID.Value.Valid {
+		_, err := parseGID(string(c.ParentTenantID.Value.PrefixedID))
+		errs.add("parent_tenant_id", err)
+	}
+
+	errs.add("quota", validateQuota(c.Quota.Value.Ptr()))
+	errs.add("description", validateDescription(c.Description.Value.Ptr()))
+
+	return errs.err()
+}
+
+type batchGetRequest struct {
+	IDs     []gidx.PrefixedID `json:"ids"`
+	Include []string          `json:"include"`
+}
+
+func (c *batchGetRequest) validate() error {
+	switch {
+	case len(c.IDs) == 0:
+		return fmt.Errorf("%w: no ids", ErrInvalidBatchGet)
+	case len(c.IDs) > maxBatchGetIDs:
+		return fmt.Errorf("%w: at most %d tenants may be fetched at once", ErrInvalidBatchGet, maxBatchGetIDs)
+	}
+
+	for _, include := range c.Include {
+		switch include {
+		case batchGetIncludeParent, batchGetIncludePath:
+		default:
+			return fmt.Errorf("%w: unknown include %q, include %s or %s", ErrInvalidBatchGet, include, batchGetIncludeParent, batchGetIncludePath)
+		}
 	}
 
 	return nil
 }
+
+// includes reports whether the expansion was requested.
+func (c *batchGetRequest) includes(include string) bool {
+	for _, value := range c.Include {
+		if value == include {
+			return true
+		}
+	}
+
+	return false
+}
+
+type validateNameRequest struct {
+	Name           string           `json:"name"`
+	ParentTenantID *gidx.PrefixedID `json:"parent_tenant_id"`
+}
+
+type setLabelRequest struct {
+	Value *string `json:"value"`
+}
+
+func (c *setLabelRequest) validate() error {
+	if c.Value == nil {
+		return ErrLabelValueMissing
+	}
+
+	return nil
+}
+
+// subtreeLabelRequest sets a label on a tenant and all of its descendants.
+type subtreeLabelRequest struct {
+	Key   string  `json:"key"`
+	Value *string `json:"value"`
+}
+
+func (c *subtreeLabelRequest) validate() error {
+	if err := validateLabelKey(c.Key); err != nil {
+		return err
+	}
+
+	if c.Value == nil {
+		return ErrLabelValueMissing
+	}
+
+	return nil
+}
+
+// maxBatchCreateSize is the maximum number of tenants which may be created in a single batch.
+const maxBatchCreateSize = 1000
+
+type batchCreateRequest struct {
+	Tenants []batchCreateTenant `json:"tenants"`
+}
+
+type batchCreateTenant struct {
+	createTenantRequest
+	ParentTenantID *gidx.PrefixedID `json:"parent_tenant_id"`
+}
+
+// validate checks the size of the batch. The tenants are validated
+// individually, so partial batches may reject only the invalid tenants.
+func (c *batchCreateRequest) validate() error {
+	if len(c.Tenants) == 0 {
+		return fmt.Errorf("%w: no tenants", ErrInvalidBatchCreate)
+	}
+
+	if len(c.Tenants) > maxBatchCreateSize {
+		return fmt.Errorf("%w: at most %d tenants may be created at once", ErrInvalidBatchCreate, maxBatchCreateSize)
+	}
+
+	return nil
+}
+
+func (c *batchCreateTenant) validate() error {
+	if err := c.createTenantRequest.validate(); err != nil {
+		return err
+	}
+
+	if c.ParentTenantID != nil {
+		if _, err := parseGID(string(*c.ParentTenantID)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// maxBatchMoveSize is the maximum number of tenants which may be moved in a single batch.
+const maxBatchMoveSize = 1000
+
+type batchMoveRequest struct {
+	Moves []tenantMove `json:"moves"`
+}
+
+type tenantMove struct {
+	ID             gidx.PrefixedID    `json:"id"`
+	ParentTenantID optionalPrefixedID `json:"parent_tenant_id"`
+}
+
+func (c *batchMoveRequest) validate() error {
+	if len(c.Moves) == 0 {
+		return fmt.Errorf("%w: no moves", ErrInvalidBatchMove)
+	}
+
+	if len(c.Moves) > maxBatchMoveSize {
+		return fmt.Errorf("%w: at most %d tenants may be moved at once", ErrInvalidBatchMove, maxBatchMoveSize)
+	}
+
+	var errs fieldErrors
+
+	seen := make(map[gidx.PrefixedID]bool, len(c.Moves))
+
+	for i, move := range c.Moves {
+		field := fmt.Sprintf("moves[%d].", i)
+
+		if _, err := parseGID(string(move.ID)); err != nil {
+			errs.add(field+"id", err)
+		} else if seen[move.ID] {
+			errs.add(field+"id", fmt.Errorf("%w: %s is moved more than once", ErrInvalidBatchMove, move.ID))
+		}
+
+		seen[move.ID] = true
+
+		switch {
+		case !move.ParentTenantID.Set:
+			errs.add(field+"parent_tenant_id", fmt.Errorf("%w: parent_tenant_id is required, use null to move to the root", ErrInvalidBatchMove))
+		case move.ParentTenantID.Value.Valid:
+			_, err := parseGID(string(move.ParentTenantID.Value.PrefixedID))
+			errs.add(field+"parent_tenant_id", err)
+		}
+	}
+
+	return errs.err()
+}
+
+type swapParentsRequest struct {
+	IDs []gidx.PrefixedID `json:"ids"`
+}
+
+func (c *swapParentsRequest) validate() error {
+	if len(c.IDs) != 2 {
+		return fmt.Errorf("%w: exactly two ids are required", ErrInvalidSwap)
+	}
+
+	var errs fieldErrors
+
+	for i, id := range c.IDs {
+		if _, err := parseGID(string(id)); err != nil {
+			errs.add(fmt.Sprintf("ids[%d]", i), err)
+		}
+	}
+
+	if c.IDs[0] == c.IDs[1] {
+		errs.add("ids[1]", fmt.Errorf("%w: a tenant can't be swapped with itself", ErrInvalidSwap))
+	}
+
+	return errs.err()
+}
+
+type reparentChildrenRequest struct {
+	NewParentID gidx.PrefixedID `json:"new_parent_id"`
+}
+
+func (c *reparentChildrenRequest) validate(tenantID gidx.PrefixedID) error {
+	if c.NewParentID == "" {
+		return fmt.Errorf("%w: new_parent_id is required", ErrInvalidReparent)
+	}
+
+	if _, err := parseGID(string(c.NewParentID)); err != nil {
+		return err
+	}
+
+	if c.NewParentID == tenantID {
+		return fmt.Errorf("%w: new_parent_id must not be the current parent", ErrInvalidReparent)
+	}
+
+	return nil
+}
+
+// maxBatchDescriptionSize is the maximum number of tenant descriptions which may be set in a single batch.
+const maxBatchDescriptionSize = 1000
+
+type batchDescriptionRequest struct {
+	Updates []descriptionUpdate `json:"updates"`
+}
+
+type descriptionUpdate struct {
+	ID          gidx.PrefixedID `json:"id"`
+	Description null.String     `json:"description"`
+}
+
+func (c *batchDescriptionRequest) validate() error {
+	if len(c.Updates) == 0 {
+		return fmt.Errorf("%w: no updates", ErrInvalidBatchDescription)
+	}
+
+	if len(c.Updates) > maxBatchDescriptionSize {
+		return fmt.Errorf("%w: at most %d tenants may be updated at once", ErrInvalidBatchDescription, maxBatchDescriptionSize)
+	}
+
+	var errs fieldErrors
+
+	seen := make(map[gidx.PrefixedID]bool, len(c.Updates))
+
+	for i, update := range c.Updates {
+		field := fmt.Sprintf("updates[%d].", i)
+
+		if _, err := parseGID(string(update.ID)); err != nil {
+			errs.add(field+"id", err)
+		} else if seen[update.ID] {
+			errs.add(field+"id", fmt.Errorf("%w: %s is updated more than once", ErrInvalidBatchDescription, update.ID))
+		}
+
+		seen[update.ID] = true
+
+		errs.add(field+"description", validateDescription(update.Description.Ptr()))
+	}
+
+	return errs.err()
+}
+
+type renameTenantRequest struct {
+	Name string `json:"name"`
+}
+
+func (c *renameTenantRequest) validate() error {
+	var errs fieldErrors
+
+	errs.add("name", validateTenantName(c.Name))
+
+	return errs.err()
+}
@@ -5,6 +5,33 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/x/gidx"
+)
+
+const (
+	// errorCodeChildLimitExceeded is the error code returned when a tenant has the maximum number of children.
+	errorCodeChildLimitExceeded = "child_limit_exceeded"
+
+	// errorCodeNameConflict is the error code returned when a parent already has a child with the same name.
+	errorCodeNameConflict = "name_conflict"
+
+	// errorCodeNamePatternMismatch is the error code returned when a tenant name does not match the configured pattern.
+	errorCodeNamePatternMismatch = "name_pattern_mismatch"
+
+	// errorCodeNameReserved is the error code returned when a tenant name is a reserved route segment or a tenant ID.
+	errorCodeNameReserved = "name_reserved"
+
+	// errorCodeInvalidKind is the error code returned when a tenant kind is not one of the configured kinds.
+	errorCodeInvalidKind = "invalid_kind"
+
+	// errorCodeLocked is the error code returned when a locked tenant would be deleted or moved.
+	errorCodeLocked = "locked"
+
+	// errorCodeHasChildren is the error code returned when a tenant deleted only if childless has children.
+	errorCodeHasChildren = "has_children"
+
+	// errorCodeResultTooLarge is the error code returned when a recursive result has more than the maximum number of tenants.
+	errorCodeResultTooLarge = "result_too_large"
 )
 
 type v1TenantResponse struct {
@@ -12,72 +39,623 @@ type v1TenantResponse struct {
 	Version string  `json:"version"`
 }
 
+// v1TenantWithChildrenResponse is a tenant with its direct children inline.
+type v1TenantWithChildrenResponse struct {
+	Tenant   *tenant     `json:"tenant"`
+	Children tenantSlice `json:"children"`
+	// ChildrenTruncated is set when the tenant has more children than are
+	// included, which are listed from the tenant's children instead.
+	ChildrenTruncated bool   `json:"children_truncated"`
+	Version           string `json:"version"`
+}
+
 type v1TenantSliceResponse struct {
 	Tenants tenantSlice `json:"tenants"`
 	Version string      `json:"version"`
+	// Total is the number of tenants across all pages, omitted when the
+	// client opted out of counting them.
+	Total *int64 `json:"total,omitempty"`
+	PaginationParams
+}
+
+// v1TenantDepthSliceResponse lists the descendants of a tenant in pre-order,
+// with the depth of each below the tenant.
+type v1TenantDepthSliceResponse struct {
+	Tenants []depthTenant `json:"tenants"`
+	Version string        `json:"version"`
+	// Total is the number of tenants across all pages, omitted when the
+	// client opted out of counting them.
+	Total *int64 `json:"total,omitempty"`
+	PaginationParams
+}
+
+// v1TenantIDSliceResponse lists only the IDs of tenants.
+type v1TenantIDSliceResponse struct {
+	IDs     []gidx.PrefixedID `json:"ids"`
+	Version string            `json:"version"`
+	// Total is the number of tenants across all pages, omitted when the
+	// client opted out of counting them.
+	Total *int64 `json:"total,omitempty"`
+	PaginationParams
+}
+
+type v1TenantNameSliceResponse struct {
+	Names   []*tenantNameChange `json:"names"`
+	Version string              `json:"version"`
 	PaginationParams
 }
 
+type v1TenantLabelsResponseBody struct {
+	Labels  map[string]string `json:"labels"`
+	Version string            `json:"version"`
+}
+
+type v1TenantLabelKeysResponseBody struct {
+	Keys    []labelKeyCount `json:"keys"`
+	Version string          `json:"version"`
+	PaginationParams
+}
+
+type v1TenantLabelValuesResponseBody struct {
+	Key     string            `json:"key"`
+	Values  []labelValueCount `json:"values"`
+	Version string            `json:"version"`
+	PaginationParams
+}
+
+type v1TenantLabelResponseBody struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Version string `json:"version"`
+}
+
+type v1TenantSubtreeLabelResponseBody struct {
+	ID    gidx.PrefixedID `json:"id"`
+	Key   string          `json:"key"`
+	Value string          `json:"value"`
+	// Tenants are the tenants of the subtree whose label changed.
+	Tenants []gidx.PrefixedID `json:"tenants"`
+	Count   int               `json:"count"`
+	DryRun  bool              `json:"dry_run"`
+	Version string            `json:"version"`
+}
+
+type v1TenantImportResponseBody struct {
+	Tenants tenantSlice                         `json:"tenants"`
+	IDs     map[gidx.PrefixedID]gidx.PrefixedID `json:"ids"`
+	DryRun  bool                                `json:"dry_run"`
+	Version string                              `json:"version"`
+}
+
+type v1TenantChildCountsResponseBody struct {
+	Counts  map[gidx.PrefixedID]int64 `json:"counts"`
+	Version string                    `json:"version"`
+}
+
+type v1TenantAncestryResponseBody struct {
+	Ancestor bool   `json:"ancestor"`
+	Depth    int    `json:"depth"`
+	Version  string `json:"version"`
+}
+
+type v1TenantContainsResponseBody struct {
+	Contains bool   `json:"contains"`
+	Version  string `json:"version"`
+}
+
+type v1TenantNameValidationResponseBody struct {
+	Valid bool `json:"valid"`
+	// Reason is the code of the rule rejecting the name, omitted when valid.
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+	Version string `json:"version"`
+}
+
+type v1TenantBatchCreateResponseBody struct {
+	Results []batchCreateResult `json:"results"`
+	Version string              `json:"version"`
+}
+
+type v1TenantBatchGetResponseBody struct {
+	Tenants []batchGetTenant  `json:"tenants"`
+	Missing []gidx.PrefixedID `json:"missing,omitempty"`
+	Version string            `json:"version"`
+}
+
+type v1TenantStatsResponseBody struct {
+	subtreeStats
+	Version string `json:"version"`
+}
+
+type v1TenantDepthHistogramResponseBody struct {
+	Levels  []depthCount `json:"levels"`
+	Version string       `json:"version"`
+}
+
+type v1TenantCreationTimeseriesResponseBody struct {
+	timeseriesRange
+	Buckets []timeseriesBucket `json:"buckets"`
+	Version string             `json:"version"`
+}
+
+type v1VersionResponse struct {
+	Version string `json:"version"`
+}
+
+type v1BuildInfoResponse struct {
+	Version    string `json:"version"`
+	Commit     string `json:"commit"`
+	BuildDate  string `json:"build_date"`
+	APIVersion string `json:"api_version"`
+}
+
+type subtreeStats struct {
+	Descendants int64 `json:"descendants"`
+	MaxDepth    int   `json:"max_depth"`
+	Leaves      int64 `json:"leaves"`
+}
+
+type depthCount struct {
+	Depth int   `json:"depth"`
+	Count int64 `json:"count"`
+}
+
+type reindexResult struct {
+	Processed  int               `json:"processed"`
+	Updated    int               `json:"updated"`
+	Unresolved []gidx.PrefixedID `json:"unresolved,omitempty"`
+	Next       gidx.PrefixedID   `json:"next,omitempty"`
+}
+
+type nameNormalizationConflict struct {
+	ID             gidx.PrefixedID `json:"id"`
+	Name           string          `json:"name"`
+	NormalizedName string          `json:"normalized_name"`
+	// Reason is name_conflict when the normalized name is taken,
+	// invalid_name when it is not a valid name, and validation_failed when
+	// a registered validator rejects it.
+	Reason string `json:"reason"`
+}
+
+type nameNormalizationResult struct {
+	Processed   int                         `json:"processed"`
+	Updated     int                         `json:"updated"`
+	Conflicting int                         `json:"conflicting"`
+	Conflicts   []nameNormalizationConflict `json:"conflicts,omitempty"`
+	Next        gidx.PrefixedID             `json:"next,omitempty"`
+}
+
+type v1NameNormalizationResponseBody struct {
+	nameNormalizationResult
+	Version string `json:"version"`
+}
+
+type v1ReindexResponseBody struct {
+	reindexResult
+	Version string `json:"version"`
+}
+
+type v1TenantIntegrityResponseBody struct {
+	tenantIntegrityReport
+	Version string `json:"version"`
+}
+
+type v1TenantDeletePreviewResponseBody struct {
+	tenantDeletePreview
+	Version string `json:"version"`
+}
+
+type v1TenantRepairResponseBody struct {
+	tenantRepair
+	Version string `json:"version"`
+}
+
 func v1TenantCreatedResponse(c echo.Context, t *models.Tenant) error {
-	return c.JSON(http.StatusCreated, v1TenantResponse{
+	return render(c, http.StatusCreated, v1TenantResponse{
 		Tenant:  v1Tenant(t),
 		Version: apiVersion,
 	})
 }
 
-func v1TenantsResponse(c echo.Context, ts []*models.Tenant, pagination PaginationParams) error {
-	return c.JSON(http.StatusOK, v1TenantSliceResponse{
+// v1TenantsResponse responds with the list of tenants, including a weak ETag
+// for the list. When the request's If-None-Match header matches the ETag, a
+// not modified response is returned instead.
+func v1TenantsResponse(c echo.Context, ts []*models.Tenant, total *int64, pagination PaginationParams) error {
+	setTotalCount(c, total)
+
+	etag := collectionETag(ts, responseRepresentation(c))
+
+	c.Response().Header().Set(headerETag, etag)
+
+	if etagMatches(c.Request().Header.Get(headerIfNoneMatch), etag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return render(c, http.StatusOK, v1TenantSliceResponse{
 		Tenants:          v1TenantSlice(ts),
 		Version:          apiVersion,
+		Total:            total,
+		PaginationParams: pagination,
+	})
+}
+
+// v1TenantDepthsResponse responds with the listed descendants of the tenant
+// with the provided path, including the depth of each below the tenant.
+func v1TenantDepthsResponse(c echo.Context, ts []*models.Tenant, parentPath string, total *int64, pagination PaginationParams) error {
+	setTotalCount(c, total)
+
+	etag := collectionETag(ts, responseRepresentation(c))
+
+	c.Response().Header().Set(headerETag, etag)
+
+	if etagMatches(c.Request().Header.Get(headerIfNoneMatch), etag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return render(c, http.StatusOK, v1TenantDepthSliceResponse{
+		Tenants:          v1DepthTenantSlice(ts, parentPath),
+		Version:          apiVersion,
+		Total:            total,
 		PaginationParams: pagination,
 	})
 }
 
+// v1TenantIDsResponse responds with the IDs of the listed tenants. Only the
+// IDs are loaded, so no ETag is computed for the list.
+func v1TenantIDsResponse(c echo.Context, ts []*models.Tenant, total *int64, pagination PaginationParams) error {
+	setTotalCount(c, total)
+
+	ids := make([]gidx.PrefixedID, len(ts))
+
+	for i, t := range ts {
+		ids[i] = t.ID
+	}
+
+	return render(c, http.StatusOK, v1TenantIDSliceResponse{
+		IDs:              ids,
+		Version:          apiVersion,
+		Total:            total,
+		PaginationParams: pagination,
+	})
+}
+
+func v1TenantsCreatedResponse(c echo.Context, ts []*models.Tenant) error {
+	return render(c, http.StatusCreated, v1TenantSliceResponse{
+		Tenants: v1TenantSlice(ts),
+		Version: apiVersion,
+	})
+}
+
+func v1TenantBatchCreateResponse(c echo.Context, results []batchCreateResult) error {
+	return render(c, http.StatusMultiStatus, v1TenantBatchCreateResponseBody{
+		Results: results,
+		Version: apiVersion,
+	})
+}
+
+func v1TenantBatchGetResponse(c echo.Context, results []batchGetTenant, missing []gidx.PrefixedID) error {
+	return render(c, http.StatusOK, v1TenantBatchGetResponseBody{
+		Tenants: results,
+		Missing: missing,
+		Version: apiVersion,
+	})
+}
+
+func v1TenantsMovedResponse(c echo.Context, ts []*models.Tenant) error {
+	return render(c, http.StatusOK, v1TenantSliceResponse{
+		Tenants: v1TenantSlice(ts),
+		Version: apiVersion,
+	})
+}
+
+func v1TenantsUpdatedResponse(c echo.Context, ts []*models.Tenant) error {
+	return render(c, http.StatusOK, v1TenantSliceResponse{
+		Tenants: v1TenantSlice(ts),
+		Version: apiVersion,
+	})
+}
+
 func v1TenantGetResponse(c echo.Context, t *models.Tenant) error {
-	return c.JSON(http.StatusOK, v1TenantResponse{
+	return render(c, http.StatusOK, v1TenantResponse{
 		Tenant:  v1Tenant(t),
 		Version: apiVersion,
 	})
 }
 
-func v1TenantNotFoundResponse(c echo.Context, err error) error {
-	return c.JSON(http.StatusNotFound, struct {
-		Version string `json:"version"`
-		Message string `json:"message"`
-		Error   string `json:"error"`
-		Status  int    `json:"status"`
-	}{
+// v1TenantGetWithChildrenResponse responds with the tenant and its eager
+// loaded direct children.
+func v1TenantGetWithChildrenResponse(c echo.Context, t *models.Tenant, truncated bool) error {
+	children := tenantSlice{}
+
+	for _, child := range t.R.ParentTenantTenants {
+		children = append(children, v1Tenant(child))
+	}
+
+	return render(c, http.StatusOK, v1TenantWithChildrenResponse{
+		Tenant:            v1Tenant(t),
+		Children:          children,
+		ChildrenTruncated: truncated,
+		Version:           apiVersion,
+	})
+}
+
+// v1TenantOrNullResponse responds with the tenant, or a null tenant when
+// there is none.
+func v1TenantOrNullResponse(c echo.Context, t *models.Tenant) error {
+	if t == nil {
+		return render(c, http.StatusOK, v1TenantResponse{Version: apiVersion})
+	}
+
+	return v1TenantGetResponse(c, t)
+}
+
+func v1TenantNamesResponse(c echo.Context, names []*tenantNameChange, pagination PaginationParams) error {
+	return render(c, http.StatusOK, v1TenantNameSliceResponse{
+		Names:            names,
+		Version:          apiVersion,
+		PaginationParams: pagination,
+	})
+}
+
+func v1TenantLabelsResponse(c echo.Context, labels map[string]string) error {
+	return render(c, http.StatusOK, v1TenantLabelsResponseBody{
+		Labels:  labels,
 		Version: apiVersion,
-		Message: "tenant not found",
-		Error:   err.Error(),
-		Status:  http.StatusNotFound,
 	})
 }
 
-func v1BadRequestResponse(c echo.Context, err error) error {
-	return c.JSON(http.StatusBadRequest, struct {
-		Version string `json:"version"`
-		Message string `json:"message"`
-		Error   string `json:"error"`
-		Status  int    `json:"status"`
-	}{
+func v1TenantLabelKeysResponse(c echo.Context, keys []labelKeyCount, pagination PaginationParams) error {
+	return render(c, http.StatusOK, v1TenantLabelKeysResponseBody{
+		Keys:             keys,
+		Version:          apiVersion,
+		PaginationParams: pagination,
+	})
+}
+
+func v1TenantLabelValuesResponse(c echo.Context, key string, values []labelValueCount, pagination PaginationParams) error {
+	return render(c, http.StatusOK, v1TenantLabelValuesResponseBody{
+		Key:              key,
+		Values:           values,
+		Version:          apiVersion,
+		PaginationParams: pagination,
+	})
+}
+
+func v1TenantLabelResponse(c echo.Context, key, value string) error {
+	return render(c, http.StatusOK, v1TenantLabelResponseBody{
+		Key:     key,
+		Value:   value,
 		Version: apiVersion,
-		Message: "bad request",
-		Error:   err.Error(),
-		Status:  http.StatusBadRequest,
 	})
 }
 
-func v1InternalServerErrorResponse(c echo.Context, err error) error {
-	return c.JSON(http.StatusInternalServerError, struct {
-		Version string `json:"version"`
-		Message string `json:"message"`
-		Error   string `json:"error"`
-		Status  int    `json:"status"`
-	}{
+func v1TenantSubtreeLabelResponse(c echo.Context, id gidx.PrefixedID, key, value string, ids []gidx.PrefixedID, dryRun bool) error {
+	return render(c, http.StatusOK, v1TenantSubtreeLabelResponseBody{
+		ID:      id,
+		Key:     key,
+		Value:   value,
+		Tenants: ids,
+		Count:   len(ids),
+		DryRun:  dryRun,
+		Version: apiVersion,
+	})
+}
+
+func v1TenantImportResponse(c echo.Context, status int, plan *importPlan, dryRun bool) error {
+	return render(c, status, v1TenantImportResponseBody{
+		Tenants: v1TenantSlice(plan.tenants),
+		IDs:     plan.ids,
+		DryRun:  dryRun,
 		Version: apiVersion,
-		Message: "internal server error",
-		Error:   err.Error(),
-		Status:  http.StatusInternalServerError,
 	})
 }
+
+func v1TenantChildCountsResponse(c echo.Context, counts map[gidx.PrefixedID]int64) error {
+	return render(c, http.StatusOK, v1TenantChildCountsResponseBody{
+		Counts:  counts,
+		Version: apiVersion,
+	})
+}
+
+func v1TenantAncestryResponse(c echo.Context, ancestor bool, depth int) error {
+	return render(c, http.StatusOK, v1TenantAncestryResponseBody{
+		Ancestor: ancestor,
+		Depth:    depth,
+		Version:  apiVersion,
+	})
+}
+
+func v1TenantContainsResponse(c echo.Context, contains bool) error {
+	return render(c, http.StatusOK, v1TenantContainsResponseBody{
+		Contains: contains,
+		Version:  apiVersion,
+	})
+}
+
+func v1TenantNameValidationResponse(c echo.Context, reason string, err error) error {
+	body := v1TenantNameValidationResponseBody{
+		Valid:   reason == "",
+		Reason:  reason,
+		Version: apiVersion,
+	}
+
+	if err != nil {
+		body.Message = err.Error()
+	}
+
+	return render(c, http.StatusOK, body)
+}
+
+func v1TenantStatsResponse(c echo.Context, stats subtreeStats) error {
+	return render(c, http.StatusOK, v1TenantStatsResponseBody{
+		subtreeStats: stats,
+		Version:      apiVersion,
+	})
+}
+
+func v1TenantDepthHistogramResponse(c echo.Context, levels []depthCount) error {
+	return render(c, http.StatusOK, v1TenantDepthHistogramResponseBody{
+		Levels:  levels,
+		Version: apiVersion,
+	})
+}
+
+func v1TenantCreationTimeseriesResponse(c echo.Context, tr timeseriesRange, buckets []timeseriesBucket) error {
+	return render(c, http.StatusOK, v1TenantCreationTimeseriesResponseBody{
+		timeseriesRange: tr,
+		Buckets:         buckets,
+		Version:         apiVersion,
+	})
+}
+
+func v1ReindexResponse(c echo.Context, result reindexResult) error {
+	return render(c, http.StatusOK, v1ReindexResponseBody{
+		reindexResult: result,
+		Version:       apiVersion,
+	})
+}
+
+func v1TenantIntegrityResponse(c echo.Context, report tenantIntegrityReport) error {
+	return render(c, http.StatusOK, v1TenantIntegrityResponseBody{
+		tenantIntegrityReport: report,
+		Version:               apiVersion,
+	})
+}
+
+func v1TenantDeletePreviewResponse(c echo.Context, preview tenantDeletePreview) error {
+	return render(c, http.StatusOK, v1TenantDeletePreviewResponseBody{
+		tenantDeletePreview: preview,
+		Version:             apiVersion,
+	})
+}
+
+func v1TenantRepairResponse(c echo.Context, repair tenantRepair) error {
+	return render(c, http.StatusOK, v1TenantRepairResponseBody{
+		tenantRepair: repair,
+		Version:      apiVersion,
+	})
+}
+
+func v1NameNormalizationResponse(c echo.Context, result nameNormalizationResult) error {
+	return render(c, http.StatusOK, v1NameNormalizationResponseBody{
+		nameNormalizationResult: result,
+		Version:                 apiVersion,
+	})
+}
+
+func v1TenantNotFoundResponse(c echo.Context, err error) error {
+	return v1ErrorResponse(c, http.StatusNotFound, "tenant not found", err)
+}
+
+func v1LabelNotFoundResponse(c echo.Context, err error) error {
+	return v1ErrorResponse(c, http.StatusNotFound, "label not found", err)
+}
+
+func v1BadRequestResponse(c echo.Context, err error) error {
+	return v1ErrorResponse(c, http.StatusBadRequest, "bad request", err)
+}
+
+func v1ForbiddenResponse(c echo.Context, err error) error {
+	return v1ErrorResponse(c, http.StatusForbidden, "forbidden", err)
+}
+
+func v1ConflictResponse(c echo.Context, err error) error {
+	return v1ErrorResponse(c, http.StatusConflict, "conflict", err)
+}
+
+func v1ChildLimitExceededResponse(c echo.Context, err error) error {
+	return v1ErrorCodeResponse(c, http.StatusUnprocessableEntity, "unprocessable entity", errorCodeChildLimitExceeded, err)
+}
+
+func v1ResultTooLargeResponse(c echo.Context, err error) error {
+	return v1ErrorCodeResponse(c, http.StatusRequestEntityTooLarge, "result too large", errorCodeResultTooLarge, err)
+}
+
+func v1NamePatternMismatchResponse(c echo.Context, err error) error {
+	return v1ErrorCodeResponse(c, http.StatusUnprocessableEntity, "unprocessable entity", errorCodeNamePatternMismatch, err)
+}
+
+func v1NameReservedResponse(c echo.Context, err error) error {
+	return v1ErrorCodeResponse(c, http.StatusUnprocessableEntity, "unprocessable entity", errorCodeNameReserved, err)
+}
+
+func v1InvalidKindResponse(c echo.Context, err error) error {
+	return v1ErrorCodeResponse(c, http.StatusUnprocessableEntity, "unprocessable entity", errorCodeInvalidKind, err)
+}
+
+func v1NameConflictResponse(c echo.Context, err error) error {
+	return v1ErrorCodeResponse(c, http.StatusConflict, "conflict", errorCodeNameConflict, err)
+}
+
+func v1TenantLockedResponse(c echo.Context, err error) error {
+	return v1ErrorCodeResponse(c, http.StatusLocked, "locked", errorCodeLocked, err)
+}
+
+// v1TenantHasChildrenResponse responds to the rejected delete of a tenant with
+// children, including the number of children.
+func v1TenantHasChildrenResponse(c echo.Context, children int64, err error) error {
+	body := v1ErrorBody(c, http.StatusConflict, "conflict", errorCodeHasChildren, err)
+	body.ChildCount = &children
+
+	return render(c, http.StatusConflict, body)
+}
+
+func v1NotAcceptableResponse(c echo.Context, err error) error {
+	return v1ErrorResponse(c, http.StatusNotAcceptable, "not acceptable", err)
+}
+
+func v1InternalServerErrorResponse(c echo.Context, err error) error {
+	// Queries fail when they are canceled by the request timeout, which is
+	// reported as a timeout rather than a server error.
+	if requestTimedOut(c) {
+		return v1GatewayTimeoutResponse(c, err)
+	}
+
+	return v1ErrorResponse(c, http.StatusInternalServerError, "internal server error", err)
+}
+
+func v1ServiceUnavailableResponse(c echo.Context, err error) error {
+	return v1ErrorResponse(c, http.StatusServiceUnavailable, "service unavailable", err)
+}
+
+func v1GatewayTimeoutResponse(c echo.Context, err error) error {
+	return v1ErrorResponse(c, http.StatusGatewayTimeout, "request timed out", err)
+}
+
+type v1ErrorResponseBody struct {
+	Version   string `json:"version"`
+	Message   string `json:"message"`
+	Error     string `json:"error"`
+	Status    int    `json:"status"`
+	RequestID string `json:"request_id,omitempty"`
+	Code      string `json:"code,omitempty"`
+	// ChildCount is the number of children of a tenant which could not be deleted.
+	ChildCount *int64 `json:"child_count,omitempty"`
+	// Fields lists every invalid field of a rejected request.
+	Fields []v1FieldError `json:"fields,omitempty"`
+}
+
+func v1ErrorResponse(c echo.Context, status int, message string, err error) error {
+	return v1ErrorCodeResponse(c, status, message, "", err)
+}
+
+// v1ErrorCodeResponse responds with an error including a machine readable code
+// identifying the error, allowing clients to handle it without parsing the message.
+// The message is localized to the negotiated language, while the code and
+// error are not.
+func v1ErrorCodeResponse(c echo.Context, status int, message, code string, err error) error {
+	return render(c, status, v1ErrorBody(c, status, message, code, err))
+}
+
+// v1ErrorBody builds the body of an error response.
+func v1ErrorBody(c echo.Context, status int, message, code string, err error) v1ErrorResponseBody {
+	return v1ErrorResponseBody{
+		Version:   apiVersion,
+		Message:   localizedMessage(c, message, code),
+		Error:     err.Error(),
+		Status:    status,
+		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+		Code:      code,
+		Fields:    v1FieldErrors(err),
+	}
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/tenant-api/internal/models"
+)
+
+func TestV1TenantsResponseEmpty(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+	require.NoError(t, v1TenantsResponse(c, nil, nil, PaginationParams{}))
+
+	var body map[string]json.RawMessage
+
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.JSONEq(t, `[]`, string(body["tenants"]), "expected an empty tenant list")
+}
+
+func TestV1TenantsResponseTotal(t *testing.T) {
+	zero := int64(0)
+
+	testCases := []struct {
+		name     string
+		total    *int64
+		expected string
+	}{
+		{"included", &zero, "0"},
+		{"omitted", nil, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+			require.NoError(t, v1TenantsResponse(c, nil, tc.total, PaginationParams{}))
+
+			var body map[string]json.RawMessage
+
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+			if tc.expected == "" {
+				assert.NotContains(t, body, "total", "expected total to be omitted")
+			} else {
+				assert.JSONEq(t, tc.expected, string(body["total"]), "unexpected total")
+			}
+
+			assert.Equal(t, tc.expected, rec.Header().Get(headerTotalCount), "unexpected total count header")
+		})
+	}
+}
+
+func TestV1TenantIDsResponse(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+	ts := []*models.Tenant{{ID: "tnntten-a"}, {ID: "tnntten-b"}}
+
+	require.NoError(t, v1TenantIDsResponse(c, ts, nil, PaginationParams{}))
+
+	var body map[string]json.RawMessage
+
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.JSONEq(t, `["tnntten-a", "tnntten-b"]`, string(body["ids"]), "expected a flat id list")
+	assert.NotContains(t, body, "tenants", "expected no tenants")
+	assert.Empty(t, rec.Header().Get(headerETag), "expected no etag")
+}
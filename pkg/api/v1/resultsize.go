@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"go.infratographer.com/x/gidx"
+)
+
+// subtreeSizeQuery counts the live tenant $1, with the path $2, and its
+// descendants, counting at most $3 tenants so large subtrees aren't
+// counted in full.
+const subtreeSizeQuery = `
+	SELECT count(*)
+	FROM (
+		SELECT 1
+		FROM tenants
+		WHERE deleted_at IS NULL AND (id = $1 OR path LIKE $2)
+		LIMIT $3
+	) AS subtree
+`
+
+// resultTooLargeError returns the error of a recursive result of the tenant
+// having more than the maximum number of tenants.
+func (r *Router) resultTooLargeError(tenantID gidx.PrefixedID) error {
+	return fmt.Errorf("%w: the subtree of %s has more than %d tenants, list its descendants by page or request a narrower subtree",
+		ErrResultTooLarge, tenantID, r.maxResultSize)
+}
+
+// checkSubtreeSize returns ErrResultTooLarge when the subtree of the tenant
+// with the path has more than the maximum number of tenants.
+func (r *Router) checkSubtreeSize(ctx context.Context, exec boil.ContextExecutor, tenantID gidx.PrefixedID, path string) error {
+	if r.maxResultSize <= 0 {
+		return nil
+	}
+
+	var count int
+
+	if err := exec.QueryRowContext(ctx, subtreeSizeQuery, tenantID, subtreePathPattern(path), r.maxResultSize+1).Scan(&count); err != nil {
+		return err
+	}
+
+	if count > r.maxResultSize {
+		return r.resultTooLargeError(tenantID)
+	}
+
+	return nil
+}
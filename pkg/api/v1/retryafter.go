@@ -0,0 +1,31 @@
+package api
+
+import (
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// setRetryAfter asks the client to wait before retrying the request, for
+// the base delay plus a random jitter of up to the configured retry-after
+// jitter. Spreading the delays keeps clients rejected at the same time from
+// retrying at the same time. The delay is rounded up to whole seconds.
+func (r *Router) setRetryAfter(c echo.Context, base time.Duration) {
+	c.Response().Header().Set(echo.HeaderRetryAfter, r.retryAfter(base))
+}
+
+// retryAfter returns the Retry-After value, in seconds, of the base delay
+// with a random jitter.
+func (r *Router) retryAfter(base time.Duration) string {
+	delay := base
+
+	if r.retryAfterJitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(r.retryAfterJitter) + 1)) //nolint:gosec // Jitter doesn't need a secure source.
+	}
+
+	seconds := (delay + time.Second - 1) / time.Second
+
+	return strconv.FormatInt(int64(seconds), 10)
+}
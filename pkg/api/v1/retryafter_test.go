@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryAfterJitter(t *testing.T) {
+	retryAfter := func(r *Router, base time.Duration) int {
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/", nil), rec)
+
+		r.setRetryAfter(c, base)
+
+		seconds, err := strconv.Atoi(rec.Header().Get(echo.HeaderRetryAfter))
+		require.NoError(t, err, "expected Retry-After in seconds")
+
+		return seconds
+	}
+
+	t.Run("no jitter", func(t *testing.T) {
+		r := NewRouter(nil, nil)
+
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, 1, retryAfter(r, publishRetryAfter), "expected the base delay without jitter")
+		}
+	})
+
+	t.Run("within the jittered range", func(t *testing.T) {
+		r := NewRouter(nil, nil, WithRetryAfterJitter(5*time.Second))
+
+		seen := make(map[int]bool)
+
+		for i := 0; i < 1000; i++ {
+			seconds := retryAfter(r, publishRetryAfter)
+
+			assert.GreaterOrEqual(t, seconds, 1, "expected at least the base delay")
+			assert.LessOrEqual(t, seconds, 6, "expected at most the base delay plus the jitter")
+
+			seen[seconds] = true
+		}
+
+		assert.Greater(t, len(seen), 1, "expected the delays to be spread out")
+	})
+
+	t.Run("rounded up to whole seconds", func(t *testing.T) {
+		r := NewRouter(nil, nil, WithRetryAfterJitter(500*time.Millisecond))
+
+		for i := 0; i < 100; i++ {
+			seconds := retryAfter(r, 2*time.Second)
+
+			assert.Contains(t, []int{2, 3}, seconds, "expected the jittered delay rounded up")
+		}
+	})
+}
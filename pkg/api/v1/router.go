@@ -2,17 +2,43 @@ package api
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
+	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"go.infratographer.com/tenant-api/internal/pubsub"
+	"go.infratographer.com/tenant-api/internal/reqctx"
+	"go.infratographer.com/x/gidx"
 	"go.opentelemetry.io/otel"
 	"go.uber.org/zap"
 )
 
 const (
 	apiVersion = "v1"
+
+	// HeaderXCorrelationID is the header clients provide the correlation id
+	// of their workflow in, which is included in the events their requests emit.
+	HeaderXCorrelationID = "X-Correlation-ID"
+
+	// HeaderAcceptLanguage is the header clients list the languages they
+	// prefer error messages in.
+	HeaderAcceptLanguage = "Accept-Language"
+
+	// HeaderContentLanguage is the header naming the language of a localized error message.
+	HeaderContentLanguage = "Content-Language"
+
+	// requestIDLength is the number of random bytes in a generated request id.
+	requestIDLength = 16
+
+	// idPrefixMismatchQuery counts the tenants, including deleted tenants,
+	// whose ID does not match the pattern.
+	idPrefixMismatchQuery = `SELECT count(*) FROM tenants WHERE id NOT LIKE $1`
 )
 
 var tracer = otel.Tracer("go.infratographer.com/tenant-api/pkg/api/v1")
@@ -23,14 +49,91 @@ type Router struct {
 	logger     *zap.Logger
 	pubsub     *pubsub.Client
 	middleware []echo.MiddlewareFunc
+	adminScope string
+	lockScope  string
+	writeScope string
+	validators []Validator
+
+	// idPrefix is the gidx prefix of tenant IDs.
+	idPrefix string
+
+	// strictIDPrefix rejects requests with IDs of other prefixes in their path or body.
+	strictIDPrefix bool
+
+	// fieldNaming is the naming strategy of JSON response fields.
+	fieldNaming FieldNaming
+
+	// timeout bounds the duration of each request's database operations, unbounded when zero.
+	timeout time.Duration
+
+	// routeTimeouts override the timeout of the routes, by route template.
+	routeTimeouts map[string]time.Duration
+
+	// aggregateBatchEvents publishes a single event for each batch create, batch move and cascade delete rather than one for each tenant.
+	aggregateBatchEvents bool
+
+	// retryAfterJitter is the maximum random delay added to Retry-After values.
+	retryAfterJitter time.Duration
+
+	// maxDepth limits how many levels the recursive hierarchy queries walk.
+	maxDepth int
+
+	// maxPathSegments limits the number of names in the name paths tenants are looked up by.
+	maxPathSegments int
+
+	// maxChildren limits the number of direct children of a tenant, unlimited when zero.
+	maxChildren int
+
+	// maxResultSize limits the number of tenants in recursive results, unlimited when zero.
+	maxResultSize int
+
+	// idempotentCreate responds to creates of an existing child with the existing child.
+	idempotentCreate bool
+
+	// namePattern is the pattern created and renamed tenant names must match, unrestricted when nil.
+	namePattern *regexp.Regexp
+
+	// kinds are the kinds tenants may be created with, unrestricted when empty.
+	kinds []string
+
+	// globalNames requires tenant names to be unique across all tenants, rather than within a parent.
+	globalNames bool
+
+	// systemActor is the actor of requests without a token subject, anonymous when empty.
+	systemActor string
+
+	// isolation is the isolation level of the transactions of multi-step changes.
+	isolation sql.IsolationLevel
+
+	// txAttempts is the number of times a transaction failing with a serialization failure is attempted.
+	txAttempts int
+
+	// listSort is the order tenants are listed in when the request doesn't provide one.
+	listSort ListSort
+
+	// externalBaseURL is the base URL pagination links are built from, the
+	// request's host when nil.
+	externalBaseURL *url.URL
+
+	// catalogs are the message catalogs error messages are localized with, by language.
+	catalogs map[string]MessageCatalog
 }
 
-// NewRouter creates a new APIv1 router.
+// NewRouter creates a new APIv1 router. The pubsub client may be nil, which
+// disables publishing events for changes.
 func NewRouter(db *sql.DB, ps *pubsub.Client, options ...RouterOption) *Router {
 	router := &Router{
-		db:     db,
-		logger: zap.NewNop(),
-		pubsub: ps,
+		db:              db,
+		logger:          zap.NewNop(),
+		pubsub:          ps,
+		adminScope:      DefaultAdminScope,
+		lockScope:       DefaultLockScope,
+		writeScope:      DefaultWriteScope,
+		idPrefix:        TenantIDPrefix,
+		maxDepth:        DefaultMaxDepth,
+		maxPathSegments: DefaultMaxPathSegments,
+		txAttempts:      DefaultTxAttempts,
+		listSort:        DefaultListSort,
 	}
 
 	for _, opt := range options {
@@ -52,27 +155,141 @@ func defaultRequestType(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
+// requestIDPattern matches the request and correlation ids propagated from
+// clients, others are replaced so they can't inject content into logs,
+// headers or events.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// requestContext ensures every request has a request id, propagating a valid
+// id provided by the client or generated by an earlier middleware, and a
+// correlation id, propagating a valid id provided by the client. It attaches
+// the ids, the route and the tenant being operated on, and a logger including
+// them, to the request context.
+func (r *Router) requestContext(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id := c.Request().Header.Get(echo.HeaderXRequestID)
+		if !requestIDPattern.MatchString(id) {
+			id = c.Response().Header().Get(echo.HeaderXRequestID)
+		}
+
+		if !requestIDPattern.MatchString(id) {
+			id = newRequestID()
+		}
+
+		c.Response().Header().Set(echo.HeaderXRequestID, id)
+
+		correlationID := c.Request().Header.Get(HeaderXCorrelationID)
+		if !requestIDPattern.MatchString(correlationID) {
+			correlationID = newRequestID()
+		}
+
+		c.Response().Header().Set(HeaderXCorrelationID, correlationID)
+
+		ctx := reqctx.WithRequestID(c.Request().Context(), id)
+		ctx = reqctx.WithCorrelationID(ctx, correlationID)
+		ctx = reqctx.WithOperation(ctx, c.Request().Method+" "+c.Path())
+
+		if tenantID := c.Param("id"); r.isTenantID(tenantID) {
+			ctx = reqctx.WithTenantID(ctx, tenantID)
+		}
+
+		ctx = reqctx.WithLogger(ctx, r.logger.With(reqctx.Fields(ctx)...))
+
+		c.SetRequest(c.Request().WithContext(ctx))
+
+		return next(c)
+	}
+}
+
+// requestLogger returns the logger for the current request.
+func (r *Router) requestLogger(c echo.Context) *zap.Logger {
+	return reqctx.Logger(c.Request().Context(), r.logger)
+}
+
+func newRequestID() string {
+	b := make([]byte, requestIDLength)
+
+	// crypto/rand.Read never returns an error on supported platforms.
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
 // Routes will add the routes for this API version to a router group
 func (r *Router) Routes(e *echo.Group) {
+	// Build information is served unauthenticated, outside of the versioned API.
+	e.GET("/version", r.buildInfo, negotiateContentType, r.setFieldNaming)
+
 	v1 := e.Group(apiVersion)
 	{
 		v1.Use(defaultRequestType)
+		v1.Use(r.requestContext)
+		v1.Use(r.requestTimeout)
+		v1.Use(r.negotiateLanguage)
+		v1.Use(negotiateContentType)
+		v1.Use(r.setFieldNaming)
+		v1.Use(r.publishBackpressure)
 		v1.Use(r.middleware...)
+		v1.Use(r.requireIDPrefix)
 
 		v1.GET("/", r.apiVersion)
 
 		v1.GET("/tenants", r.tenantList)
 		v1.POST("/tenants", r.tenantCreate)
+		v1.POST("/tenants/import", r.tenantImport)
+		v1.POST("/tenants/batch", r.tenantBatchCreate)
+		v1.PATCH("/tenants/batch", r.tenantBatchDescription)
+		v1.POST("/tenants/batch-move", r.tenantBatchMove)
+		v1.POST("/tenants/swap-parents", r.tenantSwapParents)
+		v1.POST("/tenants/batch-get", r.tenantBatchGet)
+		v1.POST("/tenants/validate-name", r.tenantValidateName)
+		v1.GET("/tenants/child-counts", r.tenantChildCounts)
+		v1.GET("/tenants/lca", r.tenantLowestCommonAncestor)
+		v1.GET("/tenants/depth-histogram", r.tenantDepthHistogram)
+		v1.GET("/tenants/creation-timeseries", r.tenantCreationTimeseries)
+		v1.GET("/tenants/labels/keys", r.tenantLabelKeys)
+		v1.GET("/tenants/labels/values", r.tenantLabelValues)
 
 		v1.GET("/tenants/:id", r.tenantGet)
 		v1.PATCH("/tenants/:id", r.tenantUpdate)
 		v1.DELETE("/tenants/:id", r.tenantDelete)
+		v1.GET("/tenants/:id/delete-preview", r.tenantDeletePreview)
+		v1.POST("/tenants/:id/touch", r.tenantTouch, requireScope(r.writeScope))
+		v1.POST("/tenants/:id/reparent-children", r.tenantReparentChildren)
+		v1.POST("/tenants/:id/rename", r.tenantRename)
 
 		v1.GET("/tenants/:id/tenants", r.tenantList)
 		v1.POST("/tenants/:id/tenants", r.tenantCreate)
 
+		v1.GET("/tenants/:id/names", r.tenantNamesList)
+
+		v1.GET("/tenants/:id/labels", r.tenantLabelsList)
+		v1.PUT("/tenants/:id/labels/:key", r.tenantLabelSet)
+		v1.DELETE("/tenants/:id/labels/:key", r.tenantLabelDelete)
+		v1.POST("/tenants/:id/subtree-labels", r.tenantSubtreeLabelSet)
+
+		v1.GET("/tenants/:id/export", r.tenantExport)
+
 		v1.GET("/tenants/:id/parents", r.tenantParentsList)
 		v1.GET("/tenants/:id/parents/:parent_id", r.tenantParentsList)
+
+		v1.GET("/tenants/:id/is-ancestor-of/:other_id", r.tenantIsAncestorOf)
+		v1.GET("/tenants/:id/contains/:other_id", r.tenantContains)
+
+		v1.GET("/tenants/:id/stats", r.tenantStats)
+
+		admin := v1.Group("/admin", requireScope(r.adminScope))
+
+		admin.POST("/tenants/reindex", r.tenantReindex)
+		admin.POST("/tenants/normalize-names", r.tenantNormalizeNames)
+		admin.GET("/tenants/integrity", r.tenantIntegrity)
+		admin.POST("/tenants/repair", r.tenantRepairIntegrity)
+	}
+
+	if r.pubsub == nil {
+		r.logger.Debug("pubsub is disabled, skipped adding stream")
+
+		return
 	}
 
 	_, err := r.pubsub.AddStream()
@@ -81,6 +298,26 @@ func (r *Router) Routes(e *echo.Group) {
 	}
 }
 
+// ValidateIDPrefix ensures the configured tenant ID prefix is a valid gidx
+// prefix, and that all existing tenants, including deleted tenants, have it.
+func (r *Router) ValidateIDPrefix(ctx context.Context) error {
+	if len(r.idPrefix) != gidx.PrefixPartLength {
+		return fmt.Errorf("%w: %q must be %d characters", ErrInvalidIDPrefix, r.idPrefix, gidx.PrefixPartLength)
+	}
+
+	var mismatched int64
+
+	if err := r.db.QueryRowContext(ctx, idPrefixMismatchQuery, r.idPrefix+"-%").Scan(&mismatched); err != nil {
+		return err
+	}
+
+	if mismatched != 0 {
+		return fmt.Errorf("%w: %d tenants do not have prefix %s", ErrIDPrefixMismatch, mismatched, r.idPrefix)
+	}
+
+	return nil
+}
+
 // DatabaseCheck ensure the database connection is established.
 func (r *Router) DatabaseCheck(ctx context.Context) error {
 	if err := r.db.PingContext(ctx); err != nil {
@@ -92,7 +329,7 @@ func (r *Router) DatabaseCheck(ctx context.Context) error {
 
 // apiVersion responds with the current api version.
 func (r *Router) apiVersion(c echo.Context) error {
-	return c.JSON(http.StatusOK, echo.Map{
-		"version": apiVersion,
+	return render(c, http.StatusOK, v1VersionResponse{
+		Version: apiVersion,
 	})
 }
@@ -1,6 +1,11 @@
 package api
 
 import (
+	"database/sql"
+	"net/url"
+	"regexp"
+	"time"
+
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 )
@@ -21,3 +26,233 @@ func WithLogger(logger *zap.Logger) RouterOption {
 		r.logger = logger
 	}
 }
+
+// WithValidators registers validators which are run before tenants are
+// created, updated or deleted.
+func WithValidators(validators ...Validator) RouterOption {
+	return func(r *Router) {
+		r.validators = append(r.validators, validators...)
+	}
+}
+
+// WithMaxChildren limits the number of direct children a tenant may have.
+// Zero, the default, allows an unlimited number of children.
+func WithMaxChildren(max int) RouterOption {
+	return func(r *Router) {
+		r.maxChildren = max
+	}
+}
+
+// WithMaxResultSize limits the number of tenants of the recursive endpoints
+// which aren't paginated: exports, delete previews and cascade deletes.
+// Requests for larger subtrees are rejected before their results are read
+// in full. Zero, the default, leaves the results unlimited.
+func WithMaxResultSize(max int) RouterOption {
+	return func(r *Router) {
+		r.maxResultSize = max
+	}
+}
+
+// WithMaxDepth limits how many levels the recursive hierarchy queries walk.
+// Requests reaching tenants whose ancestry is deeper than the limit fail
+// rather than walking cycles in the parent pointers indefinitely.
+func WithMaxDepth(depth int) RouterOption {
+	return func(r *Router) {
+		if depth > 0 {
+			r.maxDepth = depth
+		}
+	}
+}
+
+// WithMaxPathSegments limits the number of names in the name paths tenants
+// may be looked up by, such as root.child.grandchild. Longer paths are
+// rejected before any query runs.
+func WithMaxPathSegments(segments int) RouterOption {
+	return func(r *Router) {
+		if segments > 0 {
+			r.maxPathSegments = segments
+		}
+	}
+}
+
+// WithExternalBaseURL sets the base URL pagination links are built from,
+// for when the api is served behind a reverse proxy under another host or
+// path prefix. Links are relative to the request's host by default.
+func WithExternalBaseURL(base *url.URL) RouterOption {
+	return func(r *Router) {
+		r.externalBaseURL = base
+	}
+}
+
+// WithTxIsolation sets the isolation level of the transactions of multi-step
+// changes, such as creates, moves and batches. The database's default level
+// is used by default.
+func WithTxIsolation(level sql.IsolationLevel) RouterOption {
+	return func(r *Router) {
+		r.isolation = level
+	}
+}
+
+// WithTxAttempts sets the number of times the transactions of multi-step
+// changes are attempted when they fail with a serialization failure caused
+// by a concurrent transaction. A single attempt disables retries.
+func WithTxAttempts(attempts int) RouterOption {
+	return func(r *Router) {
+		if attempts > 0 {
+			r.txAttempts = attempts
+		}
+	}
+}
+
+// WithDefaultListSort sets the order tenants are listed in when the request
+// doesn't provide one with the sort parameter, such as newest first. Tenants
+// are listed oldest first by default.
+func WithDefaultListSort(sort ListSort) RouterOption {
+	return func(r *Router) {
+		r.listSort = sort
+	}
+}
+
+// WithNamePattern requires the names of created and renamed tenants to match
+// the pattern, such as ^[a-z][a-z0-9-]*$. Existing names aren't checked until
+// they are changed. Any valid name is allowed when the pattern is nil.
+func WithNamePattern(pattern *regexp.Regexp) RouterOption {
+	return func(r *Router) {
+		r.namePattern = pattern
+	}
+}
+
+// WithIdempotentCreate responds to requests creating a child with the same
+// name as an existing child of the parent with the existing child, rather
+// than a conflict. This allows provisioning to safely retry creates.
+func WithIdempotentCreate(idempotent bool) RouterOption {
+	return func(r *Router) {
+		r.idempotentCreate = idempotent
+	}
+}
+
+// WithGlobalNameUniqueness requires tenant names to be unique across all
+// tenants, rather than only among the children of a parent.
+// ApplyNameUniqueness must have enforced the mode in the database, which
+// ValidateNameUniqueness checks before serving.
+func WithGlobalNameUniqueness(global bool) RouterOption {
+	return func(r *Router) {
+		r.globalNames = global
+	}
+}
+
+// WithSystemActor sets the actor recorded for requests without a token
+// subject, such as those made by internal jobs. This distinguishes system
+// changes from anonymous ones, which have no actor.
+func WithSystemActor(actor string) RouterOption {
+	return func(r *Router) {
+		r.systemActor = actor
+	}
+}
+
+// WithIDPrefix sets the gidx prefix new tenant IDs are created with and
+// requested tenant IDs must have. ValidateIDPrefix should be called before
+// serving to ensure existing tenants match the prefix.
+func WithIDPrefix(prefix string) RouterOption {
+	return func(r *Router) {
+		r.idPrefix = prefix
+	}
+}
+
+// WithStrictIDPrefix rejects requests with an ID of another prefix in their
+// path or body before they are handled, isolating tenants from other
+// resource types served behind the same gateway. Name paths formatted like
+// IDs of another prefix are rejected as well.
+func WithStrictIDPrefix(strict bool) RouterOption {
+	return func(r *Router) {
+		r.strictIDPrefix = strict
+	}
+}
+
+// WithTenantKinds restricts the kinds tenants may be created with, such as
+// customer or partner, to the provided kinds. Creates with any other kind
+// are rejected. Any kind is allowed when no kinds are provided, the default.
+func WithTenantKinds(kinds ...string) RouterOption {
+	return func(r *Router) {
+		r.kinds = append(r.kinds, kinds...)
+	}
+}
+
+// WithFieldNaming sets the naming strategy of JSON response fields, such as
+// camelCase for JavaScript clients. Fields are named in snake_case by
+// default. Request bodies are accepted in either naming strategy.
+func WithFieldNaming(naming FieldNaming) RouterOption {
+	return func(r *Router) {
+		r.fieldNaming = naming
+	}
+}
+
+// WithRequestTimeout bounds the duration of each request's database
+// operations. Requests exceeding the timeout have their queries canceled and
+// fail with a gateway timeout. Zero, the default, leaves requests unbounded.
+func WithRequestTimeout(timeout time.Duration) RouterOption {
+	return func(r *Router) {
+		r.timeout = timeout
+	}
+}
+
+// WithRouteTimeouts overrides the request timeout of routes, keyed by their
+// route template such as /v1/tenants/:id/export, so recursive endpoints can
+// be given longer than simple reads. Routes without an override use the
+// request timeout, and an override of zero leaves the route unbounded.
+func WithRouteTimeouts(timeouts map[string]time.Duration) RouterOption {
+	return func(r *Router) {
+		if r.routeTimeouts == nil {
+			r.routeTimeouts = make(map[string]time.Duration, len(timeouts))
+		}
+
+		for route, timeout := range timeouts {
+			r.routeTimeouts[route] = timeout
+		}
+	}
+}
+
+// WithAggregateBatchEvents publishes a single event for each batch create,
+// batch move and cascade delete, carrying every affected tenant in its
+// additional subject ids and the operation in its additional data, rather
+// than an event for each tenant. Aggregate events don't carry the field
+// changes of each tenant, such as the labels of created tenants or the
+// previous parents of moved tenants, so consumers needing them should keep
+// the default of an event for each tenant. Other moves, such as reparenting
+// children or swapping parents, always publish an event for each tenant.
+func WithAggregateBatchEvents(aggregate bool) RouterOption {
+	return func(r *Router) {
+		r.aggregateBatchEvents = aggregate
+	}
+}
+
+// WithRetryAfterJitter adds a random delay of up to jitter to the
+// Retry-After values of rejected requests, so clients rejected at the same
+// time spread their retries out rather than retrying together. Zero, the
+// default, asks every client to wait the same time.
+func WithRetryAfterJitter(jitter time.Duration) RouterOption {
+	return func(r *Router) {
+		r.retryAfterJitter = jitter
+	}
+}
+
+// WithAdminScope sets the scope required to access the admin endpoints.
+func WithAdminScope(scope string) RouterOption {
+	return func(r *Router) {
+		r.adminScope = scope
+	}
+}
+
+// WithLockScope sets the scope required to lock or unlock tenants.
+func WithLockScope(scope string) RouterOption {
+	return func(r *Router) {
+		r.lockScope = scope
+	}
+}
+
+// WithWriteScope sets the scope required to touch tenants.
+func WithWriteScope(scope string) RouterOption {
+	return func(r *Router) {
+		r.writeScope = scope
+	}
+}
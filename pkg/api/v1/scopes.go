@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// DefaultAdminScope is the default scope required to access the admin endpoints.
+	DefaultAdminScope = "tenant-api:admin"
+
+	// DefaultLockScope is the default scope required to lock or unlock tenants.
+	DefaultLockScope = "tenant-api:lock"
+
+	// DefaultWriteScope is the default scope required to touch tenants.
+	DefaultWriteScope = "tenant-api:write"
+)
+
+// tokenScopes returns the scopes granted to the request's token.
+// Both the space delimited "scope" claim and the "scp" list claim are supported.
+func tokenScopes(c echo.Context) []string {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return nil
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil
+	}
+
+	var scopes []string
+
+	if scope, ok := claims["scope"].(string); ok {
+		scopes = append(scopes, strings.Fields(scope)...)
+	}
+
+	if scp, ok := claims["scp"].([]interface{}); ok {
+		for _, s := range scp {
+			if scope, ok := s.(string); ok {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+
+	return scopes
+}
+
+// hasScope returns true when the request's token was granted the provided scope.
+func hasScope(c echo.Context, scope string) bool {
+	for _, s := range tokenScopes(c) {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// requireScope rejects requests whose token has not been granted the provided scope.
+func requireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !hasScope(c, scope) {
+				return v1ForbiddenResponse(c, fmt.Errorf("%w: %s", ErrMissingScope, scope))
+			}
+
+			return next(c)
+		}
+	}
+}
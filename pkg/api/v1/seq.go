@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"go.infratographer.com/tenant-api/internal/models"
+)
+
+// seqColumn is the column of the sequence number a tenant was last changed
+// at. The database assigns it from a sequence on every insert and update,
+// including soft deletes, so it isn't part of the tenant model, which would
+// write it back.
+const seqColumn = "tenants.seq"
+
+// seqTenant is a tenant with the sequence number it was last changed at.
+type seqTenant struct {
+	models.Tenant `boil:"tenant,bind"`
+	Seq           int64 `boil:"seq"`
+}
+
+// parseSinceSeq parses the optional since_seq query parameter, which lists
+// the tenants changed after the sequence number, including deleted tenants
+// with their deleted_at, in the order they were changed. Responses return
+// the sequence number to list the following changes since, as a high-water
+// mark for incremental syncs which, unlike timestamps, doesn't skip tenants
+// changed at the same time.
+//
+// Sequence numbers are assigned when a tenant is written, not when its
+// transaction commits, so a transaction still in flight may commit a lower
+// sequence number than one already listed. Clients must re-read from a
+// lagging mark, such as the sequence number returned by a list made longer
+// ago than the request timeout, and apply the changes listed again by their
+// updated_at, for the changes committed out of order to be listed.
+func parseSinceSeq(c echo.Context) (*int64, error) {
+	value := c.QueryParam("since_seq")
+	if value == "" {
+		return nil, nil
+	}
+
+	seq, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seq < 0 {
+		return nil, fmt.Errorf("%w: since_seq must be a sequence number returned by a previous list", ErrInvalidQueryParam)
+	}
+
+	return &seq, nil
+}
+
+// sinceSeqQueryMods select the tenants changed after the sequence number,
+// in the order they were changed.
+func sinceSeqQueryMods(seq int64) (filter, order qm.QueryMod) {
+	return qm.Where(seqColumn+" > ?", seq), qm.OrderBy(seqColumn)
+}
+
+// listSinceSeq queries the tenants matching the query mods with their
+// sequence numbers, returning the sequence number the following changes
+// are listed since, the last tenant's, or seq when none are listed.
+func (r *Router) listSinceSeq(ctx context.Context, mods []qm.QueryMod, seq int64) ([]*models.Tenant, int64, error) {
+	var rows []*seqTenant
+
+	if err := models.Tenants(mods...).Bind(ctx, r.db, &rows); err != nil {
+		return nil, 0, err
+	}
+
+	ts := make([]*models.Tenant, len(rows))
+
+	for i, row := range rows {
+		ts[i] = &row.Tenant
+		seq = row.Seq
+	}
+
+	return ts, seq, nil
+}
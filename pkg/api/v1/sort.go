@@ -0,0 +1,64 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"go.infratographer.com/tenant-api/internal/models"
+)
+
+// DefaultListSort is the order tenants are listed in when neither the
+// request nor the configuration provide one, oldest first.
+var DefaultListSort = ListSort{Field: "created_at"}
+
+// sortColumns are the tenant columns lists may be sorted by, by field name.
+var sortColumns = map[string]string{
+	"created_at": models.TenantTableColumns.CreatedAt,
+	"updated_at": models.TenantTableColumns.UpdatedAt,
+	"name":       models.TenantTableColumns.Name,
+}
+
+// ListSort is the order of tenant lists.
+type ListSort struct {
+	// Field is the tenant field sorted by.
+	Field string
+	// Descending sorts the field from the highest value, such as newest first.
+	Descending bool
+}
+
+// ParseListSort parses a list order, the name of the field to sort by, one
+// of created_at, updated_at or name, prefixed with - to sort descending. For
+// example, -created_at lists the newest tenants first.
+func ParseListSort(value string) (ListSort, error) {
+	field, descending := strings.CutPrefix(value, "-")
+
+	if _, ok := sortColumns[field]; !ok {
+		return ListSort{}, fmt.Errorf("%w: %q, sort by created_at, updated_at or name, prefixed with - for descending", ErrInvalidSort, value)
+	}
+
+	return ListSort{Field: field, Descending: descending}, nil
+}
+
+// parseSort parses the optional sort query parameter, falling back to the
+// configured default order.
+func (r *Router) parseSort(c echo.Context) (ListSort, error) {
+	value := c.QueryParam("sort")
+	if value == "" {
+		return r.listSort, nil
+	}
+
+	return ParseListSort(value)
+}
+
+// queryMod orders the query by the field, and then by ID, so tenants with the
+// same value are listed in the same order on every page.
+func (s ListSort) queryMod() qm.QueryMod {
+	direction := "ASC"
+	if s.Descending {
+		direction = "DESC"
+	}
+
+	return qm.OrderBy(fmt.Sprintf("%s %s, %s %s", sortColumns[s.Field], direction, models.TenantTableColumns.ID, direction))
+}
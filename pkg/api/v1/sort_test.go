@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseListSort(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		expected ListSort
+		err      error
+	}{
+		{"ascending", "created_at", ListSort{Field: "created_at"}, nil},
+		{"descending", "-created_at", ListSort{Field: "created_at", Descending: true}, nil},
+		{"name", "name", ListSort{Field: "name"}, nil},
+		{"updated", "-updated_at", ListSort{Field: "updated_at", Descending: true}, nil},
+		{"unknown field", "path", ListSort{}, ErrInvalidSort},
+		{"empty", "", ListSort{}, ErrInvalidSort},
+		{"only direction", "-", ListSort{}, ErrInvalidSort},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sort, err := ParseListSort(tc.value)
+
+			assert.True(t, errors.Is(err, tc.err), "unexpected error %v", err)
+			assert.Equal(t, tc.expected, sort, "unexpected sort")
+		})
+	}
+}
+
+func TestTenantListSort(t *testing.T) {
+	newestFirst, err := ParseListSort("-created_at")
+	require.NoError(t, err, "no error expected parsing sort")
+
+	srv, err := newTestServer(t, &testServerConfig{
+		routerOpts: []RouterOption{WithDefaultListSort(newestFirst)},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	parent := srv.createTenant(t, "", "parent")
+
+	first := srv.createTenant(t, parent.ID, "b")
+	second := srv.createTenant(t, parent.ID, "c")
+	third := srv.createTenant(t, parent.ID, "a")
+
+	list := func(t *testing.T, query string) []*tenant {
+		t.Helper()
+
+		var result *v1TenantSliceResponse
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(parent.ID)+"/tenants"+query, nil, nil, &result)
+		require.NoError(t, err, "no error expected for tenant list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		require.NotNil(t, result, "expected tenant list response")
+
+		return result.Tenants
+	}
+
+	t.Run("configured default", func(t *testing.T) {
+		assert.Equal(t, tenantIDs([]*tenant{third, second, first}), tenantIDs(list(t, "")), "expected newest tenants first")
+	})
+
+	t.Run("request sort wins", func(t *testing.T) {
+		assert.Equal(t, tenantIDs([]*tenant{first, second, third}), tenantIDs(list(t, "?sort=created_at")), "expected oldest tenants first")
+		assert.Equal(t, tenantIDs([]*tenant{third, first, second}), tenantIDs(list(t, "?sort=name")), "expected tenants by name")
+	})
+
+	t.Run("paginated", func(t *testing.T) {
+		assert.Equal(t, tenantIDs([]*tenant{second}), tenantIDs(list(t, "?limit=1&page=2")), "expected the default order across pages")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants?sort=path", nil, nil, nil)
+		require.NoError(t, err, "no error expected for tenant list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+	})
+}
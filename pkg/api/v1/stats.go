@@ -0,0 +1,146 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// subtreeStatsQuery walks down from the tenant ($1), at most $2 levels,
+// returning the size of the walked subtree, its depth, how many of its
+// descendants have no children and whether the walk was stopped by the
+// maximum depth.
+const subtreeStatsQuery = `
+	WITH RECURSIVE subtree AS (
+		SELECT id, 0 AS depth
+		FROM tenants
+		WHERE id = $1 AND deleted_at IS NULL
+
+		UNION ALL
+
+		SELECT t.id, s.depth + 1
+		FROM tenants t
+		INNER JOIN subtree s ON t.parent_tenant_id = s.id
+		WHERE
+			s.depth < $2
+			AND t.deleted_at IS NULL
+	), nodes AS (
+		SELECT
+			depth,
+			EXISTS (
+				SELECT 1 FROM tenants children
+				WHERE children.parent_tenant_id = subtree.id AND children.deleted_at IS NULL
+			) AS has_children
+		FROM subtree
+	)
+	SELECT
+		count(*),
+		coalesce(max(depth), 0),
+		count(*) FILTER (WHERE depth > 0 AND NOT has_children),
+		coalesce(bool_or(depth = $2 AND has_children), false)
+	FROM nodes
+`
+
+// depthHistogramQuery walks down from the root tenants, at most one level
+// deeper than $1, returning the number of tenants at each depth. Tenants
+// deeper than $1 are only walked to detect the walk being stopped by the
+// maximum depth.
+const depthHistogramQuery = `
+	WITH RECURSIVE tree AS (
+		SELECT id, 0 AS depth
+		FROM tenants
+		WHERE parent_tenant_id IS NULL AND deleted_at IS NULL
+
+		UNION ALL
+
+		SELECT t.id, tree.depth + 1
+		FROM tenants t
+		INNER JOIN tree ON t.parent_tenant_id = tree.id
+		WHERE
+			tree.depth <= $1
+			AND t.deleted_at IS NULL
+	)
+	SELECT depth, count(*)
+	FROM tree
+	GROUP BY depth
+	ORDER BY depth
+`
+
+// tenantStats responds with the size of the tenant's subtree: how many
+// descendants it has, how many levels deep it is and how many of its
+// descendants are leaves.
+func (r *Router) tenantStats(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantStats")
+	defer span.End()
+
+	tenantID, err := r.parseID(c, "id")
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	var (
+		stats    subtreeStats
+		size     int64
+		exceeded bool
+	)
+
+	if err := r.db.QueryRowContext(ctx, subtreeStatsQuery, tenantID, r.maxDepth).
+		Scan(&size, &stats.MaxDepth, &stats.Leaves, &exceeded); err != nil {
+		return r.tenantQueryErrorResponse(c, err)
+	}
+
+	if size == 0 {
+		return v1TenantNotFoundResponse(c, sql.ErrNoRows)
+	}
+
+	if exceeded {
+		return r.maxDepthExceededResponse(c, tenantID)
+	}
+
+	// The subtree includes the tenant itself.
+	stats.Descendants = size - 1
+
+	return v1TenantStatsResponse(c, stats)
+}
+
+// tenantDepthHistogram responds with the number of tenants at each level of
+// the hierarchy, where root tenants are at depth zero.
+func (r *Router) tenantDepthHistogram(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantDepthHistogram")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, depthHistogramQuery, r.maxDepth)
+	if err != nil {
+		return r.tenantQueryErrorResponse(c, err)
+	}
+
+	defer rows.Close()
+
+	levels := []depthCount{}
+
+	for rows.Next() {
+		var level depthCount
+
+		if err := rows.Scan(&level.Depth, &level.Count); err != nil {
+			return r.tenantQueryErrorResponse(c, err)
+		}
+
+		levels = append(levels, level)
+	}
+
+	if err := rows.Err(); err != nil {
+		return r.tenantQueryErrorResponse(c, err)
+	}
+
+	if len(levels) > 0 && levels[len(levels)-1].Depth > r.maxDepth {
+		err := fmt.Errorf("%w: the hierarchy is more than %d levels deep", ErrMaxDepthExceeded, r.maxDepth)
+
+		r.requestLogger(c).Error("tenant hierarchy exceeds maximum depth", zap.Error(err))
+
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	return v1TenantDepthHistogramResponse(c, levels)
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/x/gidx"
+)
+
+func TestTenantStats(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	tree := buildTree(t, srv)
+
+	statsPath := func(id gidx.PrefixedID) string {
+		return "/v1/tenants/" + string(id) + "/stats"
+	}
+
+	testCases := []struct {
+		name     string
+		tenant   string
+		expected subtreeStats
+	}{
+		{"root", "t1", subtreeStats{Descendants: 7, MaxDepth: 3, Leaves: 3}},
+		{"intermediate", "t1a", subtreeStats{Descendants: 3, MaxDepth: 2, Leaves: 2}},
+		{"single child", "t2", subtreeStats{Descendants: 1, MaxDepth: 1, Leaves: 1}},
+		{"leaf", "t1a1a", subtreeStats{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var result *v1TenantStatsResponseBody
+
+			resp, err := srv.Request(http.MethodGet, statsPath(tree.tenantsByName[tc.tenant].ID), nil, nil, &result)
+			require.NoError(t, err, "no error expected for tenant stats")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+			require.NotNil(t, result, "expected stats response")
+			assert.Equal(t, tc.expected, result.subtreeStats, "unexpected stats")
+		})
+	}
+
+	t.Run("deleted descendants are not counted", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodDelete, "/v1/tenants/"+string(tree.tenantsByName["t1b1a"].ID), nil, nil, nil)
+		require.NoError(t, err, "no error expected for deleting tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		var result *v1TenantStatsResponseBody
+
+		resp, err = srv.Request(http.MethodGet, statsPath(tree.tenantsByName["t1b"].ID), nil, nil, &result)
+		require.NoError(t, err, "no error expected for tenant stats")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected stats response")
+		assert.Equal(t, subtreeStats{Descendants: 1, MaxDepth: 1, Leaves: 1}, result.subtreeStats, "unexpected stats")
+	})
+
+	t.Run("unknown tenant", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodGet, statsPath(gidx.MustNewID(TenantIDPrefix)), nil, nil, nil)
+		require.NoError(t, err, "no error expected for tenant stats")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, "unexpected status code returned")
+	})
+}
+
+func TestTenantDepthHistogram(t *testing.T) {
+	histogram := func(t *testing.T, srv *testServer) (*http.Response, *v1TenantDepthHistogramResponseBody) {
+		t.Helper()
+
+		var result *v1TenantDepthHistogramResponseBody
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/depth-histogram", nil, nil, &result)
+		require.NoError(t, err, "no error expected for depth histogram")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp, result
+	}
+
+	t.Run("tree", func(t *testing.T) {
+		srv, err := newTestServer(t, nil)
+		defer srv.close()
+
+		require.NoError(t, err, "no error expected for new test server")
+
+		tree := buildTree(t, srv)
+
+		resp, result := histogram(t, srv)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected depth histogram response")
+		assert.Equal(t, []depthCount{{0, 2}, {1, 3}, {2, 2}, {3, 3}}, result.Levels, "unexpected depth histogram")
+
+		resp, err = srv.Request(http.MethodDelete, "/v1/tenants/"+string(tree.tenantsByName["t1b1a"].ID), nil, nil, nil)
+		require.NoError(t, err, "no error expected for deleting tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		_, result = histogram(t, srv)
+
+		require.NotNil(t, result, "expected depth histogram response")
+		assert.Equal(t, []depthCount{{0, 2}, {1, 3}, {2, 2}, {3, 2}}, result.Levels, "expected deleted tenants not to be counted")
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		srv, err := newTestServer(t, nil)
+		defer srv.close()
+
+		require.NoError(t, err, "no error expected for new test server")
+
+		resp, result := histogram(t, srv)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected depth histogram response")
+		assert.Empty(t, result.Levels, "expected no levels")
+	})
+
+	t.Run("max depth exceeded", func(t *testing.T) {
+		srv, err := newTestServer(t, &testServerConfig{routerOpts: []RouterOption{WithMaxDepth(2)}})
+		defer srv.close()
+
+		require.NoError(t, err, "no error expected for new test server")
+
+		buildTree(t, srv)
+
+		resp, _ := histogram(t, srv)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode, "unexpected status code returned")
+	})
+}
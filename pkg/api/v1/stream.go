@@ -0,0 +1,190 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.uber.org/zap"
+)
+
+// streamFlushInterval is the number of tenants written between flushes of a
+// streamed list response.
+const streamFlushInterval = 100
+
+// streamColumns are the tenant columns selected by streamed lists, in the
+// order they are scanned.
+var streamColumns = []string{
+	models.TenantTableColumns.ID,
+	models.TenantTableColumns.Name,
+	models.TenantTableColumns.ParentTenantID,
+	models.TenantTableColumns.CreatedAt,
+	models.TenantTableColumns.UpdatedAt,
+	models.TenantTableColumns.DeletedAt,
+	models.TenantTableColumns.Locked,
+	models.TenantTableColumns.CreatedBy,
+	models.TenantTableColumns.UpdatedBy,
+	models.TenantTableColumns.Quota,
+	models.TenantTableColumns.Description,
+	models.TenantTableColumns.Kind,
+}
+
+// parseStream parses the optional stream query parameter.
+func parseStream(c echo.Context) (bool, error) {
+	value := c.QueryParam("stream")
+	if value == "" {
+		return false, nil
+	}
+
+	stream, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%w: stream must be true or false", ErrInvalidQueryParam)
+	}
+
+	return stream, nil
+}
+
+// tenantListStream streams all tenants matching the query mods as a JSON
+// array, writing the tenants as they are read from the database so memory
+// use does not grow with the number of tenants. Pagination does not apply to
+// streamed lists.
+//
+// As with exports, an error part way through the list can only be reported
+// by ending the response early, leaving the array incomplete.
+func (r *Router) tenantListStream(ctx context.Context, c echo.Context, mods []qm.QueryMod) error {
+	if contentType, _ := c.Get(contentTypeKey).(string); contentType != echo.MIMEApplicationJSON {
+		return v1NotAcceptableResponse(c, fmt.Errorf("%w: streamed lists are only available as %s", ErrNotAcceptable, echo.MIMEApplicationJSON))
+	}
+
+	mods = append([]qm.QueryMod{qm.Select(streamColumns...)}, mods...)
+
+	rows, err := models.Tenants(mods...).QueryContext(ctx, r.db)
+	if err != nil {
+		r.requestLogger(c).Error("failed to query tenants", zap.Error(err))
+
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	defer rows.Close()
+
+	w := newTenantStreamWriter(c.Response())
+	w.naming = fieldNaming(c)
+
+	if err := w.start(); err != nil {
+		r.requestLogger(c).Error("failed to write tenant stream", zap.Error(err))
+
+		return nil
+	}
+
+	for rows.Next() {
+		t, err := scanStreamTenant(rows)
+		if err != nil {
+			r.requestLogger(c).Error("failed to scan tenant stream", zap.Error(err))
+
+			return nil
+		}
+
+		if err := w.write(t); err != nil {
+			r.requestLogger(c).Error("failed to write tenant stream", zap.Error(err))
+
+			return nil
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		r.requestLogger(c).Error("failed to read tenant stream", zap.Error(err))
+
+		return nil
+	}
+
+	if err := w.end(); err != nil {
+		r.requestLogger(c).Error("failed to write tenant stream", zap.Error(err))
+	}
+
+	return nil
+}
+
+// scanStreamTenant scans a row of the streamColumns.
+func scanStreamTenant(rows *sql.Rows) (*models.Tenant, error) {
+	t := new(models.Tenant)
+
+	err := rows.Scan(
+		&t.ID,
+		&t.Name,
+		&t.ParentTenantID,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+		&t.DeletedAt,
+		&t.Locked,
+		&t.CreatedBy,
+		&t.UpdatedBy,
+		&t.Quota,
+		&t.Description,
+		&t.Kind,
+	)
+
+	return t, err
+}
+
+// tenantStreamWriter incrementally writes tenants to the response as a JSON array.
+type tenantStreamWriter struct {
+	resp   *echo.Response
+	naming FieldNaming
+	count  int
+}
+
+func newTenantStreamWriter(resp *echo.Response) *tenantStreamWriter {
+	return &tenantStreamWriter{
+		resp:   resp,
+		naming: FieldNamingSnakeCase,
+	}
+}
+
+func (w *tenantStreamWriter) start() error {
+	w.resp.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+	w.resp.WriteHeader(http.StatusOK)
+
+	_, err := w.resp.Write([]byte("["))
+
+	return err
+}
+
+func (w *tenantStreamWriter) write(t *models.Tenant) error {
+	if w.count > 0 {
+		if _, err := w.resp.Write([]byte(",")); err != nil {
+			return err
+		}
+	}
+
+	b, err := w.naming.marshal(v1Tenant(t))
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.resp.Write(append(b, '\n')); err != nil {
+		return err
+	}
+
+	w.count++
+
+	if w.count%streamFlushInterval == 0 {
+		w.resp.Flush()
+	}
+
+	return nil
+}
+
+func (w *tenantStreamWriter) end() error {
+	if _, err := w.resp.Write([]byte("]")); err != nil {
+		return err
+	}
+
+	w.resp.Flush()
+
+	return nil
+}
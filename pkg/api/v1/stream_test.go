@@ -0,0 +1,168 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/x/gidx"
+)
+
+// discardResponseWriter is a response writer which retains nothing written to it.
+type discardResponseWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *discardResponseWriter) Header() http.Header         { return w.header }
+func (w *discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardResponseWriter) WriteHeader(status int)      { w.status = status }
+func (w *discardResponseWriter) Flush()                      {}
+
+func TestTenantStreamWriter(t *testing.T) {
+	t.Run("array", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		w := newTenantStreamWriter(echo.NewResponse(rec, echo.New()))
+
+		ids := []gidx.PrefixedID{gidx.MustNewID(TenantIDPrefix), gidx.MustNewID(TenantIDPrefix)}
+
+		require.NoError(t, w.start())
+
+		for _, id := range ids {
+			require.NoError(t, w.write(&models.Tenant{ID: id, Name: "tenant"}))
+		}
+
+		require.NoError(t, w.end())
+
+		assert.Equal(t, http.StatusOK, rec.Code, "unexpected status code returned")
+		assert.Equal(t, echo.MIMEApplicationJSONCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+
+		var result tenantSlice
+
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result), "expected a JSON array")
+		require.Len(t, result, 2)
+		assert.Equal(t, ids[0], result[0].ID)
+		assert.Equal(t, ids[1], result[1].ID)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		w := newTenantStreamWriter(echo.NewResponse(rec, echo.New()))
+
+		require.NoError(t, w.start())
+		require.NoError(t, w.end())
+
+		assert.Equal(t, "[]", rec.Body.String())
+	})
+
+	t.Run("memory is bounded", func(t *testing.T) {
+		const count = 200000
+
+		w := newTenantStreamWriter(echo.NewResponse(&discardResponseWriter{header: make(http.Header)}, echo.New()))
+
+		tenant := &models.Tenant{
+			ID:        gidx.MustNewID(TenantIDPrefix),
+			Name:      "tenant",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+
+		var before, after runtime.MemStats
+
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		require.NoError(t, w.start())
+
+		for i := 0; i < count; i++ {
+			require.NoError(t, w.write(tenant))
+		}
+
+		require.NoError(t, w.end())
+
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+
+		// Each encoded tenant is about 200 bytes, so a buffered response would
+		// retain tens of megabytes.
+		growth := int64(after.HeapAlloc) - int64(before.HeapAlloc)
+		assert.Less(t, growth, int64(1<<20), "expected heap not to grow with the number of tenants")
+	})
+}
+
+func TestTenantListStream(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	tree := buildTree(t, srv)
+
+	t.Run("streams all tenants", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(tree.tenantsByName["t1"].ID)+"/tenants?stream=true&limit=1", nil, nil, nil)
+		require.NoError(t, err, "no error expected for streamed list")
+
+		defer resp.Body.Close() //nolint:errcheck // Not needed
+
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err, "no error expected reading streamed list")
+
+		var result tenantSlice
+
+		require.NoError(t, json.Unmarshal(body, &result), "expected a JSON array")
+		assert.ElementsMatch(t, tenantIDs([]*tenant{tree.tenantsByName["t1a"], tree.tenantsByName["t1b"]}), tenantIDs(result), "expected children ignoring the limit")
+	})
+
+	t.Run("filters apply", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants?stream=true&has_children=true", nil, nil, nil)
+		require.NoError(t, err, "no error expected for streamed list")
+
+		defer resp.Body.Close() //nolint:errcheck // Not needed
+
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		var result tenantSlice
+
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result), "expected a JSON array")
+		assert.ElementsMatch(t, tenantIDs([]*tenant{tree.tenantsByName["t1"], tree.tenantsByName["t2"]}), tenantIDs(result))
+	})
+
+	t.Run("total not counted by default", func(t *testing.T) {
+		for _, query := range []string{"stream=true", "stream=true&include_total=false"} {
+			resp, err := srv.Request(http.MethodGet, "/v1/tenants?"+query, nil, nil, nil)
+			require.NoError(t, err, "no error expected for streamed list")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			assert.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned for %s", query)
+			assert.Empty(t, resp.Header.Get(headerTotalCount), "expected no total count for %s", query)
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		for _, query := range []string{"stream=maybe", "stream=true&include_total=true"} {
+			resp, err := srv.Request(http.MethodGet, "/v1/tenants?"+query, nil, nil, nil)
+			require.NoError(t, err, "no error expected for streamed list")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned for %s", query)
+		}
+	})
+
+	t.Run("protobuf not supported", func(t *testing.T) {
+		headers := make(http.Header)
+		headers.Set("Accept", MIMEApplicationProtobuf)
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants?stream=true", headers, nil, nil)
+		require.NoError(t, err, "no error expected for streamed list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusNotAcceptable, resp.StatusCode, "unexpected status code returned")
+	})
+}
@@ -1,17 +1,21 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
+	"github.com/volatiletech/null/v8"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 	"go.infratographer.com/tenant-api/internal/models"
 	"go.infratographer.com/tenant-api/internal/pubsub"
 	"go.infratographer.com/tenant-api/internal/x/nullx"
-	"go.infratographer.com/x/echojwtx"
 	"go.infratographer.com/x/gidx"
+	"go.infratographer.com/x/pubsubx"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
@@ -24,14 +28,14 @@ const (
 	// TenantIDResource is the GID resource portion of the prefix.
 	TenantIDResource = "ten"
 
-	// TenantIDPrefix is the full prefix for a Tenant GID.
+	// TenantIDPrefix is the default full prefix for a Tenant GID.
 	TenantIDPrefix = TenantIDService + TenantIDResource
 )
 
 func (r *Router) tenantCreate(c echo.Context) error {
-	tenantID, err := parseID(c, "id")
+	tenantID, err := r.parseID(c, "id")
 	if err != nil && !errors.Is(err, ErrIDNotFound) {
-		r.logger.Error("invalid tenant id", zap.Error(err))
+		r.requestLogger(c).Error("invalid tenant id", zap.Error(err))
 
 		return v1BadRequestResponse(c, err)
 	}
@@ -44,63 +48,242 @@ func (r *Router) tenantCreate(c echo.Context) error {
 	ctx, span := tracer.Start(c.Request().Context(), "tenantCreate", traceOpts...)
 	defer span.End()
 
-	createRequest := new(createTenantRequest)
+	payload := new(pathCreateTenantRequest)
 
-	if err := c.Bind(createRequest); err != nil {
-		r.logger.Error("failed to bind tenant create request", zap.Error(err))
+	if err := bindRequest(c, payload); err != nil {
+		r.requestLogger(c).Error("failed to bind tenant create request", zap.Error(err))
 
 		return v1BadRequestResponse(c, err)
 	}
 
-	if err := createRequest.validate(); err != nil {
-		r.logger.Error("invalid create request", zap.Error(err))
+	if err := payload.validate(tenantID); err != nil {
+		r.requestLogger(c).Error("invalid create request", zap.Error(err))
 
 		return v1BadRequestResponse(c, err)
 	}
 
-	id, err := gidx.NewID(TenantIDPrefix)
+	createRequest := &payload.createTenantRequest
+
+	upsert, err := parseUpsert(c)
 	if err != nil {
-		r.logger.Error("invalid new tenant id", zap.Error(err))
+		return v1BadRequestResponse(c, err)
+	}
 
-		return v1InternalServerErrorResponse(c, err)
+	// Root tenant names aren't unique, so an existing root is looked up
+	// rather than relying on the create conflicting.
+	if upsert && tenantID == "" {
+		existing, err := existingChild(ctx, r.db, "", createRequest.Name)
+		if err == nil {
+			return v1TenantGetResponse(c, existing)
+		}
+
+		if !errors.Is(err, sql.ErrNoRows) {
+			r.requestLogger(c).Error("failed to query existing tenant", zap.Error(err))
+
+			return v1InternalServerErrorResponse(c, err)
+		}
+	}
+
+	t, err := r.createTenant(ctx, tenantID, r.actor(c), createRequest)
+	if err != nil {
+		return r.tenantCreateErrorResponse(c, tenantID, createRequest.Name, r.idempotentCreate || upsert, err)
+	}
+
+	r.publishCreate(ctx, c, t, createRequest.Labels)
+
+	return v1TenantCreatedResponse(c, t)
+}
+
+// createTenant creates the requested tenant in its own transaction.
+// Concurrent creates of the same child conflict when committed, so the
+// create is retried to resolve the conflict against the committed tenant.
+func (r *Router) createTenant(ctx context.Context, parentID gidx.PrefixedID, actor string, req *createTenantRequest) (*models.Tenant, error) {
+	var t *models.Tenant
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+
+		t, err = r.insertTenantTx(ctx, tx, parentID, actor, req)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// insertTenantTx creates the requested tenant under the parent, or as a root
+// tenant when no parent is provided. ErrNameConflict is returned when the
+// parent already has a child with the requested name.
+func (r *Router) insertTenantTx(ctx context.Context, exec boil.ContextExecutor, parentID gidx.PrefixedID, actor string, req *createTenantRequest) (*models.Tenant, error) {
+	if err := r.checkNamePattern(req.Name); err != nil {
+		return nil, err
+	}
+
+	if err := r.checkNameResolvable(req.Name); err != nil {
+		return nil, err
+	}
+
+	if err := r.checkKind(req.Kind); err != nil {
+		return nil, err
+	}
+
+	id, err := gidx.NewID(r.idPrefix)
+	if err != nil {
+		return nil, err
 	}
 
 	t := &models.Tenant{
-		ID:   id,
-		Name: createRequest.Name,
+		ID:          id,
+		Name:        req.Name,
+		Path:        tenantPath("", id),
+		CreatedBy:   actorColumn(actor),
+		UpdatedBy:   actorColumn(actor),
+		Quota:       null.Int64FromPtr(req.Quota),
+		Description: null.StringFromPtr(req.Description),
+		Kind:        null.StringFromPtr(req.Kind),
 	}
 
-	var additionalGID []gidx.PrefixedID
+	if parentID != "" {
+		parentPath, err := r.lookupTenantPath(ctx, exec, parentID)
+		if err != nil {
+			return nil, err
+		}
 
-	if tenantID != "" {
-		t.ParentTenantID = nullx.PrefixedIDFrom(tenantID)
-		additionalGID = append(additionalGID, tenantID)
+		if err := r.checkNameConflict(ctx, exec, id, parentID, req.Name); err != nil {
+			return nil, err
+		}
+
+		if err := r.checkChildLimit(ctx, exec, parentID); err != nil {
+			return nil, err
+		}
+
+		t.ParentTenantID = nullx.PrefixedIDFrom(parentID)
+		t.Path = tenantPath(parentPath, id)
 	}
 
-	if err := t.Insert(ctx, r.db, boil.Infer()); err != nil {
-		r.logger.Error("error inserting tenant", zap.Error(err))
+	if err := r.validateCreate(ctx, t); err != nil {
+		return nil, err
+	}
 
-		return v1InternalServerErrorResponse(c, err)
+	if err := t.Insert(ctx, exec, boil.Infer()); err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("%w: %q already exists", ErrNameConflict, req.Name)
+		}
+
+		return nil, err
+	}
+
+	if err := insertLabels(ctx, exec, t.ID, req.Labels, t.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// existingChild returns the child of the parent with the provided name, or
+// the oldest root tenant with the name when no parent is provided.
+func existingChild(ctx context.Context, exec boil.ContextExecutor, parentID gidx.PrefixedID, name string) (*models.Tenant, error) {
+	parentMod := models.TenantWhere.ParentTenantID.EQ(nullx.PrefixedIDFrom(parentID))
+	if parentID == "" {
+		parentMod = models.TenantWhere.ParentTenantID.IsNull()
+	}
+
+	return models.Tenants(
+		parentMod,
+		models.TenantWhere.Name.EQ(name),
+		qm.OrderBy(models.TenantTableColumns.CreatedAt+" ASC"),
+	).One(ctx, exec)
+}
+
+// parseUpsert parses the optional upsert query parameter of creates. Upserts
+// respond with the existing tenant of the same name under the same parent,
+// if any, rather than a conflict, making the create idempotent by name.
+func parseUpsert(c echo.Context) (bool, error) {
+	value := c.QueryParam("upsert")
+	if value == "" {
+		return false, nil
+	}
+
+	upsert, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%w: upsert must be true or false", ErrInvalidQueryParam)
+	}
+
+	return upsert, nil
+}
+
+// publishCreate emits a create event for the tenant carrying its labels.
+func (r *Router) publishCreate(ctx context.Context, c echo.Context, t *models.Tenant, labels map[string]string) {
+	var additionalGID []gidx.PrefixedID
+
+	if t.ParentTenantID.Valid {
+		additionalGID = append(additionalGID, t.ParentTenantID.PrefixedID)
 	}
 
-	actor := echojwtx.Actor(c)
+	actor := r.actor(c)
 
-	msg, err := pubsub.NewTenantMessage(
+	msg, err := pubsub.NewTenantWithLabelsMessage(
 		gidx.PrefixedID(actor),
 		t.ID,
+		labels,
 		additionalGID...,
 	)
 	if err != nil {
 		// TODO: add status to reconcile and requeue this
-		r.logger.Error("failed to create tenant message", zap.Error(err))
+		r.requestLogger(c).Error("failed to create tenant message", zap.Error(err))
 	}
 
 	if err := r.pubsub.PublishCreate(ctx, "tenants", "global", msg); err != nil {
 		// TODO: add status to reconcile and requeue this
-		r.logger.Error("failed to publish tenant message", zap.Error(err))
+		r.requestLogger(c).Error("failed to publish tenant message", zap.Error(err))
 	}
+}
 
-	return v1TenantCreatedResponse(c, t)
+// tenantCreateErrorResponse responds to a rejected or failed tenant create.
+// When the create is idempotent, a create conflicting with an existing child
+// of the parent responds with the existing child.
+func (r *Router) tenantCreateErrorResponse(c echo.Context, parentID gidx.PrefixedID, name string, idempotent bool, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return v1TenantNotFoundResponse(c, err)
+	case errors.Is(err, ErrChildLimitExceeded):
+		return v1ChildLimitExceededResponse(c, err)
+	case errors.Is(err, ErrNamePatternMismatch):
+		return v1NamePatternMismatchResponse(c, err)
+	case errors.Is(err, ErrTenantNameReserved):
+		return v1NameReservedResponse(c, err)
+	case errors.Is(err, ErrInvalidKind):
+		return v1InvalidKindResponse(c, err)
+	case errors.Is(err, ErrValidationFailed):
+		r.requestLogger(c).Error("tenant create rejected by validator", zap.Error(err))
+
+		return v1BadRequestResponse(c, err)
+	case errors.Is(err, ErrNameConflict):
+		if !idempotent {
+			return v1NameConflictResponse(c, err)
+		}
+
+		existing, qErr := existingChild(c.Request().Context(), r.db, parentID, name)
+		if qErr != nil {
+			if errors.Is(qErr, sql.ErrNoRows) {
+				// The existing child was deleted since the conflict.
+				return v1NameConflictResponse(c, err)
+			}
+
+			r.requestLogger(c).Error("failed to query existing tenant", zap.Error(qErr))
+
+			return v1InternalServerErrorResponse(c, qErr)
+		}
+
+		return v1TenantGetResponse(c, existing)
+	}
+
+	r.requestLogger(c).Error("failed to create tenant", zap.Error(err))
+
+	return v1InternalServerErrorResponse(c, err)
 }
 
 func (r *Router) tenantList(c echo.Context) error {
@@ -111,50 +294,320 @@ func (r *Router) tenantList(c echo.Context) error {
 
 	var mods []qm.QueryMod
 
-	if tenantID, err := parseID(c, "id"); err == nil {
-		mods = append(mods, models.TenantWhere.ParentTenantID.EQ(nullx.PrefixedIDFrom(tenantID)))
-	} else if errors.Is(err, ErrIDNotFound) {
+	parents, err := r.parseParentFilter(c)
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	recursive, withDepth, err := parseRecursive(c)
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	depth, err := r.parseDepthFilter(c)
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	sinceSeq, err := parseSinceSeq(c)
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	// parentPath is the materialized path of the tenant whose descendants are listed recursively.
+	var parentPath string
+
+	if tenantID, err := r.parseID(c, "id"); err == nil {
+		if parents != nil {
+			return v1BadRequestResponse(c, fmt.Errorf("%w: parent_id can't be used when listing subtenants", ErrInvalidQueryParam))
+		}
+
+		if depth != nil {
+			return v1BadRequestResponse(c, fmt.Errorf("%w: depth can't be used when listing subtenants", ErrInvalidQueryParam))
+		}
+
+		if recursive {
+			parent, err := models.FindTenant(ctx, r.db, tenantID, models.TenantColumns.ID, models.TenantColumns.Path)
+			if err != nil {
+				return r.tenantQueryErrorResponse(c, err)
+			}
+
+			parentPath = parent.Path
+
+			mods = append(mods, subtreeQueryMod(parentPath))
+		} else {
+			mods = append(mods, models.TenantWhere.ParentTenantID.EQ(nullx.PrefixedIDFrom(tenantID)))
+		}
+	} else if !errors.Is(err, ErrIDNotFound) {
+		return v1BadRequestResponse(c, err)
+	} else if recursive {
+		return v1BadRequestResponse(c, fmt.Errorf("%w: recursive can only be used when listing subtenants", ErrInvalidQueryParam))
+	} else if parents != nil && depth != nil {
+		return v1BadRequestResponse(c, fmt.Errorf("%w: depth can't be used with parent_id", ErrInvalidQueryParam))
+	} else if parents != nil {
+		mods = append(mods, parents)
+	} else if depth != nil {
+		mods = append(mods, depth)
+	} else if sinceSeq == nil && c.QueryParam("is_root") == "" {
+		// Changes are listed at every level, rather than only roots, as are
+		// tenants filtered by is_root.
 		mods = append(mods, models.TenantWhere.ParentTenantID.IsNull())
-	} else {
+	}
+
+	filters, err := parseListFilters(c)
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	excluded, err := r.parseExcludeSubtreeFilter(c)
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	if excluded != nil {
+		filters = append(filters, excluded)
+	}
+
+	idRange, err := r.parseIDRangeFilter(c)
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	filters = append(filters, idRange...)
+
+	includeTotal, err := parseIncludeTotal(c)
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	order, err := r.parseSort(c)
+	if err != nil {
 		return v1BadRequestResponse(c, err)
 	}
 
+	orderMod := order.queryMod()
+
+	if recursive {
+		if c.QueryParam("sort") != "" {
+			return v1BadRequestResponse(c, fmt.Errorf("%w: recursive lists are always in pre-order and can't be sorted", ErrInvalidQueryParam))
+		}
+
+		orderMod = preOrderQueryMod
+	}
+
+	cursorMod, err := parseCursor(c, order)
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	if cursorMod != nil {
+		if recursive {
+			return v1BadRequestResponse(c, fmt.Errorf("%w: cursor can't be used with recursive lists", ErrInvalidQueryParam))
+		}
+
+		if c.QueryParam("page") != "" {
+			return v1BadRequestResponse(c, fmt.Errorf("%w: cursor can't be used with page", ErrInvalidQueryParam))
+		}
+
+		// Pages following a cursor aren't offset.
+		pagination.Page = 0
+	}
+
+	if sinceSeq != nil {
+		switch {
+		case recursive:
+			return v1BadRequestResponse(c, fmt.Errorf("%w: since_seq can't be used with recursive lists", ErrInvalidQueryParam))
+		case c.QueryParam("sort") != "":
+			return v1BadRequestResponse(c, fmt.Errorf("%w: since_seq lists are always in sequence order and can't be sorted", ErrInvalidQueryParam))
+		case cursorMod != nil:
+			return v1BadRequestResponse(c, fmt.Errorf("%w: since_seq can't be used with cursor", ErrInvalidQueryParam))
+		case c.QueryParam("page") != "":
+			return v1BadRequestResponse(c, fmt.Errorf("%w: since_seq can't be used with page", ErrInvalidQueryParam))
+		}
+
+		var seqFilter qm.QueryMod
+
+		seqFilter, orderMod = sinceSeqQueryMods(*sinceSeq)
+		filters = append(filters, seqFilter)
+
+		// Deleted tenants are listed as tombstones with their deleted_at, as
+		// soft deletes are changes too.
+		mods = append(mods, qm.WithDeleted())
+
+		// The following changes are listed since the returned sequence number, not by page.
+		pagination.Page = 0
+	}
+
+	mods = append(mods, filters...)
+
+	idsOnly, err := parseIDsOnly(c)
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	stream, err := parseStream(c)
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	if stream {
+		// The total is counted by default, so only an explicit request for
+		// it conflicts with streaming.
+		if includeTotal && c.QueryParam("include_total") != "" {
+			return v1BadRequestResponse(c, fmt.Errorf("%w: include_total can't be used when streaming", ErrInvalidQueryParam))
+		}
+
+		if idsOnly {
+			return v1BadRequestResponse(c, fmt.Errorf("%w: ids_only can't be used when streaming", ErrInvalidQueryParam))
+		}
+
+		if withDepth {
+			return v1BadRequestResponse(c, fmt.Errorf("%w: with_depth can't be used when streaming", ErrInvalidQueryParam))
+		}
+
+		if sinceSeq != nil {
+			return v1BadRequestResponse(c, fmt.Errorf("%w: since_seq can't be used when streaming", ErrInvalidQueryParam))
+		}
+
+		return r.tenantListStream(ctx, c, append(mods, orderMod))
+	}
+
+	if idsOnly && withDepth {
+		return v1BadRequestResponse(c, fmt.Errorf("%w: with_depth can't be used with ids_only", ErrInvalidQueryParam))
+	}
+
+	var total *int64
+
+	if includeTotal {
+		count, err := models.Tenants(mods...).Count(ctx, r.db)
+		if err != nil {
+			r.requestLogger(c).Error("failed to count tenants", zap.Error(err))
+
+			return v1InternalServerErrorResponse(c, err)
+		}
+
+		total = &count
+	}
+
+	// The cursor is added after counting, so the total includes earlier pages.
+	if cursorMod != nil {
+		mods = append(mods, cursorMod)
+	}
+
+	// The order is added after counting, as the count query can't be ordered.
+	mods = append(mods, orderMod)
 	mods = append(mods, pagination.queryMods()...)
 
-	ts, err := models.Tenants(mods...).All(ctx, r.db)
+	var ts models.TenantSlice
+
+	if sinceSeq != nil {
+		if idsOnly {
+			mods = append(mods, qm.Select(models.TenantTableColumns.ID, seqColumn))
+		}
+
+		var next int64
+
+		ts, next, err = r.listSinceSeq(ctx, mods, *sinceSeq)
+
+		pagination.SinceSeq = &next
+	} else {
+		if idsOnly {
+			// The sorted column is selected for the cursor of the next page.
+			mods = append(mods, qm.Select(models.TenantTableColumns.ID, sortColumns[order.Field]))
+		}
+
+		ts, err = models.Tenants(mods...).All(ctx, r.db)
+	}
+
 	if err != nil {
-		r.logger.Error("failed to query tenants", zap.Error(err))
+		r.requestLogger(c).Error("failed to query tenants", zap.Error(err))
 
 		return v1InternalServerErrorResponse(c, err)
 	}
 
-	return v1TenantsResponse(c, ts, pagination)
+	// Full pages of sorted lists return the cursor of the next page, which
+	// keeps its position when earlier tenants are deleted, unlike pages.
+	if !recursive && sinceSeq == nil && len(ts) != 0 && len(ts) == pagination.limitUsed() {
+		pagination.Cursor = newListCursor(order, ts[len(ts)-1])
+	}
+
+	r.setPaginationLinks(c, pagination, len(ts), total)
+
+	if idsOnly {
+		return v1TenantIDsResponse(c, ts, total, pagination)
+	}
+
+	if withDepth {
+		return v1TenantDepthsResponse(c, ts, parentPath, total, pagination)
+	}
+
+	return v1TenantsResponse(c, ts, total, pagination)
+}
+
+// parseIDsOnly parses the optional ids_only query parameter, which lists
+// only the IDs of the tenants rather than the full tenants.
+func parseIDsOnly(c echo.Context) (bool, error) {
+	value := c.QueryParam("ids_only")
+	if value == "" {
+		return false, nil
+	}
+
+	idsOnly, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%w: ids_only must be true or false", ErrInvalidQueryParam)
+	}
+
+	return idsOnly, nil
 }
 
 func (r *Router) tenantGet(c echo.Context) error {
 	ctx, span := tracer.Start(c.Request().Context(), "tenantGet")
 	defer span.End()
 
-	var mods []qm.QueryMod
-
-	tenantID, err := parseID(c, "id")
+	includeChildren, err := parseGetInclude(c)
 	if err != nil {
 		return v1BadRequestResponse(c, err)
 	}
 
-	mods = append(mods, models.TenantWhere.ID.EQ(tenantID))
+	var t *models.Tenant
+
+	// The tenant may be referenced by either its ID or its name path.
+	if ref := c.Param("id"); r.isTenantID(ref) {
+		t, err = models.Tenants(models.TenantWhere.ID.EQ(gidx.PrefixedID(ref))).One(ctx, r.db)
+	} else {
+		names, perr := r.parseNamePath(ref)
+		if perr != nil {
+			return v1BadRequestResponse(c, perr)
+		}
+
+		t, err = r.tenantByNamePath(ctx, names)
+	}
 
-	t, err := models.Tenants(mods...).One(ctx, r.db)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return v1TenantNotFoundResponse(c, err)
 		}
 
-		r.logger.Error("failed to query tenants", zap.Error(err))
+		if errors.Is(err, ErrAmbiguousPath) {
+			return v1ConflictResponse(c, err)
+		}
+
+		r.requestLogger(c).Error("failed to query tenants", zap.Error(err))
 
 		return v1InternalServerErrorResponse(c, err)
 	}
 
+	if includeChildren {
+		truncated, err := r.loadInlineChildren(ctx, t)
+		if err != nil {
+			r.requestLogger(c).Error("failed to query tenant children", zap.Error(err))
+
+			return v1InternalServerErrorResponse(c, err)
+		}
+
+		return v1TenantGetWithChildrenResponse(c, t, truncated)
+	}
+
 	return v1TenantGetResponse(c, t)
 }
 
@@ -162,64 +615,147 @@ func (r *Router) tenantUpdate(c echo.Context) error {
 	ctx, span := tracer.Start(c.Request().Context(), "tenantUpdate")
 	defer span.End()
 
-	var mods []qm.QueryMod
-
-	tenantID, err := parseID(c, "id")
+	tenantID, err := r.parseID(c, "id")
 	if err != nil {
 		return v1BadRequestResponse(c, err)
 	}
 
 	payload := new(updateTenantRequest)
 
-	if err := c.Bind(&payload); err != nil {
-		r.logger.Error("failed to bind update tenant request", zap.Error(err))
+	if err := bindRequest(c, &payload); err != nil {
+		r.requestLogger(c).Error("failed to bind update tenant request", zap.Error(err))
 
 		return v1BadRequestResponse(c, err)
 	}
 
 	if err := payload.validate(); err != nil {
-		r.logger.Error("invalid update tenant request", zap.Error(err))
+		r.requestLogger(c).Error("invalid update tenant request", zap.Error(err))
 
 		return v1BadRequestResponse(c, err)
 	}
 
-	mods = append(mods, models.TenantWhere.ID.EQ(tenantID))
+	actor := r.actor(c)
 
-	t, err := models.Tenants(mods...).One(ctx, r.db)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return v1TenantNotFoundResponse(c, err)
+	var (
+		t       *models.Tenant
+		current models.Tenant
+		moved   bool
+	)
+
+	// The tenant is read and locked within the transaction, so a retried
+	// update is applied to the tenant as left by the conflicting transaction,
+	// and concurrent renames record the name each of them replaced.
+	err = r.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+
+		t, err = models.Tenants(models.TenantWhere.ID.EQ(tenantID), qm.For("UPDATE")).One(ctx, tx)
+		if err != nil {
+			return err
 		}
 
-		r.logger.Error("failed to query tenants", zap.Error(err))
+		current = *t
 
-		return v1InternalServerErrorResponse(c, err)
-	}
+		if payload.Name != nil {
+			t.Name = *payload.Name
+		}
 
-	if payload.Name != nil {
-		t.Name = *payload.Name
-	}
+		moved = payload.ParentTenantID.Set && payload.ParentTenantID.Value != t.ParentTenantID
 
-	if _, err := t.Update(ctx, r.db, boil.Infer()); err != nil {
-		r.logger.Error("failed to update tenant", zap.Error(err))
+		if moved {
+			if err := r.moveTenant(ctx, tx, t, payload.ParentTenantID.Value); err != nil {
+				return err
+			}
+		}
 
-		return v1InternalServerErrorResponse(c, err)
+		// The lock is changed after moving, so a locked tenant must be unlocked
+		// before it can be moved.
+		if payload.Locked != nil && *payload.Locked != t.Locked {
+			if !hasScope(c, r.lockScope) {
+				return fmt.Errorf("%w: %s", ErrMissingScope, r.lockScope)
+			}
+
+			t.Locked = *payload.Locked
+		}
+
+		if payload.Quota.Set {
+			t.Quota = payload.Quota.Value
+		}
+
+		if payload.Description.Set {
+			t.Description = payload.Description.Value
+		}
+
+		if t.Name != current.Name {
+			if err := r.checkNamePattern(t.Name); err != nil {
+				return err
+			}
+
+			if err := r.checkNameResolvable(t.Name); err != nil {
+				return err
+			}
+
+			if err := r.checkNameConflict(ctx, tx, t.ID, t.ParentTenantID.PrefixedID, t.Name); err != nil {
+				return err
+			}
+		}
+
+		t.UpdatedBy = actorColumn(actor)
+
+		if err := r.validateUpdate(ctx, &current, t); err != nil {
+			return err
+		}
+
+		if _, err := t.Update(ctx, tx, boil.Infer()); err != nil {
+			if isUniqueViolation(err) {
+				return fmt.Errorf("%w: %q already exists", ErrNameConflict, t.Name)
+			}
+
+			return err
+		}
+
+		if t.Name != current.Name {
+			change := tenantNameChange{
+				OldName:   current.Name,
+				NewName:   t.Name,
+				Actor:     actor,
+				ChangedAt: t.UpdatedAt,
+			}
+
+			if err := recordNameChange(ctx, tx, t.ID, change); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return r.tenantUpdateErrorResponse(c, err)
 	}
 
-	actor := echojwtx.Actor(c)
+	var msg *pubsubx.ChangeMessage
+
+	if moved {
+		msg, err = pubsub.MoveTenantMessage(
+			gidx.PrefixedID(actor),
+			t.ID,
+			current.ParentTenantID.PrefixedID,
+			t.ParentTenantID.PrefixedID,
+		)
+	} else {
+		msg, err = pubsub.UpdateTenantMessage(
+			gidx.PrefixedID(actor),
+			t.ID,
+		)
+	}
 
-	msg, err := pubsub.UpdateTenantMessage(
-		gidx.PrefixedID(actor),
-		t.ID,
-	)
 	if err != nil {
 		// TODO: add status to reconcile and requeue this
-		r.logger.Error("failed to create, update tenant message", zap.Error(err))
+		r.requestLogger(c).Error("failed to create, update tenant message", zap.Error(err))
 	}
 
 	if err := r.pubsub.PublishUpdate(ctx, "tenants", "global", msg); err != nil {
 		// TODO: add status to reconcile and requeue this
-		r.logger.Error("failed to publish, update tenant message", zap.Error(err))
+		r.requestLogger(c).Error("failed to publish, update tenant message", zap.Error(err))
 	}
 
 	return v1TenantGetResponse(c, t)
@@ -231,11 +767,29 @@ func (r *Router) tenantDelete(c echo.Context) error {
 
 	var mods []qm.QueryMod
 
-	tenantID, err := parseID(c, "id")
+	tenantID, err := r.parseID(c, "id")
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	ifChildless, err := parseIfChildless(c)
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	cascade, err := parseCascade(c)
 	if err != nil {
 		return v1BadRequestResponse(c, err)
 	}
 
+	if cascade {
+		if ifChildless {
+			return v1BadRequestResponse(c, fmt.Errorf("%w: cascade and if_childless can't be combined", ErrInvalidQueryParam))
+		}
+
+		return r.cascadeDelete(ctx, c, tenantID)
+	}
+
 	mods = append(mods, models.TenantWhere.ID.EQ(tenantID))
 
 	t, err := models.Tenants(mods...).One(ctx, r.db)
@@ -244,18 +798,33 @@ func (r *Router) tenantDelete(c echo.Context) error {
 			return v1TenantNotFoundResponse(c, err)
 		}
 
-		r.logger.Error("failed to query tenants", zap.Error(err))
+		r.requestLogger(c).Error("failed to query tenants", zap.Error(err))
 
 		return v1InternalServerErrorResponse(c, err)
 	}
 
-	if _, err := t.Delete(ctx, r.db, false); err != nil {
-		r.logger.Error("failed to delete tenant", zap.Error(err))
+	if t.Locked {
+		return v1TenantLockedResponse(c, fmt.Errorf("%w: %s can't be deleted", ErrTenantLocked, t.ID))
+	}
+
+	if err := r.validateDelete(ctx, t); err != nil {
+		r.requestLogger(c).Error("tenant delete rejected by validator", zap.Error(err))
+
+		return v1BadRequestResponse(c, err)
+	}
+
+	children, err := r.deleteTenant(ctx, t, ifChildless)
+	if err != nil {
+		r.requestLogger(c).Error("failed to delete tenant", zap.Error(err))
 
 		return err
 	}
 
-	actor := echojwtx.Actor(c)
+	if children > 0 {
+		return v1TenantHasChildrenResponse(c, children, fmt.Errorf("%w: %s has %d children", ErrTenantHasChildren, t.ID, children))
+	}
+
+	actor := r.actor(c)
 
 	msg, err := pubsub.DeleteTenantMessage(
 		gidx.PrefixedID(actor),
@@ -263,57 +832,110 @@ func (r *Router) tenantDelete(c echo.Context) error {
 	)
 	if err != nil {
 		// TODO: add status to reconcile and requeue this
-		r.logger.Error("failed to create, delete tenant message", zap.Error(err))
+		r.requestLogger(c).Error("failed to create, delete tenant message", zap.Error(err))
 	}
 
 	if err := r.pubsub.PublishDelete(ctx, "tenants", "global", msg); err != nil {
 		// TODO: add status to reconcile and requeue this
-		r.logger.Error("failed to publish, delete tenant message", zap.Error(err))
+		r.requestLogger(c).Error("failed to publish, delete tenant message", zap.Error(err))
 	}
 
 	return nil
 }
 
+// parseIfChildless parses the optional if_childless query parameter.
+func parseIfChildless(c echo.Context) (bool, error) {
+	value := c.QueryParam("if_childless")
+	if value == "" {
+		return false, nil
+	}
+
+	ifChildless, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%w: if_childless must be true or false", ErrInvalidQueryParam)
+	}
+
+	return ifChildless, nil
+}
+
+// deleteTenant soft deletes the tenant. When ifChildless is set, the tenant
+// is only deleted when it has no children, and the number of children
+// preventing the delete is returned. The children are counted in the
+// delete's transaction, so a child created concurrently can't be orphaned.
+func (r *Router) deleteTenant(ctx context.Context, t *models.Tenant, ifChildless bool) (int64, error) {
+	if !ifChildless {
+		_, err := t.Delete(ctx, r.db, false)
+
+		return 0, err
+	}
+
+	var children int64
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+
+		children, err = models.Tenants(models.TenantWhere.ParentTenantID.EQ(nullx.PrefixedIDFrom(t.ID))).Count(ctx, tx)
+		if err != nil || children > 0 {
+			return err
+		}
+
+		_, err = t.Delete(ctx, tx, false)
+
+		return err
+	})
+
+	return children, err
+}
+
 const (
+	// parentsQuery walks the parent pointers up from the tenant ($1), walking
+	// at most $2 levels.
 	parentsQuery = `
 		WITH RECURSIVE get_parents AS (
-			SELECT id, name, parent_tenant_id, created_at, updated_at, deleted_at
+			SELECT id, name, parent_tenant_id, created_at, updated_at, deleted_at, locked, created_by, updated_by, quota, description, kind, 0 AS depth
 			FROM tenants
 			WHERE
 				id = $1
 				AND deleted_at IS NULL
 
 			UNION (
-				SELECT t.id, t.name, t.parent_tenant_id, t.created_at, t.updated_at, t.deleted_at
+				SELECT t.id, t.name, t.parent_tenant_id, t.created_at, t.updated_at, t.deleted_at, t.locked, t.created_by, t.updated_by, t.quota, t.description, t.kind, gp.depth + 1
 				FROM tenants t
 				INNER JOIN get_parents gp ON t.id = gp.parent_tenant_id
-				WHERE t.deleted_at IS NULL
+				WHERE
+					gp.depth < $2
+					AND t.deleted_at IS NULL
 				ORDER BY created_at
 			)
 		)
-		SELECT id, name, parent_tenant_id, created_at, updated_at, deleted_at
+		SELECT id, name, parent_tenant_id, created_at, updated_at, deleted_at, locked, created_by, updated_by, quota, description, kind, depth
 		FROM get_parents
+		ORDER BY depth
 	`
+	// parentsUntilQuery walks the parent pointers up from the tenant ($1)
+	// until the parent ($3) is reached, walking at most $2 levels.
 	parentsUntilQuery = `
 		WITH RECURSIVE get_parents AS (
-			SELECT id, name, parent_tenant_id, created_at, updated_at, deleted_at
+			SELECT id, name, parent_tenant_id, created_at, updated_at, deleted_at, locked, created_by, updated_by, quota, description, kind, 0 AS depth
 			FROM tenants
 			WHERE
 				id = $1
 				AND deleted_at IS NULL
 
 			UNION (
-				SELECT t.id, t.name, t.parent_tenant_id, t.created_at, t.updated_at, t.deleted_at
+				SELECT t.id, t.name, t.parent_tenant_id, t.created_at, t.updated_at, t.deleted_at, t.locked, t.created_by, t.updated_by, t.quota, t.description, t.kind, gp.depth + 1
 				FROM tenants t
 				INNER JOIN get_parents gp ON t.id = gp.parent_tenant_id
 				WHERE
-					gp.id != $2
+					gp.id != $3
+					AND gp.depth < $2
 					AND t.deleted_at IS NULL
 				ORDER BY created_at
 			)
 		)
-		SELECT id, name, parent_tenant_id, created_at, updated_at, deleted_at
+		SELECT id, name, parent_tenant_id, created_at, updated_at, deleted_at, locked, created_by, updated_by, quota, description, kind, depth
 		FROM get_parents
+		ORDER BY depth
 	`
 )
 
@@ -323,31 +945,41 @@ func (r *Router) tenantParentsList(c echo.Context) error {
 
 	pagination := parsePagination(c)
 
-	tenantID, err := parseID(c, "id")
+	tenantID, err := r.parseID(c, "id")
 	if err != nil {
 		return v1BadRequestResponse(c, err)
 	}
 
-	parentID, err := parseID(c, "parent_id")
+	parentID, err := r.parseID(c, "parent_id")
 	if err != nil && !errors.Is(err, ErrIDNotFound) {
 		return v1BadRequestResponse(c, err)
 	}
 
+	includeTotal, err := parseIncludeTotal(c)
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
 	var rows *sql.Rows
 
 	if parentID == "" {
-		rows, err = r.db.QueryContext(ctx, parentsQuery, tenantID)
+		rows, err = r.db.QueryContext(ctx, parentsQuery, tenantID, r.maxDepth)
 	} else {
-		rows, err = r.db.QueryContext(ctx, parentsUntilQuery, tenantID, parentID)
+		rows, err = r.db.QueryContext(ctx, parentsUntilQuery, tenantID, r.maxDepth, parentID)
 	}
 
 	if err != nil {
-		r.logger.Error("failed to query tenant parents", zap.Error(err))
+		r.requestLogger(c).Error("failed to query tenant parents", zap.Error(err))
 
 		return v1InternalServerErrorResponse(c, err)
 	}
 
-	var tenants []*models.Tenant
+	defer rows.Close()
+
+	var (
+		tenants []*models.Tenant
+		depth   int
+	)
 
 	for rows.Next() {
 		tenant := new(models.Tenant)
@@ -359,6 +991,13 @@ func (r *Router) tenantParentsList(c echo.Context) error {
 			&tenant.CreatedAt,
 			&tenant.UpdatedAt,
 			&tenant.DeletedAt,
+			&tenant.Locked,
+			&tenant.CreatedBy,
+			&tenant.UpdatedBy,
+			&tenant.Quota,
+			&tenant.Description,
+			&tenant.Kind,
+			&depth,
 		)
 
 		if err != nil {
@@ -368,12 +1007,35 @@ func (r *Router) tenantParentsList(c echo.Context) error {
 		tenants = append(tenants, tenant)
 	}
 
+	if err := rows.Err(); err != nil {
+		r.requestLogger(c).Error("failed to read tenant parents", zap.Error(err))
+
+		return v1InternalServerErrorResponse(c, err)
+	}
+
 	if len(tenants) == 0 {
-		return v1TenantNotFoundResponse(c, nil)
+		return v1TenantNotFoundResponse(c, sql.ErrNoRows)
+	}
+
+	// The walk only stops at the maximum depth before reaching a root or the
+	// requested parent when the ancestry is deeper than the limit.
+	if last := tenants[len(tenants)-1]; depth == r.maxDepth && last.ParentTenantID.Valid && last.ID != parentID {
+		return r.maxDepthExceededResponse(c, tenantID)
+	}
+
+	var total *int64
+
+	if includeTotal {
+		// The parents are all loaded to be paginated, so counting them is free.
+		// The first row is the tenant itself.
+		count := int64(len(tenants) - 1)
+		total = &count
 	}
 
 	if pagination.getPageOffset()+1 >= len(tenants) {
-		return v1TenantsResponse(c, nil, pagination)
+		r.setPaginationLinks(c, pagination, 0, total)
+
+		return v1TenantsResponse(c, nil, total, pagination)
 	}
 
 	limit := pagination.getPageOffset() + 1 + pagination.limitUsed()
@@ -381,7 +1043,79 @@ func (r *Router) tenantParentsList(c echo.Context) error {
 		limit = len(tenants)
 	}
 
-	return v1TenantsResponse(c, tenants[pagination.getPageOffset()+1:limit], pagination)
+	page := tenants[pagination.getPageOffset()+1 : limit]
+
+	r.setPaginationLinks(c, pagination, len(page), total)
+
+	return v1TenantsResponse(c, page, total, pagination)
+}
+
+// tenantUpdateErrorResponse responds to a rejected or failed tenant update.
+func (r *Router) tenantUpdateErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, ErrMissingScope):
+		return v1ForbiddenResponse(c, err)
+	case errors.Is(err, ErrNamePatternMismatch):
+		return v1NamePatternMismatchResponse(c, err)
+	case errors.Is(err, ErrTenantNameReserved):
+		return v1NameReservedResponse(c, err)
+	case errors.Is(err, ErrValidationFailed):
+		r.requestLogger(c).Error("tenant update rejected by validator", zap.Error(err))
+
+		return v1BadRequestResponse(c, err)
+	case errors.Is(err, sql.ErrNoRows),
+		errors.Is(err, ErrTenantCycle),
+		errors.Is(err, ErrNameConflict),
+		errors.Is(err, ErrChildLimitExceeded),
+		errors.Is(err, ErrTenantLocked):
+		return r.tenantMoveErrorResponse(c, err)
+	}
+
+	r.requestLogger(c).Error("failed to update tenant", zap.Error(err))
+
+	return v1InternalServerErrorResponse(c, err)
+}
+
+// tenantMoveErrorResponse responds to a rejected or failed tenant move.
+func (r *Router) tenantMoveErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return v1TenantNotFoundResponse(c, err)
+	case errors.Is(err, ErrTenantCycle):
+		return v1BadRequestResponse(c, err)
+	case errors.Is(err, ErrNameConflict):
+		return v1NameConflictResponse(c, err)
+	case errors.Is(err, ErrChildLimitExceeded):
+		return v1ChildLimitExceededResponse(c, err)
+	case errors.Is(err, ErrTenantLocked):
+		return v1TenantLockedResponse(c, err)
+	}
+
+	r.requestLogger(c).Error("failed to move tenant", zap.Error(err))
+
+	return v1InternalServerErrorResponse(c, err)
+}
+
+// maxDepthExceededResponse logs and responds to a request which reached a
+// tenant whose ancestry is deeper than the maximum depth.
+func (r *Router) maxDepthExceededResponse(c echo.Context, tenantID gidx.PrefixedID) error {
+	err := fmt.Errorf("%w: %s is more than %d levels deep", ErrMaxDepthExceeded, tenantID, r.maxDepth)
+
+	r.requestLogger(c).Error("tenant ancestry exceeds maximum depth", zap.Error(err))
+
+	return v1InternalServerErrorResponse(c, err)
+}
+
+// tenantQueryErrorResponse responds to a failed tenant query, returning not
+// found when the tenant does not exist.
+func (r *Router) tenantQueryErrorResponse(c echo.Context, err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return v1TenantNotFoundResponse(c, err)
+	}
+
+	r.requestLogger(c).Error("failed to query tenants", zap.Error(err))
+
+	return v1InternalServerErrorResponse(c, err)
 }
 
 func v1Tenant(t *models.Tenant) *tenant {
@@ -392,9 +1126,17 @@ func v1Tenant(t *models.Tenant) *tenant {
 		CreatedAt:      t.CreatedAt,
 		UpdatedAt:      t.UpdatedAt,
 		DeletedAt:      t.DeletedAt.Ptr(),
+		Locked:         t.Locked,
+		CreatedBy:      t.CreatedBy.Ptr(),
+		UpdatedBy:      t.UpdatedBy.Ptr(),
+		Quota:          t.Quota.Ptr(),
+		Description:    t.Description.Ptr(),
+		Kind:           t.Kind.Ptr(),
 	}
 }
 
+// v1TenantSlice converts the tenants for a response. The result is never nil,
+// so that responses without tenants include an empty list rather than null.
 func v1TenantSlice(ts []*models.Tenant) tenantSlice {
 	tenants := make(tenantSlice, len(ts))
 
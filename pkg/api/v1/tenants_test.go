@@ -6,14 +6,23 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/labstack/echo/v4"
 	nats "github.com/nats-io/nats.go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"go.infratographer.com/tenant-api/internal/models"
 	"go.infratographer.com/tenant-api/internal/pubsub"
+	"go.infratographer.com/tenant-api/internal/x/nullx"
 	"go.infratographer.com/x/echojwtx"
 	"go.infratographer.com/x/gidx"
 	"go.infratographer.com/x/pubsubx"
@@ -526,3 +535,2973 @@ func buildTree(t *testing.T, srv *testServer) *hierarchy {
 
 	return tree
 }
+
+func TestTenantListFilters(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	root1 := srv.createTenant(t, "", "root1")
+	root2 := srv.createTenant(t, "", "root2")
+	child1 := srv.createTenant(t, root1.ID, "child1")
+	child2 := srv.createTenant(t, root1.ID, "child2")
+	grandchild := srv.createTenant(t, child1.ID, "grandchild")
+
+	t.Run("has children", func(t *testing.T) {
+		roots := srv.listTenants(t, "/v1/tenants?has_children=true")
+		assert.ElementsMatch(t, []gidx.PrefixedID{root1.ID}, tenantIDs(roots), "expected only roots with children")
+
+		children := srv.listTenants(t, "/v1/tenants/"+string(root1.ID)+"/tenants?has_children=true")
+		assert.ElementsMatch(t, []gidx.PrefixedID{child1.ID}, tenantIDs(children), "expected only subtenants with children")
+	})
+
+	t.Run("without children", func(t *testing.T) {
+		roots := srv.listTenants(t, "/v1/tenants?has_children=false")
+		assert.ElementsMatch(t, []gidx.PrefixedID{root2.ID}, tenantIDs(roots), "expected only roots without children")
+
+		children := srv.listTenants(t, "/v1/tenants/"+string(root1.ID)+"/tenants?has_children=false")
+		assert.ElementsMatch(t, []gidx.PrefixedID{child2.ID}, tenantIDs(children), "expected only subtenants without children")
+
+		leaves := srv.listTenants(t, "/v1/tenants/"+string(child1.ID)+"/tenants?has_children=false")
+		assert.ElementsMatch(t, []gidx.PrefixedID{grandchild.ID}, tenantIDs(leaves), "expected grandchild to be a leaf")
+	})
+
+	t.Run("total count header", func(t *testing.T) {
+		path := "/v1/tenants/" + string(root1.ID) + "/tenants?has_children=false"
+
+		resp, err := srv.Request(http.MethodGet, path+"&limit=1", nil, nil, nil)
+		require.NoError(t, err, "no error expected for tenant list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		assert.Equal(t, strconv.Itoa(len(srv.listTenants(t, path))), resp.Header.Get(headerTotalCount), "expected the count of matching tenants")
+
+		resp, err = srv.Request(http.MethodGet, path+"&include_total=false", nil, nil, nil)
+		require.NoError(t, err, "no error expected for tenant list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Empty(t, resp.Header.Get(headerTotalCount), "expected no header without a total")
+	})
+
+	t.Run("with pagination", func(t *testing.T) {
+		page1 := srv.listTenants(t, "/v1/tenants?has_children=false&limit=1&page=1")
+		assert.Len(t, page1, 1, "expected one tenant on the first page")
+
+		page2 := srv.listTenants(t, "/v1/tenants?has_children=false&limit=1&page=2")
+		assert.Empty(t, page2, "expected no tenants on the second page")
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants?has_children=maybe", nil, nil, nil)
+		require.NoError(t, err, "no error expected for tenant list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+	})
+
+	t.Run("multiple parents", func(t *testing.T) {
+		children := srv.listTenants(t, "/v1/tenants?parent_id="+string(root1.ID)+"&parent_id="+string(child1.ID))
+		assert.ElementsMatch(t, []gidx.PrefixedID{child1.ID, child2.ID, grandchild.ID}, tenantIDs(children), "expected children of both parents")
+
+		children = srv.listTenants(t, "/v1/tenants?parent_id="+string(root2.ID))
+		assert.Empty(t, children, "expected no children")
+	})
+
+	t.Run("roots and parents", func(t *testing.T) {
+		tenants := srv.listTenants(t, "/v1/tenants?parent_id=null&parent_id="+string(child1.ID))
+		assert.ElementsMatch(t, []gidx.PrefixedID{root1.ID, root2.ID, grandchild.ID}, tenantIDs(tenants), "expected roots and children of the parent")
+
+		roots := srv.listTenants(t, "/v1/tenants?parent_id=null")
+		assert.ElementsMatch(t, []gidx.PrefixedID{root1.ID, root2.ID}, tenantIDs(roots), "expected only roots")
+	})
+
+	t.Run("parents with filters", func(t *testing.T) {
+		tenants := srv.listTenants(t, "/v1/tenants?parent_id=null&parent_id="+string(root1.ID)+"&has_children=true")
+		assert.ElementsMatch(t, []gidx.PrefixedID{root1.ID, child1.ID}, tenantIDs(tenants), "expected only tenants with children")
+	})
+
+	t.Run("invalid parents", func(t *testing.T) {
+		tooMany := strings.Repeat("parent_id=null&", maxParentIDs+1)
+
+		for _, query := range []string{
+			"parent_id=not-valid",
+			"parent_id=" + string(gidx.MustNewID("testing")),
+			tooMany,
+		} {
+			resp, err := srv.Request(http.MethodGet, "/v1/tenants?"+query, nil, nil, nil)
+			require.NoError(t, err, "no error expected for tenant list")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned for %s", query)
+		}
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(root1.ID)+"/tenants?parent_id=null", nil, nil, nil)
+		require.NoError(t, err, "no error expected for tenant list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "expected parent_id to be rejected for subtenants")
+	})
+
+	t.Run("updated by", func(t *testing.T) {
+		actor := gidx.MustNewID("idntusr")
+
+		for _, id := range []gidx.PrefixedID{root2.ID, child2.ID} {
+			_, err := srv.db.ExecContext(context.Background(), "UPDATE tenants SET updated_by = $1 WHERE id = $2", actor, id)
+			require.NoError(t, err, "no error expected setting updating actor")
+		}
+
+		roots := srv.listTenants(t, "/v1/tenants?updated_by="+string(actor))
+		assert.ElementsMatch(t, []gidx.PrefixedID{root2.ID}, tenantIDs(roots), "expected only roots updated by the actor")
+
+		children := srv.listTenants(t, "/v1/tenants/"+string(root1.ID)+"/tenants?updated_by="+string(actor)+"&has_children=false")
+		assert.ElementsMatch(t, []gidx.PrefixedID{child2.ID}, tenantIDs(children), "expected only subtenants updated by the actor")
+
+		others := srv.listTenants(t, "/v1/tenants?updated_by="+string(gidx.MustNewID("idntusr")))
+		assert.Empty(t, others, "expected no tenants updated by another actor")
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants?updated_by=not-valid", nil, nil, nil)
+		require.NoError(t, err, "no error expected for tenant list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "expected invalid actor id to be rejected")
+	})
+
+	t.Run("is root", func(t *testing.T) {
+		sharedRoot := srv.createTenant(t, "", "shared")
+		sharedChild := srv.createTenant(t, root2.ID, "shared")
+
+		everyone := "/v1/tenants?parent_id=null&parent_id=" + string(root1.ID) + "&parent_id=" + string(root2.ID)
+
+		roots := srv.listTenants(t, everyone+"&is_root=true")
+		assert.ElementsMatch(t, []gidx.PrefixedID{root1.ID, root2.ID, sharedRoot.ID}, tenantIDs(roots), "expected only roots")
+
+		nested := srv.listTenants(t, everyone+"&is_root=false")
+		assert.ElementsMatch(t, []gidx.PrefixedID{child1.ID, child2.ID, sharedChild.ID}, tenantIDs(nested), "expected only nested tenants")
+
+		named := srv.listTenants(t, everyone+"&is_root=true&name=shared")
+		assert.ElementsMatch(t, []gidx.PrefixedID{sharedRoot.ID}, tenantIDs(named), "expected only the root named shared")
+
+		named = srv.listTenants(t, everyone+"&is_root=false&name=shared")
+		assert.ElementsMatch(t, []gidx.PrefixedID{sharedChild.ID}, tenantIDs(named), "expected only the nested tenant named shared")
+
+		named = srv.listTenants(t, everyone+"&is_root=0&name=root1")
+		assert.Empty(t, named, "expected no nested tenant named root1")
+
+		// Without any other filter, is_root=false lists nested tenants at every level.
+		nested = srv.listTenants(t, "/v1/tenants?is_root=false")
+		assert.Subset(t, tenantIDs(nested), []gidx.PrefixedID{child1.ID, child2.ID, sharedChild.ID}, "expected nested tenants without a parent filter")
+
+		for _, tenant := range nested {
+			assert.NotNil(t, tenant.ParentTenantID, "expected only nested tenants, got %s", tenant.ID)
+		}
+
+		roots = srv.listTenants(t, "/v1/tenants?is_root=true")
+		assert.ElementsMatch(t, []gidx.PrefixedID{root1.ID, root2.ID, sharedRoot.ID}, tenantIDs(roots), "expected only roots without a parent filter")
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants?is_root=maybe", nil, nil, nil)
+		require.NoError(t, err, "no error expected for tenant list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "expected invalid is_root to be rejected")
+	})
+
+	t.Run("has description", func(t *testing.T) {
+		documented := srv.createTenant(t, root2.ID, "documented")
+		blank := srv.createTenant(t, root2.ID, "blank")
+		undocumented := srv.createTenant(t, root2.ID, "undocumented")
+
+		setDescription := func(t *testing.T, id gidx.PrefixedID, description string) {
+			t.Helper()
+
+			resp, err := srv.Request(http.MethodPatch, "/v1/tenants/"+string(id), nil, strings.NewReader(fmt.Sprintf(`{"description": %q}`, description)), nil)
+			require.NoError(t, err, "no error expected for tenant update")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		}
+
+		setDescription(t, documented.ID, "documented tenant")
+		setDescription(t, blank.ID, "")
+
+		described := srv.listTenants(t, "/v1/tenants/"+string(root2.ID)+"/tenants?has_description=true")
+		assert.ElementsMatch(t, []gidx.PrefixedID{documented.ID}, tenantIDs(described), "expected only tenants with a description")
+
+		missing := srv.listTenants(t, "/v1/tenants/"+string(root2.ID)+"/tenants?has_description=false")
+		assert.Contains(t, tenantIDs(missing), undocumented.ID, "expected tenants without a description")
+		assert.Contains(t, tenantIDs(missing), blank.ID, "expected tenants with an empty description to be missing a description")
+		assert.NotContains(t, tenantIDs(missing), documented.ID, "expected no tenants with a description")
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants?has_description=maybe", nil, nil, nil)
+		require.NoError(t, err, "no error expected for tenant list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "expected invalid has_description to be rejected")
+	})
+
+	t.Run("name glob", func(t *testing.T) {
+		children := srv.listTenants(t, "/v1/tenants/"+string(root1.ID)+"/tenants?name_glob=child*")
+		assert.ElementsMatch(t, []gidx.PrefixedID{child1.ID, child2.ID}, tenantIDs(children), "expected tenants matching the glob")
+
+		single := srv.listTenants(t, "/v1/tenants/"+string(root1.ID)+"/tenants?name_glob=child?")
+		assert.ElementsMatch(t, []gidx.PrefixedID{child1.ID, child2.ID}, tenantIDs(single), "expected ? to match a single character")
+
+		none := srv.listTenants(t, "/v1/tenants/"+string(root1.ID)+"/tenants?name_glob=child")
+		assert.Empty(t, none, "expected the glob to match the whole name")
+
+		percent := srv.createTenant(t, root2.ID, "100%")
+		srv.createTenant(t, root2.ID, "1000")
+
+		literal := srv.listTenants(t, "/v1/tenants/"+string(root2.ID)+"/tenants?name_glob=100%25")
+		assert.Equal(t, []gidx.PrefixedID{percent.ID}, tenantIDs(literal), "expected % to match only itself")
+
+		underscore := srv.createTenant(t, root2.ID, "a_b")
+		srv.createTenant(t, root2.ID, "axb")
+
+		literal = srv.listTenants(t, "/v1/tenants/"+string(root2.ID)+"/tenants?name_glob=a_*")
+		assert.Equal(t, []gidx.PrefixedID{underscore.ID}, tenantIDs(literal), "expected _ to match only itself")
+	})
+}
+
+func TestTenantListCursor(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	var created []gidx.PrefixedID
+
+	for i := 0; i < 6; i++ {
+		created = append(created, srv.createTenant(t, "", fmt.Sprintf("tenant%d", i)).ID)
+	}
+
+	listPage := func(t *testing.T, path string) *v1TenantSliceResponse {
+		t.Helper()
+
+		var result *v1TenantSliceResponse
+
+		resp, err := srv.Request(http.MethodGet, path, nil, nil, &result)
+		require.NoError(t, err, "no error expected listing tenants")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code listing tenants")
+
+		return result
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, query := range []string{"cursor=invalid", "cursor=e30", "cursor=e30&page=2"} {
+			resp, err := srv.Request(http.MethodGet, "/v1/tenants?"+query, nil, nil, nil)
+			require.NoError(t, err, "no error expected listing tenants")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "expected %s to be rejected", query)
+		}
+
+		first := listPage(t, "/v1/tenants?limit=2")
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants?limit=2&sort=name&cursor="+first.Cursor, nil, nil, nil)
+		require.NoError(t, err, "no error expected listing tenants")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "expected a cursor of another sort to be rejected")
+	})
+
+	t.Run("deleted mid pagination", func(t *testing.T) {
+		first := listPage(t, "/v1/tenants?limit=2")
+		require.Equal(t, created[:2], tenantIDs(first.Tenants), "unexpected first page")
+		require.NotEmpty(t, first.Cursor, "expected a cursor for the next page")
+
+		// Deleting a tenant of the first page shifts the offsets of the following pages.
+		resp, err := srv.Request(http.MethodDelete, "/v1/tenants/"+string(created[0]), nil, nil, nil)
+		require.NoError(t, err, "no error expected deleting tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code deleting tenant")
+
+		offset := listPage(t, "/v1/tenants?limit=2&page=2")
+		assert.NotContains(t, tenantIDs(offset.Tenants), created[2], "expected the offset page to skip a tenant")
+
+		seen := tenantIDs(first.Tenants)
+
+		for cursor := first.Cursor; cursor != ""; {
+			page := listPage(t, "/v1/tenants?limit=2&cursor="+cursor)
+			seen = append(seen, tenantIDs(page.Tenants)...)
+			cursor = page.Cursor
+		}
+
+		assert.Equal(t, created, seen, "expected no tenant to be skipped by the cursor pages")
+	})
+}
+
+func TestTenantListDepth(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	tree := buildTree(t, srv)
+
+	maxDepth := 0
+
+	for path := range tree.tenantsByPath {
+		if depth := strings.Count(path, namePathSeparator); depth > maxDepth {
+			maxDepth = depth
+		}
+	}
+
+	require.Greater(t, maxDepth, 1, "expected a fixture with several levels")
+
+	for depth := 0; depth <= maxDepth+1; depth++ {
+		t.Run("depth "+strconv.Itoa(depth), func(t *testing.T) {
+			var expected []gidx.PrefixedID
+
+			for path, tenant := range tree.tenantsByPath {
+				if strings.Count(path, namePathSeparator) == depth {
+					expected = append(expected, tenant.ID)
+				}
+			}
+
+			tenants := srv.listTenants(t, "/v1/tenants?depth="+strconv.Itoa(depth))
+			assert.ElementsMatch(t, expected, tenantIDs(tenants), "unexpected tenants at depth %d", depth)
+		})
+	}
+
+	t.Run("depth 0 are roots", func(t *testing.T) {
+		assert.ElementsMatch(t, tenantIDs(srv.listTenants(t, "/v1/tenants")), tenantIDs(srv.listTenants(t, "/v1/tenants?depth=0")))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, path := range []string{
+			"/v1/tenants?depth=-1",
+			"/v1/tenants?depth=two",
+			"/v1/tenants?depth=1&parent_id=null",
+			"/v1/tenants/" + string(tree.tenantsByName["t1"].ID) + "/tenants?depth=1",
+		} {
+			resp, err := srv.Request(http.MethodGet, path, nil, nil, nil)
+			require.NoError(t, err, "no error expected for tenant list")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned for %s", path)
+		}
+	})
+}
+
+func TestTenantListExcludeSubtree(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	tree := buildTree(t, srv)
+
+	ids := func(names ...string) []gidx.PrefixedID {
+		result := make([]gidx.PrefixedID, len(names))
+
+		for i, name := range names {
+			result[i] = tree.tenantsByName[name].ID
+		}
+
+		return result
+	}
+
+	query := func(param string, names ...string) string {
+		values := make([]string, len(names))
+
+		for i, name := range names {
+			values[i] = param + "=" + string(tree.tenantsByName[name].ID)
+		}
+
+		return strings.Join(values, "&")
+	}
+
+	parents := query("parent_id", "t1a", "t1a1", "t1b", "t1b1")
+
+	t.Run("mid-tree node", func(t *testing.T) {
+		tenants := srv.listTenants(t, "/v1/tenants?"+parents+"&"+query("exclude_subtree", "t1a1"))
+		assert.ElementsMatch(t, ids("t1b1", "t1b1a"), tenantIDs(tenants), "expected the tenant and its descendants to be excluded")
+	})
+
+	t.Run("multiple subtrees", func(t *testing.T) {
+		tenants := srv.listTenants(t, "/v1/tenants?"+parents+"&"+query("exclude_subtree", "t1a1a", "t1b"))
+		assert.ElementsMatch(t, ids("t1a1", "t1a1b"), tenantIDs(tenants), "expected both subtrees to be excluded")
+	})
+
+	t.Run("roots", func(t *testing.T) {
+		roots := srv.listTenants(t, "/v1/tenants?"+query("exclude_subtree", "t2"))
+		assert.ElementsMatch(t, ids("t1"), tenantIDs(roots), "expected the excluded root to be removed")
+
+		children := srv.listTenants(t, "/v1/tenants/"+string(tree.tenantsByName["t1"].ID)+"/tenants?"+query("exclude_subtree", "t1"))
+		assert.Empty(t, children, "expected children of an excluded tenant to be removed")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		tooMany := strings.Repeat(query("exclude_subtree", "t1")+"&", maxExcludedSubtrees+1)
+
+		for _, q := range []string{
+			"exclude_subtree=not-valid",
+			"exclude_subtree=" + string(gidx.MustNewID("testing")),
+			tooMany,
+		} {
+			resp, err := srv.Request(http.MethodGet, "/v1/tenants?"+q, nil, nil, nil)
+			require.NoError(t, err, "no error expected for tenant list")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned for %s", q)
+		}
+	})
+}
+
+func TestTenantListIDRange(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	var ids []gidx.PrefixedID
+
+	for i := 0; i < 6; i++ {
+		ids = append(ids, srv.createTenant(t, "", fmt.Sprintf("tenant%d", i)).ID)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	t.Run("range", func(t *testing.T) {
+		tenants := srv.listTenants(t, "/v1/tenants?id_gte="+string(ids[1])+"&id_lt="+string(ids[4]))
+		assert.ElementsMatch(t, ids[1:4], tenantIDs(tenants), "expected the tenants in the range")
+	})
+
+	t.Run("shards", func(t *testing.T) {
+		var scanned []gidx.PrefixedID
+
+		for _, query := range []string{
+			"id_lt=" + string(ids[2]),
+			"id_gte=" + string(ids[2]) + "&id_lt=" + string(ids[5]),
+			"id_gte=" + string(ids[5]),
+		} {
+			scanned = append(scanned, tenantIDs(srv.listTenants(t, "/v1/tenants?"+query))...)
+		}
+
+		assert.ElementsMatch(t, ids, scanned, "expected the shards to cover every tenant once")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, q := range []string{
+			"id_gte=not-valid",
+			"id_lt=" + string(gidx.MustNewID("testing")),
+			"id_gte=" + string(ids[4]) + "&id_lt=" + string(ids[1]),
+			"id_gte=" + string(ids[1]) + "&id_lt=" + string(ids[1]),
+		} {
+			resp, err := srv.Request(http.MethodGet, "/v1/tenants?"+q, nil, nil, nil)
+			require.NoError(t, err, "no error expected for tenant list")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned for %s", q)
+		}
+	})
+}
+
+func TestTenantListSinceSeq(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	// listChanges lists the tenants changed since the sequence number, following
+	// the returned sequence numbers until no more changes are listed.
+	listChanges := func(t *testing.T, since int64, limit int) ([]gidx.PrefixedID, int64) {
+		t.Helper()
+
+		var ids []gidx.PrefixedID
+
+		for {
+			var result *v1TenantSliceResponse
+
+			resp, err := srv.Request(http.MethodGet, fmt.Sprintf("/v1/tenants?since_seq=%d&limit=%d", since, limit), nil, nil, &result)
+			require.NoError(t, err, "no error expected for tenant list")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+			require.NotNil(t, result.SinceSeq, "expected the sequence number to list since")
+
+			if len(result.Tenants) == 0 {
+				assert.Equal(t, since, *result.SinceSeq, "expected the sequence number to be kept without changes")
+
+				return ids, since
+			}
+
+			assert.Greater(t, *result.SinceSeq, since, "expected the sequence number to advance")
+
+			ids = append(ids, tenantIDs(result.Tenants)...)
+			since = *result.SinceSeq
+		}
+	}
+
+	t1 := srv.createTenant(t, "", "t1")
+	t2 := srv.createTenant(t, "", "t2")
+	t1a := srv.createTenant(t, t1.ID, "t1a")
+
+	ids, seq := listChanges(t, 0, 2)
+	assert.Equal(t, []gidx.PrefixedID{t1.ID, t2.ID, t1a.ID}, ids, "expected every tenant in the order created")
+
+	t.Run("no changes", func(t *testing.T) {
+		ids, next := listChanges(t, seq, 2)
+		assert.Empty(t, ids, "expected no changes")
+		assert.Equal(t, seq, next)
+	})
+
+	t.Run("incremental", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodPatch, "/v1/tenants/"+string(t1.ID), nil, strings.NewReader(`{"description": "updated"}`), nil)
+		require.NoError(t, err, "no error expected for tenant update")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		t3 := srv.createTenant(t, "", "t3")
+
+		ids, next := listChanges(t, seq, 100)
+		assert.Equal(t, []gidx.PrefixedID{t1.ID, t3.ID}, ids, "expected only the changed tenants in the order changed")
+
+		seq = next
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodDelete, "/v1/tenants/"+string(t1a.ID), nil, nil, nil)
+		require.NoError(t, err, "no error expected for tenant delete")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		var result *v1TenantSliceResponse
+
+		resp, err = srv.Request(http.MethodGet, fmt.Sprintf("/v1/tenants?since_seq=%d", seq), nil, nil, &result)
+		require.NoError(t, err, "no error expected for tenant list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		require.Len(t, result.Tenants, 1, "expected the deleted tenant to be listed")
+		assert.Equal(t, t1a.ID, result.Tenants[0].ID, "expected the deleted tenant to be listed")
+		assert.NotNil(t, result.Tenants[0].DeletedAt, "expected the deleted tenant to be listed as a tombstone")
+		assert.Greater(t, *result.SinceSeq, seq, "expected the sequence number to advance")
+
+		seq = *result.SinceSeq
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, q := range []string{
+			"since_seq=-1",
+			"since_seq=latest",
+			"since_seq=0&sort=name",
+			"since_seq=0&page=2",
+			"since_seq=0&stream=true",
+		} {
+			resp, err := srv.Request(http.MethodGet, "/v1/tenants?"+q, nil, nil, nil)
+			require.NoError(t, err, "no error expected for tenant list")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned for %s", q)
+		}
+	})
+}
+
+func TestTenantPaths(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	ctx := context.Background()
+
+	tree := buildTree(t, srv)
+
+	t.Run("maintained on create", func(t *testing.T) {
+		for _, tenant := range tree.tenantsByID {
+			var expected string
+
+			for _, parent := range tree.parents[tenant.ID] {
+				expected = tenantPath(expected, parent.ID)
+			}
+
+			result, err := models.FindTenant(ctx, srv.db, tenant.ID)
+			require.NoError(t, err, "no error expected finding tenant")
+
+			assert.Equal(t, tenantPath(expected, tenant.ID), result.Path, "unexpected path for tenant %s", tenant.Name)
+		}
+	})
+
+	t.Run("subtree query", func(t *testing.T) {
+		for _, name := range []string{"t1", "t1a", "t1b1", "t2", "t1a1a"} {
+			target, err := models.FindTenant(ctx, srv.db, tree.tenantsByName[name].ID)
+			require.NoError(t, err, "no error expected finding tenant")
+
+			var expected []gidx.PrefixedID
+
+			for _, tenant := range tree.tenantsByID {
+				for _, parent := range tree.parents[tenant.ID] {
+					if parent.ID == target.ID {
+						expected = append(expected, tenant.ID)
+					}
+				}
+			}
+
+			subtree, err := models.Tenants(subtreeQueryMod(target.Path)).All(ctx, srv.db)
+			require.NoError(t, err, "no error expected querying subtree")
+
+			ids := make([]gidx.PrefixedID, len(subtree))
+
+			for i, tenant := range subtree {
+				ids[i] = tenant.ID
+			}
+
+			assert.ElementsMatch(t, expected, ids, "unexpected subtree for tenant %s", name)
+		}
+	})
+
+	t.Run("missing parent", func(t *testing.T) {
+		resp, err := srv.Request(
+			http.MethodPost,
+			"/v1/tenants/"+string(gidx.MustNewID(TenantIDPrefix))+"/tenants",
+			nil,
+			strings.NewReader(`{"name": "orphan"}`),
+			nil,
+		)
+		require.NoError(t, err, "no error expected for tenant create")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, "unexpected status code returned")
+	})
+}
+
+func TestTenantMaxDepth(t *testing.T) {
+	srv, err := newTestServer(t, &testServerConfig{
+		routerOpts: []RouterOption{
+			WithMaxDepth(3),
+		},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	// The chain is deeper than the maximum depth.
+	chain := []*tenant{srv.createTenant(t, "", "level0")}
+
+	for i := 1; i <= 4; i++ {
+		chain = append(chain, srv.createTenant(t, chain[i-1].ID, fmt.Sprintf("level%d", i)))
+	}
+
+	get := func(t *testing.T, path string, expectedStatus int) {
+		t.Helper()
+
+		resp, err := srv.Request(http.MethodGet, path, nil, nil, nil)
+		require.NoError(t, err, "no error expected for request")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, expectedStatus, resp.StatusCode, "unexpected status code returned for %s", path)
+	}
+
+	t.Run("within depth", func(t *testing.T) {
+		parents := srv.listTenants(t, "/v1/tenants/"+string(chain[3].ID)+"/parents")
+		assert.Equal(t, []gidx.PrefixedID{chain[2].ID, chain[1].ID, chain[0].ID}, tenantIDs(parents), "unexpected parents")
+
+		parents = srv.listTenants(t, "/v1/tenants/"+string(chain[4].ID)+"/parents/"+string(chain[1].ID))
+		assert.Equal(t, []gidx.PrefixedID{chain[3].ID, chain[2].ID, chain[1].ID}, tenantIDs(parents), "unexpected parents until parent")
+
+		get(t, "/v1/tenants/"+string(chain[1].ID)+"/is-ancestor-of/"+string(chain[4].ID), http.StatusOK)
+	})
+
+	t.Run("exceeds depth", func(t *testing.T) {
+		get(t, "/v1/tenants/"+string(chain[4].ID)+"/parents", http.StatusInternalServerError)
+		get(t, "/v1/tenants/"+string(chain[0].ID)+"/is-ancestor-of/"+string(chain[4].ID), http.StatusInternalServerError)
+	})
+
+	t.Run("subtree at depth boundary", func(t *testing.T) {
+		// The leaf is exactly the maximum depth below the tenant.
+		get(t, "/v1/tenants/"+string(chain[1].ID)+"/delete-preview", http.StatusOK)
+
+		// The tenant at the maximum depth below the root has a child.
+		get(t, "/v1/tenants/"+string(chain[0].ID)+"/delete-preview", http.StatusInternalServerError)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		_, err := srv.db.ExecContext(context.Background(), "UPDATE tenants SET parent_tenant_id = $1 WHERE id = $2", chain[1].ID, chain[0].ID)
+		require.NoError(t, err, "no error expected creating cycle")
+
+		get(t, "/v1/tenants/"+string(chain[1].ID)+"/parents", http.StatusInternalServerError)
+		get(t, "/v1/tenants/"+string(chain[2].ID)+"/is-ancestor-of/"+string(chain[1].ID), http.StatusInternalServerError)
+	})
+}
+
+func TestRequestID(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	t.Run("propagated to error body", func(t *testing.T) {
+		headers := http.Header{}
+		headers.Set(echo.HeaderXRequestID, "test-request-id")
+
+		var result *v1ErrorResponseBody
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/not-valid/tenants", headers, nil, &result)
+		require.NoError(t, err, "no error expected for tenant list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected error response")
+		assert.Equal(t, "test-request-id", result.RequestID, "expected request id in error body")
+		assert.Equal(t, "test-request-id", resp.Header.Get(echo.HeaderXRequestID), "expected request id in response header")
+	})
+
+	t.Run("generated when missing", func(t *testing.T) {
+		var result *v1ErrorResponseBody
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/not-valid/tenants", nil, nil, &result)
+		require.NoError(t, err, "no error expected for tenant list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected error response")
+		assert.NotEmpty(t, result.RequestID, "expected generated request id in error body")
+		assert.Equal(t, resp.Header.Get(echo.HeaderXRequestID), result.RequestID, "expected error body and header request ids to match")
+	})
+
+	t.Run("replaced when invalid", func(t *testing.T) {
+		for _, id := range []string{"has spaces", "quote\"d", strings.Repeat("a", 129)} {
+			headers := http.Header{}
+			headers.Set(echo.HeaderXRequestID, id)
+
+			var result *v1ErrorResponseBody
+
+			resp, err := srv.Request(http.MethodGet, "/v1/tenants/not-valid/tenants", headers, nil, &result)
+			require.NoError(t, err, "no error expected for tenant list")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+
+			require.NotNil(t, result, "expected error response")
+			assert.NotEmpty(t, result.RequestID, "expected generated request id in error body")
+			assert.NotEqual(t, id, result.RequestID, "expected invalid request id to be replaced")
+			assert.Equal(t, resp.Header.Get(echo.HeaderXRequestID), result.RequestID, "expected error body and header request ids to match")
+		}
+	})
+}
+
+func TestCorrelationID(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	ctx := context.Background()
+
+	subscriber := newPubSubClient(t, srv.logger, srv.nats.ClientURL())
+	msgChan := make(chan *nats.Msg, 10)
+
+	subscription, err := subscriber.ChanSubscribe(ctx, "com.infratographer.events.tenants.>", msgChan, "tenant-api-test")
+	require.NoError(t, err)
+
+	defer func() {
+		if err := subscription.Unsubscribe(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	receive := func(t *testing.T) *pubsubx.ChangeMessage {
+		t.Helper()
+
+		select {
+		case msg := <-msgChan:
+			pMsg := &pubsubx.ChangeMessage{}
+			require.NoError(t, json.Unmarshal(msg.Data, pMsg))
+
+			return pMsg
+		case <-time.After(natsMsgSubTimeout):
+			t.Fatal("failed to receive nats message")
+		}
+
+		return nil
+	}
+
+	t.Run("propagated to events", func(t *testing.T) {
+		headers := http.Header{}
+		headers.Set(HeaderXCorrelationID, "test-correlation-id")
+
+		resp, err := srv.Request(http.MethodPost, "/v1/tenants", headers, strings.NewReader(`{"name": "correlated"}`), nil)
+		require.NoError(t, err, "no error expected for tenant create")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusCreated, resp.StatusCode, "unexpected status code returned")
+
+		assert.Equal(t, "test-correlation-id", resp.Header.Get(HeaderXCorrelationID), "expected correlation id in response header")
+		assert.Equal(t, "test-correlation-id", receive(t).AdditionalData[pubsub.CorrelationIDKey], "expected correlation id in event")
+	})
+
+	t.Run("generated when missing", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodPost, "/v1/tenants", nil, strings.NewReader(`{"name": "uncorrelated"}`), nil)
+		require.NoError(t, err, "no error expected for tenant create")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusCreated, resp.StatusCode, "unexpected status code returned")
+
+		id := resp.Header.Get(HeaderXCorrelationID)
+		require.NotEmpty(t, id, "expected generated correlation id in response header")
+		assert.Equal(t, id, receive(t).AdditionalData[pubsub.CorrelationIDKey], "expected generated correlation id in event")
+	})
+
+	t.Run("replaced when invalid", func(t *testing.T) {
+		headers := http.Header{}
+		headers.Set(HeaderXCorrelationID, "not a valid id")
+
+		resp, err := srv.Request(http.MethodPost, "/v1/tenants", headers, strings.NewReader(`{"name": "miscorrelated"}`), nil)
+		require.NoError(t, err, "no error expected for tenant create")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusCreated, resp.StatusCode, "unexpected status code returned")
+
+		id := resp.Header.Get(HeaderXCorrelationID)
+		require.NotEmpty(t, id, "expected generated correlation id in response header")
+		assert.NotEqual(t, "not a valid id", id, "expected invalid correlation id to be replaced")
+		assert.Equal(t, id, receive(t).AdditionalData[pubsub.CorrelationIDKey], "expected generated correlation id in event")
+	})
+}
+
+func TestTenantNameHistory(t *testing.T) {
+	testActorID := gidx.MustNewID(TenantIDPrefix)
+
+	oauthClient, issuer, close := echojwtx.TestOAuthClient(string(testActorID), "")
+	defer close()
+
+	srv, err := newTestServer(t, &testServerConfig{
+		client: oauthClient,
+		auth: &echojwtx.AuthConfig{
+			Issuer: issuer,
+		},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	target := srv.createTenant(t, "", "original")
+
+	rename := func(t *testing.T, name string) {
+		t.Helper()
+
+		resp, err := srv.Request(http.MethodPatch, "/v1/tenants/"+string(target.ID), nil, strings.NewReader(fmt.Sprintf(`{"name": "%s"}`, name)), nil)
+		require.NoError(t, err, "no error expected for tenant update")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+	}
+
+	names := func(t *testing.T) []*tenantNameChange {
+		t.Helper()
+
+		var result *v1TenantNameSliceResponse
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(target.ID)+"/names", nil, nil, &result)
+		require.NoError(t, err, "no error expected for tenant name history")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		require.NotNil(t, result, "expected name history result")
+
+		return result.Names
+	}
+
+	t.Run("no-op rename records nothing", func(t *testing.T) {
+		rename(t, "original")
+
+		assert.Empty(t, names(t), "expected no name history")
+	})
+
+	t.Run("renames are recorded in order", func(t *testing.T) {
+		rename(t, "second")
+		rename(t, "third")
+
+		history := names(t)
+		require.Len(t, history, 2, "expected two renames")
+
+		assert.Equal(t, "original", history[0].OldName)
+		assert.Equal(t, "second", history[0].NewName)
+		assert.Equal(t, string(testActorID), history[0].Actor)
+		assert.False(t, history[0].ChangedAt.IsZero(), "expected changed at to be set")
+
+		assert.Equal(t, "second", history[1].OldName)
+		assert.Equal(t, "third", history[1].NewName)
+	})
+
+	t.Run("concurrent renames record the replaced names", func(t *testing.T) {
+		const concurrency = 5
+
+		var wg sync.WaitGroup
+
+		statuses := make([]int, concurrency)
+
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+
+			go func(i int) {
+				defer wg.Done()
+
+				body := strings.NewReader(fmt.Sprintf(`{"name": "concurrent-%d"}`, i))
+
+				resp, err := srv.Request(http.MethodPatch, "/v1/tenants/"+string(target.ID), nil, body, nil)
+				if !assert.NoError(t, err, "no error expected for tenant update") {
+					return
+				}
+
+				resp.Body.Close() //nolint:errcheck // Not needed
+
+				statuses[i] = resp.StatusCode
+			}(i)
+		}
+
+		wg.Wait()
+
+		for _, status := range statuses {
+			assert.Equal(t, http.StatusOK, status, "unexpected status code returned")
+		}
+
+		history := names(t)
+		require.Len(t, history, 2+concurrency, "expected every rename to be recorded")
+
+		// Each rename replaced the name the previous one set.
+		for i := 1; i < len(history); i++ {
+			assert.Equal(t, history[i-1].NewName, history[i].OldName, "unexpected old name of rename %d", i)
+		}
+	})
+
+	t.Run("unknown tenant", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(gidx.MustNewID(TenantIDPrefix))+"/names", nil, nil, nil)
+		require.NoError(t, err, "no error expected for tenant name history")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, "unexpected status code returned")
+	})
+}
+
+func TestTenantLookup(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	tree := buildTree(t, srv)
+
+	get := func(t *testing.T, ref string, expectedStatus int) *tenant {
+		t.Helper()
+
+		var result *v1TenantResponse
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+ref, nil, nil, &result)
+		require.NoError(t, err, "no error expected for tenant get")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, expectedStatus, resp.StatusCode, "unexpected status code returned")
+
+		if result == nil {
+			return nil
+		}
+
+		return result.Tenant
+	}
+
+	t.Run("by id", func(t *testing.T) {
+		target := tree.tenantsByName["t1a1"]
+
+		result := get(t, string(target.ID), http.StatusOK)
+		require.NotNil(t, result, "expected tenant")
+		assert.Equal(t, target.ID, result.ID)
+	})
+
+	t.Run("by path", func(t *testing.T) {
+		for _, path := range []string{"t1", "t1.t1a", "t1.t1a.t1a1.t1a1b", "t2.t2a"} {
+			result := get(t, path, http.StatusOK)
+			require.NotNil(t, result, "expected tenant for path %s", path)
+			assert.Equal(t, tree.tenantsByPath[path].ID, result.ID, "unexpected tenant for path %s", path)
+		}
+	})
+
+	t.Run("by path with dotted name", func(t *testing.T) {
+		dotted := srv.createTenant(t, tree.tenantsByName["t1"].ID, "t1a.t1a1")
+
+		// The escape is percent-encoded in the URL.
+		result := get(t, `t1.t1a%5C.t1a1`, http.StatusOK)
+		require.NotNil(t, result, "expected tenant")
+		assert.Equal(t, dotted.ID, result.ID, "expected the tenant with the dotted name")
+
+		result = get(t, "t1.t1a.t1a1", http.StatusOK)
+		require.NotNil(t, result, "expected tenant")
+		assert.Equal(t, tree.tenantsByPath["t1.t1a.t1a1"].ID, result.ID, "expected unescaped separators to separate names")
+	})
+
+	t.Run("path not found", func(t *testing.T) {
+		get(t, "t1.t1a.missing", http.StatusNotFound)
+		get(t, "t1a", http.StatusNotFound)
+	})
+
+	t.Run("invalid path", func(t *testing.T) {
+		get(t, "t1..t1a", http.StatusBadRequest)
+	})
+
+	t.Run("ambiguous path", func(t *testing.T) {
+		// Only root tenants may share a name.
+		srv.createTenant(t, "", "t2")
+
+		get(t, "t2", http.StatusConflict)
+	})
+}
+
+func TestTenantGetPathTooLong(t *testing.T) {
+	// The router has no database, so the path must be rejected before any query runs.
+	r := NewRouter(nil, nil, WithMaxPathSegments(3))
+
+	get := func(ref string) (*httptest.ResponseRecorder, error) {
+		rec := httptest.NewRecorder()
+
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+		c.SetParamNames("id")
+		c.SetParamValues(ref)
+
+		return rec, r.tenantGet(c)
+	}
+
+	rec, err := get("t1.t1a.t1a1.t1a1a")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "expected a path over the limit to be rejected")
+
+	var body *v1ErrorResponseBody
+
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Contains(t, body.Error, ErrPathTooLong.Error(), "expected path too long error")
+
+	rec, err = get(strings.Repeat("a.", 10000) + "a")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "expected a very long path to be rejected")
+
+	names, err := r.parseNamePath("t1.t1a.t1a1")
+	require.NoError(t, err, "expected a path at the limit to be accepted")
+	assert.Equal(t, []string{"t1", "t1a", "t1a1"}, names)
+}
+
+func TestParseNamePath(t *testing.T) {
+	r := NewRouter(nil, nil)
+
+	testCases := []struct {
+		name     string
+		ref      string
+		expected []string
+		err      error
+	}{
+		{"single name", "root", []string{"root"}, nil},
+		{"names", "root.child", []string{"root", "child"}, nil},
+		{"escaped separator", `root.tenant1\.a`, []string{"root", "tenant1.a"}, nil},
+		{"escaped escape", `root\\.child`, []string{`root\`, "child"}, nil},
+		{"empty name", "root..child", nil, ErrInvalidPath},
+		{"trailing separator", "root.", nil, ErrInvalidPath},
+		{"trailing escape", `root\`, nil, ErrInvalidPath},
+		{"other escape", `ro\ot`, nil, ErrInvalidPath},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			names, err := r.parseNamePath(tc.ref)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, names)
+		})
+	}
+}
+
+func TestIsTenantID(t *testing.T) {
+	r := NewRouter(nil, nil)
+
+	assert.True(t, r.isTenantID(string(gidx.MustNewID(TenantIDPrefix))))
+	assert.False(t, r.isTenantID("tenant1"), "expected name without separator to be a path")
+	assert.False(t, r.isTenantID("t1.t1a"), "expected dotted names to be a path")
+	assert.False(t, r.isTenantID(string(gidx.MustNewID("testing"))), "expected other resource ids to be a path")
+
+	custom := NewRouter(nil, nil, WithIDPrefix("testing"))
+
+	assert.True(t, custom.isTenantID(string(gidx.MustNewID("testing"))), "expected configured prefix to be an id")
+	assert.False(t, custom.isTenantID(string(gidx.MustNewID(TenantIDPrefix))), "expected default prefix to be a path")
+}
+
+func TestTenantIDPrefix(t *testing.T) {
+	const prefix = "testten"
+
+	srv, err := newTestServer(t, &testServerConfig{
+		routerOpts: []RouterOption{
+			WithIDPrefix(prefix),
+		},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	ctx := context.Background()
+
+	root := srv.createTenant(t, "", "root")
+	child := srv.createTenant(t, root.ID, "child")
+
+	assert.Equal(t, prefix, root.ID.Prefix(), "expected root id to have configured prefix")
+	assert.Equal(t, prefix, child.ID.Prefix(), "expected child id to have configured prefix")
+
+	t.Run("rejects other prefixes", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(gidx.MustNewID(TenantIDPrefix))+"/tenants", nil, nil, nil)
+		require.NoError(t, err, "no error expected for list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+	})
+
+	t.Run("validates existing ids", func(t *testing.T) {
+		assert.NoError(t, NewRouter(srv.db, nil, WithIDPrefix(prefix)).ValidateIDPrefix(ctx))
+		assert.ErrorIs(t, NewRouter(srv.db, nil).ValidateIDPrefix(ctx), ErrIDPrefixMismatch)
+		assert.ErrorIs(t, NewRouter(srv.db, nil, WithIDPrefix("short")).ValidateIDPrefix(ctx), ErrInvalidIDPrefix)
+	})
+}
+
+func TestTenantLabels(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	target := srv.createTenant(t, "", "labeled")
+	labelsPath := "/v1/tenants/" + string(target.ID) + "/labels"
+
+	subscriber := newPubSubClient(t, srv.logger, srv.nats.ClientURL())
+	msgChan := make(chan *nats.Msg, 10)
+
+	subscription, err := subscriber.ChanSubscribe(
+		context.TODO(),
+		"com.infratographer.events.tenants.>",
+		msgChan,
+		"tenant-api-test",
+	)
+
+	require.NoError(t, err)
+
+	defer func() {
+		if err := subscription.Unsubscribe(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	setLabel := func(t *testing.T, key, value string) {
+		t.Helper()
+
+		var result *v1TenantLabelResponseBody
+
+		resp, err := srv.Request(http.MethodPut, labelsPath+"/"+key, nil, strings.NewReader(fmt.Sprintf(`{"value": "%s"}`, value)), &result)
+		require.NoError(t, err, "no error expected for setting label")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		require.NotNil(t, result, "expected label result")
+		assert.Equal(t, key, result.Key, "unexpected label key")
+		assert.Equal(t, value, result.Value, "unexpected label value")
+	}
+
+	expectLabelChange := func(t *testing.T, key, previous, current string) {
+		t.Helper()
+
+		select {
+		case msg := <-msgChan:
+			pMsg := &pubsubx.ChangeMessage{}
+			require.NoError(t, json.Unmarshal(msg.Data, pMsg))
+
+			assert.Equal(t, tenantSubjectUpdate, msg.Subject, "expected nats subject to be tenant update subject")
+			assert.Equal(t, pubsub.UpdateEventType, pMsg.EventType, "expected event type to be update")
+			assert.Equal(t, target.ID, pMsg.SubjectID, "expected subject id to be labeled tenant id")
+			require.Len(t, pMsg.FieldChanges, 1, "expected a single field change")
+			assert.Equal(t, "labels."+key, pMsg.FieldChanges[0].Field, "unexpected changed field")
+			assert.Equal(t, previous, pMsg.FieldChanges[0].PreviousValue, "unexpected previous value")
+			assert.Equal(t, current, pMsg.FieldChanges[0].CurrentValue, "unexpected current value")
+		case <-time.After(natsMsgSubTimeout):
+			t.Error("failed to receive nats message")
+		}
+	}
+
+	expectNoMessage := func(t *testing.T) {
+		t.Helper()
+
+		select {
+		case msg := <-msgChan:
+			t.Errorf("unexpected nats message on %s", msg.Subject)
+		case <-time.After(natsMsgSubTimeout):
+		}
+	}
+
+	t.Run("set new label", func(t *testing.T) {
+		setLabel(t, "region", "us-east")
+
+		expectLabelChange(t, "region", "", "us-east")
+	})
+
+	t.Run("set label to current value", func(t *testing.T) {
+		setLabel(t, "region", "us-east")
+
+		expectNoMessage(t)
+	})
+
+	t.Run("change label", func(t *testing.T) {
+		setLabel(t, "region", "us-west")
+
+		expectLabelChange(t, "region", "us-east", "us-west")
+	})
+
+	t.Run("missing value", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodPut, labelsPath+"/region", nil, strings.NewReader(`{}`), nil)
+		require.NoError(t, err, "no error expected for setting label")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+
+		expectNoMessage(t)
+	})
+
+	t.Run("list labels", func(t *testing.T) {
+		setLabel(t, "tier", "gold")
+		expectLabelChange(t, "tier", "", "gold")
+
+		var result *v1TenantLabelsResponseBody
+
+		resp, err := srv.Request(http.MethodGet, labelsPath, nil, nil, &result)
+		require.NoError(t, err, "no error expected for listing labels")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		require.NotNil(t, result, "expected labels result")
+
+		assert.Equal(t, map[string]string{"region": "us-west", "tier": "gold"}, result.Labels)
+	})
+
+	t.Run("delete label", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodDelete, labelsPath+"/tier", nil, nil, nil)
+		require.NoError(t, err, "no error expected for deleting label")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		expectLabelChange(t, "tier", "gold", "")
+	})
+
+	t.Run("delete missing label", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodDelete, labelsPath+"/tier", nil, nil, nil)
+		require.NoError(t, err, "no error expected for deleting label")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, "unexpected status code returned")
+
+		expectNoMessage(t)
+	})
+
+	t.Run("unknown tenant", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(gidx.MustNewID(TenantIDPrefix))+"/labels", nil, nil, nil)
+		require.NoError(t, err, "no error expected for listing labels")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, "unexpected status code returned")
+	})
+}
+
+func TestTenantSubtreeLabels(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	tree := buildTree(t, srv)
+
+	subscriber := newPubSubClient(t, srv.logger, srv.nats.ClientURL())
+	msgChan := make(chan *nats.Msg, 20)
+
+	subscription, err := subscriber.ChanSubscribe(
+		context.TODO(),
+		"com.infratographer.events.tenants.>",
+		msgChan,
+		"tenant-api-test",
+	)
+
+	require.NoError(t, err)
+
+	defer func() {
+		if err := subscription.Unsubscribe(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	subtree := func(path string) []gidx.PrefixedID {
+		var ids []gidx.PrefixedID
+
+		for p, tenant := range tree.tenantsByPath {
+			if p == path || strings.HasPrefix(p, path+namePathSeparator) {
+				ids = append(ids, tenant.ID)
+			}
+		}
+
+		return ids
+	}
+
+	setSubtreeLabel := func(t *testing.T, id gidx.PrefixedID, query, body string) (*http.Response, *v1TenantSubtreeLabelResponseBody) {
+		t.Helper()
+
+		var result *v1TenantSubtreeLabelResponseBody
+
+		resp, err := srv.Request(http.MethodPost, "/v1/tenants/"+string(id)+"/subtree-labels"+query, nil, strings.NewReader(body), &result)
+		require.NoError(t, err, "no error expected for setting subtree label")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp, result
+	}
+
+	labelValue := func(t *testing.T, id gidx.PrefixedID, key string) string {
+		t.Helper()
+
+		var result *v1TenantLabelsResponseBody
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(id)+"/labels", nil, nil, &result)
+		require.NoError(t, err, "no error expected for listing labels")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		return result.Labels[key]
+	}
+
+	receiveChanged := func(t *testing.T, count int) []gidx.PrefixedID {
+		t.Helper()
+
+		var ids []gidx.PrefixedID
+
+		for len(ids) < count {
+			select {
+			case msg := <-msgChan:
+				pMsg := &pubsubx.ChangeMessage{}
+				require.NoError(t, json.Unmarshal(msg.Data, pMsg))
+
+				assert.Equal(t, pubsub.UpdateEventType, pMsg.EventType, "expected event type to be update")
+				require.Len(t, pMsg.FieldChanges, 1, "expected a single field change")
+				assert.Equal(t, "labels.region", pMsg.FieldChanges[0].Field, "unexpected changed field")
+
+				ids = append(ids, pMsg.SubjectID)
+			case <-time.After(natsMsgSubTimeout):
+				t.Errorf("received %d of %d nats messages", len(ids), count)
+
+				return ids
+			}
+		}
+
+		return ids
+	}
+
+	t1 := tree.tenantsByName["t1"]
+	t1a := tree.tenantsByName["t1a"]
+
+	resp, err := srv.Request(http.MethodPut, "/v1/tenants/"+string(t1a.ID)+"/labels/region", nil, strings.NewReader(`{"value": "eu"}`), nil)
+	require.NoError(t, err, "no error expected for setting label")
+	resp.Body.Close() //nolint:errcheck // Not needed
+	require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+	receiveChanged(t, 1)
+
+	// t1a is already labelled, so it isn't changed.
+	var expected []gidx.PrefixedID
+
+	for _, id := range subtree("t1") {
+		if id != t1a.ID {
+			expected = append(expected, id)
+		}
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		resp, result := setSubtreeLabel(t, t1.ID, "?dry_run=true", `{"key": "region", "value": "eu"}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		require.NotNil(t, result, "expected subtree label result")
+
+		assert.True(t, result.DryRun, "expected dry run")
+		assert.Equal(t, len(expected), result.Count, "unexpected count of changed tenants")
+		assert.ElementsMatch(t, expected, result.Tenants, "unexpected changed tenants")
+		assert.Empty(t, labelValue(t, t1.ID, "region"), "expected nothing to be written")
+
+		select {
+		case msg := <-msgChan:
+			t.Errorf("unexpected nats message on %s", msg.Subject)
+		case <-time.After(natsMsgSubTimeout):
+		}
+	})
+
+	t.Run("propagated", func(t *testing.T) {
+		resp, result := setSubtreeLabel(t, t1.ID, "", `{"key": "region", "value": "eu"}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		require.NotNil(t, result, "expected subtree label result")
+
+		assert.False(t, result.DryRun, "expected labels to be written")
+		assert.Equal(t, len(expected), result.Count, "unexpected count of changed tenants")
+
+		assert.ElementsMatch(t, expected, receiveChanged(t, len(expected)), "expected an event per changed tenant")
+
+		for _, id := range subtree("t1") {
+			assert.Equal(t, "eu", labelValue(t, id, "region"), "expected label on every tenant of the subtree")
+		}
+
+		assert.Empty(t, labelValue(t, tree.tenantsByName["t2"].ID, "region"), "expected other subtrees to be unchanged")
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		resp, result := setSubtreeLabel(t, t1.ID, "", `{"key": "region", "value": "eu"}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		assert.Zero(t, result.Count, "expected no tenants to be changed")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for query, body := range map[string]string{
+			"":              `{"key": "-invalid", "value": "eu"}`,
+			"?dry_run=what": `{"key": "region", "value": "eu"}`,
+			"?dry_run=true": `{"key": "region"}`,
+		} {
+			resp, _ := setSubtreeLabel(t, t1.ID, query, body)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned for %s", body)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		resp, _ := setSubtreeLabel(t, gidx.PrefixedID("tnntten-doesnotexist"), "", `{"key": "region", "value": "eu"}`)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, "unexpected status code returned")
+	})
+}
+
+func TestTenantSubtreeLabelsMaxResultSize(t *testing.T) {
+	srv, err := newTestServer(t, &testServerConfig{
+		routerOpts: []RouterOption{WithMaxResultSize(3)},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	tree := buildTree(t, srv)
+
+	for name, status := range map[string]int{
+		"t1":   http.StatusRequestEntityTooLarge,
+		"t1a1": http.StatusOK,
+	} {
+		resp, err := srv.Request(http.MethodPost, "/v1/tenants/"+string(tree.tenantsByName[name].ID)+"/subtree-labels", nil, strings.NewReader(`{"key": "region", "value": "eu"}`), nil)
+		require.NoError(t, err, "no error expected for setting subtree label")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, status, resp.StatusCode, "unexpected status code returned for %s", name)
+	}
+
+	var result *v1TenantLabelsResponseBody
+
+	resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(tree.tenantsByName["t1"].ID)+"/labels", nil, nil, &result)
+	require.NoError(t, err, "no error expected for listing labels")
+	resp.Body.Close() //nolint:errcheck // Not needed
+	assert.Empty(t, result.Labels, "expected the too large subtree not to be labelled")
+}
+
+func TestValidateLabelKey(t *testing.T) {
+	testCases := []struct {
+		key   string
+		valid bool
+	}{
+		{"tier", true},
+		{"team/owner", true},
+		{"cost-center.v2_id", true},
+		{"", false},
+		{"-leading", false},
+		{"trailing.", false},
+		{"with space", false},
+		{strings.Repeat("k", maxLabelKeyLength+1), false},
+	}
+
+	for _, tc := range testCases {
+		err := validateLabelKey(tc.key)
+
+		if tc.valid {
+			assert.NoError(t, err, "expected %q to be valid", tc.key)
+		} else {
+			assert.ErrorIs(t, err, ErrInvalidLabelKey, "expected %q to be invalid", tc.key)
+		}
+	}
+}
+
+func TestValidateTenantName(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+	}{
+		{"tenant", nil},
+		{"with space", nil},
+		{"ünïcödé", nil},
+		{strings.Repeat("t", maxTenantNameLength), nil},
+		{strings.Repeat("ü", maxTenantNameLength), nil},
+		{"", ErrTenantNameMissing},
+		{strings.Repeat("t", maxTenantNameLength+1), ErrTenantNameTooLong},
+		{"new\nline", ErrTenantNameInvalid},
+		{"tab\tbed", ErrTenantNameInvalid},
+		{"invalid\xff", ErrTenantNameInvalid},
+	}
+
+	for _, tc := range testCases {
+		err := validateTenantName(tc.name)
+
+		if tc.err == nil {
+			assert.NoError(t, err, "expected %q to be valid", tc.name)
+		} else {
+			assert.ErrorIs(t, err, tc.err, "unexpected error for %q", tc.name)
+		}
+	}
+}
+
+func TestTenantCreateWithLabels(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	ctx := context.Background()
+
+	subscriber := newPubSubClient(t, srv.logger, srv.nats.ClientURL())
+	msgChan := make(chan *nats.Msg, 10)
+
+	subscription, err := subscriber.ChanSubscribe(ctx, "com.infratographer.events.tenants.>", msgChan, "tenant-api-test")
+	require.NoError(t, err)
+
+	defer func() {
+		if err := subscription.Unsubscribe(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	t.Run("labels created with tenant", func(t *testing.T) {
+		var created *v1TenantResponse
+
+		resp, err := srv.Request(http.MethodPost, "/v1/tenants", nil, strings.NewReader(`{"name": "labeled", "labels": {"tier": "gold", "region": "us-east"}}`), &created)
+		require.NoError(t, err, "no error expected for create")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusCreated, resp.StatusCode, "unexpected status code returned")
+
+		var labels *v1TenantLabelsResponseBody
+
+		resp, err = srv.Request(http.MethodGet, "/v1/tenants/"+string(created.Tenant.ID)+"/labels", nil, nil, &labels)
+		require.NoError(t, err, "no error expected for listing labels")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		require.NotNil(t, labels, "expected labels result")
+		assert.Equal(t, map[string]string{"tier": "gold", "region": "us-east"}, labels.Labels, "unexpected labels")
+
+		select {
+		case msg := <-msgChan:
+			pMsg := &pubsubx.ChangeMessage{}
+			require.NoError(t, json.Unmarshal(msg.Data, pMsg))
+
+			assert.Equal(t, tenantSubjectCreate, msg.Subject, "expected nats subject to be tenant create subject")
+			assert.Equal(t, created.Tenant.ID, pMsg.SubjectID, "unexpected event subject id")
+			assert.Equal(t, []pubsubx.FieldChange{
+				{Field: "labels.region", CurrentValue: "us-east"},
+				{Field: "labels.tier", CurrentValue: "gold"},
+			}, pMsg.FieldChanges, "expected labels in create event")
+		case <-time.After(natsMsgSubTimeout):
+			t.Error("failed to receive nats message")
+		}
+	})
+
+	t.Run("invalid label prevents creation", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodPost, "/v1/tenants", nil, strings.NewReader(`{"name": "rejected", "labels": {"tier": "gold", "not valid": "x"}}`), nil)
+		require.NoError(t, err, "no error expected for create")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+
+		count, err := models.Tenants(models.TenantWhere.Name.EQ("rejected")).Count(ctx, srv.db)
+		require.NoError(t, err, "no error expected counting tenants")
+		assert.Zero(t, count, "expected tenant not to be created")
+
+		select {
+		case msg := <-msgChan:
+			t.Errorf("unexpected event published on %s", msg.Subject)
+		case <-time.After(natsMsgSubTimeout):
+		}
+	})
+}
+
+func TestTenantChildLimit(t *testing.T) {
+	srv, err := newTestServer(t, &testServerConfig{
+		routerOpts: []RouterOption{
+			WithMaxChildren(2),
+		},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	parent := srv.createTenant(t, "", "parent")
+	child := srv.createTenant(t, parent.ID, "child1")
+
+	// The parent is at the limit once it has the maximum number of children.
+	srv.createTenant(t, parent.ID, "child2")
+
+	t.Run("limit reached", func(t *testing.T) {
+		var result *v1ErrorResponseBody
+
+		resp, err := srv.Request(http.MethodPost, "/v1/tenants/"+string(parent.ID)+"/tenants", nil, strings.NewReader(`{"name": "child3"}`), &result)
+		require.NoError(t, err, "no error expected for creating tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected error response")
+		assert.Equal(t, errorCodeChildLimitExceeded, result.Code, "unexpected error code")
+
+		assert.Len(t, srv.listTenants(t, "/v1/tenants/"+string(parent.ID)+"/tenants"), 2, "expected no additional children")
+	})
+
+	t.Run("deleted children are not counted", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodDelete, "/v1/tenants/"+string(child.ID), nil, nil, nil)
+		require.NoError(t, err, "no error expected for deleting tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		srv.createTenant(t, parent.ID, "child3")
+	})
+
+	t.Run("root tenants are unlimited", func(t *testing.T) {
+		srv.createTenant(t, "", "root1")
+		srv.createTenant(t, "", "root2")
+	})
+}
+
+func TestCheckNamePattern(t *testing.T) {
+	r := NewRouter(nil, nil, WithNamePattern(regexp.MustCompile(`^[a-z][a-z0-9-]*$`)))
+
+	for _, name := range []string{"tenant", "tenant-1", "t"} {
+		assert.NoError(t, r.checkNamePattern(name), "expected %q to match", name)
+	}
+
+	for _, name := range []string{"Tenant", "1tenant", "with space", "tenant_1"} {
+		err := r.checkNamePattern(name)
+
+		assert.ErrorIs(t, err, ErrNamePatternMismatch, "expected %q not to match", name)
+		assert.ErrorContains(t, err, `^[a-z][a-z0-9-]*$`, "expected the pattern in the error")
+	}
+
+	assert.NoError(t, NewRouter(nil, nil).checkNamePattern("With Space"), "expected any name without a pattern")
+}
+
+func TestTenantNamePattern(t *testing.T) {
+	srv, err := newTestServer(t, &testServerConfig{
+		routerOpts: []RouterOption{
+			WithNamePattern(regexp.MustCompile(`^[a-z][a-z0-9-]*$`)),
+		},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	parent := srv.createTenant(t, "", "parent")
+
+	t.Run("create matching", func(t *testing.T) {
+		srv.createTenant(t, parent.ID, "child-1")
+	})
+
+	t.Run("create not matching", func(t *testing.T) {
+		var result *v1ErrorResponseBody
+
+		resp, err := srv.Request(http.MethodPost, "/v1/tenants/"+string(parent.ID)+"/tenants", nil, strings.NewReader(`{"name": "Child 2"}`), &result)
+		require.NoError(t, err, "no error expected for creating tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected error response")
+		assert.Equal(t, errorCodeNamePatternMismatch, result.Code, "unexpected error code")
+		assert.Contains(t, result.Error, `^[a-z][a-z0-9-]*$`, "expected the pattern in the error")
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		child := srv.createTenant(t, parent.ID, "renamed")
+
+		var result *v1ErrorResponseBody
+
+		resp, err := srv.Request(http.MethodPatch, "/v1/tenants/"+string(child.ID), nil, strings.NewReader(`{"name": "Renamed"}`), &result)
+		require.NoError(t, err, "no error expected for updating tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected error response")
+		assert.Equal(t, errorCodeNamePatternMismatch, result.Code, "unexpected error code")
+
+		resp, err = srv.Request(http.MethodPatch, "/v1/tenants/"+string(child.ID), nil, strings.NewReader(`{"name": "renamed-2"}`), nil)
+		require.NoError(t, err, "no error expected for updating tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "expected a matching rename to succeed")
+	})
+}
+
+func TestCheckNameResolvable(t *testing.T) {
+	r := NewRouter(nil, nil)
+
+	e := echo.New()
+	r.Routes(e.Group("/"))
+
+	var segments int
+
+	for _, route := range e.Routes() {
+		_, segment, ok := strings.Cut(route.Path, "/v1/tenants/")
+		if !ok || strings.HasPrefix(segment, ":") {
+			continue
+		}
+
+		segment, _, _ = strings.Cut(segment, "/")
+		segments++
+
+		assert.ErrorIs(t, r.checkNameResolvable(segment), ErrTenantNameReserved, "expected route segment %q to be reserved", segment)
+	}
+
+	require.NotZero(t, segments, "expected static tenant routes")
+
+	assert.ErrorIs(t, r.checkNameResolvable(string(gidx.MustNewID(TenantIDPrefix))), ErrTenantNameReserved, "expected tenant IDs to be reserved")
+	assert.ErrorIs(t, r.checkNameResolvable(TenantIDPrefix+"-name"), ErrTenantNameReserved, "expected ID shaped names to be reserved")
+
+	for _, name := range []string{"tenant", "welcome-team", "lca-team", string(gidx.MustNewID("testing"))} {
+		assert.NoError(t, r.checkNameResolvable(name), "expected %q to be resolvable", name)
+	}
+}
+
+func TestTenantNameResolvable(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	idShaped := TenantIDPrefix + "-name"
+
+	for _, name := range []string{"lca", idShaped} {
+		t.Run("create "+name, func(t *testing.T) {
+			var result *v1ErrorResponseBody
+
+			resp, err := srv.Request(http.MethodPost, "/v1/tenants", nil, strings.NewReader(`{"name": "`+name+`"}`), &result)
+			require.NoError(t, err, "no error expected for creating tenant")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode, "unexpected status code returned")
+
+			require.NotNil(t, result, "expected error response")
+			assert.Equal(t, errorCodeNameReserved, result.Code, "unexpected error code")
+		})
+
+		t.Run("rename "+name, func(t *testing.T) {
+			root := srv.createTenant(t, "", "root-"+name)
+
+			var result *v1ErrorResponseBody
+
+			resp, err := srv.Request(http.MethodPatch, "/v1/tenants/"+string(root.ID), nil, strings.NewReader(`{"name": "`+name+`"}`), &result)
+			require.NoError(t, err, "no error expected for updating tenant")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode, "unexpected status code returned")
+
+			require.NotNil(t, result, "expected error response")
+			assert.Equal(t, errorCodeNameReserved, result.Code, "unexpected error code")
+
+			resp, err = srv.Request(http.MethodPost, "/v1/tenants/"+string(root.ID)+"/rename", nil, strings.NewReader(`{"name": "`+name+`"}`), nil)
+			require.NoError(t, err, "no error expected for renaming tenant")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode, "unexpected status code returned")
+		})
+	}
+}
+
+func TestCheckKind(t *testing.T) {
+	r := NewRouter(nil, nil, WithTenantKinds("customer", "internal", "partner"))
+
+	kind := func(value string) *string { return &value }
+
+	assert.NoError(t, r.checkKind(nil), "expected tenants without a kind")
+
+	for _, value := range []string{"customer", "internal", "partner"} {
+		assert.NoError(t, r.checkKind(kind(value)), "expected %q to be allowed", value)
+	}
+
+	for _, value := range []string{"vendor", "Customer", ""} {
+		assert.ErrorIs(t, r.checkKind(kind(value)), ErrInvalidKind, "expected %q not to be allowed", value)
+	}
+
+	assert.ErrorContains(t, r.checkKind(kind("vendor")), "customer, internal, partner", "expected the allowed kinds in the error")
+
+	unrestricted := NewRouter(nil, nil)
+
+	assert.NoError(t, unrestricted.checkKind(kind("vendor")), "expected any kind without configured kinds")
+	assert.ErrorIs(t, unrestricted.checkKind(kind("")), ErrInvalidKind, "expected an empty kind to be rejected")
+}
+
+func TestTenantKinds(t *testing.T) {
+	srv, err := newTestServer(t, &testServerConfig{
+		routerOpts: []RouterOption{
+			WithTenantKinds("customer", "partner"),
+		},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	create := func(t *testing.T, path, body string, out interface{}) *http.Response {
+		t.Helper()
+
+		resp, err := srv.Request(http.MethodPost, path, nil, strings.NewReader(body), out)
+		require.NoError(t, err, "no error expected for creating tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp
+	}
+
+	var root *v1TenantResponse
+
+	resp := create(t, "/v1/tenants", `{"name": "root", "kind": "customer"}`, &root)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, "unexpected status code returned")
+	require.NotNil(t, root.Tenant.Kind, "expected the tenant kind")
+	assert.Equal(t, "customer", *root.Tenant.Kind, "unexpected tenant kind")
+
+	var partner *v1TenantResponse
+
+	resp = create(t, "/v1/tenants/"+string(root.Tenant.ID)+"/tenants", `{"name": "partner", "kind": "partner"}`, &partner)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, "unexpected status code returned")
+
+	unkinded := srv.createTenant(t, root.Tenant.ID, "unkinded")
+	assert.Nil(t, unkinded.Kind, "expected no kind when none is provided")
+
+	t.Run("invalid kind", func(t *testing.T) {
+		for _, body := range []string{
+			`{"name": "vendor", "kind": "vendor"}`,
+			`{"name": "empty", "kind": ""}`,
+		} {
+			var result *v1ErrorResponseBody
+
+			resp := create(t, "/v1/tenants/"+string(root.Tenant.ID)+"/tenants", body, &result)
+			require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode, "unexpected status code returned for %s", body)
+
+			require.NotNil(t, result, "expected error response")
+			assert.Equal(t, errorCodeInvalidKind, result.Code, "unexpected error code")
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		partners := srv.listTenants(t, "/v1/tenants/"+string(root.Tenant.ID)+"/tenants?kind=partner")
+		assert.Equal(t, []gidx.PrefixedID{partner.Tenant.ID}, tenantIDs(partners), "expected only partner tenants")
+
+		roots := srv.listTenants(t, "/v1/tenants?kind=customer&kind=partner")
+		assert.Equal(t, []gidx.PrefixedID{root.Tenant.ID}, tenantIDs(roots), "expected root tenants of either kind")
+
+		none := srv.listTenants(t, "/v1/tenants/"+string(root.Tenant.ID)+"/tenants?kind=customer")
+		assert.Empty(t, none, "expected no children of the kind")
+	})
+}
+
+func TestTenantCreateNameConflict(t *testing.T) {
+	const concurrency = 10
+
+	createConcurrently := func(t *testing.T, srv *testServer, parentID gidx.PrefixedID, name string) ([]int, []*v1TenantResponse) {
+		t.Helper()
+
+		var wg sync.WaitGroup
+
+		statuses := make([]int, concurrency)
+		results := make([]*v1TenantResponse, concurrency)
+
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+
+			go func(i int) {
+				defer wg.Done()
+
+				resp, err := srv.Request(http.MethodPost, "/v1/tenants/"+string(parentID)+"/tenants", nil, strings.NewReader(`{"name": "`+name+`"}`), &results[i])
+				if !assert.NoError(t, err, "no error expected for creating tenant") {
+					return
+				}
+
+				resp.Body.Close() //nolint:errcheck // Not needed
+
+				statuses[i] = resp.StatusCode
+			}(i)
+		}
+
+		wg.Wait()
+
+		return statuses, results
+	}
+
+	t.Run("conflict", func(t *testing.T) {
+		srv, err := newTestServer(t, nil)
+		defer srv.close()
+
+		require.NoError(t, err, "no error expected for new test server")
+
+		parent := srv.createTenant(t, "", "parent")
+
+		t.Run("existing child", func(t *testing.T) {
+			srv.createTenant(t, parent.ID, "existing")
+
+			var result *v1ErrorResponseBody
+
+			resp, err := srv.Request(http.MethodPost, "/v1/tenants/"+string(parent.ID)+"/tenants", nil, strings.NewReader(`{"name": "existing"}`), &result)
+			require.NoError(t, err, "no error expected for creating tenant")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			assert.Equal(t, http.StatusConflict, resp.StatusCode, "unexpected status code returned")
+
+			require.NotNil(t, result, "expected error response")
+			assert.Equal(t, errorCodeNameConflict, result.Code, "unexpected error code")
+		})
+
+		t.Run("concurrent creates", func(t *testing.T) {
+			statuses, _ := createConcurrently(t, srv, parent.ID, "concurrent")
+
+			var created, conflicts int
+
+			for _, status := range statuses {
+				switch status {
+				case http.StatusCreated:
+					created++
+				case http.StatusConflict:
+					conflicts++
+				default:
+					t.Errorf("unexpected status code %d returned", status)
+				}
+			}
+
+			assert.Equal(t, 1, created, "expected a single tenant to be created")
+			assert.Equal(t, concurrency-1, conflicts, "expected remaining creates to conflict")
+
+			count, err := models.Tenants(
+				models.TenantWhere.Name.EQ("concurrent"),
+			).Count(context.Background(), srv.db)
+			require.NoError(t, err, "no error expected counting tenants")
+			assert.EqualValues(t, 1, count, "expected a single tenant")
+		})
+
+		t.Run("deleted child", func(t *testing.T) {
+			child := srv.createTenant(t, parent.ID, "recreated")
+
+			resp, err := srv.Request(http.MethodDelete, "/v1/tenants/"+string(child.ID), nil, nil, nil)
+			require.NoError(t, err, "no error expected for deleting tenant")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+			srv.createTenant(t, parent.ID, "recreated")
+		})
+
+		t.Run("rename to deleted child", func(t *testing.T) {
+			child := srv.createTenant(t, parent.ID, "deleted")
+
+			resp, err := srv.Request(http.MethodDelete, "/v1/tenants/"+string(child.ID), nil, nil, nil)
+			require.NoError(t, err, "no error expected for deleting tenant")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+			sibling := srv.createTenant(t, parent.ID, "sibling")
+
+			resp, err = srv.Request(http.MethodPatch, "/v1/tenants/"+string(sibling.ID), nil, strings.NewReader(`{"name": "deleted"}`), nil)
+			require.NoError(t, err, "no error expected for updating tenant")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			assert.Equal(t, http.StatusOK, resp.StatusCode, "expected the name of a deleted child to be reusable")
+		})
+
+		t.Run("rename", func(t *testing.T) {
+			child := srv.createTenant(t, parent.ID, "renamed")
+
+			var result *v1ErrorResponseBody
+
+			resp, err := srv.Request(http.MethodPatch, "/v1/tenants/"+string(child.ID), nil, strings.NewReader(`{"name": "existing"}`), &result)
+			require.NoError(t, err, "no error expected for updating tenant")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			assert.Equal(t, http.StatusConflict, resp.StatusCode, "unexpected status code returned")
+
+			require.NotNil(t, result, "expected error response")
+			assert.Equal(t, errorCodeNameConflict, result.Code, "unexpected error code")
+		})
+	})
+
+	t.Run("idempotent", func(t *testing.T) {
+		srv, err := newTestServer(t, &testServerConfig{
+			routerOpts: []RouterOption{
+				WithIdempotentCreate(true),
+			},
+		})
+		defer srv.close()
+
+		require.NoError(t, err, "no error expected for new test server")
+
+		parent := srv.createTenant(t, "", "parent")
+
+		statuses, results := createConcurrently(t, srv, parent.ID, "concurrent")
+
+		var created int
+
+		ids := make(map[gidx.PrefixedID]struct{})
+
+		for i, status := range statuses {
+			switch status {
+			case http.StatusCreated:
+				created++
+			case http.StatusOK:
+			default:
+				t.Errorf("unexpected status code %d returned", status)
+
+				continue
+			}
+
+			require.NotNil(t, results[i], "expected tenant response")
+			require.NotNil(t, results[i].Tenant, "expected tenant")
+
+			ids[results[i].Tenant.ID] = struct{}{}
+		}
+
+		assert.Equal(t, 1, created, "expected a single tenant to be created")
+		assert.Len(t, ids, 1, "expected all creates to respond with the same tenant")
+	})
+}
+
+func TestTenantCreateUpsert(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	parent := srv.createTenant(t, "", "upsert-parent")
+	child := srv.createTenant(t, parent.ID, "child")
+
+	subscriber := newPubSubClient(t, srv.logger, srv.nats.ClientURL())
+	msgChan := make(chan *nats.Msg, 10)
+
+	subscription, err := subscriber.ChanSubscribe(context.TODO(), "com.infratographer.events.tenants.>", msgChan, "tenant-api-test")
+	require.NoError(t, err)
+
+	defer func() {
+		if err := subscription.Unsubscribe(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	create := func(t *testing.T, path, name string) (int, *v1TenantResponse) {
+		t.Helper()
+
+		var result *v1TenantResponse
+
+		resp, err := srv.Request(http.MethodPost, path, nil, strings.NewReader(fmt.Sprintf(`{"name": %q}`, name)), &result)
+		require.NoError(t, err, "no error expected for tenant create")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp.StatusCode, result
+	}
+
+	childPath := "/v1/tenants/" + string(parent.ID) + "/tenants"
+
+	t.Run("existing child", func(t *testing.T) {
+		status, result := create(t, childPath+"?upsert=true", "child")
+		require.Equal(t, http.StatusOK, status, "expected the existing tenant")
+		require.NotNil(t, result, "expected tenant response")
+		assert.Equal(t, child.ID, result.Tenant.ID, "expected the original tenant")
+		assert.Equal(t, child.CreatedAt.Unix(), result.Tenant.CreatedAt.Unix(), "expected the original tenant")
+
+		select {
+		case msg := <-msgChan:
+			t.Errorf("expected no event for an existing tenant, received %s", msg.Subject)
+		case <-time.After(natsMsgSubTimeout):
+		}
+	})
+
+	t.Run("existing root", func(t *testing.T) {
+		status, result := create(t, "/v1/tenants?upsert=true", "upsert-parent")
+		require.Equal(t, http.StatusOK, status, "expected the existing root")
+		assert.Equal(t, parent.ID, result.Tenant.ID, "expected the original root")
+	})
+
+	t.Run("new child", func(t *testing.T) {
+		status, result := create(t, childPath+"?upsert=true", "other")
+		require.Equal(t, http.StatusCreated, status, "expected a new tenant")
+		assert.NotEqual(t, child.ID, result.Tenant.ID)
+
+		select {
+		case msg := <-msgChan:
+			assert.Equal(t, tenantSubjectCreate, msg.Subject, "expected a create event for a new tenant")
+		case <-time.After(natsMsgSubTimeout):
+			t.Error("failed to receive nats message")
+		}
+	})
+
+	t.Run("without upsert", func(t *testing.T) {
+		status, _ := create(t, childPath, "child")
+		assert.Equal(t, http.StatusConflict, status, "expected a conflict without upsert")
+
+		status, _ = create(t, childPath+"?upsert=maybe", "child")
+		assert.Equal(t, http.StatusBadRequest, status, "expected invalid upsert to be rejected")
+	})
+}
+
+func TestTenantCreateBodyParent(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	parent := srv.createTenant(t, "", "parent")
+	other := srv.createTenant(t, "", "other")
+
+	create := func(t *testing.T, path, body string) (int, *v1TenantResponse) {
+		t.Helper()
+
+		var result *v1TenantResponse
+
+		resp, err := srv.Request(http.MethodPost, path, nil, strings.NewReader(body), &result)
+		require.NoError(t, err, "no error expected for tenant create")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp.StatusCode, result
+	}
+
+	childPath := "/v1/tenants/" + string(parent.ID) + "/tenants"
+
+	t.Run("conflicting", func(t *testing.T) {
+		status, _ := create(t, childPath, fmt.Sprintf(`{"name": "child", "parent_tenant_id": %q}`, other.ID))
+		assert.Equal(t, http.StatusBadRequest, status, "expected a parent disagreeing with the path to be rejected")
+
+		status, _ = create(t, "/v1/tenants", fmt.Sprintf(`{"name": "child", "parent_tenant_id": %q}`, parent.ID))
+		assert.Equal(t, http.StatusBadRequest, status, "expected a parent for a root create to be rejected")
+
+		children := srv.listTenants(t, "/v1/tenants/"+string(other.ID)+"/tenants")
+		assert.Empty(t, children, "expected no tenant to be created")
+	})
+
+	t.Run("matching", func(t *testing.T) {
+		status, result := create(t, childPath, fmt.Sprintf(`{"name": "child", "parent_tenant_id": %q}`, parent.ID))
+		require.Equal(t, http.StatusCreated, status, "expected a parent matching the path to be accepted")
+		require.NotNil(t, result.Tenant.ParentTenantID)
+		assert.Equal(t, parent.ID, *result.Tenant.ParentTenantID)
+	})
+}
+
+func TestTenantLock(t *testing.T) {
+	testActorID := gidx.MustNewID(TenantIDPrefix)
+
+	// the test oauth client grants the "test" scope.
+	oauthClient, issuer, close := echojwtx.TestOAuthClient(string(testActorID), "")
+	defer close()
+
+	t.Run("requires lock scope", func(t *testing.T) {
+		srv, err := newTestServer(t, &testServerConfig{
+			client: oauthClient,
+			auth: &echojwtx.AuthConfig{
+				Issuer: issuer,
+			},
+		})
+		defer srv.close()
+
+		require.NoError(t, err, "no error expected for new test server")
+
+		tenant := srv.createTenant(t, "", "unlocked")
+
+		resp, err := srv.Request(http.MethodPatch, "/v1/tenants/"+string(tenant.ID), nil, strings.NewReader(`{"locked": true}`), nil)
+		require.NoError(t, err, "no error expected for locking tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode, "unexpected status code returned")
+
+		resp, err = srv.Request(http.MethodPatch, "/v1/tenants/"+string(tenant.ID), nil, strings.NewReader(`{"locked": false}`), nil)
+		require.NoError(t, err, "no error expected for updating tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "expected unchanged lock not to require scope")
+	})
+
+	srv, err := newTestServer(t, &testServerConfig{
+		client: oauthClient,
+		auth: &echojwtx.AuthConfig{
+			Issuer: issuer,
+		},
+		routerOpts: []RouterOption{
+			WithLockScope("test"),
+		},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	parent := srv.createTenant(t, "", "parent")
+	tenant := srv.createTenant(t, "", "locked")
+
+	setLocked := func(t *testing.T, locked bool) {
+		t.Helper()
+
+		var result *v1TenantResponse
+
+		resp, err := srv.Request(http.MethodPatch, "/v1/tenants/"+string(tenant.ID), nil, strings.NewReader(fmt.Sprintf(`{"locked": %t}`, locked)), &result)
+		require.NoError(t, err, "no error expected for updating tenant lock")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected tenant response")
+		assert.Equal(t, locked, result.Tenant.Locked, "unexpected tenant lock")
+	}
+
+	assertLocked := func(t *testing.T, method, path, body string) {
+		t.Helper()
+
+		var result *v1ErrorResponseBody
+
+		resp, err := srv.Request(method, path, nil, strings.NewReader(body), &result)
+		require.NoError(t, err, "no error expected for request")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusLocked, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected error response")
+		assert.Equal(t, errorCodeLocked, result.Code, "unexpected error code")
+	}
+
+	setLocked(t, true)
+
+	t.Run("delete", func(t *testing.T) {
+		assertLocked(t, http.MethodDelete, "/v1/tenants/"+string(tenant.ID), "")
+	})
+
+	t.Run("move", func(t *testing.T) {
+		assertLocked(t, http.MethodPatch, "/v1/tenants/"+string(tenant.ID), `{"parent_tenant_id": "`+string(parent.ID)+`"}`)
+	})
+
+	t.Run("batch move", func(t *testing.T) {
+		assertLocked(t, http.MethodPost, "/v1/tenants/batch-move", `{"moves": [{"id": "`+string(tenant.ID)+`", "parent_tenant_id": "`+string(parent.ID)+`"}]}`)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodPatch, "/v1/tenants/"+string(tenant.ID), nil, strings.NewReader(`{"name": "renamed"}`), nil)
+		require.NoError(t, err, "no error expected for renaming tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "expected locked tenants to be renamed")
+	})
+
+	t.Run("unlocked", func(t *testing.T) {
+		setLocked(t, false)
+
+		resp, err := srv.Request(http.MethodDelete, "/v1/tenants/"+string(tenant.ID), nil, nil, nil)
+		require.NoError(t, err, "no error expected for deleting tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+	})
+}
+
+func TestTenantQuota(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	request := func(t *testing.T, method, path, body string) (*http.Response, *v1TenantResponse) {
+		t.Helper()
+
+		var result *v1TenantResponse
+
+		resp, err := srv.Request(method, path, nil, strings.NewReader(body), &result)
+		require.NoError(t, err, "no error expected for request")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp, result
+	}
+
+	t.Run("negative quota rejected", func(t *testing.T) {
+		resp, _ := request(t, http.MethodPost, "/v1/tenants", `{"name": "negative", "quota": -1}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		resp, result := request(t, http.MethodPost, "/v1/tenants", `{"name": "unlimited"}`)
+		require.Equal(t, http.StatusCreated, resp.StatusCode, "unexpected status code returned")
+		assert.Nil(t, result.Tenant.Quota, "expected no quota")
+	})
+
+	resp, result := request(t, http.MethodPost, "/v1/tenants", `{"name": "billed", "quota": 10}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, "unexpected status code returned")
+	require.NotNil(t, result.Tenant.Quota, "expected quota")
+	assert.Equal(t, int64(10), *result.Tenant.Quota, "unexpected quota")
+
+	tenantPath := "/v1/tenants/" + string(result.Tenant.ID)
+
+	quota := func(t *testing.T) *int64 {
+		t.Helper()
+
+		resp, result := request(t, http.MethodGet, tenantPath, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		return result.Tenant.Quota
+	}
+
+	t.Run("update", func(t *testing.T) {
+		resp, _ := request(t, http.MethodPatch, tenantPath, `{"quota": 20}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, quota(t), "expected quota")
+		assert.Equal(t, int64(20), *quota(t), "unexpected quota")
+	})
+
+	t.Run("omitted quota unchanged", func(t *testing.T) {
+		resp, _ := request(t, http.MethodPatch, tenantPath, `{"name": "renamed"}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, quota(t), "expected quota")
+		assert.Equal(t, int64(20), *quota(t), "unexpected quota")
+	})
+
+	t.Run("negative update rejected", func(t *testing.T) {
+		resp, _ := request(t, http.MethodPatch, tenantPath, `{"quota": -5}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+	})
+
+	t.Run("cleared", func(t *testing.T) {
+		resp, _ := request(t, http.MethodPatch, tenantPath, `{"quota": null}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		assert.Nil(t, quota(t), "expected quota to be cleared")
+	})
+}
+
+func TestTenantDeleteIfChildless(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	tree := buildTree(t, srv)
+
+	deleteTenant := func(t *testing.T, name, query string, out interface{}) *http.Response {
+		t.Helper()
+
+		resp, err := srv.Request(http.MethodDelete, "/v1/tenants/"+string(tree.tenantsByName[name].ID)+query, nil, nil, out)
+		require.NoError(t, err, "no error expected for deleting tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		resp := deleteTenant(t, "t1", "?if_childless=maybe", nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+	})
+
+	t.Run("rejected with children", func(t *testing.T) {
+		var result *v1ErrorResponseBody
+
+		resp := deleteTenant(t, "t1", "?if_childless=true", &result)
+		require.Equal(t, http.StatusConflict, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected error response")
+		assert.Equal(t, errorCodeHasChildren, result.Code, "unexpected error code")
+		require.NotNil(t, result.ChildCount, "expected child count")
+		assert.Equal(t, int64(2), *result.ChildCount, "unexpected child count")
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(tree.tenantsByName["t1"].ID), nil, nil, nil)
+		require.NoError(t, err, "no error expected for getting tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "expected tenant not to be deleted")
+	})
+
+	t.Run("childless", func(t *testing.T) {
+		resp := deleteTenant(t, "t2a", "?if_childless=true", nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+	})
+
+	t.Run("deleted children are not counted", func(t *testing.T) {
+		resp := deleteTenant(t, "t2", "?if_childless=true", nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		resp := deleteTenant(t, "t1b", "?if_childless=false", nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "expected tenants with children to be deleted")
+	})
+}
+
+func TestTenantDeleteCascade(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	tree := buildTree(t, srv)
+
+	subtree := func(path string) []gidx.PrefixedID {
+		var ids []gidx.PrefixedID
+
+		for p, tenant := range tree.tenantsByPath {
+			if p == path || strings.HasPrefix(p, path+namePathSeparator) {
+				ids = append(ids, tenant.ID)
+			}
+		}
+
+		return ids
+	}
+
+	preview := func(t *testing.T, id gidx.PrefixedID) (*http.Response, *v1TenantDeletePreviewResponseBody) {
+		t.Helper()
+
+		var result *v1TenantDeletePreviewResponseBody
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(id)+"/delete-preview", nil, nil, &result)
+		require.NoError(t, err, "no error expected for previewing delete")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp, result
+	}
+
+	deleteTenant := func(t *testing.T, id gidx.PrefixedID, query string) *http.Response {
+		t.Helper()
+
+		resp, err := srv.Request(http.MethodDelete, "/v1/tenants/"+string(id)+query, nil, nil, nil)
+		require.NoError(t, err, "no error expected for deleting tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		resp := deleteTenant(t, tree.tenantsByName["t1"].ID, "?cascade=maybe")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+
+		resp = deleteTenant(t, tree.tenantsByName["t1"].ID, "?cascade=true&if_childless=true")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+	})
+
+	t.Run("preview not found", func(t *testing.T) {
+		resp, _ := preview(t, gidx.PrefixedID("tnntten-doesnotexist"))
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, "unexpected status code returned")
+	})
+
+	t.Run("locked descendant", func(t *testing.T) {
+		locked := tree.tenantsByName["t2a"]
+
+		resp, err := srv.Request(http.MethodPatch, "/v1/tenants/"+string(locked.ID), nil, strings.NewReader(`{"locked": true}`), nil)
+		require.NoError(t, err, "no error expected for locking tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		resp, result := preview(t, tree.tenantsByName["t2"].ID)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		assert.Equal(t, []gidx.PrefixedID{locked.ID}, result.Locked, "expected locked descendant to be listed")
+
+		resp = deleteTenant(t, tree.tenantsByName["t2"].ID, "?cascade=true")
+		assert.Equal(t, http.StatusLocked, resp.StatusCode, "unexpected status code returned")
+
+		_, result = preview(t, tree.tenantsByName["t2"].ID)
+		assert.Equal(t, 2, result.Count, "expected nothing to be deleted")
+	})
+
+	t.Run("preview matches delete", func(t *testing.T) {
+		target := tree.tenantsByName["t1a"]
+		expected := subtree("t1.t1a")
+
+		resp, result := preview(t, target.ID)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		require.NotNil(t, result, "expected preview response")
+
+		assert.Equal(t, target.ID, result.Tenants[0], "expected tenant to be listed first")
+		assert.ElementsMatch(t, expected, result.Tenants, "unexpected previewed tenants")
+		assert.Equal(t, len(expected), result.Count, "unexpected previewed count")
+		assert.Empty(t, result.Locked, "expected no locked tenants")
+
+		resp, _ = preview(t, target.ID)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "expected preview not to delete anything")
+
+		before := srv.listTenants(t, "/v1/tenants/"+string(tree.tenantsByName["t1"].ID)+"/tenants?recursive=true")
+
+		resp = deleteTenant(t, target.ID, "?cascade=true")
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		after := srv.listTenants(t, "/v1/tenants/"+string(tree.tenantsByName["t1"].ID)+"/tenants?recursive=true")
+
+		removed := len(before) - len(after)
+
+		assert.Equal(t, result.Count, removed, "expected delete to remove the previewed tenants")
+
+		for _, id := range result.Tenants {
+			resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(id), nil, nil, nil)
+			require.NoError(t, err, "no error expected for getting tenant")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode, "expected previewed tenant to be deleted")
+		}
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(tree.tenantsByName["t1b1"].ID), nil, nil, nil)
+		require.NoError(t, err, "no error expected for getting tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "expected tenants outside the subtree to be kept")
+	})
+}
+
+func TestTenantChildCounts(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	tree := buildTree(t, srv)
+
+	idQuery := func(ids ...gidx.PrefixedID) string {
+		params := make([]string, len(ids))
+
+		for i, id := range ids {
+			params[i] = "id=" + string(id)
+		}
+
+		return "/v1/tenants/child-counts?" + strings.Join(params, "&")
+	}
+
+	t.Run("grouped counts", func(t *testing.T) {
+		unknown := gidx.MustNewID(TenantIDPrefix)
+
+		var result *v1TenantChildCountsResponseBody
+
+		resp, err := srv.Request(http.MethodGet, idQuery(
+			tree.tenantsByName["t1"].ID,
+			tree.tenantsByName["t1a1"].ID,
+			tree.tenantsByName["t1b"].ID,
+			tree.tenantsByName["t2a"].ID,
+			tree.tenantsByName["t1"].ID,
+			unknown,
+		), nil, nil, &result)
+		require.NoError(t, err, "no error expected for child counts")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected child counts response")
+		assert.Equal(t, map[gidx.PrefixedID]int64{
+			tree.tenantsByName["t1"].ID:   2,
+			tree.tenantsByName["t1a1"].ID: 2,
+			tree.tenantsByName["t1b"].ID:  1,
+			tree.tenantsByName["t2a"].ID:  0,
+			unknown:                       0,
+		}, result.Counts, "unexpected child counts")
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		tooMany := make([]gidx.PrefixedID, maxChildCountIDs+1)
+
+		for i := range tooMany {
+			tooMany[i] = gidx.MustNewID(TenantIDPrefix)
+		}
+
+		for _, path := range []string{
+			"/v1/tenants/child-counts",
+			"/v1/tenants/child-counts?id=not-valid",
+			idQuery(tooMany...),
+		} {
+			resp, err := srv.Request(http.MethodGet, path, nil, nil, nil)
+			require.NoError(t, err, "no error expected for child counts")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned for %s", path)
+		}
+	})
+}
+
+func TestTenantGetIncludeChildren(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	tree := buildTree(t, srv)
+
+	get := func(t *testing.T, path string, out interface{}) *http.Response {
+		t.Helper()
+
+		resp, err := srv.Request(http.MethodGet, path, nil, nil, out)
+		require.NoError(t, err, "no error expected for tenant get")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp
+	}
+
+	parent := tree.tenantsByName["t1"]
+
+	t.Run("children", func(t *testing.T) {
+		var result *v1TenantWithChildrenResponse
+
+		resp := get(t, "/v1/tenants/"+string(parent.ID)+"?include=children", &result)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected tenant response")
+		require.NotNil(t, result.Tenant, "expected tenant")
+		assert.Equal(t, parent.ID, result.Tenant.ID, "unexpected tenant")
+		assert.Equal(t, tenantIDs([]*tenant{
+			tree.tenantsByName["t1a"],
+			tree.tenantsByName["t1b"],
+		}), tenantIDs(result.Children), "expected direct children in creation order")
+		assert.False(t, result.ChildrenTruncated, "expected all children included")
+	})
+
+	t.Run("deleted children excluded", func(t *testing.T) {
+		child := tree.tenantsByName["t1b1a"]
+
+		resp, err := srv.Request(http.MethodDelete, "/v1/tenants/"+string(child.ID), nil, nil, nil)
+		require.NoError(t, err, "no error expected for tenant delete")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned deleting tenant")
+
+		var result *v1TenantWithChildrenResponse
+
+		resp = get(t, "/v1/tenants/"+string(tree.tenantsByName["t1b1"].ID)+"?include=children", &result)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected tenant response")
+		assert.NotNil(t, result.Children, "expected an empty list of children")
+		assert.Empty(t, result.Children, "expected deleted child excluded")
+	})
+
+	t.Run("default response unchanged", func(t *testing.T) {
+		var result map[string]interface{}
+
+		resp := get(t, "/v1/tenants/"+string(parent.ID), &result)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		assert.NotContains(t, result, "children", "expected no children without include")
+		assert.NotContains(t, result, "children_truncated", "expected no children without include")
+	})
+
+	t.Run("unknown include", func(t *testing.T) {
+		resp := get(t, "/v1/tenants/"+string(parent.ID)+"?include=parent", nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+	})
+}
+
+func TestTenantListsEmpty(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	assertEmptyList := func(t *testing.T, path, field string) {
+		t.Helper()
+
+		var body map[string]json.RawMessage
+
+		resp, err := srv.Request(http.MethodGet, path, nil, nil, &body)
+		require.NoError(t, err, "no error expected for list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		require.Contains(t, body, field, "expected list field in response")
+		assert.JSONEq(t, `[]`, string(body[field]), "expected an empty, non-null list")
+	}
+
+	t.Run("no root tenants", func(t *testing.T) {
+		assertEmptyList(t, "/v1/tenants", "tenants")
+	})
+
+	root := srv.createTenant(t, "", "root")
+
+	t.Run("no children", func(t *testing.T) {
+		assertEmptyList(t, "/v1/tenants/"+string(root.ID)+"/tenants", "tenants")
+	})
+
+	t.Run("no filter matches", func(t *testing.T) {
+		assertEmptyList(t, "/v1/tenants?has_children=true", "tenants")
+	})
+
+	t.Run("no parents", func(t *testing.T) {
+		assertEmptyList(t, "/v1/tenants/"+string(root.ID)+"/parents", "tenants")
+	})
+
+	t.Run("no name history", func(t *testing.T) {
+		assertEmptyList(t, "/v1/tenants/"+string(root.ID)+"/names", "names")
+	})
+
+	t.Run("parents of unknown tenant", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(gidx.MustNewID(TenantIDPrefix))+"/parents", nil, nil, nil)
+		require.NoError(t, err, "no error expected for parents list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, "unexpected status code returned")
+	})
+}
+
+func TestTenantListTotal(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	root := srv.createTenant(t, "", "root")
+
+	for _, name := range []string{"a", "b", "c"} {
+		srv.createTenant(t, root.ID, name)
+	}
+
+	listBody := func(t *testing.T, path string) map[string]json.RawMessage {
+		t.Helper()
+
+		var body map[string]json.RawMessage
+
+		resp, err := srv.Request(http.MethodGet, path, nil, nil, &body)
+		require.NoError(t, err, "no error expected for list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		return body
+	}
+
+	childrenPath := "/v1/tenants/" + string(root.ID) + "/tenants"
+
+	t.Run("included by default", func(t *testing.T) {
+		body := listBody(t, childrenPath+"?limit=2")
+
+		assert.JSONEq(t, "3", string(body["total"]), "expected total across all pages")
+	})
+
+	t.Run("omitted when opted out", func(t *testing.T) {
+		body := listBody(t, childrenPath+"?limit=2&include_total=false")
+
+		assert.NotContains(t, body, "total", "expected total to be omitted")
+
+		var tenants []json.RawMessage
+
+		require.NoError(t, json.Unmarshal(body["tenants"], &tenants))
+		assert.Len(t, tenants, 2, "expected page to be returned")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodGet, childrenPath+"?include_total=maybe", nil, nil, nil)
+		require.NoError(t, err, "no error expected for list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+	})
+}
+
+func TestTenantListIDsOnly(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	root := srv.createTenant(t, "", "root")
+
+	var ids []gidx.PrefixedID
+
+	for _, name := range []string{"a", "b", "c"} {
+		ids = append(ids, srv.createTenant(t, root.ID, name).ID)
+	}
+
+	childrenPath := "/v1/tenants/" + string(root.ID) + "/tenants"
+
+	t.Run("ids", func(t *testing.T) {
+		var body map[string]json.RawMessage
+
+		resp, err := srv.Request(http.MethodGet, childrenPath+"?ids_only=true&limit=2", nil, nil, &body)
+		require.NoError(t, err, "no error expected for list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		assert.NotContains(t, body, "tenants", "expected no tenants")
+		assert.JSONEq(t, "3", string(body["total"]), "expected total across all pages")
+
+		var listed []gidx.PrefixedID
+
+		require.NoError(t, json.Unmarshal(body["ids"], &listed), "expected a flat array of ids")
+		assert.Equal(t, ids[:2], listed, "expected the first page of ids in order")
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		var body map[string]json.RawMessage
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(ids[0])+"/tenants?ids_only=true", nil, nil, &body)
+		require.NoError(t, err, "no error expected for list")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		assert.JSONEq(t, "[]", string(body["ids"]), "expected an empty array")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, query := range []string{"ids_only=maybe", "ids_only=true&stream=true&include_total=false"} {
+			resp, err := srv.Request(http.MethodGet, childrenPath+"?"+query, nil, nil, nil)
+			require.NoError(t, err, "no error expected for list")
+			resp.Body.Close() //nolint:errcheck // Not needed
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned for %s", query)
+		}
+	})
+}
+
+func TestTenantActors(t *testing.T) {
+	ctx := context.Background()
+	systemActor := gidx.MustNewID("idntsys")
+
+	t.Run("anonymous", func(t *testing.T) {
+		srv, err := newTestServer(t, nil)
+		defer srv.close()
+
+		require.NoError(t, err, "no error expected for new test server")
+
+		tenant := srv.createTenant(t, "", "anonymous")
+
+		assert.Nil(t, tenant.CreatedBy, "expected no creating actor")
+		assert.Nil(t, tenant.UpdatedBy, "expected no updating actor")
+	})
+
+	t.Run("system actor", func(t *testing.T) {
+		srv, err := newTestServer(t, &testServerConfig{
+			routerOpts: []RouterOption{
+				WithSystemActor(string(systemActor)),
+			},
+		})
+		defer srv.close()
+
+		require.NoError(t, err, "no error expected for new test server")
+
+		subscriber := newPubSubClient(t, srv.logger, srv.nats.ClientURL())
+		msgChan := make(chan *nats.Msg, 10)
+
+		subscription, err := subscriber.ChanSubscribe(ctx, "com.infratographer.events.tenants.>", msgChan, "tenant-api-test")
+		require.NoError(t, err)
+
+		defer func() {
+			if err := subscription.Unsubscribe(); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		tenant := srv.createTenant(t, "", "system")
+
+		require.NotNil(t, tenant.CreatedBy, "expected creating actor")
+		assert.Equal(t, string(systemActor), *tenant.CreatedBy)
+		require.NotNil(t, tenant.UpdatedBy, "expected updating actor")
+		assert.Equal(t, string(systemActor), *tenant.UpdatedBy)
+
+		select {
+		case msg := <-msgChan:
+			pMsg := &pubsubx.ChangeMessage{}
+			require.NoError(t, json.Unmarshal(msg.Data, pMsg))
+
+			assert.Equal(t, systemActor, pMsg.ActorID, "expected system actor in event")
+		case <-time.After(natsMsgSubTimeout):
+			t.Error("failed to receive nats message")
+		}
+	})
+
+	t.Run("token subject", func(t *testing.T) {
+		testActorID := gidx.MustNewID(TenantIDPrefix)
+
+		oauthClient, issuer, close := echojwtx.TestOAuthClient(string(testActorID), "")
+		defer close()
+
+		srv, err := newTestServer(t, &testServerConfig{
+			client: oauthClient,
+			auth: &echojwtx.AuthConfig{
+				Issuer: issuer,
+			},
+			routerOpts: []RouterOption{
+				WithSystemActor(string(systemActor)),
+			},
+		})
+		defer srv.close()
+
+		require.NoError(t, err, "no error expected for new test server")
+
+		tenant := srv.createTenant(t, "", "subject")
+
+		require.NotNil(t, tenant.CreatedBy, "expected creating actor")
+		assert.Equal(t, string(testActorID), *tenant.CreatedBy, "expected token subject over system actor")
+
+		var result *v1TenantResponse
+
+		resp, err := srv.Request(http.MethodPatch, "/v1/tenants/"+string(tenant.ID), nil, strings.NewReader(`{"name": "renamed"}`), &result)
+		require.NoError(t, err, "no error expected for updating tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result.Tenant.UpdatedBy, "expected updating actor")
+		assert.Equal(t, string(testActorID), *result.Tenant.UpdatedBy)
+	})
+}
+
+func TestTenantGlobalNameUniqueness(t *testing.T) {
+	srv, err := newTestServer(t, &testServerConfig{
+		routerOpts: []RouterOption{
+			WithGlobalNameUniqueness(true),
+		},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	ctx := context.Background()
+
+	global := NewRouter(srv.db, nil, WithGlobalNameUniqueness(true))
+	scoped := NewRouter(srv.db, nil)
+
+	globalIndexExists := func(t *testing.T) bool {
+		t.Helper()
+
+		var count int
+
+		err := srv.db.QueryRowContext(ctx, `SELECT count(*) FROM pg_indexes WHERE tablename = 'tenants' AND indexname = 'tenants_name_key'`).Scan(&count)
+		require.NoError(t, err, "no error expected querying indexes")
+
+		return count != 0
+	}
+
+	request := func(t *testing.T, method, path, body string) int {
+		t.Helper()
+
+		resp, err := srv.Request(method, path, nil, strings.NewReader(body), nil)
+		require.NoError(t, err, "no error expected for request")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp.StatusCode
+	}
+
+	a := srv.createTenant(t, "", "a")
+	b := srv.createTenant(t, "", "b")
+
+	// Tenants sharing a name already exist when the mode is first applied.
+	first := &models.Tenant{ID: gidx.MustNewID(TenantIDPrefix), Name: "shared", ParentTenantID: nullx.PrefixedIDFrom(a.ID)}
+	first.Path = tenantPath(tenantPath("", a.ID), first.ID)
+	require.NoError(t, first.Insert(ctx, srv.db, boil.Infer()))
+
+	second := &models.Tenant{ID: gidx.MustNewID(TenantIDPrefix), Name: "shared", ParentTenantID: nullx.PrefixedIDFrom(b.ID)}
+	second.Path = tenantPath(tenantPath("", b.ID), second.ID)
+	require.NoError(t, second.Insert(ctx, srv.db, boil.Infer()))
+
+	t.Run("duplicates prevent enabling", func(t *testing.T) {
+		err := ApplyNameUniqueness(ctx, srv.db, true)
+		assert.ErrorIs(t, err, ErrDuplicateNames)
+		assert.False(t, globalIndexExists(t), "expected no index to be created")
+	})
+
+	status := request(t, http.MethodPatch, "/v1/tenants/"+string(second.ID), `{"name": "other"}`)
+	require.Equal(t, http.StatusOK, status, "no error expected renaming duplicate")
+
+	assert.ErrorIs(t, global.ValidateNameUniqueness(ctx), ErrNameUniquenessMismatch, "expected missing index to be reported")
+	assert.NoError(t, scoped.ValidateNameUniqueness(ctx), "no error expected without the index")
+
+	require.NoError(t, ApplyNameUniqueness(ctx, srv.db, true), "no error expected enabling global uniqueness")
+	assert.True(t, globalIndexExists(t), "expected global index to be created")
+
+	require.NoError(t, ApplyNameUniqueness(ctx, srv.db, true), "expected enabling to be repeatable")
+
+	assert.NoError(t, global.ValidateNameUniqueness(ctx), "no error expected with the index")
+	assert.ErrorIs(t, scoped.ValidateNameUniqueness(ctx), ErrNameUniquenessMismatch, "expected unexpected index to be reported")
+
+	t.Run("create conflicts across parents", func(t *testing.T) {
+		status := request(t, http.MethodPost, "/v1/tenants/"+string(b.ID)+"/tenants", `{"name": "shared"}`)
+		assert.Equal(t, http.StatusConflict, status, "unexpected status code returned")
+
+		status = request(t, http.MethodPost, "/v1/tenants", `{"name": "shared"}`)
+		assert.Equal(t, http.StatusConflict, status, "expected root create to conflict")
+	})
+
+	t.Run("rename conflicts across parents", func(t *testing.T) {
+		status := request(t, http.MethodPatch, "/v1/tenants/"+string(second.ID), `{"name": "shared"}`)
+		assert.Equal(t, http.StatusConflict, status, "unexpected status code returned")
+	})
+
+	t.Run("move keeps unique names", func(t *testing.T) {
+		status := request(t, http.MethodPatch, "/v1/tenants/"+string(second.ID), fmt.Sprintf(`{"parent_tenant_id": "%s"}`, a.ID))
+		assert.Equal(t, http.StatusOK, status, "unexpected status code returned")
+	})
+
+	t.Run("deleted names are reusable", func(t *testing.T) {
+		status := request(t, http.MethodDelete, "/v1/tenants/"+string(first.ID), "")
+		assert.Equal(t, http.StatusOK, status, "unexpected status code returned")
+
+		status = request(t, http.MethodPost, "/v1/tenants/"+string(b.ID)+"/tenants", `{"name": "shared"}`)
+		assert.Equal(t, http.StatusCreated, status, "unexpected status code returned")
+	})
+
+	t.Run("disabling drops the index", func(t *testing.T) {
+		require.NoError(t, ApplyNameUniqueness(ctx, srv.db, false), "no error expected disabling global uniqueness")
+		assert.False(t, globalIndexExists(t), "expected global index to be dropped")
+
+		require.NoError(t, ApplyNameUniqueness(ctx, srv.db, false), "expected disabling to be repeatable")
+	})
+}
+
+func TestTenantsWithPubSubDisabled(t *testing.T) {
+	srv, err := newTestServer(t, &testServerConfig{disablePubSub: true})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	parent := srv.createTenant(t, "", "parent")
+	child := srv.createTenant(t, parent.ID, "child")
+
+	var updated *v1TenantResponse
+
+	resp, err := srv.Request(http.MethodPatch, "/v1/tenants/"+string(child.ID), nil, strings.NewReader(`{"name": "renamed"}`), &updated)
+	require.NoError(t, err, "no error expected for tenant update")
+	resp.Body.Close() //nolint:errcheck // Not needed
+
+	require.Equal(t, http.StatusOK, resp.StatusCode, "expected update to succeed without pubsub")
+	assert.Equal(t, "renamed", updated.Tenant.Name, "expected tenant to be renamed")
+
+	resp, err = srv.Request(http.MethodDelete, "/v1/tenants/"+string(child.ID), nil, nil, nil)
+	require.NoError(t, err, "no error expected for tenant delete")
+	resp.Body.Close() //nolint:errcheck // Not needed
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "expected delete to succeed without pubsub")
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// requestTimeout bounds the context of each request, and therefore every
+// database query made with it, by the timeout of its route. Queries still
+// running when the timeout is reached are canceled and the request fails with
+// a gateway timeout.
+func (r *Router) requestTimeout(next echo.HandlerFunc) echo.HandlerFunc {
+	if r.timeout <= 0 && len(r.routeTimeouts) == 0 {
+		return next
+	}
+
+	return func(c echo.Context) error {
+		timeout := r.routeTimeout(c)
+		if timeout <= 0 {
+			return next(c)
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+		defer cancel()
+
+		c.SetRequest(c.Request().WithContext(ctx))
+
+		err := next(c)
+
+		// Handlers which return the context error without responding are
+		// answered here, so the client is not left with an empty response.
+		if !c.Response().Committed && requestTimedOut(c) {
+			return v1GatewayTimeoutResponse(c, ctx.Err())
+		}
+
+		return err
+	}
+}
+
+// routeTimeout returns the timeout of the request's route, the route's
+// override when it has one and otherwise the global timeout.
+func (r *Router) routeTimeout(c echo.Context) time.Duration {
+	if timeout, ok := r.routeTimeouts[c.Path()]; ok {
+		return timeout
+	}
+
+	return r.timeout
+}
+
+// ParseRouteTimeouts parses the timeouts of routes, keyed by route template
+// such as /v1/tenants/:id/tenants, for WithRouteTimeouts.
+func ParseRouteTimeouts(values map[string]string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration, len(values))
+
+	for route, value := range values {
+		if !strings.HasPrefix(route, "/") {
+			return nil, fmt.Errorf("%w: route %q must be a route template starting with /", ErrInvalidRouteTimeout, route)
+		}
+
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("%w: timeout %q of %s must be a non-negative duration", ErrInvalidRouteTimeout, value, route)
+		}
+
+		timeouts[route] = timeout
+	}
+
+	return timeouts, nil
+}
+
+// requestTimedOut reports whether the request's context exceeded its deadline.
+func requestTimedOut(c echo.Context) bool {
+	return errors.Is(c.Request().Context().Err(), context.DeadlineExceeded)
+}
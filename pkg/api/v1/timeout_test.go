@@ -0,0 +1,200 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/x/gidx"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	serve := func(timeout time.Duration, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+		r := NewRouter(nil, nil, WithRequestTimeout(timeout))
+
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+		_ = r.requestTimeout(handler)(c)
+
+		return rec
+	}
+
+	// waitForTimeout blocks like a long running query until the request times out.
+	waitForTimeout := func(c echo.Context) error {
+		<-c.Request().Context().Done()
+
+		return c.Request().Context().Err()
+	}
+
+	t.Run("query canceled", func(t *testing.T) {
+		rec := serve(10*time.Millisecond, func(c echo.Context) error {
+			return v1InternalServerErrorResponse(c, waitForTimeout(c))
+		})
+
+		assert.Equal(t, http.StatusGatewayTimeout, rec.Code, "unexpected status code returned")
+
+		var result v1ErrorResponseBody
+
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		assert.Equal(t, http.StatusGatewayTimeout, result.Status, "unexpected error status")
+	})
+
+	t.Run("handler did not respond", func(t *testing.T) {
+		rec := serve(10*time.Millisecond, waitForTimeout)
+
+		assert.Equal(t, http.StatusGatewayTimeout, rec.Code, "unexpected status code returned")
+	})
+
+	t.Run("completed in time", func(t *testing.T) {
+		rec := serve(time.Minute, func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		})
+
+		assert.Equal(t, http.StatusOK, rec.Code, "unexpected status code returned")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		rec := serve(0, func(c echo.Context) error {
+			_, ok := c.Request().Context().Deadline()
+			assert.False(t, ok, "expected no deadline")
+
+			return c.NoContent(http.StatusOK)
+		})
+
+		assert.Equal(t, http.StatusOK, rec.Code, "unexpected status code returned")
+	})
+}
+
+func TestRouteTimeouts(t *testing.T) {
+	r := NewRouter(nil, nil,
+		WithRequestTimeout(10*time.Millisecond),
+		WithRouteTimeouts(map[string]time.Duration{
+			"/v1/tenants/:id/export": time.Minute,
+			"/v1/tenants/:id/stats":  0,
+		}),
+	)
+
+	// deadline returns the deadline of the request to the route, relative to
+	// when it was served.
+	deadline := func(route string) (time.Duration, bool) {
+		var (
+			remaining time.Duration
+			ok        bool
+		)
+
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+		c.SetPath(route)
+
+		_ = r.requestTimeout(func(c echo.Context) error {
+			var at time.Time
+
+			at, ok = c.Request().Context().Deadline()
+			remaining = time.Until(at)
+
+			return c.NoContent(http.StatusOK)
+		})(c)
+
+		return remaining, ok
+	}
+
+	t.Run("override applies", func(t *testing.T) {
+		remaining, ok := deadline("/v1/tenants/:id/export")
+		require.True(t, ok, "expected a deadline")
+		assert.Greater(t, remaining, 10*time.Millisecond, "expected the route's longer timeout")
+		assert.LessOrEqual(t, remaining, time.Minute, "expected the route's timeout")
+	})
+
+	t.Run("global default", func(t *testing.T) {
+		remaining, ok := deadline("/v1/tenants/:id")
+		require.True(t, ok, "expected a deadline")
+		assert.LessOrEqual(t, remaining, 10*time.Millisecond, "expected the global timeout")
+	})
+
+	t.Run("unbounded override", func(t *testing.T) {
+		_, ok := deadline("/v1/tenants/:id/stats")
+		assert.False(t, ok, "expected no deadline")
+	})
+
+	t.Run("overrides without a global timeout", func(t *testing.T) {
+		r := NewRouter(nil, nil, WithRouteTimeouts(map[string]time.Duration{"/v1/tenants/:id/export": time.Minute}))
+
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+		c.SetPath("/v1/tenants/:id/export")
+
+		_ = r.requestTimeout(func(c echo.Context) error {
+			_, ok := c.Request().Context().Deadline()
+			assert.True(t, ok, "expected the route's deadline")
+
+			return nil
+		})(c)
+	})
+}
+
+func TestParseRouteTimeouts(t *testing.T) {
+	timeouts, err := ParseRouteTimeouts(map[string]string{
+		"/v1/tenants/:id/export": "1m",
+		"/v1/tenants/:id":        "0s",
+	})
+	require.NoError(t, err, "no error expected parsing route timeouts")
+	assert.Equal(t, map[string]time.Duration{
+		"/v1/tenants/:id/export": time.Minute,
+		"/v1/tenants/:id":        0,
+	}, timeouts)
+
+	for _, values := range []map[string]string{
+		{"v1/tenants": "1m"},
+		{"/v1/tenants": "soon"},
+		{"/v1/tenants": "-1s"},
+	} {
+		_, err := ParseRouteTimeouts(values)
+		assert.ErrorIs(t, err, ErrInvalidRouteTimeout, "expected %v to be rejected", values)
+	}
+}
+
+func TestTenantRequestTimeout(t *testing.T) {
+	srv, err := newTestServer(t, &testServerConfig{
+		routerOpts: []RouterOption{
+			// Short enough for every query to be canceled.
+			WithRequestTimeout(time.Nanosecond),
+		},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	resp, err := srv.Request(http.MethodGet, "/v1/tenants", nil, nil, nil)
+	require.NoError(t, err, "no error expected for list")
+	resp.Body.Close() //nolint:errcheck // Not needed
+	assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode, "unexpected status code returned")
+}
+
+func TestTenantRouteTimeout(t *testing.T) {
+	srv, err := newTestServer(t, &testServerConfig{
+		routerOpts: []RouterOption{
+			// Short enough for every query to be canceled.
+			WithRequestTimeout(time.Nanosecond),
+			WithRouteTimeouts(map[string]time.Duration{
+				"/v1/tenants/:id": time.Minute,
+			}),
+		},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	resp, err := srv.Request(http.MethodGet, "/v1/tenants/"+string(gidx.MustNewID(TenantIDPrefix)), nil, nil, nil)
+	require.NoError(t, err, "no error expected for get")
+	resp.Body.Close() //nolint:errcheck // Not needed
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "expected the route's timeout to apply")
+
+	resp, err = srv.Request(http.MethodGet, "/v1/tenants", nil, nil, nil)
+	require.NoError(t, err, "no error expected for list")
+	resp.Body.Close() //nolint:errcheck // Not needed
+	assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode, "expected the global timeout to apply")
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// defaultTimeseriesBuckets is the number of buckets listed when the
+	// request doesn't provide the start of the time series.
+	defaultTimeseriesBuckets = 30
+
+	// maxTimeseriesBuckets is the maximum number of buckets a time series may have.
+	maxTimeseriesBuckets = 1000
+
+	// creationTimeseriesQuery counts the tenants created in [$2, $3), grouped
+	// by the start of the $1 interval they were created in, in UTC. Deleted
+	// tenants are counted, as they were created all the same.
+	creationTimeseriesQuery = `
+		SELECT date_trunc($1, created_at AT TIME ZONE 'UTC') AS bucket, count(*)
+		FROM tenants
+		WHERE created_at >= $2 AND created_at < $3
+		GROUP BY bucket
+		ORDER BY bucket
+	`
+)
+
+// timeseriesInterval is the length of the buckets of a time series.
+type timeseriesInterval string
+
+const (
+	// timeseriesHour buckets a time series by hour.
+	timeseriesHour timeseriesInterval = "hour"
+
+	// timeseriesDay buckets a time series by day, the default.
+	timeseriesDay timeseriesInterval = "day"
+
+	// timeseriesWeek buckets a time series by week, starting on Mondays.
+	timeseriesWeek timeseriesInterval = "week"
+)
+
+// truncate returns the start of the bucket including t, in UTC.
+func (i timeseriesInterval) truncate(t time.Time) time.Time {
+	t = t.UTC()
+
+	switch i {
+	case timeseriesHour:
+		return t.Truncate(time.Hour)
+	case timeseriesWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+		// Weekdays start on Sunday, weeks start on Monday.
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// next returns the start of the bucket following the bucket starting at start.
+func (i timeseriesInterval) next(start time.Time) time.Time {
+	switch i {
+	case timeseriesHour:
+		return start.Add(time.Hour)
+	case timeseriesWeek:
+		return start.AddDate(0, 0, 7)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// timeseriesRange is the range of a time series, with from being the start
+// of the first bucket and to being exclusive.
+type timeseriesRange struct {
+	Interval timeseriesInterval `json:"interval"`
+	From     time.Time          `json:"from"`
+	To       time.Time          `json:"to"`
+}
+
+// buckets returns the start of each bucket of the range.
+func (r timeseriesRange) buckets() []time.Time {
+	var starts []time.Time
+
+	for start := r.From; start.Before(r.To); start = r.Interval.next(start) {
+		starts = append(starts, start)
+	}
+
+	return starts
+}
+
+// timeseriesBucket is the number of tenants created in the interval starting at Start.
+type timeseriesBucket struct {
+	Start time.Time `json:"start"`
+	Count int64     `json:"count"`
+}
+
+// parseTimeseriesRange parses the interval, from and to query parameters of
+// a time series. The interval is one of hour, day or week, defaulting to day.
+// The from and to times are RFC 3339 timestamps, to defaulting to now and
+// from to 30 intervals earlier. From is moved back to the start of its
+// bucket, so the first bucket is complete, while to is exclusive.
+func parseTimeseriesRange(c echo.Context, now time.Time) (timeseriesRange, error) {
+	tr := timeseriesRange{
+		Interval: timeseriesDay,
+		To:       now.UTC(),
+	}
+
+	switch interval := timeseriesInterval(c.QueryParam("interval")); interval {
+	case "":
+	case timeseriesHour, timeseriesDay, timeseriesWeek:
+		tr.Interval = interval
+	default:
+		return tr, fmt.Errorf("%w: interval must be %s, %s or %s", ErrInvalidQueryParam, timeseriesHour, timeseriesDay, timeseriesWeek)
+	}
+
+	if value := c.QueryParam("to"); value != "" {
+		to, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return tr, fmt.Errorf("%w: to must be an RFC 3339 timestamp", ErrInvalidQueryParam)
+		}
+
+		tr.To = to.UTC()
+	}
+
+	if value := c.QueryParam("from"); value != "" {
+		from, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return tr, fmt.Errorf("%w: from must be an RFC 3339 timestamp", ErrInvalidQueryParam)
+		}
+
+		tr.From = tr.Interval.truncate(from)
+	} else {
+		tr.From = tr.Interval.truncate(tr.To)
+
+		for i := 1; i < defaultTimeseriesBuckets; i++ {
+			tr.From = tr.Interval.truncate(tr.From.Add(-time.Nanosecond))
+		}
+	}
+
+	if !tr.From.Before(tr.To) {
+		return tr, fmt.Errorf("%w: from must be before to", ErrInvalidQueryParam)
+	}
+
+	// The buckets are counted up to the limit, so distant ranges aren't walked to their end.
+	count := 0
+
+	for start := tr.From; start.Before(tr.To); start = tr.Interval.next(start) {
+		if count++; count > maxTimeseriesBuckets {
+			return tr, fmt.Errorf("%w: at most %d buckets may be listed", ErrInvalidQueryParam, maxTimeseriesBuckets)
+		}
+	}
+
+	return tr, nil
+}
+
+// tenantCreationTimeseries responds with the number of tenants created in
+// each interval of the requested range, including intervals without any
+// created tenants.
+func (r *Router) tenantCreationTimeseries(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantCreationTimeseries")
+	defer span.End()
+
+	tr, err := parseTimeseriesRange(c, time.Now())
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, creationTimeseriesQuery, string(tr.Interval), tr.From, tr.To)
+	if err != nil {
+		return r.tenantQueryErrorResponse(c, err)
+	}
+
+	defer rows.Close()
+
+	// The counts are keyed by the Unix time of the bucket start, as the
+	// scanned times may have another location than the computed buckets.
+	counts := make(map[int64]int64)
+
+	for rows.Next() {
+		var (
+			start time.Time
+			count int64
+		)
+
+		if err := rows.Scan(&start, &count); err != nil {
+			return r.tenantQueryErrorResponse(c, err)
+		}
+
+		counts[start.Unix()] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return r.tenantQueryErrorResponse(c, err)
+	}
+
+	starts := tr.buckets()
+	buckets := make([]timeseriesBucket, len(starts))
+
+	for i, start := range starts {
+		buckets[i] = timeseriesBucket{Start: start, Count: counts[start.Unix()]}
+	}
+
+	return v1TenantCreationTimeseriesResponse(c, tr, buckets)
+}
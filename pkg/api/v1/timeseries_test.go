@@ -0,0 +1,166 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeseriesIntervalTruncate(t *testing.T) {
+	// 2023-06-14 is a Wednesday.
+	ts := time.Date(2023, 6, 14, 13, 45, 10, 0, time.UTC)
+
+	testCases := []struct {
+		interval timeseriesInterval
+		input    time.Time
+		expected time.Time
+	}{
+		{timeseriesHour, ts, time.Date(2023, 6, 14, 13, 0, 0, 0, time.UTC)},
+		{timeseriesDay, ts, time.Date(2023, 6, 14, 0, 0, 0, 0, time.UTC)},
+		{timeseriesWeek, ts, time.Date(2023, 6, 12, 0, 0, 0, 0, time.UTC)},
+		{timeseriesWeek, time.Date(2023, 6, 12, 0, 0, 0, 0, time.UTC), time.Date(2023, 6, 12, 0, 0, 0, 0, time.UTC)},
+		{timeseriesWeek, time.Date(2023, 6, 18, 23, 59, 59, 0, time.UTC), time.Date(2023, 6, 12, 0, 0, 0, 0, time.UTC)},
+		{timeseriesDay, time.Date(2023, 6, 14, 23, 30, 0, 0, time.FixedZone("", -2*60*60)), time.Date(2023, 6, 15, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, tc.interval.truncate(tc.input), "unexpected %s bucket of %s", tc.interval, tc.input)
+	}
+}
+
+func TestParseTimeseriesRange(t *testing.T) {
+	now := time.Date(2023, 6, 14, 13, 45, 10, 0, time.UTC)
+
+	parse := func(query url.Values) (timeseriesRange, error) {
+		req := httptest.NewRequest(http.MethodGet, "/?"+query.Encode(), nil)
+
+		return parseTimeseriesRange(echo.New().NewContext(req, httptest.NewRecorder()), now)
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		tr, err := parse(url.Values{})
+		require.NoError(t, err)
+
+		assert.Equal(t, timeseriesDay, tr.Interval)
+		assert.Equal(t, now, tr.To)
+		assert.Len(t, tr.buckets(), defaultTimeseriesBuckets, "expected the default number of buckets")
+		assert.Equal(t, time.Date(2023, 5, 16, 0, 0, 0, 0, time.UTC), tr.From)
+	})
+
+	t.Run("from aligned to bucket", func(t *testing.T) {
+		tr, err := parse(url.Values{
+			"interval": {"hour"},
+			"from":     {"2023-06-14T10:30:00Z"},
+			"to":       {"2023-06-14T12:00:00Z"},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []time.Time{
+			time.Date(2023, 6, 14, 10, 0, 0, 0, time.UTC),
+			time.Date(2023, 6, 14, 11, 0, 0, 0, time.UTC),
+		}, tr.buckets(), "expected to to be exclusive")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, query := range []url.Values{
+			{"interval": {"month"}},
+			{"from": {"yesterday"}},
+			{"to": {"2023-06-14"}},
+			{"from": {"2023-06-14T00:00:00Z"}, "to": {"2023-06-13T00:00:00Z"}},
+			{"interval": {"hour"}, "from": {"2020-01-01T00:00:00Z"}},
+		} {
+			_, err := parse(query)
+			assert.ErrorIs(t, err, ErrInvalidQueryParam, "expected %v to be rejected", query)
+		}
+	})
+}
+
+func TestTenantCreationTimeseries(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	// Each tenant is created at a known time, around the day boundary of
+	// Sunday 2023-06-18 and the following Monday.
+	createdAt := []time.Time{
+		time.Date(2023, 6, 17, 12, 0, 0, 0, time.UTC),
+		time.Date(2023, 6, 18, 23, 59, 59, 0, time.UTC),
+		time.Date(2023, 6, 19, 0, 0, 0, 0, time.UTC),
+		time.Date(2023, 6, 19, 0, 30, 0, 0, time.UTC),
+		time.Date(2023, 6, 19, 2, 0, 0, 0, time.UTC),
+	}
+
+	for i, at := range createdAt {
+		tenant := srv.createTenant(t, "", fmt.Sprintf("tenant%d", i))
+
+		_, err := srv.db.Exec("UPDATE tenants SET created_at = $1 WHERE id = $2", at, tenant.ID)
+		require.NoError(t, err, "no error expected setting created at")
+	}
+
+	timeseries := func(t *testing.T, query url.Values) []timeseriesBucket {
+		t.Helper()
+
+		var result *v1TenantCreationTimeseriesResponseBody
+
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/creation-timeseries?"+query.Encode(), nil, nil, &result)
+		require.NoError(t, err, "no error expected for creation timeseries")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+		require.NotNil(t, result, "expected timeseries")
+
+		return result.Buckets
+	}
+
+	counts := func(buckets []timeseriesBucket) []int64 {
+		result := make([]int64, len(buckets))
+
+		for i, bucket := range buckets {
+			result[i] = bucket.Count
+		}
+
+		return result
+	}
+
+	t.Run("day", func(t *testing.T) {
+		buckets := timeseries(t, url.Values{"interval": {"day"}, "from": {"2023-06-16T00:00:00Z"}, "to": {"2023-06-20T00:00:00Z"}})
+
+		require.Len(t, buckets, 4)
+		assert.Equal(t, time.Date(2023, 6, 16, 0, 0, 0, 0, time.UTC), buckets[0].Start.UTC())
+		assert.Equal(t, []int64{0, 1, 1, 3}, counts(buckets), "expected midnight in the following day")
+	})
+
+	t.Run("hour", func(t *testing.T) {
+		buckets := timeseries(t, url.Values{"interval": {"hour"}, "from": {"2023-06-18T23:00:00Z"}, "to": {"2023-06-19T03:00:00Z"}})
+
+		assert.Equal(t, []int64{1, 2, 0, 1}, counts(buckets))
+	})
+
+	t.Run("week", func(t *testing.T) {
+		buckets := timeseries(t, url.Values{"interval": {"week"}, "from": {"2023-06-14T00:00:00Z"}, "to": {"2023-06-26T00:00:00Z"}})
+
+		require.Len(t, buckets, 2)
+		assert.Equal(t, time.Date(2023, 6, 12, 0, 0, 0, 0, time.UTC), buckets[0].Start.UTC(), "expected weeks to start on monday")
+		assert.Equal(t, []int64{2, 3}, counts(buckets), "expected sunday in the previous week")
+	})
+
+	t.Run("exclusive to", func(t *testing.T) {
+		buckets := timeseries(t, url.Values{"interval": {"day"}, "from": {"2023-06-19T00:00:00Z"}, "to": {"2023-06-19T00:30:00Z"}})
+
+		assert.Equal(t, []int64{1}, counts(buckets), "expected tenants created at to to be excluded")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodGet, "/v1/tenants/creation-timeseries?interval=month", nil, nil, nil)
+		require.NoError(t, err, "no error expected for creation timeseries")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+	})
+}
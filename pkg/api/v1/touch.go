@@ -0,0 +1,56 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/tenant-api/internal/pubsub"
+	"go.infratographer.com/x/gidx"
+	"go.uber.org/zap"
+)
+
+// tenantTouch bumps the tenant's updated_at without changing any of its
+// fields and emits an update event with no field changes, so consumers can
+// be prompted to reconcile the tenant. Touching is safe to repeat, each touch
+// only moves updated_at forward.
+func (r *Router) tenantTouch(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantTouch")
+	defer span.End()
+
+	tenantID, err := r.parseID(c, "id")
+	if err != nil {
+		return v1BadRequestResponse(c, err)
+	}
+
+	t, err := models.FindTenant(ctx, r.db, tenantID)
+	if err != nil {
+		return r.tenantQueryErrorResponse(c, err)
+	}
+
+	actor := r.actor(c)
+
+	t.UpdatedBy = actorColumn(actor)
+
+	// The update sets updated_at to the current time.
+	if _, err := t.Update(ctx, r.db, boil.Whitelist(models.TenantColumns.UpdatedAt, models.TenantColumns.UpdatedBy)); err != nil {
+		r.requestLogger(c).Error("failed to touch tenant", zap.Error(err))
+
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	msg, err := pubsub.TouchTenantMessage(
+		gidx.PrefixedID(actor),
+		t.ID,
+	)
+	if err != nil {
+		// TODO: add status to reconcile and requeue this
+		r.requestLogger(c).Error("failed to create, touch tenant message", zap.Error(err))
+	}
+
+	if err := r.pubsub.PublishUpdate(ctx, "tenants", "global", msg); err != nil {
+		// TODO: add status to reconcile and requeue this
+		r.requestLogger(c).Error("failed to publish, touch tenant message", zap.Error(err))
+	}
+
+	return v1TenantGetResponse(c, t)
+}
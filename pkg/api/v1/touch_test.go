@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	nats "github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/x/echojwtx"
+	"go.infratographer.com/x/gidx"
+	"go.infratographer.com/x/pubsubx"
+)
+
+func TestTenantTouch(t *testing.T) {
+	testActorID := gidx.MustNewID(TenantIDPrefix)
+
+	// the test oauth client grants the "test" scope.
+	oauthClient, issuer, close := echojwtx.TestOAuthClient(string(testActorID), "")
+	defer close()
+
+	t.Run("requires write scope", func(t *testing.T) {
+		srv, err := newTestServer(t, &testServerConfig{
+			client: oauthClient,
+			auth: &echojwtx.AuthConfig{
+				Issuer: issuer,
+			},
+		})
+		defer srv.close()
+
+		require.NoError(t, err, "no error expected for new test server")
+
+		tenant := srv.createTenant(t, "", "untouched")
+
+		resp, err := srv.Request(http.MethodPost, "/v1/tenants/"+string(tenant.ID)+"/touch", nil, nil, nil)
+		require.NoError(t, err, "no error expected for touching tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode, "unexpected status code returned")
+	})
+
+	srv, err := newTestServer(t, &testServerConfig{
+		client: oauthClient,
+		auth: &echojwtx.AuthConfig{
+			Issuer: issuer,
+		},
+		routerOpts: []RouterOption{
+			WithWriteScope("test"),
+		},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	ctx := context.Background()
+
+	tenant := srv.createTenant(t, "", "touched")
+
+	subscriber := newPubSubClient(t, srv.logger, srv.nats.ClientURL())
+	msgChan := make(chan *nats.Msg, 10)
+
+	subscription, err := subscriber.ChanSubscribe(ctx, "com.infratographer.events.tenants.>", msgChan, "tenant-api-test")
+	require.NoError(t, err)
+
+	defer func() {
+		if err := subscription.Unsubscribe(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	touch := func(t *testing.T, id gidx.PrefixedID, out interface{}) *http.Response {
+		t.Helper()
+
+		resp, err := srv.Request(http.MethodPost, "/v1/tenants/"+string(id)+"/touch", nil, nil, out)
+		require.NoError(t, err, "no error expected for touching tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		resp := touch(t, gidx.MustNewID(TenantIDPrefix), nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, "unexpected status code returned")
+	})
+
+	t.Run("bumps updated_at", func(t *testing.T) {
+		updatedAt := tenant.UpdatedAt
+
+		for i := 0; i < 2; i++ {
+			var result *v1TenantResponse
+
+			resp := touch(t, tenant.ID, &result)
+			require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+			require.NotNil(t, result, "expected tenant response")
+			assert.Equal(t, tenant.Name, result.Tenant.Name, "expected name to be unchanged")
+			assert.Equal(t, tenant.ParentTenantID, result.Tenant.ParentTenantID, "expected parent to be unchanged")
+			assert.Equal(t, tenant.CreatedAt.UTC(), result.Tenant.CreatedAt.UTC(), "expected created_at to be unchanged")
+			assert.True(t, result.Tenant.UpdatedAt.After(updatedAt), "expected updated_at to be bumped")
+
+			require.NotNil(t, result.Tenant.UpdatedBy, "expected updated_by to be set")
+			assert.Equal(t, string(testActorID), *result.Tenant.UpdatedBy, "unexpected updated_by")
+
+			updatedAt = result.Tenant.UpdatedAt
+
+			select {
+			case msg := <-msgChan:
+				pMsg := &pubsubx.ChangeMessage{}
+				require.NoError(t, json.Unmarshal(msg.Data, pMsg))
+
+				assert.Equal(t, tenantSubjectUpdate, msg.Subject, "expected nats subject to be tenant update subject")
+				assert.Equal(t, tenant.ID, pMsg.SubjectID, "expected event for touched tenant")
+				assert.NotNil(t, pMsg.FieldChanges, "expected empty field changes")
+				assert.Empty(t, pMsg.FieldChanges, "expected empty field changes")
+			case <-time.After(natsMsgSubTimeout):
+				t.Error("failed to receive nats message")
+			}
+		}
+	})
+}
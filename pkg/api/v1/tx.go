@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.infratographer.com/tenant-api/internal/reqctx"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultTxAttempts is the default number of times a transaction failing
+	// with a serialization failure is attempted.
+	DefaultTxAttempts = 3
+
+	// txRetryBackoff is the delay before the first retry of a transaction,
+	// doubling on each further retry.
+	txRetryBackoff = 10 * time.Millisecond
+)
+
+// isolationLevels are the transaction isolation levels which may be configured, by name.
+var isolationLevels = map[string]sql.IsolationLevel{
+	"default":         sql.LevelDefault,
+	"read-committed":  sql.LevelReadCommitted,
+	"repeatable-read": sql.LevelRepeatableRead,
+	"serializable":    sql.LevelSerializable,
+}
+
+// ParseIsolationLevel parses the name of a transaction isolation level, one
+// of default, read-committed, repeatable-read or serializable. The default
+// level is the database's default, which for CockroachDB is serializable.
+func ParseIsolationLevel(name string) (sql.IsolationLevel, error) {
+	level, ok := isolationLevels[name]
+	if !ok {
+		return sql.LevelDefault, fmt.Errorf("%w: %q", ErrInvalidIsolationLevel, name)
+	}
+
+	return level, nil
+}
+
+// inTx runs fn within a transaction at the configured isolation level,
+// committing the transaction when fn succeeds. Transactions failing with a
+// serialization failure, either within fn or when committing, are retried
+// from the start up to the configured number of attempts, so fn must not
+// keep state from earlier attempts.
+func (r *Router) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	backoff := txRetryBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := r.inTxAttempt(ctx, fn)
+		if err == nil || !isSerializationFailure(err) || attempt >= r.txAttempts {
+			return err
+		}
+
+		reqctx.Logger(ctx, r.logger).Debug("retrying transaction after serialization failure", zap.Int("attempt", attempt), zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
+
+// inTxAttempt makes a single attempt at running fn within a transaction.
+func (r *Router) inTxAttempt(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: r.isolation})
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback() //nolint:errcheck // Rollback after commit is a no-op.
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/tenant-api/internal/x/nullx"
+)
+
+func TestParseIsolationLevel(t *testing.T) {
+	testCases := []struct {
+		name  string
+		level sql.IsolationLevel
+	}{
+		{"default", sql.LevelDefault},
+		{"read-committed", sql.LevelReadCommitted},
+		{"repeatable-read", sql.LevelRepeatableRead},
+		{"serializable", sql.LevelSerializable},
+	}
+
+	for _, tc := range testCases {
+		level, err := ParseIsolationLevel(tc.name)
+		require.NoError(t, err, "no error expected parsing %s", tc.name)
+		assert.Equal(t, tc.level, level, "unexpected level for %s", tc.name)
+	}
+
+	for _, name := range []string{"", "Serializable", "snapshot"} {
+		_, err := ParseIsolationLevel(name)
+		assert.ErrorIs(t, err, ErrInvalidIsolationLevel, "expected %q to be invalid", name)
+	}
+}
+
+func TestInTx(t *testing.T) {
+	srv, err := newTestServer(t, nil)
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	ctx := context.Background()
+
+	tenant := srv.createTenant(t, "", "tx")
+
+	serializationFailure := &pq.Error{Code: serializationFailureCode, Message: "restart transaction"}
+
+	// rename renames the tenant within the transaction, failing with a
+	// simulated serialization conflict for the first failures attempts.
+	rename := func(attempts *int, failures int, name string) func(tx *sql.Tx) error {
+		return func(tx *sql.Tx) error {
+			*attempts++
+
+			if _, err := tx.ExecContext(ctx, "UPDATE tenants SET name = $1 WHERE id = $2", name, tenant.ID); err != nil {
+				return err
+			}
+
+			if *attempts <= failures {
+				return serializationFailure
+			}
+
+			return nil
+		}
+	}
+
+	tenantName := func(t *testing.T) string {
+		t.Helper()
+
+		result, err := models.FindTenant(ctx, srv.db, tenant.ID)
+		require.NoError(t, err, "no error expected finding tenant")
+
+		return result.Name
+	}
+
+	t.Run("retries serialization failures", func(t *testing.T) {
+		r := NewRouter(srv.db, nil, WithTxIsolation(sql.LevelSerializable))
+
+		var attempts int
+
+		require.NoError(t, r.inTx(ctx, rename(&attempts, DefaultTxAttempts-1, "retried")), "expected the final attempt to succeed")
+		assert.Equal(t, DefaultTxAttempts, attempts, "unexpected number of attempts")
+		assert.Equal(t, "retried", tenantName(t), "expected the successful attempt to be committed")
+	})
+
+	t.Run("bounded attempts", func(t *testing.T) {
+		r := NewRouter(srv.db, nil, WithTxAttempts(2))
+
+		var attempts int
+
+		err := r.inTx(ctx, rename(&attempts, 5, "exhausted"))
+		assert.ErrorIs(t, err, serializationFailure, "expected the serialization failure after the final attempt")
+		assert.Equal(t, 2, attempts, "unexpected number of attempts")
+		assert.Equal(t, "retried", tenantName(t), "expected failed attempts to be rolled back")
+	})
+
+	t.Run("other errors not retried", func(t *testing.T) {
+		r := NewRouter(srv.db, nil)
+
+		var attempts int
+
+		err := r.inTx(ctx, func(tx *sql.Tx) error {
+			attempts++
+
+			return ErrNameConflict
+		})
+		assert.ErrorIs(t, err, ErrNameConflict, "expected the error to be returned")
+		assert.Equal(t, 1, attempts, "expected a single attempt")
+	})
+
+	t.Run("wrapped serialization failures", func(t *testing.T) {
+		r := NewRouter(srv.db, nil)
+
+		var attempts int
+
+		err := r.inTx(ctx, func(tx *sql.Tx) error {
+			attempts++
+
+			if attempts == 1 {
+				return errors.Join(ErrNameConflict, serializationFailure)
+			}
+
+			return nil
+		})
+		assert.NoError(t, err, "expected the retry to succeed")
+		assert.Equal(t, 2, attempts, "unexpected number of attempts")
+	})
+
+	t.Run("move retried", func(t *testing.T) {
+		parent := srv.createTenant(t, "", "tx-parent")
+
+		r := NewRouter(srv.db, nil)
+
+		var attempts int
+
+		// A conflict on the first attempt of the move must not leave the
+		// tenant half moved.
+		err := r.inTx(ctx, func(tx *sql.Tx) error {
+			attempts++
+
+			moving, err := models.FindTenant(ctx, tx, tenant.ID)
+			if err != nil {
+				return err
+			}
+
+			if err := r.moveTenant(ctx, tx, moving, nullx.PrefixedIDFrom(parent.ID)); err != nil {
+				return err
+			}
+
+			if _, err := moving.Update(ctx, tx, boil.Infer()); err != nil {
+				return err
+			}
+
+			if attempts == 1 {
+				return serializationFailure
+			}
+
+			return nil
+		})
+		require.NoError(t, err, "expected the retried move to succeed")
+		assert.Equal(t, 2, attempts, "unexpected number of attempts")
+
+		moved, err := models.FindTenant(ctx, srv.db, tenant.ID)
+		require.NoError(t, err, "no error expected finding tenant")
+		assert.Equal(t, parent.ID, moved.ParentTenantID.PrefixedID, "expected tenant to be moved")
+		assert.Equal(t, tenantPath(tenantPath("", parent.ID), tenant.ID), moved.Path, "expected the path to be updated once")
+	})
+}
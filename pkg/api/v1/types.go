@@ -15,4 +15,17 @@ type tenant struct {
 	CreatedAt      time.Time        `json:"created_at"`
 	UpdatedAt      time.Time        `json:"updated_at"`
 	DeletedAt      *time.Time       `json:"deleted_at,omitempty"`
+	Locked         bool             `json:"locked"`
+	CreatedBy      *string          `json:"created_by"`
+	UpdatedBy      *string          `json:"updated_by"`
+	Quota          *int64           `json:"quota"`
+	Description    *string          `json:"description"`
+	Kind           *string          `json:"kind"`
+}
+
+type tenantNameChange struct {
+	OldName   string    `json:"old_name"`
+	NewName   string    `json:"new_name"`
+	Actor     string    `json:"actor,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
 }
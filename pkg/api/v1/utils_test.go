@@ -2,12 +2,14 @@ package api
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/cockroachdb/cockroach-go/v2/testserver"
@@ -15,15 +17,18 @@ import (
 	natssrv "github.com/nats-io/nats-server/v2/server"
 	nats "github.com/nats-io/nats.go"
 	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
 	dbm "go.infratographer.com/tenant-api/db"
 	"go.infratographer.com/tenant-api/internal/pubsub"
 	"go.infratographer.com/x/crdbx"
 	"go.infratographer.com/x/echojwtx"
+	"go.infratographer.com/x/gidx"
 	"go.uber.org/zap"
 )
 
 type testServer struct {
 	*httptest.Server
+	db       *sql.DB
 	logger   *zap.Logger
 	client   *http.Client
 	nats     *natssrv.Server
@@ -53,6 +58,42 @@ func (t *testServer) RequestWithClient(client *http.Client, method, path string,
 	return httpRequest(client, method, uri, headers, body, out)
 }
 
+// createTenant creates a new tenant through the api, as a subtenant of parentID when provided.
+func (t *testServer) createTenant(tb testing.TB, parentID gidx.PrefixedID, name string) *tenant {
+	tb.Helper()
+
+	path := "/v1/tenants"
+	if parentID != "" {
+		path += "/" + string(parentID) + "/tenants"
+	}
+
+	body, err := json.Marshal(createTenantRequest{Name: name})
+	require.NoError(tb, err, "no error expected encoding create request")
+
+	var result *v1TenantResponse
+
+	resp, err := t.Request(http.MethodPost, path, nil, strings.NewReader(string(body)), &result)
+	require.NoError(tb, err, "no error expected creating tenant")
+	resp.Body.Close() //nolint:errcheck // Not needed
+	require.Equal(tb, http.StatusCreated, resp.StatusCode, "unexpected status code creating tenant")
+
+	return result.Tenant
+}
+
+// listTenants requests the provided list path and returns the tenants from the response.
+func (t *testServer) listTenants(tb testing.TB, path string) tenantSlice {
+	tb.Helper()
+
+	var result *v1TenantSliceResponse
+
+	resp, err := t.Request(http.MethodGet, path, nil, nil, &result)
+	require.NoError(tb, err, "no error expected listing tenants")
+	resp.Body.Close() //nolint:errcheck // Not needed
+	require.Equal(tb, http.StatusOK, resp.StatusCode, "unexpected status code listing tenants")
+
+	return result.Tenants
+}
+
 func buildURL(baseURL, path string) (string, error) {
 	u, err := url.Parse(baseURL)
 	if err != nil {
@@ -99,8 +140,12 @@ func httpRequest(client *http.Client, method, uri string, headers http.Header, b
 }
 
 type testServerConfig struct {
-	client *http.Client
-	auth   *echojwtx.AuthConfig
+	client     *http.Client
+	auth       *echojwtx.AuthConfig
+	routerOpts []RouterOption
+
+	// disablePubSub creates the router without a pubsub client.
+	disablePubSub bool
 }
 
 func newTestServer(t *testing.T, config *testServerConfig) (*testServer, error) {
@@ -151,6 +196,8 @@ func newTestServer(t *testing.T, config *testServerConfig) (*testServer, error)
 		return nil, err
 	}
 
+	ts.db = db
+
 	goose.SetBaseFS(dbm.Migrations)
 
 	if err := goose.SetDialect("postgres"); err != nil {
@@ -184,12 +231,18 @@ func newTestServer(t *testing.T, config *testServerConfig) (*testServer, error)
 		middleware = append(middleware, auth.Middleware())
 	}
 
-	router := NewRouter(
-		db,
-		newPubSubClient(t, logger, ts.nats.ClientURL()),
+	opts := append([]RouterOption{
 		WithLogger(logger),
 		WithMiddleware(middleware...),
-	)
+	}, config.routerOpts...)
+
+	var ps *pubsub.Client
+
+	if !config.disablePubSub {
+		ps = newPubSubClient(t, logger, ts.nats.ClientURL())
+	}
+
+	router := NewRouter(db, ps, opts...)
 
 	router.Routes(e.Group("/"))
 
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/labstack/echo/v4"
+	"go.infratographer.com/tenant-api/internal/models"
+	"go.infratographer.com/tenant-api/internal/x/nullx"
+	"go.infratographer.com/x/gidx"
+	"go.uber.org/zap"
+)
+
+const (
+	// nameReasonMissing is the reason a name is rejected when it is empty.
+	nameReasonMissing = "missing"
+
+	// nameReasonTooLong is the reason a name is rejected when it exceeds the maximum length.
+	nameReasonTooLong = "too_long"
+
+	// nameReasonInvalidCharacters is the reason a name is rejected when it contains invalid characters.
+	nameReasonInvalidCharacters = "invalid_characters"
+
+	// nameReasonRejected is the reason a name is rejected by a registered validator.
+	nameReasonRejected = "rejected"
+)
+
+// tenantValidateName reports whether a tenant with the proposed name could
+// be created under the parent, or as a root tenant when no parent is
+// provided, running the same checks as creates without persisting anything.
+// Invalid names are reported with the reason they are rejected rather than
+// as an error response.
+func (r *Router) tenantValidateName(c echo.Context) error {
+	ctx, span := tracer.Start(c.Request().Context(), "tenantValidateName")
+	defer span.End()
+
+	payload := new(validateNameRequest)
+
+	if err := bindRequest(c, payload); err != nil {
+		r.requestLogger(c).Error("failed to bind validate name request", zap.Error(err))
+
+		return v1BadRequestResponse(c, err)
+	}
+
+	var parentID gidx.PrefixedID
+
+	if payload.ParentTenantID != nil {
+		id, err := r.parseTenantID(string(*payload.ParentTenantID))
+		if err != nil {
+			return v1BadRequestResponse(c, err)
+		}
+
+		if _, err := models.FindTenant(ctx, r.db, id, models.TenantColumns.ID); err != nil {
+			return r.tenantQueryErrorResponse(c, err)
+		}
+
+		parentID = id
+	}
+
+	if err := validateTenantName(payload.Name); err != nil {
+		switch {
+		case errors.Is(err, ErrTenantNameTooLong):
+			return v1TenantNameValidationResponse(c, nameReasonTooLong, err)
+		case errors.Is(err, ErrTenantNameInvalid):
+			return v1TenantNameValidationResponse(c, nameReasonInvalidCharacters, err)
+		default:
+			return v1TenantNameValidationResponse(c, nameReasonMissing, err)
+		}
+	}
+
+	if err := r.checkNamePattern(payload.Name); err != nil {
+		return v1TenantNameValidationResponse(c, errorCodeNamePatternMismatch, err)
+	}
+
+	if err := r.checkNameResolvable(payload.Name); err != nil {
+		return v1TenantNameValidationResponse(c, errorCodeNameReserved, err)
+	}
+
+	if err := r.checkNameConflict(ctx, r.db, "", parentID, payload.Name); err != nil {
+		if errors.Is(err, ErrNameConflict) {
+			return v1TenantNameValidationResponse(c, errorCodeNameConflict, err)
+		}
+
+		r.requestLogger(c).Error("failed to check tenant name conflict", zap.Error(err))
+
+		return v1InternalServerErrorResponse(c, err)
+	}
+
+	t := &models.Tenant{Name: payload.Name}
+
+	if parentID != "" {
+		t.ParentTenantID = nullx.PrefixedIDFrom(parentID)
+	}
+
+	if err := r.validateCreate(ctx, t); err != nil {
+		return v1TenantNameValidationResponse(c, nameReasonRejected, err)
+	}
+
+	return v1TenantNameValidationResponse(c, "", nil)
+}
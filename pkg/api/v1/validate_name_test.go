@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	nats "github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/x/gidx"
+)
+
+func TestTenantValidateName(t *testing.T) {
+	srv, err := newTestServer(t, &testServerConfig{
+		routerOpts: []RouterOption{
+			WithValidators(reservedNameValidator{reserved: "root"}),
+		},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	ctx := context.Background()
+
+	parent := srv.createTenant(t, "", "parent")
+	srv.createTenant(t, parent.ID, "existing")
+
+	subscriber := newPubSubClient(t, srv.logger, srv.nats.ClientURL())
+	msgChan := make(chan *nats.Msg, 10)
+
+	subscription, err := subscriber.ChanSubscribe(ctx, "com.infratographer.events.tenants.>", msgChan, "tenant-api-test")
+	require.NoError(t, err)
+
+	defer func() {
+		if err := subscription.Unsubscribe(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	validateName := func(t *testing.T, body string, out interface{}) *http.Response {
+		t.Helper()
+
+		resp, err := srv.Request(http.MethodPost, "/v1/tenants/validate-name", nil, strings.NewReader(body), out)
+		require.NoError(t, err, "no error expected for validate name")
+		resp.Body.Close() //nolint:errcheck // Not needed
+
+		return resp
+	}
+
+	testCases := []struct {
+		name   string
+		body   string
+		reason string
+	}{
+		{"valid under parent", fmt.Sprintf(`{"name": "new", "parent_tenant_id": "%s"}`, parent.ID), ""},
+		{"valid root", `{"name": "existing"}`, ""},
+		{"missing", `{"name": ""}`, nameReasonMissing},
+		{"too long", fmt.Sprintf(`{"name": "%s"}`, strings.Repeat("t", maxTenantNameLength+1)), nameReasonTooLong},
+		{"invalid characters", `{"name": "new\nline"}`, nameReasonInvalidCharacters},
+		{"conflict", fmt.Sprintf(`{"name": "existing", "parent_tenant_id": "%s"}`, parent.ID), errorCodeNameConflict},
+		{"root conflict", `{"name": "parent"}`, errorCodeNameConflict},
+		{"rejected by validator", `{"name": "root"}`, nameReasonRejected},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var result *v1TenantNameValidationResponseBody
+
+			resp := validateName(t, tc.body, &result)
+			require.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+
+			require.NotNil(t, result, "expected name validation response")
+			assert.Equal(t, tc.reason == "", result.Valid, "unexpected validity")
+			assert.Equal(t, tc.reason, result.Reason, "unexpected reason")
+
+			if tc.reason == "" {
+				assert.Empty(t, result.Message, "expected no message for a valid name")
+			} else {
+				assert.NotEmpty(t, result.Message, "expected a message for an invalid name")
+			}
+		})
+	}
+
+	t.Run("parent not found", func(t *testing.T) {
+		resp := validateName(t, fmt.Sprintf(`{"name": "new", "parent_tenant_id": "%s"}`, gidx.MustNewID(TenantIDPrefix)), nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, "unexpected status code returned")
+	})
+
+	t.Run("invalid parent", func(t *testing.T) {
+		resp := validateName(t, fmt.Sprintf(`{"name": "new", "parent_tenant_id": "%s"}`, gidx.MustNewID("testing")), nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+	})
+
+	t.Run("nothing persisted", func(t *testing.T) {
+		children := srv.listTenants(t, "/v1/tenants/"+string(parent.ID)+"/tenants")
+		assert.Len(t, children, 1, "expected no tenants to be created")
+
+		select {
+		case msg := <-msgChan:
+			t.Errorf("unexpected nats message on %s", msg.Subject)
+		case <-time.After(natsMsgSubTimeout):
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// bindRequest decodes the JSON request body into the payload, rejecting
+// fields the payload doesn't define, so misspelled fields are reported
+// rather than silently ignored. Bodies of other content types are bound as
+// before, and an empty body leaves the payload unchanged. JSON fields may be
+// named in either snake_case or camelCase.
+func bindRequest(c echo.Context, payload interface{}) error {
+	req := c.Request()
+
+	if req.ContentLength == 0 {
+		return nil
+	}
+
+	if !strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+		return c.Bind(payload)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidRequestBody, err)
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	if body, err = renameJSONFields(body, snakeCase); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidRequestBody, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(payload); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: %s", ErrInvalidRequestBody, err)
+	}
+
+	return nil
+}
+
+// fieldError is the validation failure of a single request field.
+type fieldError struct {
+	field string
+	err   error
+}
+
+// fieldErrors collects the validation failures of every field of a request,
+// so clients can fix all of them at once. The failures are wrapped, so the
+// errors of each field can still be matched with errors.Is.
+type fieldErrors []fieldError
+
+// add records the field's error, if any.
+func (e *fieldErrors) add(field string, err error) {
+	if err != nil {
+		*e = append(*e, fieldError{field: field, err: err})
+	}
+}
+
+// err returns the collected failures, or nil when every field is valid.
+func (e fieldErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// Error implements error, listing every failure by field.
+func (e fieldErrors) Error() string {
+	messages := make([]string, len(e))
+
+	for i, fe := range e {
+		messages[i] = fe.field + ": " + fe.err.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the errors of each field.
+func (e fieldErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+
+	for i, fe := range e {
+		errs[i] = fe.err
+	}
+
+	return errs
+}
+
+// v1FieldError is a field validation failure of an error response.
+type v1FieldError struct {
+	Field string `json:"field"`
+	Error string `json:"error"`
+}
+
+// v1FieldErrors returns the field validation failures of the error, if any.
+func v1FieldErrors(err error) []v1FieldError {
+	var fes fieldErrors
+
+	if !errors.As(err, &fes) {
+		return nil
+	}
+
+	result := make([]v1FieldError, len(fes))
+
+	for i, fe := range fes {
+		result[i] = v1FieldError{Field: fe.field, Error: fe.err.Error()}
+	}
+
+	return result
+}
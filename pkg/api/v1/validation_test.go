@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/x/gidx"
+)
+
+func TestBindRequest(t *testing.T) {
+	bind := func(body string) (*createTenantRequest, error) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		payload := new(createTenantRequest)
+
+		return payload, bindRequest(echo.New().NewContext(req, httptest.NewRecorder()), payload)
+	}
+
+	t.Run("known fields", func(t *testing.T) {
+		payload, err := bind(`{"name": "tenant", "quota": 5}`)
+		require.NoError(t, err)
+
+		assert.Equal(t, "tenant", payload.Name)
+		require.NotNil(t, payload.Quota)
+		assert.Equal(t, int64(5), *payload.Quota)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := bind(`{"name": "tenant", "nmae": "typo"}`)
+		assert.ErrorIs(t, err, ErrInvalidRequestBody)
+		assert.Contains(t, err.Error(), `"nmae"`, "expected the unknown field in the error")
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := bind(`{"name": `)
+		assert.ErrorIs(t, err, ErrInvalidRequestBody)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		_, err := bind(``)
+		assert.NoError(t, err)
+	})
+}
+
+func TestRequestFieldErrors(t *testing.T) {
+	quota := int64(-1)
+
+	req := createTenantRequest{
+		Quota:  &quota,
+		Labels: map[string]string{"Not Valid": "value"},
+	}
+
+	err := req.validate()
+	require.Error(t, err)
+
+	assert.ErrorIs(t, err, ErrTenantNameMissing)
+	assert.ErrorIs(t, err, ErrInvalidQuota)
+	assert.ErrorIs(t, err, ErrInvalidLabelKey)
+
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/", nil), httptest.NewRecorder())
+
+	body := v1ErrorBody(c, http.StatusBadRequest, "bad request", "", err)
+
+	fields := make([]string, len(body.Fields))
+
+	for i, fe := range body.Fields {
+		fields[i] = fe.Field
+		assert.NotEmpty(t, fe.Error, "expected an error for %s", fe.Field)
+	}
+
+	assert.Equal(t, []string{"name", "quota", "labels.Not Valid"}, fields, "expected every invalid field")
+
+	encoded, err := json.Marshal(body)
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"fields":[{"field":"name"`)
+
+	t.Run("valid fields omitted", func(t *testing.T) {
+		body := v1ErrorBody(c, http.StatusBadRequest, "bad request", "", ErrInvalidID)
+		assert.Nil(t, body.Fields, "expected no fields for other errors")
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		req := batchMoveRequest{Moves: []tenantMove{
+			{ID: "not-valid", ParentTenantID: optionalPrefixedID{Set: true}},
+			{ID: "also-not-valid"},
+		}}
+
+		body := v1ErrorBody(c, http.StatusBadRequest, "bad request", "", req.validate())
+
+		fields := make([]string, len(body.Fields))
+
+		for i, fe := range body.Fields {
+			fields[i] = fe.Field
+		}
+
+		assert.Equal(t, []string{"moves[0].id", "moves[1].id", "moves[1].parent_tenant_id"}, fields, "expected every invalid field of every move")
+	})
+}
+
+func TestPathCreateTenantRequestValidate(t *testing.T) {
+	pathParent := gidx.MustNewID(TenantIDPrefix)
+	otherParent := gidx.MustNewID(TenantIDPrefix)
+
+	testCases := []struct {
+		name       string
+		body       string
+		pathParent gidx.PrefixedID
+		expectErr  bool
+	}{
+		{"omitted", `{"name": "tenant"}`, pathParent, false},
+		{"matching", fmt.Sprintf(`{"name": "tenant", "parent_tenant_id": %q}`, pathParent), pathParent, false},
+		{"conflicting", fmt.Sprintf(`{"name": "tenant", "parent_tenant_id": %q}`, otherParent), pathParent, true},
+		{"null under parent", `{"name": "tenant", "parent_tenant_id": null}`, pathParent, true},
+		{"null root", `{"name": "tenant", "parent_tenant_id": null}`, "", false},
+		{"parent of root", fmt.Sprintf(`{"name": "tenant", "parent_tenant_id": %q}`, otherParent), "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := new(pathCreateTenantRequest)
+			require.NoError(t, json.Unmarshal([]byte(tc.body), req))
+
+			err := req.validate(tc.pathParent)
+			if !tc.expectErr {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			assert.ErrorIs(t, err, ErrParentMismatch)
+
+			fields := v1FieldErrors(err)
+			require.Len(t, fields, 1, "expected a single invalid field")
+			assert.Equal(t, "parent_tenant_id", fields[0].Field)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"go.infratographer.com/tenant-api/internal/models"
+)
+
+// Validator validates tenant mutations before they are persisted, allowing
+// custom rules to be enforced without modifying the handlers. Validators are
+// registered with WithValidators and run in the order registered; the first
+// error returned rejects the request with a bad request response including
+// the error message.
+//
+// Embed NopValidator to implement only the hooks which are needed.
+type Validator interface {
+	// ValidateCreate is called with the tenant about to be created.
+	ValidateCreate(ctx context.Context, t *models.Tenant) error
+
+	// ValidateUpdate is called with the tenant as currently stored and the
+	// tenant with the requested changes applied.
+	ValidateUpdate(ctx context.Context, current, updated *models.Tenant) error
+
+	// ValidateDelete is called with the tenant about to be deleted.
+	ValidateDelete(ctx context.Context, t *models.Tenant) error
+}
+
+// NopValidator is a Validator which accepts all mutations.
+type NopValidator struct{}
+
+// ValidateCreate accepts all tenant creates.
+func (NopValidator) ValidateCreate(context.Context, *models.Tenant) error { return nil }
+
+// ValidateUpdate accepts all tenant updates.
+func (NopValidator) ValidateUpdate(context.Context, *models.Tenant, *models.Tenant) error { return nil }
+
+// ValidateDelete accepts all tenant deletes.
+func (NopValidator) ValidateDelete(context.Context, *models.Tenant) error { return nil }
+
+func (r *Router) validateCreate(ctx context.Context, t *models.Tenant) error {
+	for _, v := range r.validators {
+		if err := v.ValidateCreate(ctx, t); err != nil {
+			return fmt.Errorf("%w: %w", ErrValidationFailed, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Router) validateUpdate(ctx context.Context, current, updated *models.Tenant) error {
+	for _, v := range r.validators {
+		if err := v.ValidateUpdate(ctx, current, updated); err != nil {
+			return fmt.Errorf("%w: %w", ErrValidationFailed, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Router) validateDelete(ctx context.Context, t *models.Tenant) error {
+	for _, v := range r.validators {
+		if err := v.ValidateDelete(ctx, t); err != nil {
+			return fmt.Errorf("%w: %w", ErrValidationFailed, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.infratographer.com/tenant-api/internal/models"
+)
+
+var errReservedName = errors.New("tenant name is reserved")
+
+// reservedNameValidator is an example validator which prevents tenants from
+// using, or being renamed from, a reserved name.
+type reservedNameValidator struct {
+	NopValidator
+
+	reserved string
+}
+
+func (v reservedNameValidator) ValidateCreate(_ context.Context, t *models.Tenant) error {
+	if t.Name == v.reserved {
+		return errReservedName
+	}
+
+	return nil
+}
+
+func (v reservedNameValidator) ValidateUpdate(_ context.Context, current, updated *models.Tenant) error {
+	if current.Name != updated.Name && (current.Name == v.reserved || updated.Name == v.reserved) {
+		return errReservedName
+	}
+
+	return nil
+}
+
+func TestRouterValidators(t *testing.T) {
+	r := NewRouter(nil, nil, WithValidators(NopValidator{}, reservedNameValidator{reserved: "root"}))
+
+	ctx := context.Background()
+
+	assert.NoError(t, r.validateCreate(ctx, &models.Tenant{Name: "tenant"}))
+	assert.NoError(t, r.validateDelete(ctx, &models.Tenant{Name: "root"}))
+
+	err := r.validateCreate(ctx, &models.Tenant{Name: "root"})
+	assert.ErrorIs(t, err, ErrValidationFailed)
+	assert.ErrorIs(t, err, errReservedName)
+
+	err = r.validateUpdate(ctx, &models.Tenant{Name: "root"}, &models.Tenant{Name: "other"})
+	assert.ErrorIs(t, err, errReservedName)
+
+	assert.NoError(t, NewRouter(nil, nil).validateCreate(ctx, &models.Tenant{Name: "root"}), "expected no validation by default")
+}
+
+func TestTenantValidators(t *testing.T) {
+	srv, err := newTestServer(t, &testServerConfig{
+		routerOpts: []RouterOption{
+			WithValidators(reservedNameValidator{reserved: "root"}),
+		},
+	})
+	defer srv.close()
+
+	require.NoError(t, err, "no error expected for new test server")
+
+	t.Run("create rejected", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodPost, "/v1/tenants", nil, strings.NewReader(`{"name": "root"}`), nil)
+		require.NoError(t, err, "no error expected for creating tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+
+		assert.Empty(t, srv.listTenants(t, "/v1/tenants"), "expected no tenants created")
+	})
+
+	target := srv.createTenant(t, "", "tenant")
+
+	t.Run("update rejected", func(t *testing.T) {
+		var result *v1ErrorResponseBody
+
+		resp, err := srv.Request(http.MethodPatch, "/v1/tenants/"+string(target.ID), nil, strings.NewReader(`{"name": "root"}`), &result)
+		require.NoError(t, err, "no error expected for updating tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unexpected status code returned")
+
+		require.NotNil(t, result, "expected error response")
+		assert.Contains(t, result.Error, errReservedName.Error(), "expected validator error in response")
+	})
+
+	t.Run("delete allowed", func(t *testing.T) {
+		resp, err := srv.Request(http.MethodDelete, "/v1/tenants/"+string(target.ID), nil, nil, nil)
+		require.NoError(t, err, "no error expected for deleting tenant")
+		resp.Body.Close() //nolint:errcheck // Not needed
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code returned")
+	})
+}